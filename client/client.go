@@ -0,0 +1,166 @@
+// Package client provides a Go client library for the in-memory storage REST API.
+// It mirrors the StringStore and ListStore interfaces of the storage package,
+// sending every call over HTTP to a running server and authenticating with
+// a bearer token.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Client is a HTTP client for the in-memory storage server.
+// Strings and Lists give access to the string and string list endpoints.
+type Client struct {
+	baseURL    *url.URL
+	apiKey     string
+	httpClient *http.Client
+
+	Strings *StringsClient
+	Lists   *ListsClient
+}
+
+// Option configures optional settings of the Client.
+type Option func(*Client)
+
+// WithHTTPClient replaces the default http.Client used to perform requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New creates a new Client for the server running at baseURL (e.g. http://localhost:8080).
+// The apiKey is sent as a bearer token on every request.
+// It returns an error if the base URL is missing or invalid.
+func New(baseURL, apiKey string, opts ...Option) (*Client, error) {
+	if baseURL == "" {
+		return nil, errors.New("missing base url")
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("invalid base url: scheme and host are required")
+	}
+
+	c := &Client{
+		baseURL:    u,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.Strings = &StringsClient{c: c}
+	c.Lists = &ListsClient{c: c}
+
+	return c, nil
+}
+
+// do performs a request against the given path, encoding body as JSON when
+// it's not nil and decoding the response into out when it's not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	return c.doWithHeader(ctx, method, path, query, nil, body, out)
+}
+
+// doWithHeader is like do, but it also sends the given headers with the request.
+func (c *Client) doWithHeader(ctx context.Context, method, path string, query url.Values, header http.Header, body, out any) error {
+	u := c.baseURL.JoinPath(path)
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return errorFromResponse(res)
+	}
+
+	if out != nil && res.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response body: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// withExtraTimeout returns a copy of the client whose requests can take extra time,
+// for the requests that wait on the server.
+func (c *Client) withExtraTimeout(extra time.Duration) *Client {
+	if c.httpClient.Timeout == 0 {
+		return c
+	}
+	httpClient := *c.httpClient
+	httpClient.Timeout += extra
+	cc := *c
+	cc.httpClient = &httpClient
+	return &cc
+}
+
+// ttlSeconds converts the given duration to the whole seconds expected by the API.
+// Sub-second durations are rounded up so a positive TTL never turns into "no expiration".
+func ttlSeconds(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return int64((ttl + time.Second - 1) / time.Second)
+}
+
+// ttlMillis converts the given duration to the milliseconds of the ttl_ms field.
+// Sub-millisecond durations are rounded up, as in ttlSeconds.
+func ttlMillis(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return int64((ttl + time.Millisecond - 1) / time.Millisecond)
+}
+
+// parseExpiresAt parses the expiration time returned by the API.
+func parseExpiresAt(expiresAt string) (time.Time, error) {
+	if expiresAt == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expires_at: %w", err)
+	}
+	return t, nil
+}
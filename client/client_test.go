@@ -0,0 +1,358 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"in-memory-storage/client"
+	"in-memory-storage/internal/http"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAPIKey = "test-api-key"
+
+// newTestServer starts a test server backed by fresh stores, created with the given options.
+func newTestServer(t *testing.T, opts ...storage.Option) (*httptest.Server, storage.StringStore, storage.ListStore[string]) {
+	stringStore := storage.NewStringStore(opts...)
+	listStore := storage.NewListStore[string](opts...)
+
+	srv, err := http.NewServer(
+		"8080",
+		http.NewStringsController(stringStore),
+		http.NewStringListsController(listStore),
+		testAPIKey,
+	)
+	assert.NoError(t, err)
+
+	ts := httptest.NewServer(srv.Handler)
+	t.Cleanup(ts.Close)
+
+	return ts, stringStore, listStore
+}
+
+func TestNew(t *testing.T) {
+	testCases := map[string]struct {
+		baseURL     string
+		expectedErr bool
+	}{
+		"it should return an error if the base url is missing": {
+			baseURL:     "",
+			expectedErr: true,
+		},
+		"it should return an error if the base url has no host": {
+			baseURL:     "localhost",
+			expectedErr: true,
+		},
+		"it should create a new client": {
+			baseURL: "http://localhost:8080",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			c, err := client.New(tc.baseURL, testAPIKey)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				assert.Nil(t, c)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, c)
+			}
+		})
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	ts, _, _ := newTestServer(t)
+
+	c, err := client.New(ts.URL, "invalid-api-key")
+	assert.NoError(t, err)
+
+	_, err = c.Strings.Get(context.Background(), "key")
+	assert.Equal(t, client.ErrUnauthorized, err)
+}
+
+func TestClient_WrongType(t *testing.T) {
+	ts, _, _ := newTestServer(t, storage.WithKeyspace(storage.NewKeyspace()))
+	ctx := context.Background()
+
+	c, err := client.New(ts.URL, testAPIKey)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Strings.Set(ctx, "key", "value", 0))
+	err = c.Lists.Set(ctx, "key", []string{"a"}, 0)
+	assert.Equal(t, storage.ErrWrongType, err)
+
+	err = c.Strings.Set(ctx, "key", "other", 0)
+	assert.Equal(t, storage.ErrAlreadyExists, err)
+}
+
+func TestStringsClient(t *testing.T) {
+	ts, store, _ := newTestServer(t)
+	ctx := context.Background()
+
+	c, err := client.New(ts.URL, testAPIKey)
+	assert.NoError(t, err)
+
+	t.Run("it should set and get a value", func(t *testing.T) {
+		err := c.Strings.Set(ctx, "key", "value", time.Minute)
+		assert.NoError(t, err)
+
+		val, err := c.Strings.Get(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", val.Value)
+		assert.False(t, val.ExpiresAt.IsZero())
+	})
+
+	t.Run("it should keep a sub-second ttl", func(t *testing.T) {
+		err := c.Strings.Set(ctx, "short", "value", 250*time.Millisecond)
+		assert.NoError(t, err)
+
+		val, err := c.Strings.Get(ctx, "short")
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(250*time.Millisecond), val.ExpiresAt, 50*time.Millisecond)
+	})
+
+	t.Run("it should return an error if the key already exists", func(t *testing.T) {
+		err := c.Strings.Set(ctx, "key", "other-value", 0)
+		assert.Equal(t, storage.ErrAlreadyExists, err)
+	})
+
+	t.Run("it should update a value", func(t *testing.T) {
+		err := c.Strings.Update(ctx, "key", "new-value")
+		assert.NoError(t, err)
+
+		val, err := store.Get("key")
+		assert.NoError(t, err)
+		assert.Equal(t, "new-value", val.Value)
+	})
+
+	t.Run("it should remove a value", func(t *testing.T) {
+		err := c.Strings.Remove(ctx, "key")
+		assert.NoError(t, err)
+
+		_, err = c.Strings.Get(ctx, "key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should return an error if the key does not exist", func(t *testing.T) {
+		err := c.Strings.Remove(ctx, "missing-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should upsert a value", func(t *testing.T) {
+		err := c.Strings.Upsert(ctx, "upserted", "value", time.Minute)
+		assert.NoError(t, err)
+
+		err = c.Strings.Update(ctx, "upserted", "new-value")
+		assert.NoError(t, err)
+
+		val, err := store.Get("upserted")
+		assert.NoError(t, err)
+		assert.Equal(t, "new-value", val.Value)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), val.ExpiresAt, time.Second)
+	})
+
+	t.Run("it should increment a counter", func(t *testing.T) {
+		val, err := c.Strings.Incr(ctx, "counter")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), val)
+
+		val, err = c.Strings.IncrBy(ctx, "counter", 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), val)
+
+		val, err = c.Strings.Decr(ctx, "counter")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), val)
+
+		f, err := c.Strings.IncrByFloat(ctx, "counter", 0.5)
+		assert.NoError(t, err)
+		assert.Equal(t, 10.5, f)
+
+		_, err = c.Strings.Incr(ctx, "counter")
+		assert.Equal(t, storage.ErrNotInteger, err)
+	})
+
+	t.Run("it should write conditionally", func(t *testing.T) {
+		ok, err := c.Strings.SetNX(ctx, "cas", "first", 0)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = c.Strings.SetNX(ctx, "cas", "second", 0)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		ok, err = c.Strings.SetXX(ctx, "missing-key", "value", 0)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		ok, err = c.Strings.CompareAndSwap(ctx, "cas", "first", "second")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		val, err := c.Strings.Get(ctx, "cas")
+		assert.NoError(t, err)
+		assert.Equal(t, "second", val.Value)
+
+		err = c.Strings.UpdateIfVersion(ctx, "cas", "third", val.Version)
+		assert.NoError(t, err)
+
+		err = c.Strings.UpdateIfVersion(ctx, "cas", "fourth", val.Version)
+		assert.Equal(t, storage.ErrVersionMismatch, err)
+	})
+
+	t.Run("it should return an api error on bad requests", func(t *testing.T) {
+		err := c.Strings.Set(ctx, "", "value", 0)
+		var apiErr *client.APIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, 400, apiErr.StatusCode)
+		assert.Equal(t, http.ErrEmptyKey.Error(), apiErr.Message)
+	})
+}
+
+func TestListsClient(t *testing.T) {
+	ts, _, store := newTestServer(t)
+	ctx := context.Background()
+
+	c, err := client.New(ts.URL, testAPIKey)
+	assert.NoError(t, err)
+
+	t.Run("it should set and get a list", func(t *testing.T) {
+		err := c.Lists.Set(ctx, "list", []string{"a", "b"}, 0)
+		assert.NoError(t, err)
+
+		val, err := c.Lists.Get(ctx, "list")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, val.Value)
+	})
+
+	t.Run("it should return an error if the list already exists", func(t *testing.T) {
+		err := c.Lists.Set(ctx, "list", []string{"c"}, 0)
+		assert.Equal(t, storage.ErrAlreadyExists, err)
+	})
+
+	t.Run("it should push and pop values", func(t *testing.T) {
+		err := c.Lists.Push(ctx, "list", "c")
+		assert.NoError(t, err)
+
+		val, err := c.Lists.Pop(ctx, "list")
+		assert.NoError(t, err)
+		assert.Equal(t, "a", val)
+
+		stored, err := store.Get("list")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b", "c"}, stored.Value)
+	})
+
+	t.Run("it should read a range and the length of a list", func(t *testing.T) {
+		vals, err := c.Lists.Range(ctx, "list", -1, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"c"}, vals)
+
+		n, err := c.Lists.Len(ctx, "list")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		_, err = c.Lists.Len(ctx, "missing")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should push and pop values at both ends", func(t *testing.T) {
+		err := c.Lists.LPush(ctx, "list", "a2", "a1")
+		assert.NoError(t, err)
+		err = c.Lists.RPush(ctx, "list", "d", "e")
+		assert.NoError(t, err)
+
+		val, err := c.Lists.RPop(ctx, "list")
+		assert.NoError(t, err)
+		assert.Equal(t, "e", val)
+
+		val, err = c.Lists.LPop(ctx, "list")
+		assert.NoError(t, err)
+		assert.Equal(t, "a1", val)
+
+		vals, err := c.Lists.LPopN(ctx, "list", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a2", "b"}, vals)
+
+		vals, err = c.Lists.RPopN(ctx, "list", 5)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"d", "c"}, vals)
+
+		_, err = c.Lists.RPopN(ctx, "list", 0)
+		assert.Equal(t, storage.ErrInvalidCount, err)
+	})
+
+	t.Run("it should wait for a value with a blocking pop", func(t *testing.T) {
+		err := store.Set("queue", []string{}, 0)
+		assert.NoError(t, err)
+
+		_, _, err = c.Lists.BLPop(ctx, time.Second, "queue")
+		assert.Equal(t, storage.ErrTimeout, err)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_, err := store.RPush("queue", "a", "b")
+			assert.NoError(t, err)
+		}()
+		key, val, err := c.Lists.BRPop(ctx, 0, "other", "queue")
+		assert.NoError(t, err)
+		assert.Equal(t, "queue", key)
+		assert.Equal(t, "b", val)
+	})
+
+	t.Run("it should move and reserve values", func(t *testing.T) {
+		err := store.Set("jobs", []string{"a", "b", "c"}, 0)
+		assert.NoError(t, err)
+		err = store.Set("done", []string{}, 0)
+		assert.NoError(t, err)
+
+		val, err := c.Lists.RPopLPush(ctx, "jobs", "done")
+		assert.NoError(t, err)
+		assert.Equal(t, "c", val)
+
+		r, err := c.Lists.Reserve(ctx, "jobs", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", r.Value)
+		assert.False(t, r.VisibleAt.IsZero())
+		assert.NoError(t, c.Lists.Nack(ctx, "jobs", r.ID))
+
+		r, err = c.Lists.Reserve(ctx, "jobs", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", r.Value)
+		assert.NoError(t, c.Lists.Ack(ctx, "jobs", r.ID))
+		assert.Equal(t, storage.ErrReservationNotFound, c.Lists.Ack(ctx, "jobs", r.ID))
+
+		_, err = c.Lists.Reserve(ctx, "jobs", 0)
+		assert.Equal(t, storage.ErrInvalidVisibility, err)
+
+		stored, err := store.Get("jobs")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b"}, stored.Value)
+	})
+
+	t.Run("it should return an error when popping from an empty list", func(t *testing.T) {
+		err := c.Lists.Update(ctx, "list", []string{})
+		assert.NoError(t, err)
+
+		_, err = c.Lists.Pop(ctx, "list")
+		assert.Equal(t, storage.ErrEmptyList, err)
+	})
+
+	t.Run("it should remove a list", func(t *testing.T) {
+		err := c.Lists.Remove(ctx, "list")
+		assert.NoError(t, err)
+
+		_, err = c.Lists.Get(ctx, "list")
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		_, err = c.Lists.Pop(ctx, "list")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"in-memory-storage/storage"
+)
+
+// ErrUnauthorized is returned when the server rejects the API key.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// maxErrorBodySize limits how much of an error response body is read.
+const maxErrorBodySize = 4 << 10
+
+// wrongTypeMessage is the message of the conflicts on a key that holds another type of value.
+// The server answers with it instead of the message of storage.ErrWrongType.
+const wrongTypeMessage = "key holds another type of value"
+
+// APIError is returned when the server answers with an unexpected error status.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// errorFromResponse maps an error response back to the storage errors
+// so callers can handle them the same way as with the in-process stores.
+func errorFromResponse(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	msg := string(bytes.TrimSpace(body))
+
+	switch res.StatusCode {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		if msg == storage.ErrEmptyList.Error() {
+			return storage.ErrEmptyList
+		}
+		if msg == storage.ErrReservationNotFound.Error() {
+			return storage.ErrReservationNotFound
+		}
+		return storage.ErrNotFound
+	case http.StatusConflict:
+		if msg == wrongTypeMessage {
+			return storage.ErrWrongType
+		}
+		return storage.ErrAlreadyExists
+	case http.StatusPreconditionFailed:
+		return storage.ErrVersionMismatch
+	case http.StatusBadRequest:
+		for _, err := range []error{storage.ErrNotInteger, storage.ErrNotFloat, storage.ErrOverflow, storage.ErrInvalidCount, storage.ErrInvalidVisibility} {
+			if msg == err.Error() {
+				return err
+			}
+		}
+		return &APIError{StatusCode: res.StatusCode, Message: msg}
+	default:
+		return &APIError{StatusCode: res.StatusCode, Message: msg}
+	}
+}
@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"in-memory-storage/internal/lists"
+	"in-memory-storage/storage"
+)
+
+const listsPath = "/lists/strings"
+
+// ListsClient calls the /lists/strings endpoints. It mirrors storage.ListStore[string].
+type ListsClient struct {
+	c *Client
+}
+
+// Get will return the list for the given key.
+// It will return storage.ErrNotFound if the list does not exist or has expired.
+func (lc *ListsClient) Get(ctx context.Context, key string) (*storage.Value[[]string], error) {
+	var res lists.GetResponse[string]
+	if err := lc.c.do(ctx, http.MethodGet, listsPath, url.Values{"key": {key}}, nil, &res); err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := parseExpiresAt(res.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.Value[[]string]{Value: res.List, ExpiresAt: expiresAt}, nil
+}
+
+// Range will return the items between the start and stop positions, both included.
+// Negative positions count from the end of the list, so -1 is the last item.
+// It will return storage.ErrNotFound if the list does not exist or has expired.
+func (lc *ListsClient) Range(ctx context.Context, key string, start, stop int) ([]string, error) {
+	var res lists.RangeResponse[string]
+	query := url.Values{"key": {key}, "start": {strconv.Itoa(start)}, "stop": {strconv.Itoa(stop)}}
+	if err := lc.c.do(ctx, http.MethodGet, listsPath, query, nil, &res); err != nil {
+		return nil, err
+	}
+	return res.List, nil
+}
+
+// Len will return the number of items in the list.
+// It will return storage.ErrNotFound if the list does not exist or has expired.
+func (lc *ListsClient) Len(ctx context.Context, key string) (int, error) {
+	var res lists.LenResponse
+	query := url.Values{"key": {key}, "len": {"true"}}
+	if err := lc.c.do(ctx, http.MethodGet, listsPath, query, nil, &res); err != nil {
+		return 0, err
+	}
+	return res.Length, nil
+}
+
+// Set will store the given list with an optional TTL.
+// It will return storage.ErrAlreadyExists if the key already exists.
+func (lc *ListsClient) Set(ctx context.Context, key string, list []string, ttl time.Duration) error {
+	req := lists.SetRequest[string]{Key: key, List: list, TTLMs: ttlMillis(ttl)}
+	return lc.c.do(ctx, http.MethodPost, listsPath, nil, req, nil)
+}
+
+// Update will replace the list for the given key, creating it if it does not exist.
+// An existing list keeps its TTL.
+func (lc *ListsClient) Update(ctx context.Context, key string, list []string) error {
+	return lc.Upsert(ctx, key, list, 0)
+}
+
+// Upsert will replace the list for the given key, creating it if it does not exist.
+// The list gets the given TTL, or keeps its current one if the TTL is zero.
+func (lc *ListsClient) Upsert(ctx context.Context, key string, list []string, ttl time.Duration) error {
+	req := lists.UpdateRequest[string]{Key: key, List: list, TTLMs: ttlMillis(ttl)}
+	return lc.c.do(ctx, http.MethodPut, listsPath, nil, req, nil)
+}
+
+// Remove will delete the list linked to the given key.
+// It will return storage.ErrNotFound if the list does not exist.
+func (lc *ListsClient) Remove(ctx context.Context, key string) error {
+	return lc.c.do(ctx, http.MethodDelete, listsPath, url.Values{"key": {key}}, nil, nil)
+}
+
+// Push will add the given value to the end of the list.
+// It will return storage.ErrNotFound if the list does not exist or has expired.
+func (lc *ListsClient) Push(ctx context.Context, key, val string) error {
+	req := lists.PushRequest[string]{Key: key, Value: val}
+	return lc.c.do(ctx, http.MethodPost, listsPath+"/push", nil, req, nil)
+}
+
+// LPush will add the given values to the start of the list, one after the other,
+// so they end up in reverse order.
+// It will return storage.ErrNotFound if the list does not exist or has expired.
+func (lc *ListsClient) LPush(ctx context.Context, key string, vals ...string) error {
+	req := lists.PushRequest[string]{Key: key, Values: vals, Side: lists.SideLeft}
+	return lc.c.do(ctx, http.MethodPost, listsPath+"/push", nil, req, nil)
+}
+
+// RPush will add the given values to the end of the list, in order.
+// It will return storage.ErrNotFound if the list does not exist or has expired.
+func (lc *ListsClient) RPush(ctx context.Context, key string, vals ...string) error {
+	req := lists.PushRequest[string]{Key: key, Values: vals, Side: lists.SideRight}
+	return lc.c.do(ctx, http.MethodPost, listsPath+"/push", nil, req, nil)
+}
+
+// Pop will retrieve and remove the first item from the list.
+// It will return storage.ErrNotFound if the list does not exist or has expired
+// and storage.ErrEmptyList if the list is empty.
+func (lc *ListsClient) Pop(ctx context.Context, key string) (string, error) {
+	return lc.pop(ctx, lists.PopRequest{Key: key})
+}
+
+// LPop is the same as Pop.
+func (lc *ListsClient) LPop(ctx context.Context, key string) (string, error) {
+	return lc.pop(ctx, lists.PopRequest{Key: key, Side: lists.SideLeft})
+}
+
+// RPop will retrieve and remove the last item from the list.
+// It will return storage.ErrNotFound if the list does not exist or has expired
+// and storage.ErrEmptyList if the list is empty.
+func (lc *ListsClient) RPop(ctx context.Context, key string) (string, error) {
+	return lc.pop(ctx, lists.PopRequest{Key: key, Side: lists.SideRight})
+}
+
+// LPopN will retrieve and remove up to n items from the start of the list, in order.
+// It will return storage.ErrInvalidCount if n is not positive.
+func (lc *ListsClient) LPopN(ctx context.Context, key string, n int) ([]string, error) {
+	return lc.popN(ctx, lists.PopRequest{Key: key, Side: lists.SideLeft, Count: n})
+}
+
+// RPopN will retrieve and remove up to n items from the end of the list, starting from the last one.
+// It will return storage.ErrInvalidCount if n is not positive.
+func (lc *ListsClient) RPopN(ctx context.Context, key string, n int) ([]string, error) {
+	return lc.popN(ctx, lists.PopRequest{Key: key, Side: lists.SideRight, Count: n})
+}
+
+// LMove will atomically move the first or the last item of the source list to the start or the end
+// of the destination list. It will return storage.ErrNotFound if any of the lists does not exist
+// and storage.ErrEmptyList if the source is empty.
+func (lc *ListsClient) LMove(ctx context.Context, src, dst string, srcLeft, dstLeft bool) (string, error) {
+	req := lists.MoveRequest{Source: src, Destination: dst, From: side(srcLeft), To: side(dstLeft)}
+	var res lists.PopResponse[string]
+	if err := lc.c.do(ctx, http.MethodPost, listsPath+"/move", nil, req, &res); err != nil {
+		return "", err
+	}
+	return res.Value, nil
+}
+
+// RPopLPush will atomically move the last item of the source list to the start of the destination list.
+func (lc *ListsClient) RPopLPush(ctx context.Context, src, dst string) (string, error) {
+	return lc.LMove(ctx, src, dst, false, true)
+}
+
+// Reserve will pop the first item from the list, keeping it reserved for the visibility timeout,
+// which is rounded up to whole seconds. The item is put back in the list unless it's acknowledged with Ack.
+// It will return storage.ErrInvalidVisibility if the visibility timeout is not positive.
+func (lc *ListsClient) Reserve(ctx context.Context, key string, visibility time.Duration) (*storage.Reservation[string], error) {
+	req := lists.ReserveRequest{Key: key, Visibility: ttlSeconds(visibility)}
+	var res lists.ReserveResponse[string]
+	if err := lc.c.do(ctx, http.MethodPost, listsPath+"/reserve", nil, req, &res); err != nil {
+		return nil, err
+	}
+
+	visibleAt, err := parseExpiresAt(res.VisibleAt)
+	if err != nil {
+		return nil, err
+	}
+	return &storage.Reservation[string]{ID: res.ID, Value: res.Value, VisibleAt: visibleAt}, nil
+}
+
+// Ack will acknowledge that the reserved item has been processed.
+// It will return storage.ErrReservationNotFound if the item is not reserved.
+func (lc *ListsClient) Ack(ctx context.Context, key, id string) error {
+	req := lists.AckRequest{Key: key, ID: id}
+	return lc.c.do(ctx, http.MethodPost, listsPath+"/ack", nil, req, nil)
+}
+
+// Nack will put the reserved item back at the start of the list.
+// It will return storage.ErrReservationNotFound if the item is not reserved.
+func (lc *ListsClient) Nack(ctx context.Context, key, id string) error {
+	req := lists.AckRequest{Key: key, ID: id}
+	return lc.c.do(ctx, http.MethodPost, listsPath+"/nack", nil, req, nil)
+}
+
+// side returns the side of the list expected by the API.
+func side(left bool) string {
+	if left {
+		return lists.SideLeft
+	}
+	return lists.SideRight
+}
+
+// maxPollTimeout is the longest a single blocking pop can wait on the server.
+const maxPollTimeout = 60 * time.Second
+
+// BLPop will retrieve and remove the first item of the first non-empty list among the given keys
+// and return its key, waiting for a value up to the timeout. A zero timeout waits until the context
+// is cancelled. The server waits up to a minute per request, so longer waits are split in several requests.
+// It will return storage.ErrTimeout if the timeout is reached.
+func (lc *ListsClient) BLPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error) {
+	return lc.blockingPop(ctx, timeout, lists.SideLeft, keys)
+}
+
+// BRPop is the same as BLPop, but it retrieves and removes the last item of the list.
+func (lc *ListsClient) BRPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error) {
+	return lc.blockingPop(ctx, timeout, lists.SideRight, keys)
+}
+
+func (lc *ListsClient) blockingPop(ctx context.Context, timeout time.Duration, side string, keys []string) (string, string, error) {
+	if len(keys) == 0 {
+		return "", "", storage.ErrNoKeys
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		wait := maxPollTimeout
+		if !deadline.IsZero() {
+			wait = min(wait, time.Until(deadline))
+			if wait <= 0 {
+				return "", "", storage.ErrTimeout
+			}
+		}
+
+		var res lists.PopResponse[string]
+		req := lists.PopRequest{Key: keys[0], Keys: keys[1:], Side: side, Timeout: ttlSeconds(wait)}
+		c := lc.c.withExtraTimeout(time.Duration(req.Timeout) * time.Second)
+		if err := c.do(ctx, http.MethodPost, listsPath+"/pop", nil, req, &res); err != nil {
+			return "", "", err
+		}
+		// The server answers without a key when it times out
+		if res.Key != "" {
+			return res.Key, res.Value, nil
+		}
+	}
+}
+
+func (lc *ListsClient) pop(ctx context.Context, req lists.PopRequest) (string, error) {
+	var res lists.PopResponse[string]
+	if err := lc.c.do(ctx, http.MethodPost, listsPath+"/pop", nil, req, &res); err != nil {
+		return "", err
+	}
+	return res.Value, nil
+}
+
+func (lc *ListsClient) popN(ctx context.Context, req lists.PopRequest) ([]string, error) {
+	// A zero count would be sent as a single pop, so it's rejected here.
+	if req.Count <= 0 {
+		return nil, storage.ErrInvalidCount
+	}
+	var res lists.PopManyResponse[string]
+	if err := lc.c.do(ctx, http.MethodPost, listsPath+"/pop", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return res.Values, nil
+}
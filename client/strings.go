@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"in-memory-storage/internal/strings"
+	"in-memory-storage/storage"
+)
+
+const stringsPath = "/strings"
+
+// StringsClient calls the /strings endpoints. It mirrors storage.StringStore.
+type StringsClient struct {
+	c *Client
+}
+
+// Get will return the value for the given key.
+// It will return storage.ErrNotFound if the key does not exist or has expired.
+func (sc *StringsClient) Get(ctx context.Context, key string) (*storage.Value[string], error) {
+	var res strings.GetResponse
+	if err := sc.c.do(ctx, http.MethodGet, stringsPath, url.Values{"key": {key}}, nil, &res); err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := parseExpiresAt(res.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.Value[string]{Value: res.Value, ExpiresAt: expiresAt, Version: res.Version}, nil
+}
+
+// Set will store the given key/value pair with an optional TTL.
+// It will return storage.ErrAlreadyExists if the key already exists.
+func (sc *StringsClient) Set(ctx context.Context, key, val string, ttl time.Duration) error {
+	req := strings.SetRequest{Key: key, Value: val, TTLMs: ttlMillis(ttl)}
+	return sc.c.do(ctx, http.MethodPost, stringsPath, nil, req, nil)
+}
+
+// Update will set the value for the given key, creating the key if it does not exist.
+// An existing key keeps its TTL.
+func (sc *StringsClient) Update(ctx context.Context, key, val string) error {
+	return sc.Upsert(ctx, key, val, 0)
+}
+
+// Upsert will set the value for the given key, creating the key if it does not exist.
+// The key gets the given TTL, or keeps its current one if the TTL is zero.
+func (sc *StringsClient) Upsert(ctx context.Context, key, val string, ttl time.Duration) error {
+	req := strings.UpdateRequest{Key: key, Value: val, TTLMs: ttlMillis(ttl)}
+	return sc.c.do(ctx, http.MethodPut, stringsPath, nil, req, nil)
+}
+
+// SetNX will store the given key/value pair only if the key does not exist or has expired.
+// It returns false if the key already exists.
+func (sc *StringsClient) SetNX(ctx context.Context, key, val string, ttl time.Duration) (bool, error) {
+	var res strings.SetResponse
+	req := strings.SetRequest{Key: key, Value: val, TTLMs: ttlMillis(ttl)}
+	if err := sc.c.do(ctx, http.MethodPost, stringsPath+"/setnx", nil, req, &res); err != nil {
+		return false, err
+	}
+	return res.Set, nil
+}
+
+// SetXX will replace the value and the TTL of the key only if it exists.
+// It returns false if the key does not exist.
+func (sc *StringsClient) SetXX(ctx context.Context, key, val string, ttl time.Duration) (bool, error) {
+	var res strings.SetResponse
+	req := strings.SetRequest{Key: key, Value: val, TTLMs: ttlMillis(ttl)}
+	if err := sc.c.do(ctx, http.MethodPost, stringsPath+"/setxx", nil, req, &res); err != nil {
+		return false, err
+	}
+	return res.Set, nil
+}
+
+// CompareAndSwap will replace the value of the key with new only if its current value is old.
+// It returns false if the current value is different.
+// It will return storage.ErrNotFound if the key does not exist or has expired.
+func (sc *StringsClient) CompareAndSwap(ctx context.Context, key, old, new string) (bool, error) {
+	var res strings.CompareAndSwapResponse
+	req := strings.CompareAndSwapRequest{Key: key, Expected: old, Value: new}
+	if err := sc.c.do(ctx, http.MethodPost, stringsPath+"/cas", nil, req, &res); err != nil {
+		return false, err
+	}
+	return res.Swapped, nil
+}
+
+// UpdateIfVersion will update the value for the given key only if its version, as returned by Get, still matches.
+// It will return storage.ErrNotFound if the key does not exist or has expired,
+// or storage.ErrVersionMismatch if the key has been written since.
+func (sc *StringsClient) UpdateIfVersion(ctx context.Context, key, val string, version uint64) error {
+	req := strings.UpdateRequest{Key: key, Value: val}
+	header := http.Header{"If-Match": {strconv.Quote(strconv.FormatUint(version, 10))}}
+	return sc.c.doWithHeader(ctx, http.MethodPut, stringsPath, nil, header, req, nil)
+}
+
+// Remove will delete the value linked to the given key.
+// It will return storage.ErrNotFound if the key does not exist.
+func (sc *StringsClient) Remove(ctx context.Context, key string) error {
+	return sc.c.do(ctx, http.MethodDelete, stringsPath, url.Values{"key": {key}}, nil, nil)
+}
+
+// IncrBy will atomically increment the integer value of the key by delta and return the new value.
+// The key is created if it does not exist, starting from 0.
+// It will return storage.ErrNotInteger if the value is not an integer or storage.ErrOverflow if the increment overflows.
+func (sc *StringsClient) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	var res strings.IncrByResponse
+	req := strings.IncrByRequest{Key: key, By: &delta}
+	if err := sc.c.do(ctx, http.MethodPost, stringsPath+"/incr", nil, req, &res); err != nil {
+		return 0, err
+	}
+	return res.Value, nil
+}
+
+// Incr will atomically increment the integer value of the key by one and return the new value.
+func (sc *StringsClient) Incr(ctx context.Context, key string) (int64, error) {
+	return sc.IncrBy(ctx, key, 1)
+}
+
+// Decr will atomically decrement the integer value of the key by one and return the new value.
+func (sc *StringsClient) Decr(ctx context.Context, key string) (int64, error) {
+	return sc.IncrBy(ctx, key, -1)
+}
+
+// IncrByFloat will atomically increment the numeric value of the key by delta and return the new value.
+// The key is created if it does not exist, starting from 0.
+// It will return storage.ErrNotFloat if the value is not a number or storage.ErrOverflow if the result is not finite.
+func (sc *StringsClient) IncrByFloat(ctx context.Context, key string, delta float64) (float64, error) {
+	var res strings.IncrByFloatResponse
+	req := strings.IncrByFloatRequest{Key: key, By: delta}
+	if err := sc.c.do(ctx, http.MethodPost, stringsPath+"/incrbyfloat", nil, req, &res); err != nil {
+		return 0, err
+	}
+	return res.Value, nil
+}
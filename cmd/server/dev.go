@@ -6,5 +6,7 @@ import "os"
 
 func init() {
 	os.Setenv("HTTP_PORT", "8080")
+	os.Setenv("RESP_PORT", "6379")
+	os.Setenv("MEMCACHE_PORT", "11211")
 	os.Setenv("API_KEY", "awesome-api-key")
 }
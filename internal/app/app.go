@@ -4,22 +4,49 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	gohttp "net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"in-memory-storage/internal/http"
+	"in-memory-storage/internal/jsonschema"
+	"in-memory-storage/internal/memcache"
+	"in-memory-storage/internal/persistence"
+	"in-memory-storage/internal/resp"
 	"in-memory-storage/storage"
 )
 
-const defaultTimeout = 5 * time.Second
+const (
+	defaultTimeout = 5 * time.Second
+	// sweepInterval is the gap of time between active expiration cycles
+	sweepInterval = 100 * time.Millisecond
+	// defaultSnapshotInterval is the gap of time between snapshots
+	// when SNAPSHOT_INTERVAL is not set
+	defaultSnapshotInterval = time.Minute
+)
 
 type Application struct {
-	httpServer *http.Server
-	port       string
+	httpServer      *http.Server
+	respServer      *resp.Server
+	memcacheServer  *memcache.Server
+	stringStore     storage.StringStore
+	stringListStore storage.ListStore[string]
+	jsonListStore   storage.ListStore[json.RawMessage]
+	hashStore       storage.HashStore
+	stringSetStore  storage.SetStore[string]
+	zsetStore       storage.ZSetStore
+	streamStore     storage.StreamStore
+	pubSub          storage.PubSub
+	watcher         storage.Watcher
+	snapshotter     *persistence.Snapshotter
+	opLog           *persistence.OpLog
+	port            string
 
 	// value used to determine the gap of time
 	// required for shutdown the application
@@ -28,39 +55,296 @@ type Application struct {
 
 // New creates a new Application instance with the provided configuration.
 func New(port string) (*Application, error) {
-	stringStore := storage.NewStringStore()
-	stringListStore := storage.NewListStore[string]()
+	// All the stores share a keyspace, so a key holds a single type of value.
+	keyspace := storage.NewKeyspace()
+	// The changes of the strings and lists are sent to the watches of /watch.
+	watcher := storage.NewWatcher()
+	opts := []storage.Option{
+		storage.WithSweeper(sweepInterval),
+		storage.WithKeyspace(keyspace),
+		storage.WithObserver(watcher.Notify),
+	}
+	var stringStore storage.StringStore = storage.NewStringStore(opts...)
+	var stringListStore storage.ListStore[string] = storage.NewListStore[string](opts...)
+	var jsonListStore storage.ListStore[json.RawMessage] = storage.NewListStore[json.RawMessage](opts...)
+	hashStore := storage.NewHashStore(opts...)
+	stringSetStore := storage.NewSetStore[string](opts...)
+	zsetStore := storage.NewZSetStore(opts...)
+	streamStore := storage.NewStreamStore(opts...)
+	closeStores := func() {
+		_ = stringStore.Close()
+		_ = stringListStore.Close()
+		_ = jsonListStore.Close()
+		_ = hashStore.Close()
+		_ = stringSetStore.Close()
+		_ = zsetStore.Close()
+		_ = streamStore.Close()
+	}
+
+	// Transactions lock the stores themselves, so they're created before wrapping them.
+	transactor, err := storage.NewTransactor(stringStore, stringListStore)
+	if err != nil {
+		closeStores()
+		return nil, err
+	}
+
+	// The operation log is enabled when a path is provided.
+	// The stores are wrapped so every write is logged, and rebuilt from the log.
+	opLog, err := newOpLog()
+	if err != nil {
+		closeStores()
+		return nil, err
+	}
+
+	schemas, err := newSchemaRegistry()
+	if err != nil {
+		if opLog != nil {
+			_ = opLog.Close()
+		}
+		closeStores()
+		return nil, err
+	}
+	if opLog != nil {
+		stringStore = persistence.LogStringStore(opLog, "strings", stringStore)
+		stringListStore = persistence.LogListStore(opLog, "string_lists", stringListStore)
+		jsonListStore = persistence.LogListStore(opLog, "json_lists", jsonListStore)
+		hashStore = persistence.LogHashStore(opLog, "hashes", hashStore)
+		stringSetStore = persistence.LogSetStore(opLog, "string_sets", stringSetStore)
+		zsetStore = persistence.LogZSetStore(opLog, "zsets", zsetStore)
+		streamStore = persistence.LogStreamStore(opLog, "streams", streamStore)
+		keyspace = persistence.LogKeyspace(opLog, keyspace)
+		transactor, err = persistence.LogTransactor(opLog, transactor, stringStore, stringListStore)
+		if err != nil {
+			_ = opLog.Close()
+			closeStores()
+			return nil, err
+		}
+		if err := opLog.Replay(); err != nil {
+			_ = opLog.Close()
+			closeStores()
+			return nil, err
+		}
+	}
+
+	// Snapshots are enabled when a path is provided.
+	// When the operation log is enabled, it's the source of truth on startup.
+	snapshotter, err := newSnapshotter(
+		stringStore,
+		stringListStore,
+		opLog == nil,
+		persistence.WithJSONListStore(jsonListStore),
+		persistence.WithHashStore(hashStore),
+		persistence.WithSetStore(stringSetStore),
+		persistence.WithZSetStore(zsetStore),
+		persistence.WithStreamStore(streamStore),
+	)
+	if err != nil {
+		if opLog != nil {
+			_ = opLog.Close()
+		}
+		closeStores()
+		return nil, err
+	}
+
+	// Renaming a key can't move a JSON list out of its schema, whatever the protocol.
+	if schemas != nil {
+		keyspace = schemas.Keyspace(keyspace)
+	}
 
 	stringsCtrl := http.NewStringsController(stringStore)
 	stringsListCtrl := http.NewStringListsController(stringListStore)
+	jsonListCtrl := http.NewJSONListsController(jsonListStore, schemas)
+	hashesCtrl := http.NewHashesController(hashStore)
+	stringSetsCtrl := http.NewStringSetsController(stringSetStore)
+	zsetsCtrl := http.NewZSetsController(zsetStore)
+	streamsCtrl := http.NewStreamsController(streamStore)
+	txCtrl := http.NewTxController(transactor)
+	keysCtrl := http.NewKeysController(keyspace)
+	pubSub := storage.NewPubSub()
+	pubSubCtrl := http.NewPubSubController(pubSub)
+	watchCtrl := http.NewWatchController(watcher)
 
 	// Get API key from environment variable
 	apiKey := os.Getenv("API_KEY")
 
-	httpServer, err := http.NewServer(port, stringsCtrl, stringsListCtrl, apiKey)
+	httpServer, err := http.NewServer(
+		port,
+		stringsCtrl,
+		stringsListCtrl,
+		apiKey,
+		http.WithJSONListsController(jsonListCtrl),
+		http.WithHashesController(hashesCtrl),
+		http.WithSetsController(stringSetsCtrl),
+		http.WithZSetsController(zsetsCtrl),
+		http.WithStreamsController(streamsCtrl),
+		http.WithTxController(txCtrl),
+		http.WithKeysController(keysCtrl),
+		http.WithPubSubController(pubSubCtrl),
+		http.WithWatchController(watchCtrl),
+	)
 	if err != nil {
+		if opLog != nil {
+			_ = opLog.Close()
+		}
+		closeStores()
 		return nil, err
 	}
 
+	// The RESP server is enabled when a port is provided.
+	var respServer *resp.Server
+	if respPort := os.Getenv("RESP_PORT"); respPort != "" {
+		respServer, err = resp.NewServer(respPort, stringStore, stringListStore, keyspace, apiKey)
+		if err != nil {
+			if opLog != nil {
+				_ = opLog.Close()
+			}
+			closeStores()
+			return nil, err
+		}
+	}
+
+	// The memcached server is enabled when a port is provided.
+	var memcacheServer *memcache.Server
+	if memcachePort := os.Getenv("MEMCACHE_PORT"); memcachePort != "" {
+		memcacheServer, err = memcache.NewServer(memcachePort, stringStore, apiKey)
+		if err != nil {
+			if opLog != nil {
+				_ = opLog.Close()
+			}
+			closeStores()
+			return nil, err
+		}
+	}
+
 	return &Application{
-		httpServer: httpServer,
-		timeout:    defaultTimeout,
-		port:       port,
+		httpServer:      httpServer,
+		respServer:      respServer,
+		memcacheServer:  memcacheServer,
+		stringStore:     stringStore,
+		stringListStore: stringListStore,
+		jsonListStore:   jsonListStore,
+		hashStore:       hashStore,
+		stringSetStore:  stringSetStore,
+		zsetStore:       zsetStore,
+		streamStore:     streamStore,
+		pubSub:          pubSub,
+		watcher:         watcher,
+		snapshotter:     snapshotter,
+		opLog:           opLog,
+		timeout:         defaultTimeout,
+		port:            port,
 	}, nil
 }
 
-// Start runs the HTTP server and waits for a termination signal.
+// newOpLog opens the operation log configured from the environment.
+// It returns nil if AOF_PATH is not set.
+func newOpLog() (*persistence.OpLog, error) {
+	path := os.Getenv("AOF_PATH")
+	if path == "" {
+		return nil, nil
+	}
+
+	policy, err := persistence.ParseFsyncPolicy(os.Getenv("AOF_FSYNC"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AOF_FSYNC: %w", err)
+	}
+
+	return persistence.OpenOpLog(path, policy)
+}
+
+// newSchemaRegistry loads the JSON Schemas of the JSON lists configured from the environment.
+// It returns nil if JSON_SCHEMAS_PATH is not set, so the values are not validated.
+func newSchemaRegistry() (*jsonschema.Registry, error) {
+	path := os.Getenv("JSON_SCHEMAS_PATH")
+	if path == "" {
+		return nil, nil
+	}
+
+	schemas, err := jsonschema.LoadRegistry(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON_SCHEMAS_PATH: %w", err)
+	}
+
+	return schemas, nil
+}
+
+// newSnapshotter creates a snapshotter of the given stores configured from the environment
+// and, if restore is true, restores the stores from the last snapshot.
+// It returns nil if SNAPSHOT_PATH is not set.
+func newSnapshotter(
+	stringStore storage.StringStore,
+	stringListStore storage.ListStore[string],
+	restore bool,
+	opts ...persistence.SnapshotterOption,
+) (*persistence.Snapshotter, error) {
+	path := os.Getenv("SNAPSHOT_PATH")
+	if path == "" {
+		return nil, nil
+	}
+
+	interval := defaultSnapshotInterval
+	if v := os.Getenv("SNAPSHOT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SNAPSHOT_INTERVAL: %w", err)
+		}
+		interval = d
+	}
+
+	snapshotter, err := persistence.NewSnapshotter(
+		path,
+		interval,
+		stringStore,
+		stringListStore,
+		opts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if restore {
+		if err := snapshotter.Load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return snapshotter, nil
+}
+
+// Start runs the HTTP server, and the RESP and memcached servers if enabled, and waits for a termination signal.
 func (app *Application) Start() {
 	quitCh := make(chan os.Signal, 1)
 	signal.Notify(quitCh, syscall.SIGTERM, os.Interrupt)
 	defer signal.Stop(quitCh)
 
 	go func() {
-		if err := app.httpServer.Start(); err != nil {
+		if err := app.httpServer.Start(); err != nil && !errors.Is(err, gohttp.ErrServerClosed) {
 			log.Fatal("error starting http server: ", err)
 		}
 	}()
 
+	if app.respServer != nil {
+		go func() {
+			if err := app.respServer.Start(); err != nil && !errors.Is(err, resp.ErrServerClosed) {
+				log.Fatal("error starting resp server: ", err)
+			}
+		}()
+	}
+
+	if app.memcacheServer != nil {
+		go func() {
+			if err := app.memcacheServer.Start(); err != nil && !errors.Is(err, memcache.ErrServerClosed) {
+				log.Fatal("error starting memcache server: ", err)
+			}
+		}()
+	}
+
+	if app.snapshotter != nil {
+		app.snapshotter.Start()
+	}
+	if app.opLog != nil {
+		app.opLog.Start()
+	}
+
 	fmt.Println("Server is running in port", app.port, "... Press Ctrl+C to stop.")
 
 	<-quitCh
@@ -69,8 +353,61 @@ func (app *Application) Start() {
 	ctx, cancel := context.WithTimeout(context.Background(), app.timeout)
 	defer cancel()
 
+	// Close the subscriptions and the watches first, so their streams end and don't hold the shutdown.
+	if err := app.pubSub.Close(); err != nil {
+		log.Println("error closing pub/sub: ", err)
+	}
+	if err := app.watcher.Close(); err != nil {
+		log.Println("error closing watcher: ", err)
+	}
 	if err := app.httpServer.Shutdown(ctx); err != nil {
 		log.Fatal("error shutting down http server: ", err)
 	}
+	if app.respServer != nil {
+		if err := app.respServer.Stop(ctx); err != nil {
+			log.Println("error shutting down resp server: ", err)
+		}
+	}
+	if app.memcacheServer != nil {
+		if err := app.memcacheServer.Stop(ctx); err != nil {
+			log.Println("error shutting down memcache server: ", err)
+		}
+	}
+
+	// Write a last snapshot once no more requests are being served.
+	if app.snapshotter != nil {
+		if err := app.snapshotter.Stop(); err != nil {
+			log.Println("error saving snapshot: ", err)
+		}
+	}
+	// Stop the background sweepers once no more requests are being served.
+	if err := app.stringStore.Close(); err != nil {
+		log.Println("error closing string store: ", err)
+	}
+	if err := app.stringListStore.Close(); err != nil {
+		log.Println("error closing string list store: ", err)
+	}
+	if err := app.jsonListStore.Close(); err != nil {
+		log.Println("error closing json list store: ", err)
+	}
+	if err := app.hashStore.Close(); err != nil {
+		log.Println("error closing hash store: ", err)
+	}
+	if err := app.stringSetStore.Close(); err != nil {
+		log.Println("error closing string set store: ", err)
+	}
+	if err := app.zsetStore.Close(); err != nil {
+		log.Println("error closing sorted set store: ", err)
+	}
+	if err := app.streamStore.Close(); err != nil {
+		log.Println("error closing stream store: ", err)
+	}
+
+	// The sweepers log the reserved items they put back in the lists, so the log is closed after them.
+	if app.opLog != nil {
+		if err := app.opLog.Close(); err != nil {
+			log.Println("error closing operation log: ", err)
+		}
+	}
 	fmt.Println("Server stopped gracefully.")
 }
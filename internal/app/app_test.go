@@ -3,6 +3,8 @@ package app_test
 import (
 	"errors"
 	"in-memory-storage/internal/app"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,4 +31,86 @@ func TestApplication_New(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, app)
 	})
+
+	t.Run("it should create a new Application instance with the RESP server", func(t *testing.T) {
+		t.Setenv("RESP_PORT", "6379")
+		app, err := app.New("8080")
+		assert.NoError(t, err)
+		assert.NotNil(t, app)
+	})
+
+	t.Run("it should create a new Application instance with the memcached server", func(t *testing.T) {
+		t.Setenv("MEMCACHE_PORT", "11211")
+		app, err := app.New("8080")
+		assert.NoError(t, err)
+		assert.NotNil(t, app)
+	})
+
+	t.Run("it should create a new Application instance with snapshots", func(t *testing.T) {
+		t.Setenv("SNAPSHOT_PATH", filepath.Join(t.TempDir(), "dump.json"))
+		t.Setenv("SNAPSHOT_INTERVAL", "30s")
+		app, err := app.New("8080")
+		assert.NoError(t, err)
+		assert.NotNil(t, app)
+	})
+
+	t.Run("it should return an error if the snapshot interval is invalid", func(t *testing.T) {
+		t.Setenv("SNAPSHOT_PATH", filepath.Join(t.TempDir(), "dump.json"))
+		t.Setenv("SNAPSHOT_INTERVAL", "invalid")
+		app, err := app.New("8080")
+		assert.Error(t, err)
+		assert.Nil(t, app)
+	})
+
+	t.Run("it should return an error if the snapshot cannot be loaded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.json")
+		assert.NoError(t, os.WriteFile(path, []byte("invalid json"), 0o600))
+		t.Setenv("SNAPSHOT_PATH", path)
+		app, err := app.New("8080")
+		assert.Error(t, err)
+		assert.Nil(t, app)
+	})
+
+	t.Run("it should create a new Application instance with an operation log", func(t *testing.T) {
+		t.Setenv("AOF_PATH", filepath.Join(t.TempDir(), "appendonly.log"))
+		t.Setenv("AOF_FSYNC", "always")
+		app, err := app.New("8080")
+		assert.NoError(t, err)
+		assert.NotNil(t, app)
+	})
+
+	t.Run("it should return an error if the fsync policy is invalid", func(t *testing.T) {
+		t.Setenv("AOF_PATH", filepath.Join(t.TempDir(), "appendonly.log"))
+		t.Setenv("AOF_FSYNC", "sometimes")
+		app, err := app.New("8080")
+		assert.Error(t, err)
+		assert.Nil(t, app)
+	})
+
+	t.Run("it should create a new Application instance with JSON schemas", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "schemas.json")
+		assert.NoError(t, os.WriteFile(path, []byte(`{"users:": {"type": "object"}}`), 0o600))
+		t.Setenv("JSON_SCHEMAS_PATH", path)
+		app, err := app.New("8080")
+		assert.NoError(t, err)
+		assert.NotNil(t, app)
+	})
+
+	t.Run("it should return an error if the JSON schemas are invalid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "schemas.json")
+		assert.NoError(t, os.WriteFile(path, []byte(`{"users:": {"type": "date"}}`), 0o600))
+		t.Setenv("JSON_SCHEMAS_PATH", path)
+		app, err := app.New("8080")
+		assert.Error(t, err)
+		assert.Nil(t, app)
+	})
+
+	t.Run("it should return an error if the operation log cannot be replayed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "appendonly.log")
+		assert.NoError(t, os.WriteFile(path, []byte("invalid json\n"), 0o600))
+		t.Setenv("AOF_PATH", path)
+		app, err := app.New("8080")
+		assert.Error(t, err)
+		assert.Nil(t, app)
+	})
 }
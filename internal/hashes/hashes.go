@@ -0,0 +1,52 @@
+package hashes
+
+import "time"
+
+type GetResponse struct {
+	Fields    map[string]string `json:"fields"`
+	ExpiresAt string            `json:"expires_at,omitempty"`
+}
+
+type SetRequest struct {
+	Key       string            `json:"key"`
+	Fields    map[string]string `json:"fields"`
+	TTL       int64             `json:"ttl,omitempty"`
+	TTLMs     int64             `json:"ttl_ms,omitempty"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty"`
+}
+
+type SetFieldRequest struct {
+	Key   string `json:"key"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+type SetFieldResponse struct {
+	Created bool `json:"created"`
+}
+
+type GetFieldResponse struct {
+	Value string `json:"value"`
+}
+
+type DeleteFieldsResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+type ExistsResponse struct {
+	Exists bool `json:"exists"`
+}
+
+type LenResponse struct {
+	Len int `json:"len"`
+}
+
+type IncrByRequest struct {
+	Key   string `json:"key"`
+	Field string `json:"field"`
+	By    int64  `json:"by"`
+}
+
+type IncrByResponse struct {
+	Value int64 `json:"value"`
+}
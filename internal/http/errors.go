@@ -15,4 +15,58 @@ var (
 	ErrUnauthorized = errors.New("unauthorized")
 	// ErrInvalidBody is returned when the request body is invalid.
 	ErrInvalidBody = errors.New("invalid request body")
+	// ErrEmptyField is returned when the request contains an empty hash field.
+	ErrEmptyField = errors.New("field cannot be empty")
+	// ErrFieldNotFound is returned when the requested field is not found in the hash.
+	ErrFieldNotFound = errors.New("field not found")
+	// ErrEmptyMember is returned when the request contains an empty set member.
+	ErrEmptyMember = errors.New("member cannot be empty")
+	// ErrMemberNotFound is returned when the requested member is not found in the sorted set.
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrInvalidVersion is returned when the If-Match header doesn't contain a valid version.
+	ErrInvalidVersion = errors.New("invalid version")
+	// ErrVersionMismatch is returned when the If-Match header doesn't match the current version of the key.
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrEmptyTx is returned when a transaction doesn't contain any operation.
+	ErrEmptyTx = errors.New("transaction cannot be empty")
+	// ErrInvalidOperation is returned when a transaction contains an unknown operation or key type.
+	ErrInvalidOperation = errors.New("invalid operation")
+	// ErrTxAborted is returned when a watched key has changed before the transaction is applied.
+	ErrTxAborted = errors.New("transaction aborted, a watched key has changed")
+	// ErrInvalidSide is returned when the request contains a list side other than left or right.
+	ErrInvalidSide = errors.New("side must be left or right")
+	// ErrInvalidTimeout is returned when a blocking pop has a timeout out of bounds or a count.
+	ErrInvalidTimeout = errors.New("timeout must be between 0 and 60 seconds and can't be used with a count")
+	// ErrEmptyID is returned when the request contains an empty reservation id.
+	ErrEmptyID = errors.New("id cannot be empty")
+	// ErrReservationNotFound is returned when acknowledging an item that is not reserved.
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrSchemaMismatch is returned when a JSON value doesn't match the schema of its key.
+	ErrSchemaMismatch = errors.New("value doesn't match the schema")
+	// ErrWrongType is returned when creating a key that holds another type of value.
+	ErrWrongType = errors.New("key holds another type of value")
+	// ErrInvalidTTL is returned when the request contains a TTL that is not positive or an expiration in the past.
+	ErrInvalidTTL = errors.New("ttl must be positive and expires_at in the future")
+	// ErrConditionalTTL is returned when a conditional update contains a TTL.
+	ErrConditionalTTL = errors.New("ttl cannot be changed by a conditional update")
+	// ErrConflictingTTL is returned when the request contains more than one way to set the TTL.
+	ErrConflictingTTL = errors.New("only one of ttl, ttl_ms and expires_at can be given")
+	// ErrInvalidRange is returned when the request contains invalid range parameters.
+	ErrInvalidRange = errors.New("invalid range")
+	// ErrEmptyChannel is returned when the request doesn't contain a channel or pattern.
+	ErrEmptyChannel = errors.New("channel cannot be empty")
+	// ErrWatchClosed is sent to a watcher that doesn't keep up with the events, or when the server shuts down.
+	ErrWatchClosed = errors.New("watch closed")
+	// ErrInvalidStreamID is returned when the request contains a stream entry ID that is not written as ms-seq.
+	ErrInvalidStreamID = errors.New("invalid stream id")
+	// ErrEmptyGroup is returned when the request contains an empty consumer group.
+	ErrEmptyGroup = errors.New("group cannot be empty")
+	// ErrEmptyConsumer is returned when the request contains an empty consumer.
+	ErrEmptyConsumer = errors.New("consumer cannot be empty")
+	// ErrGroupNotFound is returned when the requested consumer group is not found in the stream.
+	ErrGroupNotFound = errors.New("consumer group not found")
+	// ErrGroupExists is returned when creating a consumer group that already exists in the stream.
+	ErrGroupExists = errors.New("consumer group already exists")
+	// ErrInvalidMinIdle is returned when a claim contains a negative minimum idle time.
+	ErrInvalidMinIdle = errors.New("min_idle_ms cannot be negative")
 )
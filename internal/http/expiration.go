@@ -0,0 +1,62 @@
+package http
+
+import (
+	"time"
+
+	"in-memory-storage/storage"
+)
+
+// timeLayout is RFC 3339 with millisecond precision, used for the expiration times of the responses.
+const timeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// requestTTL returns the TTL given in a request, either in seconds, in milliseconds or as an
+// absolute expiration time. It returns 0 if none is given, which means the key doesn't expire.
+func requestTTL(seconds, millis int64, expiresAt *time.Time) (time.Duration, error) {
+	given := 0
+	for _, ok := range []bool{seconds != 0, millis != 0, expiresAt != nil} {
+		if ok {
+			given++
+		}
+	}
+	if given > 1 {
+		return 0, ErrConflictingTTL
+	}
+
+	switch {
+	case seconds != 0:
+		if seconds < 0 {
+			return 0, ErrInvalidTTL
+		}
+		return time.Duration(seconds) * time.Second, nil
+	case millis != 0:
+		if millis < 0 {
+			return 0, ErrInvalidTTL
+		}
+		return time.Duration(millis) * time.Millisecond, nil
+	case expiresAt != nil:
+		ttl := time.Until(*expiresAt)
+		if ttl <= 0 {
+			return 0, ErrInvalidTTL
+		}
+		return ttl, nil
+	}
+	return 0, nil
+}
+
+// upsertOptions returns the options of the updates, which create or replace the key
+// and keep its TTL unless a new one is given.
+func upsertOptions(ttl time.Duration) []storage.SetOption {
+	opts := []storage.SetOption{storage.WithSetMode(storage.SetAlways)}
+	if ttl == 0 {
+		opts = append(opts, storage.WithKeepTTL())
+	}
+	return opts
+}
+
+// formatTime formats an expiration time for a response, or returns an empty string if there is none.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(timeLayout)
+}
@@ -0,0 +1,248 @@
+package http
+
+import (
+	"encoding/json"
+	"in-memory-storage/internal/hashes"
+	"in-memory-storage/storage"
+	"log"
+	"net/http"
+)
+
+type HashesController interface {
+	Set(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	SetField(w http.ResponseWriter, r *http.Request)
+	GetField(w http.ResponseWriter, r *http.Request)
+	DeleteFields(w http.ResponseWriter, r *http.Request)
+	Exists(w http.ResponseWriter, r *http.Request)
+	Len(w http.ResponseWriter, r *http.Request)
+	IncrBy(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHashesController(store storage.HashStore) HashesController {
+	return &hashesController{store: store}
+}
+
+type hashesController struct {
+	store storage.HashStore
+}
+
+func (hc *hashesController) Set(w http.ResponseWriter, r *http.Request) {
+	var req hashes.SetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Fields) == 0 {
+		http.Error(w, ErrEmptyValue.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl, err := requestTTL(req.TTL, req.TTLMs, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := hc.store.Set(req.Key, req.Fields, ttl); err != nil {
+		if err == storage.ErrAlreadyExists {
+			http.Error(w, ErrKeyAlreadyExists.Error(), http.StatusConflict)
+			return
+		}
+		if err == storage.ErrWrongType {
+			http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("ERROR: failed to set hash for key %s: %v", req.Key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (hc *hashesController) Get(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value, err := hc.store.Get(key)
+	if err != nil {
+		hc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &hashes.GetResponse{
+		Fields:    value.Value,
+		ExpiresAt: formatTime(value.ExpiresAt),
+	})
+}
+
+func (hc *hashesController) Delete(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := hc.store.Remove(key); err != nil {
+		hc.handleError(w, key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (hc *hashesController) SetField(w http.ResponseWriter, r *http.Request) {
+	var req hashes.SetFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Field == "" {
+		http.Error(w, ErrEmptyField.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := hc.store.HSet(req.Key, req.Field, req.Value)
+	if err != nil {
+		hc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &hashes.SetFieldResponse{Created: created})
+}
+
+func (hc *hashesController) GetField(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	field := r.URL.Query().Get("field")
+	if field == "" {
+		http.Error(w, ErrEmptyField.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value, err := hc.store.HGet(key, field)
+	if err != nil {
+		hc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &hashes.GetFieldResponse{Value: value})
+}
+
+func (hc *hashesController) DeleteFields(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	fields := r.URL.Query()["field"]
+	if len(fields) == 0 {
+		http.Error(w, ErrEmptyField.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := hc.store.HDel(key, fields...)
+	if err != nil {
+		hc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &hashes.DeleteFieldsResponse{Deleted: deleted})
+}
+
+func (hc *hashesController) Exists(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	field := r.URL.Query().Get("field")
+	if field == "" {
+		http.Error(w, ErrEmptyField.Error(), http.StatusBadRequest)
+		return
+	}
+
+	exists, err := hc.store.HExists(key, field)
+	if err != nil {
+		hc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &hashes.ExistsResponse{Exists: exists})
+}
+
+func (hc *hashesController) Len(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	length, err := hc.store.HLen(key)
+	if err != nil {
+		hc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &hashes.LenResponse{Len: length})
+}
+
+func (hc *hashesController) IncrBy(w http.ResponseWriter, r *http.Request) {
+	var req hashes.IncrByRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Field == "" {
+		http.Error(w, ErrEmptyField.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value, err := hc.store.HIncrBy(req.Key, req.Field, req.By)
+	if err != nil {
+		hc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &hashes.IncrByResponse{Value: value})
+}
+
+// handleError maps the storage errors to their HTTP responses.
+func (hc *hashesController) handleError(w http.ResponseWriter, key string, err error) {
+	switch err {
+	case storage.ErrNotFound, storage.ErrExpired:
+		http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+	case storage.ErrFieldNotFound:
+		http.Error(w, ErrFieldNotFound.Error(), http.StatusNotFound)
+	case storage.ErrNotInteger, storage.ErrOverflow:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case storage.ErrWrongType:
+		http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+	default:
+		log.Printf("ERROR: failed to handle hash for key %s: %v", key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,309 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"in-memory-storage/internal/hashes"
+	"in-memory-storage/internal/http"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashesController_Set(t *testing.T) {
+	store := storage.NewHashStore()
+	controller := http.NewHashesController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", map[string]string{"name": "foo"}, 0)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		key            string
+		fields         map[string]string
+		ttl            int64
+		expectedStatus int
+		expectedError  error
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			fields:         map[string]string{"name": "foo"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the fields are missing": {
+			key:            "foo",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyValue,
+		},
+		"it should return an error if the key already exists": {
+			key:            "existing-key",
+			fields:         map[string]string{"name": "bar"},
+			expectedStatus: gohttp.StatusConflict,
+			expectedError:  http.ErrKeyAlreadyExists,
+		},
+		"success with ttl": {
+			key:            "foo",
+			fields:         map[string]string{"name": "bar", "age": "42"},
+			ttl:            60,
+			expectedStatus: gohttp.StatusNoContent,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(hashes.SetRequest{
+				Key:    tc.key,
+				Fields: tc.fields,
+				TTL:    tc.ttl,
+			})
+			req := httptest.NewRequest(gohttp.MethodPost, "/hashes", bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+
+			controller.Set(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				storedValue, err := store.Get(tc.key)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.fields, storedValue.Value)
+				assert.False(t, storedValue.ExpiresAt.IsZero())
+			}
+		})
+	}
+}
+
+func TestHashesController_Get(t *testing.T) {
+	store := storage.NewHashStore()
+	controller := http.NewHashesController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", map[string]string{"name": "foo"}, 60*time.Second)
+	assert.NoError(t, err)
+	err = store.Set("expired-key", map[string]string{"name": "foo"}, 1*time.Millisecond)
+	assert.NoError(t, err)
+
+	// Ensure the expired key is actually expired
+	time.Sleep(2 * time.Millisecond)
+
+	testCases := map[string]struct {
+		key            string
+		expectedStatus int
+		expectedError  error
+		expectedFields map[string]string
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the key does not exist": {
+			key:            "non-existing-key",
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"it should return an error if the key has expired": {
+			key:            "expired-key",
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"success": {
+			key:            "existing-key",
+			expectedStatus: gohttp.StatusOK,
+			expectedFields: map[string]string{"name": "foo"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(gohttp.MethodGet, "/hashes?key="+tc.key, nil)
+			rr := httptest.NewRecorder()
+
+			controller.Get(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response hashes.GetResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedFields, response.Fields)
+				assert.NotEmpty(t, response.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestHashesController_Fields(t *testing.T) {
+	store := storage.NewHashStore()
+	controller := http.NewHashesController(store)
+
+	t.Run("it should return an error if the field is missing", func(t *testing.T) {
+		payload, _ := json.Marshal(hashes.SetFieldRequest{Key: "user", Value: "foo"})
+		req := httptest.NewRequest(gohttp.MethodPost, "/hashes/fields", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.SetField(rr, req)
+
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrEmptyField.Error())
+	})
+
+	t.Run("it should set a field", func(t *testing.T) {
+		payload, _ := json.Marshal(hashes.SetFieldRequest{Key: "user", Field: "name", Value: "foo"})
+		req := httptest.NewRequest(gohttp.MethodPost, "/hashes/fields", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.SetField(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response hashes.SetFieldResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Created)
+	})
+
+	t.Run("it should get a field", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/hashes/fields?key=user&field=name", nil)
+		rr := httptest.NewRecorder()
+
+		controller.GetField(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response hashes.GetFieldResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "foo", response.Value)
+	})
+
+	t.Run("it should return an error if the field does not exist", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/hashes/fields?key=user&field=age", nil)
+		rr := httptest.NewRecorder()
+
+		controller.GetField(rr, req)
+
+		assert.Equal(t, gohttp.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrFieldNotFound.Error())
+	})
+
+	t.Run("it should check if a field exists", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/hashes/exists?key=user&field=name", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Exists(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response hashes.ExistsResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Exists)
+	})
+
+	t.Run("it should increment a field", func(t *testing.T) {
+		payload, _ := json.Marshal(hashes.IncrByRequest{Key: "user", Field: "visits", By: 2})
+		req := httptest.NewRequest(gohttp.MethodPost, "/hashes/incr", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.IncrBy(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response hashes.IncrByResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, int64(2), response.Value)
+	})
+
+	t.Run("it should return an error if the field is not an integer", func(t *testing.T) {
+		payload, _ := json.Marshal(hashes.IncrByRequest{Key: "user", Field: "name", By: 1})
+		req := httptest.NewRequest(gohttp.MethodPost, "/hashes/incr", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.IncrBy(rr, req)
+
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), storage.ErrNotInteger.Error())
+	})
+
+	t.Run("it should return the number of fields", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/hashes/len?key=user", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Len(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response hashes.LenResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Len)
+	})
+
+	t.Run("it should delete fields", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodDelete, "/hashes/fields?key=user&field=name&field=unknown", nil)
+		rr := httptest.NewRecorder()
+
+		controller.DeleteFields(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response hashes.DeleteFieldsResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Deleted)
+
+		fields, err := store.HGetAll("user")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"visits": "2"}, fields)
+	})
+}
+
+func TestHashesEndpoints_E2E(t *testing.T) {
+	hashStore := storage.NewHashStore()
+	validAPIKey := "valid-api-key"
+
+	srv, err := http.NewServer(
+		"8080",
+		http.NewStringsController(storage.NewStringStore()),
+		http.NewStringListsController(storage.NewListStore[string]()),
+		validAPIKey,
+		http.WithHashesController(http.NewHashesController(hashStore)),
+	)
+	assert.NoError(t, err)
+
+	t.Run("should return 401 when wrong auth is provided", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/hashes?key=user", nil)
+		req.Header.Set("Authorization", "Bearer invalid-api-key")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, gohttp.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should set and delete a hash", func(t *testing.T) {
+		body, _ := json.Marshal(hashes.SetRequest{Key: "user", Fields: map[string]string{"name": "foo"}})
+		req := httptest.NewRequest(gohttp.MethodPost, "/hashes", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		assert.Equal(t, gohttp.StatusNoContent, rr.Code)
+
+		req = httptest.NewRequest(gohttp.MethodDelete, "/hashes?key=user", nil)
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr = httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		assert.Equal(t, gohttp.StatusNoContent, rr.Code)
+
+		_, err := hashStore.Get("user")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("should return 405 on unsupported methods", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodPut, "/hashes", nil)
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, gohttp.StatusMethodNotAllowed, rr.Code)
+	})
+}
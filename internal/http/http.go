@@ -5,7 +5,9 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
 )
 
@@ -14,9 +16,85 @@ type Server struct {
 
 	stringsController    StringsController
 	stringListController ListsController
+	jsonListController   ListsController
+	hashesController     HashesController
+	setsController       SetsController
+	zsetsController      ZSetsController
+	streamsController    StreamsController
+	txController         TxController
+	keysController       KeysController
+	pubSubController     PubSubController
+	watchController      WatchController
 	authMiddleware       *AuthMiddleware
 }
 
+// ServerOption configures the optional controllers of the Server.
+// Routes are only registered for the controllers that are provided.
+type ServerOption func(*Server)
+
+// WithJSONListsController registers the /lists/json routes.
+func WithJSONListsController(jsonListController ListsController) ServerOption {
+	return func(s *Server) {
+		s.jsonListController = jsonListController
+	}
+}
+
+// WithHashesController registers the /hashes routes.
+func WithHashesController(hashesController HashesController) ServerOption {
+	return func(s *Server) {
+		s.hashesController = hashesController
+	}
+}
+
+// WithSetsController registers the /sets routes.
+func WithSetsController(setsController SetsController) ServerOption {
+	return func(s *Server) {
+		s.setsController = setsController
+	}
+}
+
+// WithZSetsController registers the /zsets routes.
+func WithZSetsController(zsetsController ZSetsController) ServerOption {
+	return func(s *Server) {
+		s.zsetsController = zsetsController
+	}
+}
+
+// WithStreamsController registers the /streams routes.
+func WithStreamsController(streamsController StreamsController) ServerOption {
+	return func(s *Server) {
+		s.streamsController = streamsController
+	}
+}
+
+// WithTxController registers the /tx route.
+func WithTxController(txController TxController) ServerOption {
+	return func(s *Server) {
+		s.txController = txController
+	}
+}
+
+// WithKeysController registers the /keys and /ttl routes.
+func WithKeysController(keysController KeysController) ServerOption {
+	return func(s *Server) {
+		s.keysController = keysController
+	}
+}
+
+// WithPubSubController registers the /publish and /subscribe routes.
+func WithPubSubController(pubSubController PubSubController) ServerOption {
+	return func(s *Server) {
+		s.pubSubController = pubSubController
+	}
+}
+
+// WithWatchController registers the /watch route.
+func WithWatchController(watchController WatchController) ServerOption {
+	return func(s *Server) {
+		s.watchController = watchController
+	}
+}
+
 // NewServer creates a new HTTP server with the providided port.
 // It returns an error if the port is missing.
 func NewServer(
@@ -24,6 +102,7 @@ func NewServer(
 	stringsController StringsController,
 	stringListController ListsController,
 	apiKey string,
+	opts ...ServerOption,
 ) (*Server, error) {
 	if port == "" {
 		return nil, errors.New("missing port")
@@ -40,6 +119,9 @@ func NewServer(
 		stringListController: stringListController,
 		authMiddleware:       NewAuthMiddleware(apiKey),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
 	s.Server = &http.Server{
 		Addr: ":" + port,
 	}
@@ -88,24 +170,307 @@ func (s *Server) routes() *http.ServeMux {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
 	}))
+	mux.HandleFunc("/strings/setnx", s.authMiddleware.WithAuth(s.stringsController.SetNX))
+	mux.HandleFunc("/strings/setxx", s.authMiddleware.WithAuth(s.stringsController.SetXX))
+	mux.HandleFunc("/strings/cas", s.authMiddleware.WithAuth(s.stringsController.CompareAndSwap))
+	mux.HandleFunc("/strings/incr", s.authMiddleware.WithAuth(s.stringsController.IncrBy))
+	mux.HandleFunc("/strings/incrbyfloat", s.authMiddleware.WithAuth(s.stringsController.IncrByFloat))
 
 	// String list routes
-	mux.HandleFunc("/lists/strings", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+	s.listRoutes(mux, "/lists/strings", s.stringListController)
+
+	// JSON list routes
+	if s.jsonListController != nil {
+		s.listRoutes(mux, "/lists/json", s.jsonListController)
+	}
+
+	// Hash routes
+	if s.hashesController != nil {
+		mux.HandleFunc("/hashes", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.hashesController.Set(w, r)
+			case http.MethodGet:
+				s.hashesController.Get(w, r)
+			case http.MethodDelete:
+				s.hashesController.Delete(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/hashes/fields", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.hashesController.SetField(w, r)
+			case http.MethodGet:
+				s.hashesController.GetField(w, r)
+			case http.MethodDelete:
+				s.hashesController.DeleteFields(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/hashes/exists", s.authMiddleware.WithAuth(s.hashesController.Exists))
+		mux.HandleFunc("/hashes/len", s.authMiddleware.WithAuth(s.hashesController.Len))
+		mux.HandleFunc("/hashes/incr", s.authMiddleware.WithAuth(s.hashesController.IncrBy))
+	}
+
+	// Set routes
+	if s.setsController != nil {
+		mux.HandleFunc("/sets", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.setsController.Set(w, r)
+			case http.MethodGet:
+				s.setsController.Get(w, r)
+			case http.MethodDelete:
+				s.setsController.Delete(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/sets/members", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.setsController.Add(w, r)
+			case http.MethodDelete:
+				s.setsController.RemoveMembers(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/sets/ismember", s.authMiddleware.WithAuth(s.setsController.IsMember))
+		mux.HandleFunc("/sets/card", s.authMiddleware.WithAuth(s.setsController.Card))
+		mux.HandleFunc("/sets/pop", s.authMiddleware.WithAuth(s.setsController.Pop))
+		mux.HandleFunc("/sets/random", s.authMiddleware.WithAuth(s.setsController.RandomMember))
+		mux.HandleFunc("/sets/union", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				s.setsController.Union(w, r)
+			case http.MethodPost:
+				s.setsController.UnionStore(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/sets/intersect", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				s.setsController.Intersect(w, r)
+			case http.MethodPost:
+				s.setsController.IntersectStore(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/sets/diff", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				s.setsController.Diff(w, r)
+			case http.MethodPost:
+				s.setsController.DiffStore(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+	}
+
+	// Sorted set routes
+	if s.zsetsController != nil {
+		mux.HandleFunc("/zsets", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.zsetsController.Set(w, r)
+			case http.MethodGet:
+				s.zsetsController.Get(w, r)
+			case http.MethodDelete:
+				s.zsetsController.Delete(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/zsets/members", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.zsetsController.Add(w, r)
+			case http.MethodDelete:
+				s.zsetsController.RemoveMembers(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/zsets/incr", s.authMiddleware.WithAuth(s.zsetsController.IncrBy))
+		mux.HandleFunc("/zsets/score", s.authMiddleware.WithAuth(s.zsetsController.Score))
+		mux.HandleFunc("/zsets/rank", s.authMiddleware.WithAuth(s.zsetsController.Rank))
+		mux.HandleFunc("/zsets/card", s.authMiddleware.WithAuth(s.zsetsController.Card))
+		mux.HandleFunc("/zsets/range", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				s.zsetsController.Range(w, r)
+			case http.MethodDelete:
+				s.zsetsController.RemoveRange(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/zsets/range/score", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				s.zsetsController.RangeByScore(w, r)
+			case http.MethodDelete:
+				s.zsetsController.RemoveRangeByScore(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+	}
+
+	// Stream routes
+	if s.streamsController != nil {
+		mux.HandleFunc("/streams", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.streamsController.Add(w, r)
+			case http.MethodGet:
+				s.streamsController.Range(w, r)
+			case http.MethodDelete:
+				s.streamsController.Delete(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/streams/len", s.authMiddleware.WithAuth(s.streamsController.Len))
+		mux.HandleFunc("/streams/trim", s.authMiddleware.WithAuth(s.streamsController.Trim))
+		mux.HandleFunc("/streams/entries", s.authMiddleware.WithAuth(s.streamsController.DeleteEntries))
+		mux.HandleFunc("/streams/groups", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.streamsController.CreateGroup(w, r)
+			case http.MethodGet:
+				s.streamsController.Groups(w, r)
+			case http.MethodDelete:
+				s.streamsController.DeleteGroup(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/streams/groups/read", s.authMiddleware.WithAuth(s.streamsController.ReadGroup))
+		mux.HandleFunc("/streams/groups/ack", s.authMiddleware.WithAuth(s.streamsController.Ack))
+		mux.HandleFunc("/streams/groups/pending", s.authMiddleware.WithAuth(s.streamsController.Pending))
+		mux.HandleFunc("/streams/groups/claim", s.authMiddleware.WithAuth(s.streamsController.Claim))
+	}
+
+	// Transaction routes
+	if s.txController != nil {
+		mux.HandleFunc("/tx", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.txController.Exec(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+	}
+
+	// Keyspace routes
+	if s.keysController != nil {
+		mux.HandleFunc("/keys", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				s.keysController.Scan(w, r)
+			case http.MethodDelete:
+				s.keysController.Delete(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/keys/type", s.authMiddleware.WithAuth(s.keysController.Type))
+		mux.HandleFunc("/keys/exists", s.authMiddleware.WithAuth(s.keysController.Exists))
+		mux.HandleFunc("/keys/rename", s.authMiddleware.WithAuth(s.keysController.Rename))
+		mux.HandleFunc("/keys/count", s.authMiddleware.WithAuth(s.keysController.Count))
+		mux.HandleFunc("/ttl", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				s.keysController.TTL(w, r)
+			case http.MethodPost:
+				s.keysController.Expire(w, r)
+			case http.MethodDelete:
+				s.keysController.Persist(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+	}
+
+	// Watch routes
+	if s.watchController != nil {
+		mux.HandleFunc("/watch", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				s.watchController.Watch(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+	}
+
+	// Pub/sub routes
+	if s.pubSubController != nil {
+		mux.HandleFunc("/publish", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				s.pubSubController.Publish(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+		mux.HandleFunc("/subscribe", s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				s.pubSubController.Subscribe(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}))
+	}
+
+	return mux
+}
+
+// listRoutes registers the routes of a list controller under the given path.
+func (s *Server) listRoutes(mux *http.ServeMux, path string, controller ListsController) {
+	mux.HandleFunc(path, s.authMiddleware.WithAuth(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			s.stringListController.Set(w, r)
+			controller.Set(w, r)
 		case http.MethodGet:
-			s.stringListController.Get(w, r)
+			controller.Get(w, r)
 		case http.MethodDelete:
-			s.stringListController.Delete(w, r)
+			controller.Delete(w, r)
 		case http.MethodPut:
-			s.stringListController.Update(w, r)
+			controller.Update(w, r)
 		default:
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/lists/strings/push", s.authMiddleware.WithAuth(s.stringListController.Push))
-	mux.HandleFunc("/lists/strings/pop", s.authMiddleware.WithAuth(s.stringListController.Pop))
+	mux.HandleFunc(path+"/push", s.authMiddleware.WithAuth(controller.Push))
+	mux.HandleFunc(path+"/pop", s.authMiddleware.WithAuth(controller.Pop))
+	mux.HandleFunc(path+"/move", s.authMiddleware.WithAuth(controller.Move))
+	mux.HandleFunc(path+"/reserve", s.authMiddleware.WithAuth(controller.Reserve))
+	mux.HandleFunc(path+"/ack", s.authMiddleware.WithAuth(controller.Ack))
+	mux.HandleFunc(path+"/nack", s.authMiddleware.WithAuth(controller.Nack))
+}
 
-	return mux
+// writeJSON writes the given value as the JSON response body.
+func writeJSON(w http.ResponseWriter, v any) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("ERROR: failed to marshal response: %v", err)
+		http.Error(w, "failed to marshal response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(res); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
 }
@@ -0,0 +1,205 @@
+package http
+
+import (
+	"encoding/json"
+	"in-memory-storage/internal/jsonschema"
+	"in-memory-storage/internal/keys"
+	"in-memory-storage/storage"
+	"log"
+	"net/http"
+	"time"
+)
+
+// defaultScanCount is the number of keys returned by a scan when the count is not given.
+const defaultScanCount = 10
+
+type KeysController interface {
+	Scan(w http.ResponseWriter, r *http.Request)
+	Type(w http.ResponseWriter, r *http.Request)
+	Exists(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Rename(w http.ResponseWriter, r *http.Request)
+	Count(w http.ResponseWriter, r *http.Request)
+	TTL(w http.ResponseWriter, r *http.Request)
+	Expire(w http.ResponseWriter, r *http.Request)
+	Persist(w http.ResponseWriter, r *http.Request)
+}
+
+func NewKeysController(keyspace storage.Keyspace) KeysController {
+	return &keysController{keyspace: keyspace}
+}
+
+type keysController struct {
+	keyspace storage.Keyspace
+}
+
+func (kc *keysController) Scan(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	count, err := parseIntParam(query, "count", defaultScanCount)
+	if err != nil || count <= 0 {
+		http.Error(w, storage.ErrInvalidCount.Error(), http.StatusBadRequest)
+		return
+	}
+
+	keyList, cursor, err := kc.keyspace.Scan(query.Get("cursor"), query.Get("match"), count)
+	if err != nil {
+		log.Printf("ERROR: failed to scan keys: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if keyList == nil {
+		keyList = []string{}
+	}
+
+	writeJSON(w, &keys.ScanResponse{Keys: keyList, Cursor: cursor})
+}
+
+func (kc *keysController) Type(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, &keys.TypeResponse{Key: key, Type: string(kc.keyspace.Type(key))})
+}
+
+func (kc *keysController) Exists(w http.ResponseWriter, r *http.Request) {
+	keyList := r.URL.Query()["key"]
+	if !validKeys(keyList) {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, &keys.ExistsResponse{Count: kc.keyspace.Exists(keyList...)})
+}
+
+func (kc *keysController) Delete(w http.ResponseWriter, r *http.Request) {
+	keyList := r.URL.Query()["key"]
+	if !validKeys(keyList) {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := kc.keyspace.Delete(keyList...)
+	if err != nil {
+		log.Printf("ERROR: failed to delete keys %v: %v", keyList, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, &keys.DeleteResponse{Deleted: n})
+}
+
+func (kc *keysController) Rename(w http.ResponseWriter, r *http.Request) {
+	var req keys.RenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Source == "" || req.Destination == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := kc.keyspace.Rename(req.Source, req.Destination); err != nil {
+		if err == storage.ErrNotFound {
+			http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		if err == jsonschema.ErrSchemaChange {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("ERROR: failed to rename key %s to %s: %v", req.Source, req.Destination, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (kc *keysController) Count(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, &keys.CountResponse{Count: kc.keyspace.Count()})
+}
+
+func (kc *keysController) TTL(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl, err := kc.keyspace.TTL(key)
+	if err != nil {
+		kc.handleError(w, key, err)
+		return
+	}
+
+	res := keys.TTLResponse{Key: key, TTL: -1, TTLMs: -1}
+	if ttl != storage.NoTTL {
+		res.TTL = int64(ttl.Round(time.Second) / time.Second)
+		res.TTLMs = ttl.Milliseconds()
+		res.ExpiresAt = formatTime(time.Now().Add(ttl))
+	}
+	writeJSON(w, &res)
+}
+
+func (kc *keysController) Expire(w http.ResponseWriter, r *http.Request) {
+	var req keys.ExpireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	ttl, err := requestTTL(req.TTL, req.TTLMs, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ttl <= 0 {
+		http.Error(w, ErrInvalidTTL.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Sliding {
+		err = kc.keyspace.ExpireSliding(req.Key, ttl)
+	} else {
+		err = kc.keyspace.Expire(req.Key, ttl)
+	}
+	if err != nil {
+		kc.handleError(w, req.Key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (kc *keysController) Persist(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := kc.keyspace.Persist(key); err != nil {
+		kc.handleError(w, key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (kc *keysController) handleError(w http.ResponseWriter, key string, err error) {
+	if err == storage.ErrNotFound || err == storage.ErrExpired {
+		http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	log.Printf("ERROR: failed to change the expiration of key %s: %v", key, err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
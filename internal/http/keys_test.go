@@ -0,0 +1,372 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"in-memory-storage/internal/http"
+	"in-memory-storage/internal/jsonschema"
+	"in-memory-storage/internal/keys"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newKeyspaceController(t *testing.T) (http.KeysController, storage.StringStore, storage.ListStore[string]) {
+	t.Helper()
+	keyspace := storage.NewKeyspace()
+	stringStore := storage.NewStringStore(storage.WithKeyspace(keyspace))
+	listStore := storage.NewListStore[string](storage.WithKeyspace(keyspace))
+	return http.NewKeysController(keyspace), stringStore, listStore
+}
+
+func TestKeysController_Type(t *testing.T) {
+	controller, stringStore, listStore := newKeyspaceController(t)
+	assert.NoError(t, stringStore.Set("string", "value", 0))
+	assert.NoError(t, listStore.Set("list", []string{"a"}, 0))
+
+	testCases := map[string]struct {
+		key            string
+		expectedStatus int
+		expectedError  error
+		expectedType   string
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return the type of a string": {
+			key:            "string",
+			expectedStatus: gohttp.StatusOK,
+			expectedType:   "string",
+		},
+		"it should return the type of a list": {
+			key:            "list",
+			expectedStatus: gohttp.StatusOK,
+			expectedType:   "list",
+		},
+		"it should return none if the key doesn't exist": {
+			key:            "missing",
+			expectedStatus: gohttp.StatusOK,
+			expectedType:   "none",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(gohttp.MethodGet, "/keys/type?key="+tc.key, nil)
+			rr := httptest.NewRecorder()
+
+			controller.Type(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response keys.TypeResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedType, response.Type)
+			}
+		})
+	}
+}
+
+func TestKeysController_ExistsDeleteCount(t *testing.T) {
+	controller, stringStore, listStore := newKeyspaceController(t)
+	assert.NoError(t, stringStore.Set("string", "value", 0))
+	assert.NoError(t, listStore.Set("list", []string{"a"}, 0))
+
+	req := httptest.NewRequest(gohttp.MethodGet, "/keys/exists?key=string&key=list&key=missing", nil)
+	rr := httptest.NewRecorder()
+	controller.Exists(rr, req)
+	assert.Equal(t, gohttp.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"count": 2}`, rr.Body.String())
+
+	req = httptest.NewRequest(gohttp.MethodGet, "/keys/exists", nil)
+	rr = httptest.NewRecorder()
+	controller.Exists(rr, req)
+	assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+
+	req = httptest.NewRequest(gohttp.MethodDelete, "/keys?key=list&key=missing", nil)
+	rr = httptest.NewRecorder()
+	controller.Delete(rr, req)
+	assert.Equal(t, gohttp.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"deleted": 1}`, rr.Body.String())
+
+	req = httptest.NewRequest(gohttp.MethodGet, "/keys/count", nil)
+	rr = httptest.NewRecorder()
+	controller.Count(rr, req)
+	assert.Equal(t, gohttp.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"count": 1}`, rr.Body.String())
+}
+
+func TestKeysController_Rename(t *testing.T) {
+	controller, stringStore, listStore := newKeyspaceController(t)
+	assert.NoError(t, stringStore.Set("string", "value", 0))
+	assert.NoError(t, listStore.Set("list", []string{"a"}, 0))
+
+	testCases := map[string]struct {
+		req            keys.RenameRequest
+		expectedStatus int
+		expectedError  error
+	}{
+		"it should return an error if a key is missing": {
+			req:            keys.RenameRequest{Source: "string"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the source doesn't exist": {
+			req:            keys.RenameRequest{Source: "missing", Destination: "other"},
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"success": {
+			req:            keys.RenameRequest{Source: "list", Destination: "string"},
+			expectedStatus: gohttp.StatusNoContent,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/keys/rename", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.Rename(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				list, err := listStore.Get(tc.req.Destination)
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"a"}, list.Value)
+				_, err = stringStore.Get(tc.req.Destination)
+				assert.Equal(t, storage.ErrNotFound, err)
+			}
+		})
+	}
+}
+
+func TestKeysController_RenameSchema(t *testing.T) {
+	schemas, err := jsonschema.NewRegistry(map[string]json.RawMessage{
+		"users:": json.RawMessage(`{"type": "object"}`),
+	})
+	assert.NoError(t, err)
+	keyspace := storage.NewKeyspace()
+	listStore := storage.NewListStore[json.RawMessage](storage.WithKeyspace(keyspace))
+	controller := http.NewKeysController(schemas.Keyspace(keyspace))
+	assert.NoError(t, listStore.Set("numbers", []json.RawMessage{json.RawMessage(`3`)}, 0))
+
+	req := httptest.NewRequest(gohttp.MethodPost, "/keys/rename", bytes.NewReader([]byte(`{"source": "numbers", "destination": "users:list"}`)))
+	rr := httptest.NewRecorder()
+	controller.Rename(rr, req)
+
+	assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), jsonschema.ErrSchemaChange.Error())
+	_, err = listStore.Get("users:list")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestStringsController_SetWrongType(t *testing.T) {
+	keyspace := storage.NewKeyspace()
+	stringStore := storage.NewStringStore(storage.WithKeyspace(keyspace))
+	listStore := storage.NewListStore[string](storage.WithKeyspace(keyspace))
+	controller := http.NewStringsController(stringStore)
+	assert.NoError(t, listStore.Set("list", []string{"a"}, 0))
+
+	req := httptest.NewRequest(gohttp.MethodPost, "/strings", bytes.NewReader([]byte(`{"key": "list", "value": "foo"}`)))
+	rr := httptest.NewRecorder()
+	controller.Set(rr, req)
+
+	assert.Equal(t, gohttp.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), http.ErrWrongType.Error())
+}
+
+func TestKeysController_Scan(t *testing.T) {
+	controller, stringStore, listStore := newKeyspaceController(t)
+	assert.NoError(t, stringStore.Set("user:1", "value", 0))
+	assert.NoError(t, stringStore.Set("user:2", "value", 0))
+	assert.NoError(t, listStore.Set("user:queue", []string{"a"}, 0))
+	assert.NoError(t, listStore.Set("other", []string{"a"}, 0))
+
+	testCases := map[string]struct {
+		query            string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		"it should return an error if the count is not a number": {
+			query:            "count=many",
+			expectedStatus:   gohttp.StatusBadRequest,
+			expectedResponse: storage.ErrInvalidCount.Error(),
+		},
+		"it should return an error if the count is not positive": {
+			query:            "count=0",
+			expectedStatus:   gohttp.StatusBadRequest,
+			expectedResponse: storage.ErrInvalidCount.Error(),
+		},
+		"it should return the first page of the matching keys": {
+			query:            "match=user:*&count=2",
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"keys": ["user:1", "user:2"], "cursor": "user:2"}`,
+		},
+		"it should continue from the cursor": {
+			query:            "match=user:*&count=2&cursor=user:2",
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"keys": ["user:queue"], "cursor": ""}`,
+		},
+		"it should return all the keys by default": {
+			query:            "",
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"keys": ["other", "user:1", "user:2", "user:queue"], "cursor": ""}`,
+		},
+		"it should return an empty list if no key matches": {
+			query:            "match=missing*",
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"keys": [], "cursor": ""}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(gohttp.MethodGet, "/keys?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			controller.Scan(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedStatus == gohttp.StatusOK {
+				assert.JSONEq(t, tc.expectedResponse, rr.Body.String())
+			} else {
+				assert.Contains(t, rr.Body.String(), tc.expectedResponse)
+			}
+		})
+	}
+}
+
+func TestKeysController_Expire(t *testing.T) {
+	controller, stringStore, listStore := newKeyspaceController(t)
+	assert.NoError(t, stringStore.Set("session", "value", 0))
+	assert.NoError(t, listStore.Set("queue", []string{"a"}, 0))
+
+	testCases := map[string]struct {
+		body           string
+		expectedStatus int
+		expectedError  error
+	}{
+		"it should return an error if the body is invalid": {
+			body:           `{`,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidBody,
+		},
+		"it should return an error if the key is missing": {
+			body:           `{"ttl": 60}`,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the ttl is not positive": {
+			body:           `{"key": "session", "ttl": 0}`,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidTTL,
+		},
+		"it should return an error if the ttl in milliseconds is negative": {
+			body:           `{"key": "session", "ttl_ms": -250}`,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidTTL,
+		},
+		"it should return an error if the expiration time is in the past": {
+			body:           `{"key": "session", "expires_at": "2020-01-01T00:00:00Z"}`,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidTTL,
+		},
+		"it should return an error if more than one ttl is given": {
+			body:           `{"key": "session", "ttl": 60, "ttl_ms": 250}`,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrConflictingTTL,
+		},
+		"it should return an error if the key doesn't exist": {
+			body:           `{"key": "missing", "ttl": 60}`,
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"it should set the ttl of a string": {
+			body:           `{"key": "session", "ttl": 60}`,
+			expectedStatus: gohttp.StatusNoContent,
+		},
+		"it should set the ttl of a string in milliseconds": {
+			body:           `{"key": "session", "ttl_ms": 1500}`,
+			expectedStatus: gohttp.StatusNoContent,
+		},
+		"it should set the expiration time of a string": {
+			body:           `{"key": "session", "expires_at": "2100-01-01T00:00:00.250Z"}`,
+			expectedStatus: gohttp.StatusNoContent,
+		},
+		"it should set a sliding ttl on a list": {
+			body:           `{"key": "queue", "ttl": 30, "sliding": true}`,
+			expectedStatus: gohttp.StatusNoContent,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(gohttp.MethodPost, "/ttl", bytes.NewReader([]byte(tc.body)))
+			rr := httptest.NewRecorder()
+
+			controller.Expire(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			}
+		})
+	}
+
+	list, err := listStore.Get("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, list.Sliding)
+}
+
+func TestKeysController_TTLPersist(t *testing.T) {
+	controller, stringStore, _ := newKeyspaceController(t)
+	assert.NoError(t, stringStore.Set("session", "value", time.Minute))
+
+	req := httptest.NewRequest(gohttp.MethodGet, "/ttl?key=session", nil)
+	rr := httptest.NewRecorder()
+	controller.TTL(rr, req)
+	assert.Equal(t, gohttp.StatusOK, rr.Code)
+	var res keys.TTLResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&res))
+	assert.Equal(t, int64(60), res.TTL)
+	assert.InDelta(t, 60000, res.TTLMs, 100)
+	expiresAt, err := time.Parse(time.RFC3339Nano, res.ExpiresAt)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+	assert.Regexp(t, `\.\d{3}`, res.ExpiresAt)
+
+	req = httptest.NewRequest(gohttp.MethodDelete, "/ttl?key=session", nil)
+	rr = httptest.NewRecorder()
+	controller.Persist(rr, req)
+	assert.Equal(t, gohttp.StatusNoContent, rr.Code)
+
+	req = httptest.NewRequest(gohttp.MethodGet, "/ttl?key=session", nil)
+	rr = httptest.NewRecorder()
+	controller.TTL(rr, req)
+	assert.Equal(t, gohttp.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"key": "session", "ttl": -1, "ttl_ms": -1}`, rr.Body.String())
+
+	for _, method := range []string{gohttp.MethodGet, gohttp.MethodDelete} {
+		req = httptest.NewRequest(method, "/ttl?key=missing", nil)
+		rr = httptest.NewRecorder()
+		if method == gohttp.MethodGet {
+			controller.TTL(rr, req)
+		} else {
+			controller.Persist(rr, req)
+		}
+		assert.Equal(t, gohttp.StatusNotFound, rr.Code)
+	}
+}
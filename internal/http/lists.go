@@ -2,10 +2,16 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"in-memory-storage/internal/jsonschema"
 	"in-memory-storage/internal/lists"
 	"in-memory-storage/storage"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"time"
 )
 
@@ -16,24 +22,61 @@ type ListsController interface {
 	Delete(w http.ResponseWriter, r *http.Request)
 	Push(w http.ResponseWriter, r *http.Request)
 	Pop(w http.ResponseWriter, r *http.Request)
+	Move(w http.ResponseWriter, r *http.Request)
+	Reserve(w http.ResponseWriter, r *http.Request)
+	Ack(w http.ResponseWriter, r *http.Request)
+	Nack(w http.ResponseWriter, r *http.Request)
 }
 
 func NewStringListsController(store storage.ListStore[string]) ListsController {
-	return &stringListsController{store: store}
+	return &listsController[string]{
+		store: store,
+		empty: func(v string) bool { return v == "" },
+	}
 }
 
-type stringListsController struct {
-	store storage.ListStore[string]
+// NewJSONListsController creates a ListsController for lists of arbitrary JSON values.
+// If schemas is not nil, the values written to a key must match the schema of its prefix.
+func NewJSONListsController(store storage.ListStore[json.RawMessage], schemas *jsonschema.Registry) ListsController {
+	lc := &listsController[json.RawMessage]{
+		store: store,
+		empty: func(v json.RawMessage) bool { return len(v) == 0 },
+	}
+	if schemas != nil {
+		lc.validate = func(key string, v json.RawMessage) error {
+			return schemas.Validate(key, v)
+		}
+	}
+	return lc
 }
 
-func (slc *stringListsController) Get(w http.ResponseWriter, r *http.Request) {
-	key := r.URL.Query().Get("key")
+type listsController[T any] struct {
+	store storage.ListStore[T]
+	// empty reports whether a pushed value is missing.
+	empty func(T) bool
+	// validate checks the values written to a key, if set.
+	validate func(key string, v T) error
+}
+
+func (lc *listsController[T]) Get(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := query.Get("key")
 	if key == "" {
 		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
 		return
 	}
 
-	value, err := slc.store.Get(key)
+	// Long lists can be read in pages, or only measured, instead of returned whole.
+	if query.Get("len") != "" {
+		lc.length(w, key, query)
+		return
+	}
+	if query.Get("start") != "" || query.Get("stop") != "" {
+		lc.listRange(w, key, query)
+		return
+	}
+
+	value, err := lc.store.Get(key)
 	if err != nil {
 		if err == storage.ErrNotFound || err == storage.ErrExpired {
 			http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
@@ -46,9 +89,10 @@ func (slc *stringListsController) Get(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	res, err := json.Marshal(&lists.GetResponse[string]{
+	res, err := json.Marshal(&lists.GetResponse[T]{
 		List:      value.Value,
-		ExpiresAt: value.ExpiresAt.Format(time.RFC3339),
+		ExpiresAt: formatTime(value.ExpiresAt),
+		Version:   value.Version,
 	})
 	if err != nil {
 		log.Printf("ERROR: failed to marshal response for key %s: %v", key, err)
@@ -61,8 +105,54 @@ func (slc *stringListsController) Get(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (slc *stringListsController) Set(w http.ResponseWriter, r *http.Request) {
-	var req lists.SetRequest[string]
+func (lc *listsController[T]) Move(w http.ResponseWriter, r *http.Request) {
+	var req lists.MoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Source == "" || req.Destination == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	srcLeft, ok := parseSide(req.From, false)
+	if !ok {
+		http.Error(w, ErrInvalidSide.Error(), http.StatusBadRequest)
+		return
+	}
+	dstLeft, ok := parseSide(req.To, true)
+	if !ok {
+		http.Error(w, ErrInvalidSide.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var check func(T) error
+	if lc.validate != nil {
+		// The moved value is checked under the lock of the store, so it can't change before it's moved.
+		check = func(v T) error {
+			if err := lc.validate(req.Destination, v); err != nil {
+				return fmt.Errorf("%w: %v", ErrSchemaMismatch, err)
+			}
+			return nil
+		}
+	}
+
+	value, err := lc.store.LMoveIf(req.Source, req.Destination, srcLeft, dstLeft, check)
+	if errors.Is(err, ErrSchemaMismatch) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		lc.handleQueueError(w, req.Source, err)
+		return
+	}
+
+	writeJSON(w, &lists.PopResponse[T]{Value: value})
+}
+
+func (lc *listsController[T]) Reserve(w http.ResponseWriter, r *http.Request) {
+	var req lists.ReserveRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("ERROR: failed to decode request body: %v", err)
 		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
@@ -73,11 +163,228 @@ func (slc *stringListsController) Set(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := slc.store.Set(req.Key, req.List, time.Duration(req.TTL)*time.Second); err != nil {
+	reservation, err := lc.store.Reserve(req.Key, time.Duration(req.Visibility)*time.Second)
+	if err != nil {
+		lc.handleQueueError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &lists.ReserveResponse[T]{
+		ID:        reservation.ID,
+		Value:     reservation.Value,
+		VisibleAt: formatTime(reservation.VisibleAt),
+	})
+}
+
+func (lc *listsController[T]) Ack(w http.ResponseWriter, r *http.Request) {
+	lc.acknowledge(w, r, lc.store.Ack)
+}
+
+func (lc *listsController[T]) Nack(w http.ResponseWriter, r *http.Request) {
+	lc.acknowledge(w, r, lc.store.Nack)
+}
+
+// acknowledge decodes an AckRequest and applies it with the given Ack or Nack.
+func (lc *listsController[T]) acknowledge(w http.ResponseWriter, r *http.Request, ack func(key, id string) error) {
+	var req lists.AckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ID == "" {
+		http.Error(w, ErrEmptyID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := ack(req.Key, req.ID); err != nil {
+		lc.handleQueueError(w, req.Key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleQueueError maps the errors of moves and reservations to their HTTP responses.
+func (lc *listsController[T]) handleQueueError(w http.ResponseWriter, key string, err error) {
+	switch err {
+	case storage.ErrNotFound, storage.ErrExpired:
+		http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+	case storage.ErrEmptyList:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case storage.ErrReservationNotFound:
+		http.Error(w, ErrReservationNotFound.Error(), http.StatusNotFound)
+	case storage.ErrInvalidVisibility:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("ERROR: failed to handle list for key %s: %v", key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// check validates the values to be written to the key, answering with an error if one is not valid.
+// It returns false if the request can't go on.
+func (lc *listsController[T]) check(w http.ResponseWriter, key string, values []T) bool {
+	if lc.validate == nil {
+		return true
+	}
+	for i, v := range values {
+		if err := lc.validate(key, v); err != nil {
+			http.Error(w, fmt.Sprintf("%s: value %d: %v", ErrSchemaMismatch, i, err), http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+// parseSide reports whether the side is left, using the default one when it's empty.
+// It returns false if the side is not valid.
+func parseSide(side string, defLeft bool) (left, ok bool) {
+	switch side {
+	case "":
+		return defLeft, true
+	case lists.SideLeft:
+		return true, true
+	case lists.SideRight:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// maxPopTimeout bounds how long a blocking pop can hold a request.
+const maxPopTimeout = 60
+
+// blockingPop long-polls the lists of the request until a value can be popped from any of them.
+// It answers with no content if the timeout is reached first.
+func (lc *listsController[T]) blockingPop(w http.ResponseWriter, r *http.Request, req lists.PopRequest) {
+	if req.Timeout < 0 || req.Timeout > maxPopTimeout || req.Count != 0 {
+		http.Error(w, ErrInvalidTimeout.Error(), http.StatusBadRequest)
+		return
+	}
+	keys := append([]string{req.Key}, req.Keys...)
+	if slices.Contains(keys, "") {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	left, ok := parseSide(req.Side, true)
+	if !ok {
+		http.Error(w, ErrInvalidSide.Error(), http.StatusBadRequest)
+		return
+	}
+	pop := lc.store.BRPop
+	if left {
+		pop = lc.store.BLPop
+	}
+
+	// A zero timeout doesn't wait, like the pops without timeout.
+	timeout := time.Duration(req.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = time.Nanosecond
+	}
+
+	// The request context is cancelled if the client goes away, which stops the wait.
+	key, value, err := pop(r.Context(), timeout, keys...)
+	if err != nil {
+		if err == storage.ErrTimeout {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Context().Err() != nil {
+			return
+		}
+		log.Printf("ERROR: failed to pop from lists %v: %v", keys, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, &lists.PopResponse[T]{Value: value, Key: key})
+}
+
+// listRange writes the items between the start and stop query parameters,
+// which default to the first and the last item.
+func (lc *listsController[T]) listRange(w http.ResponseWriter, key string, query url.Values) {
+	start, err := parseIntParam(query, "start", 0)
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+	stop, err := parseIntParam(query, "stop", -1)
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := lc.store.Range(key, start, stop)
+	if err != nil {
+		lc.handleReadError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &lists.RangeResponse[T]{List: list})
+}
+
+// length writes the number of items of the list when the len query parameter is true.
+func (lc *listsController[T]) length(w http.ResponseWriter, key string, query url.Values) {
+	if ok, err := strconv.ParseBool(query.Get("len")); err != nil || !ok {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := lc.store.Len(key)
+	if err != nil {
+		lc.handleReadError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &lists.LenResponse{Length: n})
+}
+
+func (lc *listsController[T]) handleReadError(w http.ResponseWriter, key string, err error) {
+	if err == storage.ErrNotFound || err == storage.ErrExpired {
+		http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	log.Printf("ERROR: failed to get value for key %s: %v", key, err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func (lc *listsController[T]) Set(w http.ResponseWriter, r *http.Request) {
+	var req lists.SetRequest[T]
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !lc.check(w, req.Key, req.List) {
+		return
+	}
+
+	ttl, err := requestTTL(req.TTL, req.TTLMs, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := lc.store.Set(req.Key, req.List, ttl); err != nil {
 		if err == storage.ErrAlreadyExists {
 			http.Error(w, ErrKeyAlreadyExists.Error(), http.StatusConflict)
 			return
 		}
+		if err == storage.ErrWrongType {
+			http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+			return
+		}
 		log.Printf("ERROR: failed to set list for key %s: %v", req.Key, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -86,8 +393,8 @@ func (slc *stringListsController) Set(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (slc *stringListsController) Update(w http.ResponseWriter, r *http.Request) {
-	var req lists.UpdateRequest[string]
+func (lc *listsController[T]) Update(w http.ResponseWriter, r *http.Request) {
+	var req lists.UpdateRequest[T]
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("ERROR: failed to decode request body: %v", err)
 		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
@@ -98,9 +405,19 @@ func (slc *stringListsController) Update(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := slc.store.Update(req.Key, req.List); err != nil {
-		if err == storage.ErrNotFound || err == storage.ErrExpired {
-			http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+	if !lc.check(w, req.Key, req.List) {
+		return
+	}
+
+	ttl, err := requestTTL(req.TTL, req.TTLMs, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := lc.store.Set(req.Key, req.List, ttl, upsertOptions(ttl)...); err != nil {
+		if err == storage.ErrWrongType {
+			http.Error(w, ErrWrongType.Error(), http.StatusConflict)
 			return
 		}
 		log.Printf("ERROR: failed to update list for key %s: %v", req.Key, err)
@@ -111,14 +428,14 @@ func (slc *stringListsController) Update(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (slc *stringListsController) Delete(w http.ResponseWriter, r *http.Request) {
+func (lc *listsController[T]) Delete(w http.ResponseWriter, r *http.Request) {
 	key := r.URL.Query().Get("key")
 	if key == "" {
 		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := slc.store.Remove(key); err != nil {
+	if err := lc.store.Remove(key); err != nil {
 		if err == storage.ErrNotFound {
 			http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
 			return
@@ -132,8 +449,8 @@ func (slc *stringListsController) Delete(w http.ResponseWriter, r *http.Request)
 
 }
 
-func (slc *stringListsController) Push(w http.ResponseWriter, r *http.Request) {
-	var req lists.PushRequest[string]
+func (lc *listsController[T]) Push(w http.ResponseWriter, r *http.Request) {
+	var req lists.PushRequest[T]
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("ERROR: failed to decode request body: %v", err)
 		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
@@ -143,12 +460,30 @@ func (slc *stringListsController) Push(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
 		return
 	}
-	if req.Value == "" {
+
+	values := req.Values
+	if !lc.empty(req.Value) {
+		values = append([]T{req.Value}, values...)
+	}
+	if len(values) == 0 || slices.ContainsFunc(values, lc.empty) {
 		http.Error(w, ErrEmptyValue.Error(), http.StatusBadRequest)
 		return
 	}
+	if !lc.check(w, req.Key, values) {
+		return
+	}
 
-	if err := slc.store.Push(req.Key, req.Value); err != nil {
+	left, ok := parseSide(req.Side, false)
+	if !ok {
+		http.Error(w, ErrInvalidSide.Error(), http.StatusBadRequest)
+		return
+	}
+
+	push := lc.store.RPush
+	if left {
+		push = lc.store.LPush
+	}
+	if _, err := push(req.Key, values...); err != nil {
 		if err == storage.ErrNotFound || err == storage.ErrExpired {
 			http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
 			return
@@ -161,7 +496,7 @@ func (slc *stringListsController) Push(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (slc *stringListsController) Pop(w http.ResponseWriter, r *http.Request) {
+func (lc *listsController[T]) Pop(w http.ResponseWriter, r *http.Request) {
 	var req lists.PopRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("ERROR: failed to decode request body: %v", err)
@@ -172,13 +507,48 @@ func (slc *stringListsController) Pop(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.Timeout != 0 || len(req.Keys) > 0 {
+		lc.blockingPop(w, r, req)
+		return
+	}
+
+	left, ok := parseSide(req.Side, true)
+	if !ok {
+		http.Error(w, ErrInvalidSide.Error(), http.StatusBadRequest)
+		return
+	}
 
-	value, err := slc.store.Pop(req.Key)
+	var (
+		res any
+		err error
+	)
+	switch {
+	case req.Count != 0 && left:
+		var values []T
+		values, err = lc.store.LPopN(req.Key, req.Count)
+		res = &lists.PopManyResponse[T]{Values: values}
+	case req.Count != 0:
+		var values []T
+		values, err = lc.store.RPopN(req.Key, req.Count)
+		res = &lists.PopManyResponse[T]{Values: values}
+	case left:
+		var value T
+		value, err = lc.store.LPop(req.Key)
+		res = &lists.PopResponse[T]{Value: value}
+	default:
+		var value T
+		value, err = lc.store.RPop(req.Key)
+		res = &lists.PopResponse[T]{Value: value}
+	}
 	if err != nil {
 		if err == storage.ErrNotFound || err == storage.ErrEmptyList || err == storage.ErrExpired {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		if err == storage.ErrInvalidCount {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		log.Printf("ERROR: failed to pop from list for key %s: %v", req.Key, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -186,15 +556,13 @@ func (slc *stringListsController) Pop(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	res, err := json.Marshal(&lists.PopResponse[string]{
-		Value: value,
-	})
+	body, err := json.Marshal(res)
 	if err != nil {
 		log.Printf("ERROR: failed to marshal response for key %s: %v", req.Key, err)
 		http.Error(w, "failed to marshal response: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	if _, err := w.Write(res); err != nil {
+	if _, err := w.Write(body); err != nil {
 		log.Printf("failed to write response: %v", err)
 	}
 }
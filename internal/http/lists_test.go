@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"in-memory-storage/internal/http"
+	"in-memory-storage/internal/jsonschema"
 	"in-memory-storage/internal/lists"
 	"in-memory-storage/storage"
 
@@ -142,6 +143,74 @@ func TestListsController_Get(t *testing.T) {
 	}
 }
 
+func TestListsController_GetRange(t *testing.T) {
+	store := storage.NewListStore[string]()
+	controller := http.NewStringListsController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", []string{"a", "b", "c", "d"}, 0)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		query            string
+		expectedStatus   int
+		expectedError    error
+		expectedResponse string
+	}{
+		"it should return an error if the range is invalid": {
+			query:          "key=existing-key&start=foo",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidRange,
+		},
+		"it should return an error if len is invalid": {
+			query:          "key=existing-key&len=foo",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidRange,
+		},
+		"it should return an error if the key does not exist": {
+			query:          "key=non-existing-key&start=0",
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"it should return a range of the list": {
+			query:            "key=existing-key&start=1&stop=2",
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"list":["b","c"]}`,
+		},
+		"it should return the end of the list": {
+			query:            "key=existing-key&start=-2",
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"list":["c","d"]}`,
+		},
+		"it should return an empty range": {
+			query:            "key=existing-key&start=10",
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"list":[]}`,
+		},
+		"it should return the length of the list": {
+			query:            "key=existing-key&len=true",
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"length":4}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(gohttp.MethodGet, "/lists/strings?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			controller.Get(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				assert.JSONEq(t, tc.expectedResponse, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestListsController_Update(t *testing.T) {
 	store := storage.NewListStore[string]()
 	controller := http.NewStringListsController(store)
@@ -153,6 +222,8 @@ func TestListsController_Update(t *testing.T) {
 	testCases := map[string]struct {
 		key            string
 		list           []string
+		ttl            int64
+		ttlMs          int64
 		expectedStatus int
 		expectedError  error
 	}{
@@ -162,11 +233,24 @@ func TestListsController_Update(t *testing.T) {
 			expectedStatus: gohttp.StatusBadRequest,
 			expectedError:  http.ErrEmptyKey,
 		},
-		"it should return an error if the key does not exist": {
+		"it should return an error if more than one ttl is given": {
+			key:            "existing-key",
+			list:           []string{"foo"},
+			ttl:            60,
+			ttlMs:          500,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrConflictingTTL,
+		},
+		"it should create the list if it does not exist": {
 			key:            "non-existing-key",
 			list:           []string{"new-item"},
-			expectedStatus: gohttp.StatusNotFound,
-			expectedError:  http.ErrKeyNotFound,
+			expectedStatus: gohttp.StatusNoContent,
+		},
+		"it should create the list with a ttl": {
+			key:            "expiring-key",
+			list:           []string{"new-item"},
+			ttlMs:          500,
+			expectedStatus: gohttp.StatusNoContent,
 		},
 		"success": {
 			key:            "existing-key",
@@ -178,8 +262,10 @@ func TestListsController_Update(t *testing.T) {
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
 			payload, _ := json.Marshal(lists.UpdateRequest[string]{
-				Key:  tc.key,
-				List: tc.list,
+				Key:   tc.key,
+				List:  tc.list,
+				TTL:   tc.ttl,
+				TTLMs: tc.ttlMs,
 			})
 			req := httptest.NewRequest(gohttp.MethodPut, "/lists/strings", bytes.NewReader(payload))
 			req.Header.Set("Content-Type", "application/json")
@@ -195,7 +281,509 @@ func TestListsController_Update(t *testing.T) {
 				storedValue, err := store.Get(tc.key)
 				assert.NoError(t, err)
 				assert.ElementsMatch(t, tc.list, storedValue.Value)
+				assert.Equal(t, tc.ttlMs > 0, !storedValue.ExpiresAt.IsZero())
 			}
 		})
 	}
 }
+
+func TestListsController_Push(t *testing.T) {
+	store := storage.NewListStore[string]()
+	controller := http.NewStringListsController(store)
+
+	testCases := map[string]struct {
+		req            lists.PushRequest[string]
+		expectedStatus int
+		expectedError  error
+		expectedList   []string
+	}{
+		"it should return an error if the key is missing": {
+			req:            lists.PushRequest[string]{Value: "foo"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if there are no values": {
+			req:            lists.PushRequest[string]{Key: "list"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyValue,
+		},
+		"it should return an error if a value is empty": {
+			req:            lists.PushRequest[string]{Key: "list", Values: []string{"foo", ""}},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyValue,
+		},
+		"it should return an error if the side is invalid": {
+			req:            lists.PushRequest[string]{Key: "list", Value: "foo", Side: "middle"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidSide,
+		},
+		"it should return an error if the key does not exist": {
+			req:            lists.PushRequest[string]{Key: "non-existing-key", Value: "foo"},
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"it should push to the end of the list by default": {
+			req:            lists.PushRequest[string]{Key: "list", Value: "c", Values: []string{"d"}},
+			expectedStatus: gohttp.StatusNoContent,
+			expectedList:   []string{"a", "b", "c", "d"},
+		},
+		"it should push to the start of the list": {
+			req:            lists.PushRequest[string]{Key: "list", Values: []string{"c", "d"}, Side: lists.SideLeft},
+			expectedStatus: gohttp.StatusNoContent,
+			expectedList:   []string{"d", "c", "a", "b"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Remove("list")
+			err := store.Set("list", []string{"a", "b"}, 0)
+			assert.NoError(t, err)
+
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/lists/strings/push", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.Push(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				storedValue, err := store.Get(tc.req.Key)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedList, storedValue.Value)
+			}
+		})
+	}
+}
+
+func TestListsController_Pop(t *testing.T) {
+	store := storage.NewListStore[string]()
+	controller := http.NewStringListsController(store)
+
+	testCases := map[string]struct {
+		req              lists.PopRequest
+		expectedStatus   int
+		expectedError    string
+		expectedResponse string
+	}{
+		"it should return an error if the key is missing": {
+			req:            lists.PopRequest{},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey.Error(),
+		},
+		"it should return an error if the side is invalid": {
+			req:            lists.PopRequest{Key: "list", Side: "middle"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidSide.Error(),
+		},
+		"it should return an error if the count is negative": {
+			req:            lists.PopRequest{Key: "list", Count: -1},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  storage.ErrInvalidCount.Error(),
+		},
+		"it should return an error if the key does not exist": {
+			req:            lists.PopRequest{Key: "non-existing-key"},
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  storage.ErrNotFound.Error(),
+		},
+		"it should pop from the start of the list by default": {
+			req:              lists.PopRequest{Key: "list"},
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"value":"a"}`,
+		},
+		"it should pop from the end of the list": {
+			req:              lists.PopRequest{Key: "list", Side: lists.SideRight},
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"value":"c"}`,
+		},
+		"it should pop several values": {
+			req:              lists.PopRequest{Key: "list", Side: lists.SideRight, Count: 2},
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"values":["c","b"]}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Remove("list")
+			err := store.Set("list", []string{"a", "b", "c"}, 0)
+			assert.NoError(t, err)
+
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/lists/strings/pop", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.Pop(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, rr.Body.String(), tc.expectedError)
+			} else {
+				assert.JSONEq(t, tc.expectedResponse, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestListsController_BlockingPop(t *testing.T) {
+	store := storage.NewListStore[string]()
+	controller := http.NewStringListsController(store)
+
+	// Populate store with data
+	err := store.Set("empty-list", []string{}, 0)
+	assert.NoError(t, err)
+	err = store.Set("list", []string{"a", "b"}, 0)
+	assert.NoError(t, err)
+
+	pop := func(req lists.PopRequest) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(req)
+		r := httptest.NewRequest(gohttp.MethodPost, "/lists/strings/pop", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+		controller.Pop(rr, r)
+		return rr
+	}
+
+	t.Run("it should return an error if the timeout is invalid", func(t *testing.T) {
+		rr := pop(lists.PopRequest{Key: "list", Timeout: 61})
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrInvalidTimeout.Error())
+
+		rr = pop(lists.PopRequest{Key: "list", Timeout: 1, Count: 2})
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrInvalidTimeout.Error())
+	})
+
+	t.Run("it should return an error if a key is empty", func(t *testing.T) {
+		rr := pop(lists.PopRequest{Key: "list", Keys: []string{""}, Timeout: 1})
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrEmptyKey.Error())
+	})
+
+	t.Run("it should pop from the first non-empty list", func(t *testing.T) {
+		rr := pop(lists.PopRequest{Key: "empty-list", Keys: []string{"list"}, Side: lists.SideRight, Timeout: 1})
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"key":"list","value":"b"}`, rr.Body.String())
+	})
+
+	t.Run("it should wait for a push", func(t *testing.T) {
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_, err := store.RPush("empty-list", "pushed")
+			assert.NoError(t, err)
+		}()
+
+		rr := pop(lists.PopRequest{Key: "empty-list", Timeout: 5})
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"key":"empty-list","value":"pushed"}`, rr.Body.String())
+	})
+
+	t.Run("it should return no content if the timeout is reached", func(t *testing.T) {
+		rr := pop(lists.PopRequest{Key: "empty-list", Timeout: 1})
+		assert.Equal(t, gohttp.StatusNoContent, rr.Code)
+		assert.Empty(t, rr.Body.String())
+	})
+}
+
+func TestListsController_Move(t *testing.T) {
+	store := storage.NewListStore[string]()
+	controller := http.NewStringListsController(store)
+
+	testCases := map[string]struct {
+		req              lists.MoveRequest
+		expectedStatus   int
+		expectedError    string
+		expectedResponse string
+		expectedDst      []string
+	}{
+		"it should return an error if a key is missing": {
+			req:            lists.MoveRequest{Source: "src"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey.Error(),
+		},
+		"it should return an error if a side is invalid": {
+			req:            lists.MoveRequest{Source: "src", Destination: "dst", To: "middle"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidSide.Error(),
+		},
+		"it should return an error if the destination does not exist": {
+			req:            lists.MoveRequest{Source: "src", Destination: "missing"},
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound.Error(),
+		},
+		"it should move the last item to the start by default": {
+			req:              lists.MoveRequest{Source: "src", Destination: "dst"},
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"value":"b"}`,
+			expectedDst:      []string{"b", "x"},
+		},
+		"it should move between the given sides": {
+			req:              lists.MoveRequest{Source: "src", Destination: "dst", From: lists.SideLeft, To: lists.SideRight},
+			expectedStatus:   gohttp.StatusOK,
+			expectedResponse: `{"value":"a"}`,
+			expectedDst:      []string{"x", "a"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Remove("src")
+			_ = store.Remove("dst")
+			assert.NoError(t, store.Set("src", []string{"a", "b"}, 0))
+			assert.NoError(t, store.Set("dst", []string{"x"}, 0))
+
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/lists/strings/move", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.Move(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, rr.Body.String(), tc.expectedError)
+			} else {
+				assert.JSONEq(t, tc.expectedResponse, rr.Body.String())
+				dst, err := store.Get("dst")
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedDst, dst.Value)
+			}
+		})
+	}
+}
+
+func TestListsController_ReserveAck(t *testing.T) {
+	store := storage.NewListStore[string]()
+	controller := http.NewStringListsController(store)
+
+	// Populate store with data
+	err := store.Set("queue", []string{"a", "b"}, 0)
+	assert.NoError(t, err)
+
+	reserve := func(req lists.ReserveRequest) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(req)
+		r := httptest.NewRequest(gohttp.MethodPost, "/lists/strings/reserve", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+		controller.Reserve(rr, r)
+		return rr
+	}
+	ack := func(handler gohttp.HandlerFunc, req lists.AckRequest) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(req)
+		r := httptest.NewRequest(gohttp.MethodPost, "/lists/strings/ack", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+		handler(rr, r)
+		return rr
+	}
+
+	t.Run("it should return an error if the visibility timeout is missing", func(t *testing.T) {
+		rr := reserve(lists.ReserveRequest{Key: "queue"})
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), storage.ErrInvalidVisibility.Error())
+	})
+
+	t.Run("it should return an error if the list does not exist", func(t *testing.T) {
+		rr := reserve(lists.ReserveRequest{Key: "missing", Visibility: 30})
+		assert.Equal(t, gohttp.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrKeyNotFound.Error())
+	})
+
+	t.Run("it should return an error if the id is missing", func(t *testing.T) {
+		rr := ack(controller.Ack, lists.AckRequest{Key: "queue"})
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrEmptyID.Error())
+	})
+
+	t.Run("it should reserve and nack an item", func(t *testing.T) {
+		rr := reserve(lists.ReserveRequest{Key: "queue", Visibility: 30})
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var res lists.ReserveResponse[string]
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		assert.Equal(t, "a", res.Value)
+		assert.NotEmpty(t, res.ID)
+		assert.NotEmpty(t, res.VisibleAt)
+
+		rr = ack(controller.Nack, lists.AckRequest{Key: "queue", ID: res.ID})
+		assert.Equal(t, gohttp.StatusNoContent, rr.Code)
+
+		list, err := store.Get("queue")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, list.Value)
+	})
+
+	t.Run("it should reserve and ack an item", func(t *testing.T) {
+		rr := reserve(lists.ReserveRequest{Key: "queue", Visibility: 30})
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var res lists.ReserveResponse[string]
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+		rr = ack(controller.Ack, lists.AckRequest{Key: "queue", ID: res.ID})
+		assert.Equal(t, gohttp.StatusNoContent, rr.Code)
+
+		rr = ack(controller.Ack, lists.AckRequest{Key: "queue", ID: res.ID})
+		assert.Equal(t, gohttp.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrReservationNotFound.Error())
+
+		list, err := store.Get("queue")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b"}, list.Value)
+	})
+}
+
+func TestJSONListsController_Push(t *testing.T) {
+	store := storage.NewListStore[json.RawMessage]()
+	schemas, err := jsonschema.NewRegistry(map[string]json.RawMessage{
+		"users:": json.RawMessage(`{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}`),
+	})
+	assert.NoError(t, err)
+	controller := http.NewJSONListsController(store, schemas)
+
+	testCases := map[string]struct {
+		req            lists.PushRequest[json.RawMessage]
+		expectedStatus int
+		expectedError  error
+		expectedList   []json.RawMessage
+	}{
+		"it should return an error if there are no values": {
+			req:            lists.PushRequest[json.RawMessage]{Key: "users:list"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyValue,
+		},
+		"it should return an error if a value doesn't match the schema of the key": {
+			req: lists.PushRequest[json.RawMessage]{
+				Key:    "users:list",
+				Values: []json.RawMessage{json.RawMessage(`{"name":"bob"}`), json.RawMessage(`{"age":3}`)},
+			},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrSchemaMismatch,
+		},
+		"it should push values matching the schema of the key": {
+			req:            lists.PushRequest[json.RawMessage]{Key: "users:list", Value: json.RawMessage(`{"name":"bob"}`)},
+			expectedStatus: gohttp.StatusNoContent,
+			expectedList:   []json.RawMessage{json.RawMessage(`{"name":"alice"}`), json.RawMessage(`{"name":"bob"}`)},
+		},
+		"it should push any value to keys without schema": {
+			req:            lists.PushRequest[json.RawMessage]{Key: "numbers", Values: []json.RawMessage{json.RawMessage(`[1,[2]]`), json.RawMessage(`3`)}},
+			expectedStatus: gohttp.StatusNoContent,
+			expectedList:   []json.RawMessage{json.RawMessage(`0`), json.RawMessage(`[1,[2]]`), json.RawMessage(`3`)},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Remove("users:list")
+			_ = store.Remove("numbers")
+			assert.NoError(t, store.Set("users:list", []json.RawMessage{json.RawMessage(`{"name":"alice"}`)}, 0))
+			assert.NoError(t, store.Set("numbers", []json.RawMessage{json.RawMessage(`0`)}, 0))
+
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/lists/json/push", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.Push(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				storedValue, err := store.Get(tc.req.Key)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedList, storedValue.Value)
+			}
+		})
+	}
+}
+
+func TestJSONListsController_Move(t *testing.T) {
+	store := storage.NewListStore[json.RawMessage]()
+	schemas, err := jsonschema.NewRegistry(map[string]json.RawMessage{
+		"users:": json.RawMessage(`{"type": "object", "required": ["name"]}`),
+	})
+	assert.NoError(t, err)
+	controller := http.NewJSONListsController(store, schemas)
+
+	testCases := map[string]struct {
+		req            lists.MoveRequest
+		expectedStatus int
+		expectedError  error
+		expectedSrc    []json.RawMessage
+		expectedDst    []json.RawMessage
+	}{
+		"it should not move a value that doesn't match the schema of the destination": {
+			req:            lists.MoveRequest{Source: "inbox", Destination: "users:list"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrSchemaMismatch,
+			expectedSrc:    []json.RawMessage{json.RawMessage(`{"name":"bob"}`), json.RawMessage(`3`)},
+			expectedDst:    []json.RawMessage{json.RawMessage(`{"name":"alice"}`)},
+		},
+		"it should move a value matching the schema of the destination": {
+			req:            lists.MoveRequest{Source: "inbox", Destination: "users:list", From: lists.SideLeft, To: lists.SideRight},
+			expectedStatus: gohttp.StatusOK,
+			expectedSrc:    []json.RawMessage{json.RawMessage(`3`)},
+			expectedDst:    []json.RawMessage{json.RawMessage(`{"name":"alice"}`), json.RawMessage(`{"name":"bob"}`)},
+		},
+		"it should move any value to keys without schema": {
+			req:            lists.MoveRequest{Source: "users:list", Destination: "inbox"},
+			expectedStatus: gohttp.StatusOK,
+			expectedSrc:    []json.RawMessage{},
+			expectedDst:    []json.RawMessage{json.RawMessage(`{"name":"alice"}`), json.RawMessage(`{"name":"bob"}`), json.RawMessage(`3`)},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Remove("inbox")
+			_ = store.Remove("users:list")
+			assert.NoError(t, store.Set("inbox", []json.RawMessage{json.RawMessage(`{"name":"bob"}`), json.RawMessage(`3`)}, 0))
+			assert.NoError(t, store.Set("users:list", []json.RawMessage{json.RawMessage(`{"name":"alice"}`)}, 0))
+
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/lists/json/move", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.Move(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			}
+			src, err := store.Get(tc.req.Source)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedSrc, src.Value)
+			dst, err := store.Get(tc.req.Destination)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDst, dst.Value)
+		})
+	}
+}
+
+func TestJSONListsController_SetGet(t *testing.T) {
+	store := storage.NewListStore[json.RawMessage]()
+	schemas, err := jsonschema.NewRegistry(map[string]json.RawMessage{
+		"scores:": json.RawMessage(`{"type": "integer", "minimum": 0}`),
+	})
+	assert.NoError(t, err)
+	controller := http.NewJSONListsController(store, schemas)
+
+	// Values not matching the schema are rejected
+	payload := []byte(`{"key": "scores:a", "list": [1, -2]}`)
+	req := httptest.NewRequest(gohttp.MethodPost, "/lists/json", bytes.NewReader(payload))
+	rr := httptest.NewRecorder()
+	controller.Set(rr, req)
+	assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "value 1: $: must be at least 0")
+
+	// Mixed JSON values are returned as they were set
+	payload = []byte(`{"key": "mixed", "list": [1, "two", {"three": [3]}, null]}`)
+	req = httptest.NewRequest(gohttp.MethodPost, "/lists/json", bytes.NewReader(payload))
+	rr = httptest.NewRecorder()
+	controller.Set(rr, req)
+	assert.Equal(t, gohttp.StatusNoContent, rr.Code)
+
+	req = httptest.NewRequest(gohttp.MethodGet, "/lists/json?key=mixed", nil)
+	rr = httptest.NewRecorder()
+	controller.Get(rr, req)
+	assert.Equal(t, gohttp.StatusOK, rr.Code)
+	var response lists.GetResponse[any]
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []any{float64(1), "two", map[string]any{"three": []any{float64(3)}}, nil}, response.List)
+}
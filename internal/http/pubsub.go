@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/json"
+	"in-memory-storage/internal/pubsub"
+	"in-memory-storage/storage"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type PubSubController interface {
+	Publish(w http.ResponseWriter, r *http.Request)
+	Subscribe(w http.ResponseWriter, r *http.Request)
+}
+
+func NewPubSubController(ps storage.PubSub) PubSubController {
+	return &pubSubController{ps: ps}
+}
+
+type pubSubController struct {
+	ps storage.PubSub
+}
+
+func (pc *pubSubController) Publish(w http.ResponseWriter, r *http.Request) {
+	var req pubsub.PublishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Channel == "" {
+		http.Error(w, ErrEmptyChannel.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, &pubsub.PublishResponse{Receivers: pc.ps.Publish(req.Channel, req.Message)})
+}
+
+// Subscribe streams the messages of the channel and pattern query parameters as Server-Sent Events,
+// until the client disconnects. If the subscription is dropped because the client doesn't keep up
+// with the messages, or the server is shutting down, an error event is sent before closing the stream.
+func (pc *pubSubController) Subscribe(w http.ResponseWriter, r *http.Request) {
+	channels := r.URL.Query()["channel"]
+	patterns := r.URL.Query()["pattern"]
+	if len(channels) == 0 && len(patterns) == 0 || slices.Contains(channels, "") || slices.Contains(patterns, "") {
+		http.Error(w, ErrEmptyChannel.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub := pc.ps.Subscribe(channels...)
+	defer sub.Close()
+	if err := sub.PSubscribe(patterns...); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	rc, err := startStream(w)
+	if err != nil {
+		log.Printf("ERROR: failed to flush response: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			err = writeKeepAlive(w)
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				if err := sub.Err(); err != nil {
+					_ = writeEvent(w, "error", &errorEvent{Error: err.Error()})
+					_ = rc.Flush()
+				}
+				return
+			}
+			err = writeEvent(w, "message", &pubsub.MessageEvent{
+				Channel: msg.Channel,
+				Pattern: msg.Pattern,
+				Message: msg.Payload,
+			})
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			// The client is gone
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package http_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"in-memory-storage/internal/http"
+	"in-memory-storage/internal/pubsub"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPubSubController_Publish(t *testing.T) {
+	ps := storage.NewPubSub()
+	controller := http.NewPubSubController(ps)
+	sub := ps.PSubscribe("news.*")
+	defer sub.Close()
+
+	testCases := map[string]struct {
+		req               pubsub.PublishRequest
+		expectedStatus    int
+		expectedError     error
+		expectedReceivers int
+	}{
+		"it should return an error if the channel is missing": {
+			req:            pubsub.PublishRequest{Message: "hello"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyChannel,
+		},
+		"it should return the number of receivers": {
+			req:               pubsub.PublishRequest{Channel: "news.tech", Message: "hello"},
+			expectedStatus:    gohttp.StatusOK,
+			expectedReceivers: 1,
+		},
+		"it should publish to channels without subscribers": {
+			req:            pubsub.PublishRequest{Channel: "sports", Message: "hello"},
+			expectedStatus: gohttp.StatusOK,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/publish", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.Publish(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response pubsub.PublishResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedReceivers, response.Receivers)
+			}
+		})
+	}
+}
+
+func TestPubSubController_SubscribeInvalid(t *testing.T) {
+	controller := http.NewPubSubController(storage.NewPubSub())
+
+	for name, query := range map[string]string{
+		"it should return an error if there are no channels": "",
+		"it should return an error if a channel is empty":    "?channel=news&channel=",
+		"it should return an error if a pattern is empty":    "?pattern=",
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(gohttp.MethodGet, "/subscribe"+query, nil)
+			rr := httptest.NewRecorder()
+
+			controller.Subscribe(rr, req)
+
+			assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), http.ErrEmptyChannel.Error())
+		})
+	}
+}
+
+func TestPubSubController_Subscribe(t *testing.T) {
+	ps := storage.NewPubSub()
+	srv := httptest.NewServer(gohttp.HandlerFunc(http.NewPubSubController(ps).Subscribe))
+	defer srv.Close()
+
+	res, err := gohttp.Get(srv.URL + "/subscribe?channel=news&pattern=sports.*")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, gohttp.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	// The subscription exists once the headers are received
+	assert.Equal(t, 1, ps.Publish("news", "hello"))
+	assert.Equal(t, 1, ps.Publish("sports.tennis", "match point"))
+	assert.Equal(t, 0, ps.Publish("weather", "sunny"))
+	assert.NoError(t, ps.Close())
+
+	r := bufio.NewReader(res.Body)
+	event, data := readEvent(t, r)
+	assert.Equal(t, "message", event)
+	assert.JSONEq(t, `{"channel":"news","message":"hello"}`, data)
+	event, data = readEvent(t, r)
+	assert.Equal(t, "message", event)
+	assert.JSONEq(t, `{"channel":"sports.tennis","pattern":"sports.*","message":"match point"}`, data)
+	event, data = readEvent(t, r)
+	assert.Equal(t, "error", event)
+	assert.JSONEq(t, `{"error":"`+storage.ErrPubSubClosed.Error()+`"}`, data)
+}
@@ -0,0 +1,321 @@
+package http
+
+import (
+	"encoding/json"
+	"in-memory-storage/internal/sets"
+	"in-memory-storage/storage"
+	"log"
+	"net/http"
+)
+
+type SetsController interface {
+	Set(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Add(w http.ResponseWriter, r *http.Request)
+	RemoveMembers(w http.ResponseWriter, r *http.Request)
+	IsMember(w http.ResponseWriter, r *http.Request)
+	Card(w http.ResponseWriter, r *http.Request)
+	Pop(w http.ResponseWriter, r *http.Request)
+	RandomMember(w http.ResponseWriter, r *http.Request)
+	Union(w http.ResponseWriter, r *http.Request)
+	Intersect(w http.ResponseWriter, r *http.Request)
+	Diff(w http.ResponseWriter, r *http.Request)
+	UnionStore(w http.ResponseWriter, r *http.Request)
+	IntersectStore(w http.ResponseWriter, r *http.Request)
+	DiffStore(w http.ResponseWriter, r *http.Request)
+}
+
+func NewStringSetsController(store storage.SetStore[string]) SetsController {
+	return &stringSetsController{store: store}
+}
+
+type stringSetsController struct {
+	store storage.SetStore[string]
+}
+
+func (sc *stringSetsController) Set(w http.ResponseWriter, r *http.Request) {
+	var req sets.SetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Members) == 0 {
+		http.Error(w, ErrEmptyValue.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl, err := requestTTL(req.TTL, req.TTLMs, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := sc.store.Set(req.Key, req.Members, ttl); err != nil {
+		if err == storage.ErrAlreadyExists {
+			http.Error(w, ErrKeyAlreadyExists.Error(), http.StatusConflict)
+			return
+		}
+		if err == storage.ErrWrongType {
+			http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("ERROR: failed to set members for key %s: %v", req.Key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (sc *stringSetsController) Get(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value, err := sc.store.Get(key)
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &sets.GetResponse{
+		Members:   value.Value,
+		ExpiresAt: formatTime(value.ExpiresAt),
+	})
+}
+
+func (sc *stringSetsController) Delete(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := sc.store.Remove(key); err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (sc *stringSetsController) Add(w http.ResponseWriter, r *http.Request) {
+	var req sets.AddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Members) == 0 {
+		http.Error(w, ErrEmptyMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	added, err := sc.store.Add(req.Key, req.Members...)
+	if err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &sets.AddResponse{Added: added})
+}
+
+func (sc *stringSetsController) RemoveMembers(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	members := r.URL.Query()["member"]
+	if len(members) == 0 {
+		http.Error(w, ErrEmptyMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := sc.store.RemoveMembers(key, members...)
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &sets.RemoveMembersResponse{Removed: removed})
+}
+
+func (sc *stringSetsController) IsMember(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	member := r.URL.Query().Get("member")
+	if member == "" {
+		http.Error(w, ErrEmptyMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	isMember, err := sc.store.IsMember(key, member)
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &sets.IsMemberResponse{IsMember: isMember})
+}
+
+func (sc *stringSetsController) Card(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	card, err := sc.store.Card(key)
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &sets.CardResponse{Card: card})
+}
+
+func (sc *stringSetsController) Pop(w http.ResponseWriter, r *http.Request) {
+	var req sets.PopRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	member, err := sc.store.Pop(req.Key)
+	if err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &sets.MemberResponse{Member: member})
+}
+
+func (sc *stringSetsController) RandomMember(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	member, err := sc.store.RandomMember(key)
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &sets.MemberResponse{Member: member})
+}
+
+func (sc *stringSetsController) Union(w http.ResponseWriter, r *http.Request) {
+	sc.combine(w, r, sc.store.Union)
+}
+
+func (sc *stringSetsController) Intersect(w http.ResponseWriter, r *http.Request) {
+	sc.combine(w, r, sc.store.Intersect)
+}
+
+func (sc *stringSetsController) Diff(w http.ResponseWriter, r *http.Request) {
+	sc.combine(w, r, sc.store.Diff)
+}
+
+func (sc *stringSetsController) UnionStore(w http.ResponseWriter, r *http.Request) {
+	sc.combineStore(w, r, sc.store.UnionStore)
+}
+
+func (sc *stringSetsController) IntersectStore(w http.ResponseWriter, r *http.Request) {
+	sc.combineStore(w, r, sc.store.IntersectStore)
+}
+
+func (sc *stringSetsController) DiffStore(w http.ResponseWriter, r *http.Request) {
+	sc.combineStore(w, r, sc.store.DiffStore)
+}
+
+// combine responds with the members resulting from applying op to the sets
+// given by the repeated key query parameter.
+func (sc *stringSetsController) combine(w http.ResponseWriter, r *http.Request, op func(keys ...string) ([]string, error)) {
+	keys := r.URL.Query()["key"]
+	if !validKeys(keys) {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	members, err := op(keys...)
+	if err != nil {
+		sc.handleError(w, keys[0], err)
+		return
+	}
+
+	writeJSON(w, &sets.MembersResponse{Members: members})
+}
+
+// combineStore stores the result of applying op to the sets given in the
+// request body and responds with its number of members.
+func (sc *stringSetsController) combineStore(w http.ResponseWriter, r *http.Request, op func(dst string, keys ...string) (int, error)) {
+	var req sets.StoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Destination == "" || !validKeys(req.Keys) {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	card, err := op(req.Destination, req.Keys...)
+	if err != nil {
+		sc.handleError(w, req.Destination, err)
+		return
+	}
+
+	writeJSON(w, &sets.StoreResponse{Card: card})
+}
+
+// handleError maps the storage errors to their HTTP responses.
+func (sc *stringSetsController) handleError(w http.ResponseWriter, key string, err error) {
+	switch err {
+	case storage.ErrNotFound, storage.ErrExpired:
+		http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+	case storage.ErrEmptySet:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case storage.ErrWrongType:
+		http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+	default:
+		log.Printf("ERROR: failed to handle set for key %s: %v", key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// validKeys checks that at least one key is given and none of them is empty.
+func validKeys(keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if key == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,360 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"in-memory-storage/internal/http"
+	"in-memory-storage/internal/sets"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetsController_Set(t *testing.T) {
+	store := storage.NewSetStore[string]()
+	controller := http.NewStringSetsController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", []string{"foo"}, 0)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		key            string
+		members        []string
+		ttl            int64
+		expectedStatus int
+		expectedError  error
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			members:        []string{"foo"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the members are missing": {
+			key:            "foo",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyValue,
+		},
+		"it should return an error if the key already exists": {
+			key:            "existing-key",
+			members:        []string{"bar"},
+			expectedStatus: gohttp.StatusConflict,
+			expectedError:  http.ErrKeyAlreadyExists,
+		},
+		"success with ttl": {
+			key:            "foo",
+			members:        []string{"bar", "baz"},
+			ttl:            60,
+			expectedStatus: gohttp.StatusNoContent,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(sets.SetRequest{
+				Key:     tc.key,
+				Members: tc.members,
+				TTL:     tc.ttl,
+			})
+			req := httptest.NewRequest(gohttp.MethodPost, "/sets", bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+
+			controller.Set(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				storedValue, err := store.Get(tc.key)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, tc.members, storedValue.Value)
+				assert.False(t, storedValue.ExpiresAt.IsZero())
+			}
+		})
+	}
+}
+
+func TestSetsController_Get(t *testing.T) {
+	store := storage.NewSetStore[string]()
+	controller := http.NewStringSetsController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", []string{"foo", "bar"}, 60*time.Second)
+	assert.NoError(t, err)
+	err = store.Set("expired-key", []string{"foo"}, 1*time.Millisecond)
+	assert.NoError(t, err)
+
+	// Ensure the expired key is actually expired
+	time.Sleep(2 * time.Millisecond)
+
+	testCases := map[string]struct {
+		key             string
+		expectedStatus  int
+		expectedError   error
+		expectedMembers []string
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the key does not exist": {
+			key:            "non-existing-key",
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"it should return an error if the key has expired": {
+			key:            "expired-key",
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"success": {
+			key:             "existing-key",
+			expectedStatus:  gohttp.StatusOK,
+			expectedMembers: []string{"foo", "bar"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(gohttp.MethodGet, "/sets?key="+tc.key, nil)
+			rr := httptest.NewRecorder()
+
+			controller.Get(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response sets.GetResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, tc.expectedMembers, response.Members)
+				assert.NotEmpty(t, response.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestSetsController_Members(t *testing.T) {
+	store := storage.NewSetStore[string]()
+	controller := http.NewStringSetsController(store)
+
+	t.Run("it should return an error if the members are missing", func(t *testing.T) {
+		payload, _ := json.Marshal(sets.AddRequest{Key: "ids"})
+		req := httptest.NewRequest(gohttp.MethodPost, "/sets/members", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.Add(rr, req)
+
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrEmptyMember.Error())
+	})
+
+	t.Run("it should add members", func(t *testing.T) {
+		payload, _ := json.Marshal(sets.AddRequest{Key: "ids", Members: []string{"1", "2", "2"}})
+		req := httptest.NewRequest(gohttp.MethodPost, "/sets/members", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.Add(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response sets.AddResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Added)
+	})
+
+	t.Run("it should check if a member exists", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/sets/ismember?key=ids&member=1", nil)
+		rr := httptest.NewRecorder()
+
+		controller.IsMember(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response sets.IsMemberResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.IsMember)
+	})
+
+	t.Run("it should return the number of members", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/sets/card?key=ids", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Card(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response sets.CardResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Card)
+	})
+
+	t.Run("it should return a random member", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/sets/random?key=ids", nil)
+		rr := httptest.NewRecorder()
+
+		controller.RandomMember(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response sets.MemberResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Contains(t, []string{"1", "2"}, response.Member)
+	})
+
+	t.Run("it should remove members", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodDelete, "/sets/members?key=ids&member=1&member=unknown", nil)
+		rr := httptest.NewRecorder()
+
+		controller.RemoveMembers(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response sets.RemoveMembersResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Removed)
+	})
+
+	t.Run("it should pop the last member", func(t *testing.T) {
+		payload, _ := json.Marshal(sets.PopRequest{Key: "ids"})
+		req := httptest.NewRequest(gohttp.MethodPost, "/sets/pop", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.Pop(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response sets.MemberResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "2", response.Member)
+
+		_, err := store.Get("ids")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestSetsController_Algebra(t *testing.T) {
+	store := storage.NewSetStore[string]()
+	controller := http.NewStringSetsController(store)
+
+	// Populate store with data
+	err := store.Set("a", []string{"1", "2", "3"}, 0)
+	assert.NoError(t, err)
+	err = store.Set("b", []string{"2", "3", "4"}, 0)
+	assert.NoError(t, err)
+
+	t.Run("it should return an error if no keys are provided", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/sets/union", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Union(rr, req)
+
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrEmptyKey.Error())
+	})
+
+	t.Run("it should return the intersection", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/sets/intersect?key=a&key=b", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Intersect(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response sets.MembersResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.ElementsMatch(t, []string{"2", "3"}, response.Members)
+	})
+
+	t.Run("it should store the difference", func(t *testing.T) {
+		payload, _ := json.Marshal(sets.StoreRequest{Keys: []string{"a", "b"}, Destination: "only-a"})
+		req := httptest.NewRequest(gohttp.MethodPost, "/sets/diff", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.DiffStore(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response sets.StoreResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Card)
+
+		members, err := store.Members("only-a")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1"}, members)
+	})
+
+	t.Run("it should return an error if the destination is missing", func(t *testing.T) {
+		payload, _ := json.Marshal(sets.StoreRequest{Keys: []string{"a", "b"}})
+		req := httptest.NewRequest(gohttp.MethodPost, "/sets/union", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.UnionStore(rr, req)
+
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrEmptyKey.Error())
+	})
+}
+
+func TestSetsEndpoints_E2E(t *testing.T) {
+	setStore := storage.NewSetStore[string]()
+	validAPIKey := "valid-api-key"
+
+	srv, err := http.NewServer(
+		"8080",
+		http.NewStringsController(storage.NewStringStore()),
+		http.NewStringListsController(storage.NewListStore[string]()),
+		validAPIKey,
+		http.WithSetsController(http.NewStringSetsController(setStore)),
+	)
+	assert.NoError(t, err)
+
+	t.Run("should return 401 when wrong auth is provided", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/sets?key=ids", nil)
+		req.Header.Set("Authorization", "Bearer invalid-api-key")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, gohttp.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should add members and compute the union", func(t *testing.T) {
+		body, _ := json.Marshal(sets.AddRequest{Key: "ids", Members: []string{"1", "2"}})
+		req := httptest.NewRequest(gohttp.MethodPost, "/sets/members", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+
+		req = httptest.NewRequest(gohttp.MethodGet, "/sets/union?key=ids&key=missing", nil)
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr = httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+
+		var response sets.MembersResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.ElementsMatch(t, []string{"1", "2"}, response.Members)
+	})
+
+	t.Run("should delete a set", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodDelete, "/sets?key=ids", nil)
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		assert.Equal(t, gohttp.StatusNoContent, rr.Code)
+
+		_, err := setStore.Get("ids")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("should return 405 on unsupported methods", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodPut, "/sets/union", nil)
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, gohttp.StatusMethodNotAllowed, rr.Code)
+	})
+}
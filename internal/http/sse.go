@@ -0,0 +1,43 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// keepAliveInterval is how often a comment is sent to idle streams of events,
+// so proxies don't close the connection.
+const keepAliveInterval = 15 * time.Second
+
+// errorEvent is the data of the error event sent before the server closes a stream.
+type errorEvent struct {
+	Error string `json:"error"`
+}
+
+// startStream sends the headers of a stream of Server-Sent Events.
+// It returns the controller used to flush each event.
+func startStream(w http.ResponseWriter) (*http.ResponseController, error) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	return rc, rc.Flush()
+}
+
+// writeKeepAlive writes a comment, which is ignored by the clients.
+func writeKeepAlive(w http.ResponseWriter) error {
+	_, err := fmt.Fprint(w, ": ping\n\n")
+	return err
+}
+
+// writeEvent writes a Server-Sent Event with the JSON encoding of v as its data.
+func writeEvent(w http.ResponseWriter, event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,442 @@
+package http
+
+import (
+	"encoding/json"
+	"in-memory-storage/internal/streams"
+	"in-memory-storage/storage"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type StreamsController interface {
+	Add(w http.ResponseWriter, r *http.Request)
+	Range(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Len(w http.ResponseWriter, r *http.Request)
+	Trim(w http.ResponseWriter, r *http.Request)
+	DeleteEntries(w http.ResponseWriter, r *http.Request)
+	CreateGroup(w http.ResponseWriter, r *http.Request)
+	Groups(w http.ResponseWriter, r *http.Request)
+	DeleteGroup(w http.ResponseWriter, r *http.Request)
+	ReadGroup(w http.ResponseWriter, r *http.Request)
+	Ack(w http.ResponseWriter, r *http.Request)
+	Pending(w http.ResponseWriter, r *http.Request)
+	Claim(w http.ResponseWriter, r *http.Request)
+}
+
+func NewStreamsController(store storage.StreamStore) StreamsController {
+	return &streamsController{store: store}
+}
+
+type streamsController struct {
+	store storage.StreamStore
+}
+
+func (sc *streamsController) Add(w http.ResponseWriter, r *http.Request) {
+	var req streams.AddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Fields) == 0 {
+		http.Error(w, ErrEmptyValue.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := sc.store.XAdd(req.Key, req.Fields, req.MaxLen)
+	if err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &streams.AddResponse{ID: id.String()})
+}
+
+func (sc *streamsController) Range(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := query.Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	start, end, err := parseIDRange(query)
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+	count, err := parseIntParam(query, "count", -1)
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+	reverse, err := parseReverse(query)
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var entries []storage.StreamEntry
+	if reverse {
+		entries, err = sc.store.XRevRange(key, end, start, count)
+	} else {
+		entries, err = sc.store.XRange(key, start, end, count)
+	}
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &streams.RangeResponse{Entries: fromStreamEntries(entries)})
+}
+
+func (sc *streamsController) Delete(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := sc.store.Remove(key); err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (sc *streamsController) Len(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := sc.store.XLen(key)
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &streams.LenResponse{Len: n})
+}
+
+func (sc *streamsController) Trim(w http.ResponseWriter, r *http.Request) {
+	var req streams.TrimRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := sc.store.XTrim(req.Key, req.MaxLen)
+	if err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &streams.RemoveResponse{Removed: removed})
+}
+
+func (sc *streamsController) DeleteEntries(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	ids, err := parseStreamIDs(r.URL.Query()["id"])
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	removed, err := sc.store.XDel(key, ids...)
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &streams.RemoveResponse{Removed: removed})
+}
+
+func (sc *streamsController) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req streams.CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Group == "" {
+		http.Error(w, ErrEmptyGroup.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := sc.store.XGroupCreate(req.Key, req.Group, req.FromStart); err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (sc *streamsController) Groups(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	infos, err := sc.store.XGroups(key)
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	groups := make([]streams.Group, 0, len(infos))
+	for _, g := range infos {
+		groups = append(groups, streams.Group{
+			Name:            g.Name,
+			LastDeliveredID: g.LastDeliveredID.String(),
+			Consumers:       g.Consumers,
+			Pending:         g.Pending,
+		})
+	}
+	writeJSON(w, &streams.GroupsResponse{Groups: groups})
+}
+
+func (sc *streamsController) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	group := r.URL.Query().Get("group")
+	if group == "" {
+		http.Error(w, ErrEmptyGroup.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := sc.store.XGroupDestroy(key, group); err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (sc *streamsController) ReadGroup(w http.ResponseWriter, r *http.Request) {
+	var req streams.ReadGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validGroupRequest(w, req.Key, req.Group, req.Consumer) {
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+
+	entries, err := sc.store.XReadGroup(req.Key, req.Group, req.Consumer, req.Count)
+	if err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &streams.RangeResponse{Entries: fromStreamEntries(entries)})
+}
+
+func (sc *streamsController) Ack(w http.ResponseWriter, r *http.Request) {
+	var req streams.AckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Group == "" {
+		http.Error(w, ErrEmptyGroup.Error(), http.StatusBadRequest)
+		return
+	}
+	ids, err := parseStreamIDs(req.IDs)
+	if err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	acked, err := sc.store.XAck(req.Key, req.Group, ids...)
+	if err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &streams.AckResponse{Acknowledged: acked})
+}
+
+func (sc *streamsController) Pending(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := query.Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	group := query.Get("group")
+	if group == "" {
+		http.Error(w, ErrEmptyGroup.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := sc.store.XPending(key, group, query.Get("consumer"))
+	if err != nil {
+		sc.handleError(w, key, err)
+		return
+	}
+
+	pending := make([]streams.PendingEntry, 0, len(entries))
+	for _, p := range entries {
+		pending = append(pending, streams.PendingEntry{
+			ID:          p.ID.String(),
+			Consumer:    p.Consumer,
+			DeliveredAt: formatTime(p.DeliveredAt),
+			Deliveries:  p.Deliveries,
+		})
+	}
+	writeJSON(w, &streams.PendingResponse{Pending: pending})
+}
+
+func (sc *streamsController) Claim(w http.ResponseWriter, r *http.Request) {
+	var req streams.ClaimRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validGroupRequest(w, req.Key, req.Group, req.Consumer) {
+		return
+	}
+	if req.MinIdleMs < 0 {
+		http.Error(w, ErrInvalidMinIdle.Error(), http.StatusBadRequest)
+		return
+	}
+	ids, err := parseStreamIDs(req.IDs)
+	if err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	minIdle := time.Duration(req.MinIdleMs) * time.Millisecond
+	entries, err := sc.store.XClaim(req.Key, req.Group, req.Consumer, minIdle, ids...)
+	if err != nil {
+		sc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &streams.RangeResponse{Entries: fromStreamEntries(entries)})
+}
+
+// handleError maps the storage errors to their HTTP responses.
+func (sc *streamsController) handleError(w http.ResponseWriter, key string, err error) {
+	switch err {
+	case storage.ErrNotFound, storage.ErrExpired:
+		http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+	case storage.ErrGroupNotFound:
+		http.Error(w, ErrGroupNotFound.Error(), http.StatusNotFound)
+	case storage.ErrGroupExists:
+		http.Error(w, ErrGroupExists.Error(), http.StatusConflict)
+	case storage.ErrInvalidStreamID:
+		http.Error(w, ErrInvalidStreamID.Error(), http.StatusBadRequest)
+	case storage.ErrInvalidMaxLen, storage.ErrInvalidCount:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case storage.ErrWrongType:
+		http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+	case ErrEmptyID:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("ERROR: failed to handle stream for key %s: %v", key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// validGroupRequest checks the key, group and consumer of a request to a consumer group,
+// writing the error response if one of them is empty.
+func validGroupRequest(w http.ResponseWriter, key, group, consumer string) bool {
+	switch {
+	case key == "":
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+	case group == "":
+		http.Error(w, ErrEmptyGroup.Error(), http.StatusBadRequest)
+	case consumer == "":
+		http.Error(w, ErrEmptyConsumer.Error(), http.StatusBadRequest)
+	default:
+		return true
+	}
+	return false
+}
+
+// parseIDRange parses the optional start and end IDs, which default to "-" and "+",
+// the lowest and the greatest IDs. An end without sequence includes all the entries of that millisecond.
+func parseIDRange(query url.Values) (storage.StreamID, storage.StreamID, error) {
+	start, end := storage.MinStreamID, storage.MaxStreamID
+	if s := query.Get("start"); s != "" && s != "-" {
+		id, err := storage.ParseStreamID(s)
+		if err != nil {
+			return start, end, err
+		}
+		start = id
+	}
+	if s := query.Get("end"); s != "" && s != "+" {
+		id, err := storage.ParseStreamID(s)
+		if err != nil {
+			return start, end, err
+		}
+		if !strings.Contains(s, "-") {
+			id.Seq = math.MaxUint64
+		}
+		end = id
+	}
+	return start, end, nil
+}
+
+// parseStreamIDs parses the IDs of the entries of a request, which must contain at least one.
+func parseStreamIDs(values []string) ([]storage.StreamID, error) {
+	if len(values) == 0 {
+		return nil, ErrEmptyID
+	}
+	ids := make([]storage.StreamID, 0, len(values))
+	for _, v := range values {
+		id, err := storage.ParseStreamID(v)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func fromStreamEntries(entries []storage.StreamEntry) []streams.Entry {
+	res := make([]streams.Entry, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, streams.Entry{ID: e.ID.String(), Fields: e.Fields})
+	}
+	return res
+}
@@ -0,0 +1,283 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"in-memory-storage/internal/http"
+	"in-memory-storage/internal/streams"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamsController_Add(t *testing.T) {
+	store := storage.NewStreamStore()
+	controller := http.NewStreamsController(store)
+
+	testCases := map[string]struct {
+		req            streams.AddRequest
+		expectedStatus int
+		expectedError  error
+		expectedLen    int
+	}{
+		"it should return an error if the key is missing": {
+			req:            streams.AddRequest{Fields: map[string]string{"a": "1"}},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the fields are missing": {
+			req:            streams.AddRequest{Key: "events"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyValue,
+		},
+		"it should return an error if the max length is negative": {
+			req:            streams.AddRequest{Key: "events", Fields: map[string]string{"a": "1"}, MaxLen: -1},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  storage.ErrInvalidMaxLen,
+		},
+		"it should add the entry": {
+			req:            streams.AddRequest{Key: "events", Fields: map[string]string{"a": "1"}},
+			expectedStatus: gohttp.StatusOK,
+			expectedLen:    1,
+		},
+		"it should trim the stream": {
+			req:            streams.AddRequest{Key: "events", Fields: map[string]string{"a": "2"}, MaxLen: 1},
+			expectedStatus: gohttp.StatusOK,
+			expectedLen:    1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/streams", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.Add(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response streams.AddResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				id, err := storage.ParseStreamID(response.ID)
+				assert.NoError(t, err)
+
+				entries, err := store.XRange(tc.req.Key, storage.MinStreamID, storage.MaxStreamID, -1)
+				assert.NoError(t, err)
+				assert.Len(t, entries, tc.expectedLen)
+				assert.Equal(t, id, entries[len(entries)-1].ID)
+			}
+		})
+	}
+}
+
+func TestStreamsController_Range(t *testing.T) {
+	store := storage.NewStreamStore()
+	controller := http.NewStreamsController(store)
+
+	var ids []string
+	for _, v := range []string{"a", "b", "c"} {
+		id, err := store.XAdd("events", map[string]string{"v": v}, 0)
+		assert.NoError(t, err)
+		ids = append(ids, id.String())
+	}
+
+	testCases := map[string]struct {
+		query           string
+		expectedStatus  int
+		expectedError   error
+		expectedEntries []string
+	}{
+		"it should return an error if the key is missing": {
+			query:          "",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the key does not exist": {
+			query:          "key=missing",
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"it should return an error if an id is invalid": {
+			query:          "key=events&start=abc",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidRange,
+		},
+		"it should return all the entries by default": {
+			query:           "key=events",
+			expectedStatus:  gohttp.StatusOK,
+			expectedEntries: ids,
+		},
+		"it should return the entries between the ids": {
+			query:           "key=events&start=" + ids[1] + "&end=%2B&count=1",
+			expectedStatus:  gohttp.StatusOK,
+			expectedEntries: ids[1:2],
+		},
+		"it should return the newest entries in reverse": {
+			query:           "key=events&start=-&count=2&reverse=true",
+			expectedStatus:  gohttp.StatusOK,
+			expectedEntries: []string{ids[2], ids[1]},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(gohttp.MethodGet, "/streams?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			controller.Range(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response streams.RangeResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				got := []string{}
+				for _, e := range response.Entries {
+					got = append(got, e.ID)
+				}
+				assert.Equal(t, tc.expectedEntries, got)
+			}
+		})
+	}
+}
+
+func TestStreamsController_CreateGroup(t *testing.T) {
+	store := storage.NewStreamStore()
+	controller := http.NewStreamsController(store)
+	assert.NoError(t, store.XGroupCreate("jobs", "workers", true))
+
+	testCases := map[string]struct {
+		req            streams.CreateGroupRequest
+		expectedStatus int
+		expectedError  error
+	}{
+		"it should return an error if the group is missing": {
+			req:            streams.CreateGroupRequest{Key: "jobs"},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyGroup,
+		},
+		"it should return an error if the group exists": {
+			req:            streams.CreateGroupRequest{Key: "jobs", Group: "workers"},
+			expectedStatus: gohttp.StatusConflict,
+			expectedError:  http.ErrGroupExists,
+		},
+		"it should create the group": {
+			req:            streams.CreateGroupRequest{Key: "jobs", Group: "auditors", FromStart: true},
+			expectedStatus: gohttp.StatusNoContent,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/streams/groups", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.CreateGroup(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			}
+		})
+	}
+}
+
+func TestStreamsController_ConsumerGroup(t *testing.T) {
+	store := storage.NewStreamStore()
+	controller := http.NewStreamsController(store)
+	assert.NoError(t, store.XGroupCreate("jobs", "workers", true))
+	first, err := store.XAdd("jobs", map[string]string{"task": "resize"}, 0)
+	assert.NoError(t, err)
+	second, err := store.XAdd("jobs", map[string]string{"task": "upload"}, 0)
+	assert.NoError(t, err)
+
+	t.Run("it should return an error if the consumer is missing", func(t *testing.T) {
+		payload, _ := json.Marshal(streams.ReadGroupRequest{Key: "jobs", Group: "workers"})
+		rr := httptest.NewRecorder()
+		controller.ReadGroup(rr, httptest.NewRequest(gohttp.MethodPost, "/streams/groups/read", bytes.NewReader(payload)))
+
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrEmptyConsumer.Error())
+	})
+
+	t.Run("it should return an error if the group does not exist", func(t *testing.T) {
+		payload, _ := json.Marshal(streams.ReadGroupRequest{Key: "jobs", Group: "missing", Consumer: "worker-1"})
+		rr := httptest.NewRecorder()
+		controller.ReadGroup(rr, httptest.NewRequest(gohttp.MethodPost, "/streams/groups/read", bytes.NewReader(payload)))
+
+		assert.Equal(t, gohttp.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrGroupNotFound.Error())
+	})
+
+	t.Run("it should deliver one entry by default", func(t *testing.T) {
+		payload, _ := json.Marshal(streams.ReadGroupRequest{Key: "jobs", Group: "workers", Consumer: "worker-1"})
+		rr := httptest.NewRecorder()
+		controller.ReadGroup(rr, httptest.NewRequest(gohttp.MethodPost, "/streams/groups/read", bytes.NewReader(payload)))
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response streams.RangeResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, []streams.Entry{{ID: first.String(), Fields: map[string]string{"task": "resize"}}}, response.Entries)
+	})
+
+	t.Run("it should claim the pending entries", func(t *testing.T) {
+		payload, _ := json.Marshal(streams.ClaimRequest{
+			Key: "jobs", Group: "workers", Consumer: "worker-2", IDs: []string{first.String(), second.String()},
+		})
+		rr := httptest.NewRecorder()
+		controller.Claim(rr, httptest.NewRequest(gohttp.MethodPost, "/streams/groups/claim", bytes.NewReader(payload)))
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response streams.RangeResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Entries, 1)
+		assert.Equal(t, first.String(), response.Entries[0].ID)
+	})
+
+	t.Run("it should list the pending entries", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		controller.Pending(rr, httptest.NewRequest(gohttp.MethodGet, "/streams/groups/pending?key=jobs&group=workers", nil))
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response streams.PendingResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Pending, 1)
+		assert.Equal(t, first.String(), response.Pending[0].ID)
+		assert.Equal(t, "worker-2", response.Pending[0].Consumer)
+		assert.Equal(t, 2, response.Pending[0].Deliveries)
+		assert.NotEmpty(t, response.Pending[0].DeliveredAt)
+	})
+
+	t.Run("it should return an error if an id is invalid", func(t *testing.T) {
+		payload, _ := json.Marshal(streams.AckRequest{Key: "jobs", Group: "workers", IDs: []string{"abc"}})
+		rr := httptest.NewRecorder()
+		controller.Ack(rr, httptest.NewRequest(gohttp.MethodPost, "/streams/groups/ack", bytes.NewReader(payload)))
+
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrInvalidStreamID.Error())
+	})
+
+	t.Run("it should acknowledge the entries", func(t *testing.T) {
+		payload, _ := json.Marshal(streams.AckRequest{Key: "jobs", Group: "workers", IDs: []string{first.String()}})
+		rr := httptest.NewRecorder()
+		controller.Ack(rr, httptest.NewRequest(gohttp.MethodPost, "/streams/groups/ack", bytes.NewReader(payload)))
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response streams.AckResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Acknowledged)
+
+		pending, err := store.XPending("jobs", "workers", "")
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"in-memory-storage/storage"
 	"log"
 	"net/http"
+	"strconv"
+	gostrings "strings"
 	"time"
 )
 
@@ -14,6 +16,11 @@ type StringsController interface {
 	Get(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
+	SetNX(w http.ResponseWriter, r *http.Request)
+	SetXX(w http.ResponseWriter, r *http.Request)
+	CompareAndSwap(w http.ResponseWriter, r *http.Request)
+	IncrBy(w http.ResponseWriter, r *http.Request)
+	IncrByFloat(w http.ResponseWriter, r *http.Request)
 }
 
 func NewStringsController(store storage.StringStore) StringsController {
@@ -40,11 +47,21 @@ func (sc *stringController) Set(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := sc.store.Set(req.Key, req.Value, time.Duration(req.TTL)*time.Second); err != nil {
+	ttl, err := requestTTL(req.TTL, req.TTLMs, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := sc.store.Set(req.Key, req.Value, ttl); err != nil {
 		if err == storage.ErrAlreadyExists {
 			http.Error(w, ErrKeyAlreadyExists.Error(), http.StatusConflict)
 			return
 		}
+		if err == storage.ErrWrongType {
+			http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+			return
+		}
 		log.Printf("ERROR: failed to set value for key %s: %v", req.Key, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -72,7 +89,8 @@ func (sc *stringController) Get(w http.ResponseWriter, r *http.Request) {
 
 	res, err := json.Marshal(&strings.GetResponse{
 		Value:     value.Value,
-		ExpiresAt: value.ExpiresAt.Format(time.RFC3339),
+		ExpiresAt: formatTime(value.ExpiresAt),
+		Version:   value.Version,
 	})
 	if err != nil {
 		log.Printf("ERROR: failed to marshal response for key %s: %v", key, err)
@@ -80,6 +98,7 @@ func (sc *stringController) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatUint(value.Version, 10)))
 	if _, err := w.Write(res); err != nil {
 		log.Printf("failed to write response: %v", err)
 	}
@@ -119,14 +138,198 @@ func (sc *stringController) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := sc.store.Update(req.Key, req.Value); err != nil {
+	ttl, err := requestTTL(req.TTL, req.TTLMs, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, conditional, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		http.Error(w, ErrInvalidVersion.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if conditional {
+		if ttl > 0 {
+			http.Error(w, ErrConditionalTTL.Error(), http.StatusBadRequest)
+			return
+		}
+		err = sc.store.UpdateIfVersion(req.Key, req.Value, version)
+	} else {
+		// Without If-Match the key is created if it doesn't exist.
+		err = sc.store.Set(req.Key, req.Value, ttl, upsertOptions(ttl)...)
+	}
+	if err != nil {
 		if err == storage.ErrNotFound || err == storage.ErrExpired {
 			http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
 			return
 		}
+		if err == storage.ErrWrongType {
+			http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+			return
+		}
+		if err == storage.ErrVersionMismatch {
+			http.Error(w, ErrVersionMismatch.Error(), http.StatusPreconditionFailed)
+			return
+		}
 		log.Printf("ERROR: failed to update key %s: %v", req.Key, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (sc *stringController) SetNX(w http.ResponseWriter, r *http.Request) {
+	sc.conditionalSet(w, r, sc.store.SetNX)
+}
+
+func (sc *stringController) SetXX(w http.ResponseWriter, r *http.Request) {
+	sc.conditionalSet(w, r, sc.store.SetXX)
+}
+
+// conditionalSet decodes a SetRequest and stores it with the given conditional set operation.
+func (sc *stringController) conditionalSet(
+	w http.ResponseWriter,
+	r *http.Request,
+	set func(key, val string, ttl time.Duration) (bool, error),
+) {
+	var req strings.SetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Value == "" {
+		http.Error(w, ErrEmptyValue.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl, err := requestTTL(req.TTL, req.TTLMs, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ok, err := set(req.Key, req.Value, ttl)
+	if err != nil {
+		if err == storage.ErrWrongType {
+			http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("ERROR: failed to set value for key %s: %v", req.Key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, &strings.SetResponse{Set: ok})
+}
+
+func (sc *stringController) CompareAndSwap(w http.ResponseWriter, r *http.Request) {
+	var req strings.CompareAndSwapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Value == "" {
+		http.Error(w, ErrEmptyValue.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ok, err := sc.store.CompareAndSwap(req.Key, req.Expected, req.Value)
+	if err != nil {
+		if err == storage.ErrNotFound || err == storage.ErrExpired {
+			http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: failed to swap value for key %s: %v", req.Key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, &strings.CompareAndSwapResponse{Swapped: ok})
+}
+
+func (sc *stringController) IncrBy(w http.ResponseWriter, r *http.Request) {
+	var req strings.IncrByRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	by := int64(1)
+	if req.By != nil {
+		by = *req.By
+	}
+
+	value, err := sc.store.IncrBy(req.Key, by)
+	if err != nil {
+		sc.handleCounterError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &strings.IncrByResponse{Value: value})
+}
+
+func (sc *stringController) IncrByFloat(w http.ResponseWriter, r *http.Request) {
+	var req strings.IncrByFloatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value, err := sc.store.IncrByFloat(req.Key, req.By)
+	if err != nil {
+		sc.handleCounterError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &strings.IncrByFloatResponse{Value: value})
+}
+
+// handleCounterError maps the errors of the counter operations to their HTTP responses.
+func (sc *stringController) handleCounterError(w http.ResponseWriter, key string, err error) {
+	switch err {
+	case storage.ErrNotInteger, storage.ErrNotFloat, storage.ErrOverflow:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case storage.ErrWrongType:
+		http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+	default:
+		log.Printf("ERROR: failed to increment key %s: %v", key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// parseIfMatch parses the version sent in an If-Match header, as returned in the ETag header of a get.
+// It returns false if the header is empty or "*", in which case the update is not conditional.
+func parseIfMatch(header string) (uint64, bool, error) {
+	header = gostrings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+
+	version, err := strconv.ParseUint(gostrings.Trim(header, `"`), 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return version, true, nil
+}
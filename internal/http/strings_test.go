@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	gohttp "net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -23,12 +24,17 @@ func TestStringsController_Set(t *testing.T) {
 	err := store.Set("existing-key", "existing-value", 0)
 	assert.NoError(t, err)
 
+	past, future := time.Now().Add(-time.Second), time.Now().Add(1500*time.Millisecond)
+
 	testCases := map[string]struct {
 		key            string
 		value          string
 		ttl            int
+		ttlMs          int64
+		expiresAt      *time.Time
 		expectedStatus int
 		expectedError  error
+		expectedTTL    time.Duration
 	}{
 		"it should return an error if the key is missing": {
 			key:            "",
@@ -36,6 +42,28 @@ func TestStringsController_Set(t *testing.T) {
 			expectedStatus: gohttp.StatusBadRequest,
 			expectedError:  http.ErrEmptyKey,
 		},
+		"it should return an error if the ttl in milliseconds is negative": {
+			key:            "negative",
+			value:          "foo",
+			ttlMs:          -1,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidTTL,
+		},
+		"it should return an error if the expiration time is in the past": {
+			key:            "past",
+			value:          "foo",
+			expiresAt:      &past,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidTTL,
+		},
+		"it should return an error if more than one ttl is given": {
+			key:            "conflicting",
+			value:          "foo",
+			ttl:            60,
+			ttlMs:          250,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrConflictingTTL,
+		},
 		"it should return an error if the value is missing": {
 			key:            "foo",
 			value:          "",
@@ -58,15 +86,32 @@ func TestStringsController_Set(t *testing.T) {
 			value:          "foo",
 			ttl:            60,
 			expectedStatus: gohttp.StatusNoContent,
+			expectedTTL:    time.Minute,
+		},
+		"success with ttl in milliseconds": {
+			key:            "baz",
+			value:          "foo",
+			ttlMs:          250,
+			expectedStatus: gohttp.StatusNoContent,
+			expectedTTL:    250 * time.Millisecond,
+		},
+		"success with expiration time": {
+			key:            "qux",
+			value:          "foo",
+			expiresAt:      &future,
+			expectedStatus: gohttp.StatusNoContent,
+			expectedTTL:    1500 * time.Millisecond,
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
 			payload, _ := json.Marshal(strings.SetRequest{
-				Key:   tc.key,
-				Value: tc.value,
-				TTL:   int64(tc.ttl),
+				Key:       tc.key,
+				Value:     tc.value,
+				TTL:       int64(tc.ttl),
+				TTLMs:     tc.ttlMs,
+				ExpiresAt: tc.expiresAt,
 			})
 			req := httptest.NewRequest(gohttp.MethodPost, "/strings", bytes.NewReader(payload))
 			req.Header.Set("Content-Type", "application/json")
@@ -82,6 +127,9 @@ func TestStringsController_Set(t *testing.T) {
 				storedValue, err := store.Get(tc.key)
 				assert.NoError(t, err)
 				assert.Equal(t, tc.value, storedValue.Value)
+				if tc.expectedTTL > 0 {
+					assert.WithinDuration(t, time.Now().Add(tc.expectedTTL), storedValue.ExpiresAt, 50*time.Millisecond)
+				}
 			}
 		})
 	}
@@ -145,6 +193,9 @@ func TestStringsController_Get(t *testing.T) {
 				err := json.Unmarshal(rr.Body.Bytes(), &response)
 				assert.NoError(t, err)
 				assert.Equal(t, tc.expectedValue, response.Value)
+				assert.NotZero(t, response.Version)
+				assert.Regexp(t, `:\d{2}\.\d{3}`, response.ExpiresAt)
+				assert.Equal(t, strconv.Quote(strconv.FormatUint(response.Version, 10)), rr.Header().Get("ETag"))
 			}
 		})
 	}
@@ -157,12 +208,16 @@ func TestStringsController_Update(t *testing.T) {
 	// Populate store with data
 	err := store.Set("existing-key", "existing-value", 0)
 	assert.NoError(t, err)
+	err = store.Set("expiring-key", "existing-value", time.Hour)
+	assert.NoError(t, err)
 
 	testCases := map[string]struct {
 		key            string
 		value          string
+		ttl            int64
 		expectedStatus int
 		expectedError  error
+		expectedTTL    time.Duration
 	}{
 		"it should return an error if the key is missing": {
 			key:            "",
@@ -176,16 +231,30 @@ func TestStringsController_Update(t *testing.T) {
 			expectedStatus: gohttp.StatusBadRequest,
 			expectedError:  http.ErrEmptyValue,
 		},
-		"it should return an error if the key does not exist": {
+		"it should return an error if the ttl is negative": {
+			key:            "existing-key",
+			value:          "foo",
+			ttl:            -1,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidTTL,
+		},
+		"it should create the key if it does not exist": {
 			key:            "non-existing-key",
 			value:          "new-value",
-			expectedStatus: gohttp.StatusNotFound,
-			expectedError:  http.ErrKeyNotFound,
+			expectedStatus: gohttp.StatusNoContent,
 		},
-		"success": {
+		"it should keep the ttl if none is given": {
+			key:            "expiring-key",
+			value:          "updated-value",
+			expectedStatus: gohttp.StatusNoContent,
+			expectedTTL:    time.Hour,
+		},
+		"it should replace the ttl if one is given": {
 			key:            "existing-key",
 			value:          "updated-value",
+			ttl:            60,
 			expectedStatus: gohttp.StatusNoContent,
+			expectedTTL:    time.Minute,
 		},
 	}
 
@@ -194,6 +263,7 @@ func TestStringsController_Update(t *testing.T) {
 			payload, _ := json.Marshal(strings.UpdateRequest{
 				Key:   tc.key,
 				Value: tc.value,
+				TTL:   tc.ttl,
 			})
 			req := httptest.NewRequest(gohttp.MethodPut, "/strings", bytes.NewReader(payload))
 			req.Header.Set("Content-Type", "application/json")
@@ -209,6 +279,415 @@ func TestStringsController_Update(t *testing.T) {
 				storedValue, err := store.Get(tc.key)
 				assert.NoError(t, err)
 				assert.Equal(t, tc.value, storedValue.Value)
+				if tc.expectedTTL > 0 {
+					assert.WithinDuration(t, time.Now().Add(tc.expectedTTL), storedValue.ExpiresAt, time.Second)
+				} else {
+					assert.True(t, storedValue.ExpiresAt.IsZero())
+				}
+			}
+		})
+	}
+}
+
+func TestStringsController_UpdateIfMatch(t *testing.T) {
+	store := storage.NewStringStore()
+	controller := http.NewStringsController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", "existing-value", 0)
+	assert.NoError(t, err)
+	current, err := store.Get("existing-key")
+	assert.NoError(t, err)
+	etag := strconv.Quote(strconv.FormatUint(current.Version, 10))
+
+	testCases := map[string]struct {
+		ifMatch        string
+		expectedStatus int
+		expectedError  error
+		expectedValue  string
+	}{
+		"it should return an error if the version is invalid": {
+			ifMatch:        `"foo"`,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrInvalidVersion,
+			expectedValue:  "existing-value",
+		},
+		"it should return an error if the version doesn't match": {
+			ifMatch:        strconv.Quote(strconv.FormatUint(current.Version+1, 10)),
+			expectedStatus: gohttp.StatusPreconditionFailed,
+			expectedError:  http.ErrVersionMismatch,
+			expectedValue:  "existing-value",
+		},
+		"success": {
+			ifMatch:        etag,
+			expectedStatus: gohttp.StatusNoContent,
+			expectedValue:  "updated-value",
+		},
+	}
+
+	// Run sequentially as the successful update changes the version
+	for _, name := range []string{
+		"it should return an error if the version is invalid",
+		"it should return an error if the version doesn't match",
+		"success",
+	} {
+		tc := testCases[name]
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(strings.UpdateRequest{
+				Key:   "existing-key",
+				Value: "updated-value",
+			})
+			req := httptest.NewRequest(gohttp.MethodPut, "/strings", bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tc.ifMatch)
+			rr := httptest.NewRecorder()
+
+			controller.Update(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			}
+
+			storedValue, err := store.Get("existing-key")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedValue, storedValue.Value)
+		})
+	}
+
+	t.Run("it should reject an outdated version", func(t *testing.T) {
+		payload, _ := json.Marshal(strings.UpdateRequest{Key: "existing-key", Value: "other-value"})
+		req := httptest.NewRequest(gohttp.MethodPut, "/strings", bytes.NewReader(payload))
+		req.Header.Set("If-Match", etag)
+		rr := httptest.NewRecorder()
+
+		controller.Update(rr, req)
+
+		assert.Equal(t, gohttp.StatusPreconditionFailed, rr.Code)
+	})
+
+	t.Run("it should reject a ttl", func(t *testing.T) {
+		payload, _ := json.Marshal(strings.UpdateRequest{Key: "existing-key", Value: "other-value", TTL: 60})
+		req := httptest.NewRequest(gohttp.MethodPut, "/strings", bytes.NewReader(payload))
+		req.Header.Set("If-Match", etag)
+		rr := httptest.NewRecorder()
+
+		controller.Update(rr, req)
+
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrConditionalTTL.Error())
+	})
+}
+
+func TestStringsController_SetNX(t *testing.T) {
+	store := storage.NewStringStore()
+	controller := http.NewStringsController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", "existing-value", 0)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		key            string
+		value          string
+		expectedStatus int
+		expectedError  error
+		expectedSet    bool
+		expectedValue  string
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			value:          "foo",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the value is missing": {
+			key:            "foo",
+			value:          "",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyValue,
+		},
+		"it should not set an existing key": {
+			key:            "existing-key",
+			value:          "new-value",
+			expectedStatus: gohttp.StatusOK,
+			expectedValue:  "existing-value",
+		},
+		"success": {
+			key:            "new-key",
+			value:          "new-value",
+			expectedStatus: gohttp.StatusOK,
+			expectedSet:    true,
+			expectedValue:  "new-value",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(strings.SetRequest{Key: tc.key, Value: tc.value})
+			req := httptest.NewRequest(gohttp.MethodPost, "/strings/setnx", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.SetNX(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response strings.SetResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedSet, response.Set)
+
+				storedValue, err := store.Get(tc.key)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedValue, storedValue.Value)
+			}
+		})
+	}
+}
+
+func TestStringsController_SetXX(t *testing.T) {
+	store := storage.NewStringStore()
+	controller := http.NewStringsController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", "existing-value", 0)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		key            string
+		value          string
+		expectedStatus int
+		expectedError  error
+		expectedSet    bool
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			value:          "foo",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should not set a missing key": {
+			key:            "new-key",
+			value:          "new-value",
+			expectedStatus: gohttp.StatusOK,
+		},
+		"success": {
+			key:            "existing-key",
+			value:          "new-value",
+			expectedStatus: gohttp.StatusOK,
+			expectedSet:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(strings.SetRequest{Key: tc.key, Value: tc.value})
+			req := httptest.NewRequest(gohttp.MethodPost, "/strings/setxx", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.SetXX(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response strings.SetResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedSet, response.Set)
+			}
+		})
+	}
+}
+
+func TestStringsController_CompareAndSwap(t *testing.T) {
+	store := storage.NewStringStore()
+	controller := http.NewStringsController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", "existing-value", 0)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		key             string
+		expected        string
+		value           string
+		expectedStatus  int
+		expectedError   error
+		expectedSwapped bool
+		expectedValue   string
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			value:          "foo",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the key does not exist": {
+			key:            "non-existing-key",
+			value:          "new-value",
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"it should not swap if the value is different": {
+			key:            "existing-key",
+			expected:       "other-value",
+			value:          "new-value",
+			expectedStatus: gohttp.StatusOK,
+			expectedValue:  "existing-value",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(strings.CompareAndSwapRequest{Key: tc.key, Expected: tc.expected, Value: tc.value})
+			req := httptest.NewRequest(gohttp.MethodPost, "/strings/cas", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.CompareAndSwap(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response strings.CompareAndSwapResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedSwapped, response.Swapped)
+
+				storedValue, err := store.Get(tc.key)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedValue, storedValue.Value)
+			}
+		})
+	}
+
+	t.Run("success", func(t *testing.T) {
+		payload, _ := json.Marshal(strings.CompareAndSwapRequest{Key: "existing-key", Expected: "existing-value", Value: "new-value"})
+		req := httptest.NewRequest(gohttp.MethodPost, "/strings/cas", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.CompareAndSwap(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response strings.CompareAndSwapResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Swapped)
+	})
+}
+
+func TestStringsController_IncrBy(t *testing.T) {
+	store := storage.NewStringStore()
+	controller := http.NewStringsController(store)
+
+	// Populate store with data
+	err := store.Set("counter", "10", 0)
+	assert.NoError(t, err)
+	err = store.Set("text", "foo", 0)
+	assert.NoError(t, err)
+
+	by := func(v int64) *int64 { return &v }
+
+	testCases := map[string]struct {
+		key            string
+		by             *int64
+		expectedStatus int
+		expectedError  error
+		expectedValue  int64
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the value is not an integer": {
+			key:            "text",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  storage.ErrNotInteger,
+		},
+		"it should increment by one by default": {
+			key:            "counter",
+			expectedStatus: gohttp.StatusOK,
+			expectedValue:  11,
+		},
+		"it should decrement with a negative value": {
+			key:            "other-counter",
+			by:             by(-3),
+			expectedStatus: gohttp.StatusOK,
+			expectedValue:  -3,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(strings.IncrByRequest{Key: tc.key, By: tc.by})
+			req := httptest.NewRequest(gohttp.MethodPost, "/strings/incr", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.IncrBy(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response strings.IncrByResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedValue, response.Value)
+			}
+		})
+	}
+}
+
+func TestStringsController_IncrByFloat(t *testing.T) {
+	store := storage.NewStringStore()
+	controller := http.NewStringsController(store)
+
+	// Populate store with data
+	err := store.Set("price", "10.5", 0)
+	assert.NoError(t, err)
+	err = store.Set("text", "foo", 0)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		key            string
+		by             float64
+		expectedStatus int
+		expectedError  error
+		expectedValue  float64
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the value is not a number": {
+			key:            "text",
+			by:             1,
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  storage.ErrNotFloat,
+		},
+		"success": {
+			key:            "price",
+			by:             0.25,
+			expectedStatus: gohttp.StatusOK,
+			expectedValue:  10.75,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(strings.IncrByFloatRequest{Key: tc.key, By: tc.by})
+			req := httptest.NewRequest(gohttp.MethodPost, "/strings/incrbyfloat", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.IncrByFloat(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response strings.IncrByFloatResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedValue, response.Value)
 			}
 		})
 	}
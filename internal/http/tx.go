@@ -0,0 +1,214 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"in-memory-storage/internal/tx"
+	"in-memory-storage/storage"
+	"log"
+	"net/http"
+)
+
+type TxController interface {
+	Exec(w http.ResponseWriter, r *http.Request)
+}
+
+func NewTxController(transactor storage.Transactor[string]) TxController {
+	return &txController{transactor: transactor}
+}
+
+type txController struct {
+	transactor storage.Transactor[string]
+}
+
+func (tc *txController) Exec(w http.ResponseWriter, r *http.Request) {
+	var req tx.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Ops) == 0 {
+		http.Error(w, ErrEmptyTx.Error(), http.StatusBadRequest)
+		return
+	}
+
+	t, err := buildTx(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := tc.transactor.Exec(t)
+	if err != nil {
+		tc.handleError(w, err)
+		return
+	}
+
+	res := tx.Response{Results: make([]tx.Result, len(results))}
+	for i, result := range results {
+		res.Results[i] = toTxResult(result)
+	}
+
+	writeJSON(w, &res)
+}
+
+// handleError maps the errors of a transaction to their HTTP responses.
+// The errors of the operations are prefixed with their position in the transaction.
+func (tc *txController) handleError(w http.ResponseWriter, err error) {
+	if err == storage.ErrTxAborted {
+		http.Error(w, ErrTxAborted.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	var txErr *storage.TxError
+	if !errors.As(err, &txErr) {
+		log.Printf("ERROR: failed to execute transaction: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	prefix := fmt.Sprintf("operation %d: ", txErr.Index)
+	switch txErr.Err {
+	case storage.ErrNotFound, storage.ErrExpired:
+		http.Error(w, prefix+ErrKeyNotFound.Error(), http.StatusNotFound)
+	case storage.ErrEmptyList:
+		http.Error(w, prefix+txErr.Err.Error(), http.StatusNotFound)
+	case storage.ErrAlreadyExists:
+		http.Error(w, prefix+ErrKeyAlreadyExists.Error(), http.StatusConflict)
+	case storage.ErrWrongType:
+		http.Error(w, prefix+ErrWrongType.Error(), http.StatusConflict)
+	case storage.ErrNotInteger, storage.ErrOverflow:
+		http.Error(w, prefix+txErr.Err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("ERROR: failed to execute transaction: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// buildTx validates the request and queues its watches and operations in a new transaction.
+func buildTx(req tx.Request) (*storage.Tx[string], error) {
+	t := storage.NewTx[string]()
+
+	for i, watch := range req.Watch {
+		if watch.Key == "" {
+			return nil, fmt.Errorf("watch %d: %w", i, ErrEmptyKey)
+		}
+		switch watch.Type {
+		case tx.TypeString:
+			t.WatchString(watch.Key, watch.Version)
+		case tx.TypeList:
+			t.WatchList(watch.Key, watch.Version)
+		default:
+			return nil, fmt.Errorf("watch %d: %w", i, ErrInvalidOperation)
+		}
+	}
+
+	for i, op := range req.Ops {
+		if err := queueOp(t, op); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return t, nil
+}
+
+// queueOp validates the operation and queues it in the transaction.
+func queueOp(t *storage.Tx[string], op tx.Op) error {
+	if op.Key == "" {
+		return ErrEmptyKey
+	}
+
+	switch op.Type {
+	case tx.TypeString:
+		return queueStringOp(t, op)
+	case tx.TypeList:
+		return queueListOp(t, op)
+	default:
+		return ErrInvalidOperation
+	}
+}
+
+func queueStringOp(t *storage.Tx[string], op tx.Op) error {
+	switch op.Op {
+	case tx.OpGet:
+		t.GetString(op.Key)
+	case tx.OpSet:
+		if op.Value == "" {
+			return ErrEmptyValue
+		}
+		ttl, err := requestTTL(op.TTL, op.TTLMs, op.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		t.SetString(op.Key, op.Value, ttl)
+	case tx.OpUpdate:
+		if op.Value == "" {
+			return ErrEmptyValue
+		}
+		t.UpdateString(op.Key, op.Value)
+	case tx.OpRemove:
+		t.RemoveString(op.Key)
+	case tx.OpIncrBy:
+		by := int64(1)
+		if op.By != nil {
+			by = *op.By
+		}
+		t.IncrBy(op.Key, by)
+	default:
+		return ErrInvalidOperation
+	}
+	return nil
+}
+
+func queueListOp(t *storage.Tx[string], op tx.Op) error {
+	switch op.Op {
+	case tx.OpGet:
+		t.GetList(op.Key)
+	case tx.OpSet:
+		if len(op.Values) == 0 {
+			return ErrEmptyValue
+		}
+		ttl, err := requestTTL(op.TTL, op.TTLMs, op.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		t.SetList(op.Key, op.Values, ttl)
+	case tx.OpUpdate:
+		if len(op.Values) == 0 {
+			return ErrEmptyValue
+		}
+		t.UpdateList(op.Key, op.Values)
+	case tx.OpRemove:
+		t.RemoveList(op.Key)
+	case tx.OpPush:
+		if op.Value == "" {
+			return ErrEmptyValue
+		}
+		t.Push(op.Key, op.Value)
+	case tx.OpPop:
+		t.Pop(op.Key)
+	default:
+		return ErrInvalidOperation
+	}
+	return nil
+}
+
+// toTxResult converts the result of an operation to its response.
+func toTxResult(result any) tx.Result {
+	switch v := result.(type) {
+	case *storage.Value[string]:
+		if v == nil {
+			return tx.Result{}
+		}
+		return tx.Result{Value: v.Value, ExpiresAt: formatTime(v.ExpiresAt), Version: v.Version}
+	case *storage.Value[[]string]:
+		if v == nil {
+			return tx.Result{}
+		}
+		return tx.Result{Value: v.Value, ExpiresAt: formatTime(v.ExpiresAt), Version: v.Version}
+	default:
+		return tx.Result{Value: v}
+	}
+}
@@ -0,0 +1,157 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"in-memory-storage/internal/http"
+	"in-memory-storage/internal/tx"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxController_Exec(t *testing.T) {
+	stringStore := storage.NewStringStore()
+	listStore := storage.NewListStore[string]()
+	transactor, err := storage.NewTransactor(stringStore, listStore)
+	assert.NoError(t, err)
+	controller := http.NewTxController(transactor)
+
+	// Populate store with data
+	err = stringStore.Set("existing-key", "existing-value", 0)
+	assert.NoError(t, err)
+	err = listStore.Set("queue", []string{"job1", "job2"}, 0)
+	assert.NoError(t, err)
+	existing, err := stringStore.Get("existing-key")
+	assert.NoError(t, err)
+
+	by := int64(5)
+	testCases := map[string]struct {
+		req             tx.Request
+		expectedStatus  int
+		expectedError   string
+		expectedResults []tx.Result
+	}{
+		"it should return an error if there are no operations": {
+			req:            tx.Request{},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyTx.Error(),
+		},
+		"it should return an error if a key is missing": {
+			req: tx.Request{Ops: []tx.Op{
+				{Op: tx.OpGet, Type: tx.TypeString, Key: "existing-key"},
+				{Op: tx.OpGet, Type: tx.TypeString},
+			}},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  "operation 1: " + http.ErrEmptyKey.Error(),
+		},
+		"it should return an error if an operation is not supported by the type": {
+			req: tx.Request{Ops: []tx.Op{
+				{Op: tx.OpPush, Type: tx.TypeString, Key: "existing-key", Value: "foo"},
+			}},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  "operation 0: " + http.ErrInvalidOperation.Error(),
+		},
+		"it should return an error if a watch has an unknown type": {
+			req: tx.Request{
+				Watch: []tx.Watch{{Type: "hash", Key: "existing-key"}},
+				Ops:   []tx.Op{{Op: tx.OpGet, Type: tx.TypeString, Key: "existing-key"}},
+			},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  "watch 0: " + http.ErrInvalidOperation.Error(),
+		},
+		"it should return an error if an operation fails": {
+			req: tx.Request{Ops: []tx.Op{
+				{Op: tx.OpSet, Type: tx.TypeString, Key: "new-key", Value: "new-value"},
+				{Op: tx.OpUpdate, Type: tx.TypeString, Key: "missing-key", Value: "foo"},
+			}},
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  "operation 1: " + http.ErrKeyNotFound.Error(),
+		},
+		"it should return an error if a watched key has changed": {
+			req: tx.Request{
+				Watch: []tx.Watch{{Type: tx.TypeString, Key: "existing-key", Version: existing.Version + 100}},
+				Ops:   []tx.Op{{Op: tx.OpSet, Type: tx.TypeString, Key: "new-key", Value: "new-value"}},
+			},
+			expectedStatus: gohttp.StatusPreconditionFailed,
+			expectedError:  http.ErrTxAborted.Error(),
+		},
+		"success": {
+			req: tx.Request{
+				Watch: []tx.Watch{{Type: tx.TypeString, Key: "existing-key", Version: existing.Version}},
+				Ops: []tx.Op{
+					{Op: tx.OpPop, Type: tx.TypeList, Key: "queue"},
+					{Op: tx.OpSet, Type: tx.TypeString, Key: "last-job", Value: "job1"},
+					{Op: tx.OpIncrBy, Type: tx.TypeString, Key: "counter", By: &by},
+					{Op: tx.OpGet, Type: tx.TypeString, Key: "missing-key"},
+				},
+			},
+			expectedStatus: gohttp.StatusOK,
+			expectedResults: []tx.Result{
+				{Value: "job1"},
+				{},
+				{Value: float64(5)},
+				{},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(tc.req)
+			req := httptest.NewRequest(gohttp.MethodPost, "/tx", bytes.NewReader(payload))
+			rr := httptest.NewRecorder()
+
+			controller.Exec(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, rr.Body.String(), tc.expectedError)
+
+				// Nothing is applied when the transaction fails
+				_, err := stringStore.Get("new-key")
+				assert.Equal(t, storage.ErrNotFound, err)
+			} else {
+				var response tx.Response
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedResults, response.Results)
+			}
+		})
+	}
+}
+
+func TestTxController_Get(t *testing.T) {
+	stringStore := storage.NewStringStore()
+	listStore := storage.NewListStore[string]()
+	transactor, err := storage.NewTransactor(stringStore, listStore)
+	assert.NoError(t, err)
+	controller := http.NewTxController(transactor)
+
+	// Populate store with data
+	err = stringStore.Set("key", "value", 0)
+	assert.NoError(t, err)
+	err = listStore.Set("list", []string{"foo", "bar"}, 0)
+	assert.NoError(t, err)
+
+	payload, _ := json.Marshal(tx.Request{Ops: []tx.Op{
+		{Op: tx.OpGet, Type: tx.TypeString, Key: "key"},
+		{Op: tx.OpGet, Type: tx.TypeList, Key: "list"},
+	}})
+	req := httptest.NewRequest(gohttp.MethodPost, "/tx", bytes.NewReader(payload))
+	rr := httptest.NewRecorder()
+
+	controller.Exec(rr, req)
+
+	assert.Equal(t, gohttp.StatusOK, rr.Code)
+	var response tx.Response
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Len(t, response.Results, 2)
+	assert.Equal(t, "value", response.Results[0].Value)
+	assert.NotZero(t, response.Results[0].Version)
+	assert.Equal(t, []any{"foo", "bar"}, response.Results[1].Value)
+	assert.NotZero(t, response.Results[1].Version)
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"in-memory-storage/internal/keys"
+	"in-memory-storage/storage"
+	"log"
+	"net/http"
+	"time"
+)
+
+type WatchController interface {
+	Watch(w http.ResponseWriter, r *http.Request)
+}
+
+func NewWatchController(watcher storage.Watcher) WatchController {
+	return &watchController{watcher: watcher}
+}
+
+type watchController struct {
+	watcher storage.Watcher
+}
+
+// Watch streams the changes of the keys that match the pattern query parameter as Server-Sent Events,
+// until the client disconnects. An empty pattern matches every key. If the watch is dropped because
+// the client doesn't keep up with the events, or the server is shutting down, an error event is sent
+// before closing the stream.
+func (wc *watchController) Watch(w http.ResponseWriter, r *http.Request) {
+	events := wc.watcher.Watch(r.Context(), r.URL.Query().Get("pattern"))
+
+	rc, err := startStream(w)
+	if err != nil {
+		log.Printf("ERROR: failed to flush response: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			err = writeKeepAlive(w)
+		case e, ok := <-events:
+			if !ok {
+				if r.Context().Err() == nil {
+					_ = writeEvent(w, "error", &errorEvent{Error: ErrWatchClosed.Error()})
+					_ = rc.Flush()
+				}
+				return
+			}
+			err = writeEvent(w, "change", &keys.WatchEvent{
+				Event: string(e.Type),
+				Key:   e.Key,
+				Type:  string(e.KeyType),
+				Time:  e.Time,
+			})
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			// The client is gone
+			return
+		}
+	}
+}
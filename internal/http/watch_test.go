@@ -0,0 +1,82 @@
+package http_test
+
+import (
+	"bufio"
+	"encoding/json"
+	gohttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"in-memory-storage/internal/http"
+	"in-memory-storage/internal/keys"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvent reads the next Server-Sent Event of the stream, skipping the comments.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		switch {
+		case line == "\n" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimSuffix(strings.TrimPrefix(line, "event: "), "\n")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimSuffix(strings.TrimPrefix(line, "data: "), "\n")
+		}
+	}
+}
+
+func TestWatchController_Watch(t *testing.T) {
+	watcher := storage.NewWatcher()
+	keyspace := storage.NewKeyspace()
+	stringStore := storage.NewStringStore(storage.WithKeyspace(keyspace), storage.WithObserver(watcher.Notify))
+	listStore := storage.NewListStore[string](storage.WithKeyspace(keyspace), storage.WithObserver(watcher.Notify))
+	srv := httptest.NewServer(gohttp.HandlerFunc(http.NewWatchController(watcher).Watch))
+	defer srv.Close()
+
+	res, err := gohttp.Get(srv.URL + "/watch?pattern=user:*")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, gohttp.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	// The watch exists once the headers are received
+	require.NoError(t, stringStore.Set("user:1", "alice", 0))
+	require.NoError(t, stringStore.Set("order:1", "book", 0))
+	require.NoError(t, listStore.Set("user:2", []string{"a"}, 0))
+	_, err = listStore.Pop("user:2")
+	require.NoError(t, err)
+	_, err = keyspace.Delete("user:1")
+	require.NoError(t, err)
+	require.NoError(t, watcher.Close())
+
+	r := bufio.NewReader(res.Body)
+	expected := []keys.WatchEvent{
+		{Event: "set", Key: "user:1", Type: "string"},
+		{Event: "set", Key: "user:2", Type: "list"},
+		{Event: "pop", Key: "user:2", Type: "list"},
+		{Event: "remove", Key: "user:1", Type: "string"},
+	}
+	for _, e := range expected {
+		event, data := readEvent(t, r)
+		assert.Equal(t, "change", event)
+		var got keys.WatchEvent
+		require.NoError(t, json.Unmarshal([]byte(data), &got))
+		assert.WithinDuration(t, time.Now(), got.Time, time.Second)
+		got.Time = time.Time{}
+		assert.Equal(t, e, got)
+	}
+
+	event, data := readEvent(t, r)
+	assert.Equal(t, "error", event)
+	assert.JSONEq(t, `{"error":"`+http.ErrWatchClosed.Error()+`"}`, data)
+}
@@ -0,0 +1,420 @@
+package http
+
+import (
+	"encoding/json"
+	"in-memory-storage/internal/zsets"
+	"in-memory-storage/storage"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type ZSetsController interface {
+	Set(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Add(w http.ResponseWriter, r *http.Request)
+	RemoveMembers(w http.ResponseWriter, r *http.Request)
+	IncrBy(w http.ResponseWriter, r *http.Request)
+	Score(w http.ResponseWriter, r *http.Request)
+	Rank(w http.ResponseWriter, r *http.Request)
+	Card(w http.ResponseWriter, r *http.Request)
+	Range(w http.ResponseWriter, r *http.Request)
+	RemoveRange(w http.ResponseWriter, r *http.Request)
+	RangeByScore(w http.ResponseWriter, r *http.Request)
+	RemoveRangeByScore(w http.ResponseWriter, r *http.Request)
+}
+
+func NewZSetsController(store storage.ZSetStore) ZSetsController {
+	return &zsetsController{store: store}
+}
+
+type zsetsController struct {
+	store storage.ZSetStore
+}
+
+func (zc *zsetsController) Set(w http.ResponseWriter, r *http.Request) {
+	var req zsets.SetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Members) == 0 {
+		http.Error(w, ErrEmptyValue.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl, err := requestTTL(req.TTL, req.TTLMs, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := zc.store.Set(req.Key, toScoredMembers(req.Members), ttl); err != nil {
+		if err == storage.ErrAlreadyExists {
+			http.Error(w, ErrKeyAlreadyExists.Error(), http.StatusConflict)
+			return
+		}
+		zc.handleError(w, req.Key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (zc *zsetsController) Get(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value, err := zc.store.Get(key)
+	if err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.GetResponse{
+		Members:   fromScoredMembers(value.Value),
+		ExpiresAt: formatTime(value.ExpiresAt),
+	})
+}
+
+func (zc *zsetsController) Delete(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := zc.store.Remove(key); err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (zc *zsetsController) Add(w http.ResponseWriter, r *http.Request) {
+	var req zsets.AddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Members) == 0 {
+		http.Error(w, ErrEmptyMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	added, err := zc.store.ZAdd(req.Key, toScoredMembers(req.Members)...)
+	if err != nil {
+		zc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.AddResponse{Added: added})
+}
+
+func (zc *zsetsController) RemoveMembers(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	members := r.URL.Query()["member"]
+	if len(members) == 0 {
+		http.Error(w, ErrEmptyMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := zc.store.ZRem(key, members...)
+	if err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.RemoveResponse{Removed: removed})
+}
+
+func (zc *zsetsController) IncrBy(w http.ResponseWriter, r *http.Request) {
+	var req zsets.IncrByRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("ERROR: failed to decode request body: %v", err)
+		http.Error(w, ErrInvalidBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Member == "" {
+		http.Error(w, ErrEmptyMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	score, err := zc.store.ZIncrBy(req.Key, req.Member, req.By)
+	if err != nil {
+		zc.handleError(w, req.Key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.ScoreResponse{Score: score})
+}
+
+func (zc *zsetsController) Score(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	member := r.URL.Query().Get("member")
+	if member == "" {
+		http.Error(w, ErrEmptyMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	score, err := zc.store.ZScore(key, member)
+	if err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.ScoreResponse{Score: score})
+}
+
+func (zc *zsetsController) Rank(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	member := r.URL.Query().Get("member")
+	if member == "" {
+		http.Error(w, ErrEmptyMember.Error(), http.StatusBadRequest)
+		return
+	}
+	reverse, err := parseReverse(r.URL.Query())
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rankFn := zc.store.ZRank
+	if reverse {
+		rankFn = zc.store.ZRevRank
+	}
+	rank, err := rankFn(key, member)
+	if err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.RankResponse{Rank: rank})
+}
+
+func (zc *zsetsController) Card(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	card, err := zc.store.ZCard(key)
+	if err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.CardResponse{Card: card})
+}
+
+func (zc *zsetsController) Range(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	start, stop, err := parseRankRange(r.URL.Query())
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+	reverse, err := parseReverse(r.URL.Query())
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rangeFn := zc.store.ZRange
+	if reverse {
+		rangeFn = zc.store.ZRevRange
+	}
+	members, err := rangeFn(key, start, stop)
+	if err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.RangeResponse{Members: fromScoredMembers(members)})
+}
+
+func (zc *zsetsController) RemoveRange(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	start, stop, err := parseRankRange(r.URL.Query())
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := zc.store.ZRemRangeByRank(key, start, stop)
+	if err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.RemoveResponse{Removed: removed})
+}
+
+func (zc *zsetsController) RangeByScore(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := query.Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	min, max, err := parseScoreRange(query)
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+	offset, err := parseIntParam(query, "offset", 0)
+	if err != nil || offset < 0 {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+	count, err := parseIntParam(query, "count", -1)
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+
+	members, err := zc.store.ZRangeByScore(key, min, max, offset, count)
+	if err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.RangeResponse{Members: fromScoredMembers(members)})
+}
+
+func (zc *zsetsController) RemoveRangeByScore(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, ErrEmptyKey.Error(), http.StatusBadRequest)
+		return
+	}
+	min, max, err := parseScoreRange(r.URL.Query())
+	if err != nil {
+		http.Error(w, ErrInvalidRange.Error(), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := zc.store.ZRemRangeByScore(key, min, max)
+	if err != nil {
+		zc.handleError(w, key, err)
+		return
+	}
+
+	writeJSON(w, &zsets.RemoveResponse{Removed: removed})
+}
+
+// handleError maps the storage errors to their HTTP responses.
+func (zc *zsetsController) handleError(w http.ResponseWriter, key string, err error) {
+	switch err {
+	case storage.ErrNotFound, storage.ErrExpired:
+		http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+	case storage.ErrMemberNotFound:
+		http.Error(w, ErrMemberNotFound.Error(), http.StatusNotFound)
+	case storage.ErrInvalidScore:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case storage.ErrWrongType:
+		http.Error(w, ErrWrongType.Error(), http.StatusConflict)
+	default:
+		log.Printf("ERROR: failed to handle sorted set for key %s: %v", key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// parseRankRange parses the required start and stop positions.
+func parseRankRange(query url.Values) (int, int, error) {
+	start, err := strconv.Atoi(query.Get("start"))
+	if err != nil {
+		return 0, 0, err
+	}
+	stop, err := strconv.Atoi(query.Get("stop"))
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, stop, nil
+}
+
+// parseScoreRange parses the required min and max scores, which accept -inf and +inf.
+func parseScoreRange(query url.Values) (float64, float64, error) {
+	min, err := strconv.ParseFloat(query.Get("min"), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	max, err := strconv.ParseFloat(query.Get("max"), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return min, max, nil
+}
+
+// parseIntParam parses an optional integer parameter, returning def when it's missing.
+func parseIntParam(query url.Values, name string, def int) (int, error) {
+	if query.Get(name) == "" {
+		return def, nil
+	}
+	return strconv.Atoi(query.Get(name))
+}
+
+// parseReverse parses the optional reverse flag.
+func parseReverse(query url.Values) (bool, error) {
+	if query.Get("reverse") == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(query.Get("reverse"))
+}
+
+func toScoredMembers(members []zsets.Member) []storage.ScoredMember {
+	scored := make([]storage.ScoredMember, 0, len(members))
+	for _, m := range members {
+		scored = append(scored, storage.ScoredMember{Member: m.Member, Score: m.Score})
+	}
+	return scored
+}
+
+func fromScoredMembers(scored []storage.ScoredMember) []zsets.Member {
+	members := make([]zsets.Member, 0, len(scored))
+	for _, m := range scored {
+		members = append(members, zsets.Member{Member: m.Member, Score: m.Score})
+	}
+	return members
+}
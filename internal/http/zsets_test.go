@@ -0,0 +1,336 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"in-memory-storage/internal/http"
+	"in-memory-storage/internal/zsets"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZSetsController_Set(t *testing.T) {
+	store := storage.NewZSetStore()
+	controller := http.NewZSetsController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", []storage.ScoredMember{{Member: "foo", Score: 1}}, 0)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		key            string
+		members        []zsets.Member
+		ttl            int64
+		expectedStatus int
+		expectedError  error
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			members:        []zsets.Member{{Member: "foo", Score: 1}},
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the members are missing": {
+			key:            "foo",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyValue,
+		},
+		"it should return an error if the key already exists": {
+			key:            "existing-key",
+			members:        []zsets.Member{{Member: "bar", Score: 1}},
+			expectedStatus: gohttp.StatusConflict,
+			expectedError:  http.ErrKeyAlreadyExists,
+		},
+		"success with ttl": {
+			key:            "foo",
+			members:        []zsets.Member{{Member: "bar", Score: 1}, {Member: "baz", Score: 2}},
+			ttl:            60,
+			expectedStatus: gohttp.StatusNoContent,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload, _ := json.Marshal(zsets.SetRequest{
+				Key:     tc.key,
+				Members: tc.members,
+				TTL:     tc.ttl,
+			})
+			req := httptest.NewRequest(gohttp.MethodPost, "/zsets", bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+
+			controller.Set(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				storedValue, err := store.Get(tc.key)
+				assert.NoError(t, err)
+				assert.Len(t, storedValue.Value, len(tc.members))
+				assert.False(t, storedValue.ExpiresAt.IsZero())
+			}
+		})
+	}
+}
+
+func TestZSetsController_Get(t *testing.T) {
+	store := storage.NewZSetStore()
+	controller := http.NewZSetsController(store)
+
+	// Populate store with data
+	err := store.Set("existing-key", []storage.ScoredMember{{Member: "b", Score: 2}, {Member: "a", Score: 1}}, 60*time.Second)
+	assert.NoError(t, err)
+	err = store.Set("expired-key", []storage.ScoredMember{{Member: "a", Score: 1}}, 1*time.Millisecond)
+	assert.NoError(t, err)
+
+	// Ensure the expired key is actually expired
+	time.Sleep(2 * time.Millisecond)
+
+	testCases := map[string]struct {
+		key             string
+		expectedStatus  int
+		expectedError   error
+		expectedMembers []zsets.Member
+	}{
+		"it should return an error if the key is missing": {
+			key:            "",
+			expectedStatus: gohttp.StatusBadRequest,
+			expectedError:  http.ErrEmptyKey,
+		},
+		"it should return an error if the key does not exist": {
+			key:            "non-existing-key",
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"it should return an error if the key has expired": {
+			key:            "expired-key",
+			expectedStatus: gohttp.StatusNotFound,
+			expectedError:  http.ErrKeyNotFound,
+		},
+		"success": {
+			key:             "existing-key",
+			expectedStatus:  gohttp.StatusOK,
+			expectedMembers: []zsets.Member{{Member: "a", Score: 1}, {Member: "b", Score: 2}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(gohttp.MethodGet, "/zsets?key="+tc.key, nil)
+			rr := httptest.NewRecorder()
+
+			controller.Get(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedError != nil {
+				assert.Contains(t, rr.Body.String(), tc.expectedError.Error())
+			} else {
+				var response zsets.GetResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedMembers, response.Members)
+				assert.NotEmpty(t, response.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestZSetsController_Leaderboard(t *testing.T) {
+	store := storage.NewZSetStore()
+	controller := http.NewZSetsController(store)
+
+	t.Run("it should add members", func(t *testing.T) {
+		payload, _ := json.Marshal(zsets.AddRequest{Key: "board", Members: []zsets.Member{
+			{Member: "alice", Score: 30},
+			{Member: "bob", Score: 10},
+			{Member: "carol", Score: 20},
+		}})
+		req := httptest.NewRequest(gohttp.MethodPost, "/zsets/members", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.Add(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response zsets.AddResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Added)
+	})
+
+	t.Run("it should increment the score of a member", func(t *testing.T) {
+		payload, _ := json.Marshal(zsets.IncrByRequest{Key: "board", Member: "bob", By: 25})
+		req := httptest.NewRequest(gohttp.MethodPost, "/zsets/incr", bytes.NewReader(payload))
+		rr := httptest.NewRecorder()
+
+		controller.IncrBy(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response zsets.ScoreResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, float64(35), response.Score)
+	})
+
+	t.Run("it should return the score of a member", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/zsets/score?key=board&member=carol", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Score(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response zsets.ScoreResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, float64(20), response.Score)
+	})
+
+	t.Run("it should return an error if the member does not exist", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/zsets/score?key=board&member=dave", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Score(rr, req)
+
+		assert.Equal(t, gohttp.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrMemberNotFound.Error())
+	})
+
+	t.Run("it should return the reverse rank of a member", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/zsets/rank?key=board&member=bob&reverse=true", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Rank(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response zsets.RankResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 0, response.Rank)
+	})
+
+	t.Run("it should return the top members", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/zsets/range?key=board&start=0&stop=1&reverse=true", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Range(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response zsets.RangeResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, []zsets.Member{{Member: "bob", Score: 35}, {Member: "alice", Score: 30}}, response.Members)
+	})
+
+	t.Run("it should return an error if the range is invalid", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/zsets/range?key=board&start=first&stop=1", nil)
+		rr := httptest.NewRecorder()
+
+		controller.Range(rr, req)
+
+		assert.Equal(t, gohttp.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), http.ErrInvalidRange.Error())
+	})
+
+	t.Run("it should return the members in a score range", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/zsets/range/score?key=board&min=-inf&max=30&count=1", nil)
+		rr := httptest.NewRecorder()
+
+		controller.RangeByScore(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response zsets.RangeResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, []zsets.Member{{Member: "carol", Score: 20}}, response.Members)
+	})
+
+	t.Run("it should remove the members in a score range", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodDelete, "/zsets/range/score?key=board&min=0&max=30", nil)
+		rr := httptest.NewRecorder()
+
+		controller.RemoveRangeByScore(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response zsets.RemoveResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Removed)
+	})
+
+	t.Run("it should remove the members in a rank range", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodDelete, "/zsets/range?key=board&start=0&stop=-1", nil)
+		rr := httptest.NewRecorder()
+
+		controller.RemoveRange(rr, req)
+
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+		var response zsets.RemoveResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Removed)
+
+		_, err := store.Get("board")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestZSetsEndpoints_E2E(t *testing.T) {
+	zsetStore := storage.NewZSetStore()
+	validAPIKey := "valid-api-key"
+
+	srv, err := http.NewServer(
+		"8080",
+		http.NewStringsController(storage.NewStringStore()),
+		http.NewStringListsController(storage.NewListStore[string]()),
+		validAPIKey,
+		http.WithZSetsController(http.NewZSetsController(zsetStore)),
+	)
+	assert.NoError(t, err)
+
+	t.Run("should return 401 when wrong auth is provided", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodGet, "/zsets?key=board", nil)
+		req.Header.Set("Authorization", "Bearer invalid-api-key")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, gohttp.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("should add members and return the card", func(t *testing.T) {
+		body, _ := json.Marshal(zsets.AddRequest{Key: "board", Members: []zsets.Member{{Member: "alice", Score: 1}}})
+		req := httptest.NewRequest(gohttp.MethodPost, "/zsets/members", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+
+		req = httptest.NewRequest(gohttp.MethodGet, "/zsets/card?key=board", nil)
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr = httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+
+		var response zsets.CardResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Card)
+	})
+
+	t.Run("should remove members", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodDelete, "/zsets/members?key=board&member=alice", nil)
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		assert.Equal(t, gohttp.StatusOK, rr.Code)
+
+		_, err := zsetStore.Get("board")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("should return 405 on unsupported methods", func(t *testing.T) {
+		req := httptest.NewRequest(gohttp.MethodPost, "/zsets/range", nil)
+		req.Header.Set("Authorization", "Bearer "+validAPIKey)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, gohttp.StatusMethodNotAllowed, rr.Code)
+	})
+}
@@ -0,0 +1,274 @@
+// Package jsonschema validates JSON values against a subset of JSON Schema.
+// It supports the type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, minLength, maxLength, pattern, minimum and maximum keywords.
+// Schemas using any other keyword are rejected, so values are never accepted by a keyword that
+// isn't checked. The annotations, like title and description, are allowed as they don't validate anything.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidSchema is returned when a schema can't be parsed.
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	types                []string
+	enum                 []any
+	constant             *any
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	items                *Schema
+	minItems, maxItems   *int
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	minimum, maximum     *float64
+}
+
+// rawSchema is the JSON representation of a Schema.
+type rawSchema struct {
+	Type                 json.RawMessage            `json:"type"`
+	Enum                 []any                      `json:"enum"`
+	Const                json.RawMessage            `json:"const"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	Pattern              *string                    `json:"pattern"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+}
+
+var validTypes = []string{"null", "boolean", "integer", "number", "string", "array", "object"}
+
+// keywords are the keywords of rawSchema, followed by the annotations which are allowed but not used.
+var keywords = []string{
+	"type", "enum", "const", "properties", "required", "additionalProperties", "items",
+	"minItems", "maxItems", "minLength", "maxLength", "pattern", "minimum", "maximum",
+	"$schema", "$id", "$comment", "title", "description", "default", "examples",
+}
+
+// Compile parses the JSON Schema. The schema true accepts every value and false rejects them all.
+func Compile(data []byte) (*Schema, error) {
+	data = bytes.TrimSpace(data)
+	switch string(data) {
+	case "true":
+		return &Schema{}, nil
+	case "false":
+		return &Schema{types: []string{}}, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	// The keywords are checked in order so the error is always the same for the same schema.
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !slices.Contains(keywords, name) {
+			return nil, fmt.Errorf("%w: unsupported keyword %q", ErrInvalidSchema, name)
+		}
+	}
+
+	var raw rawSchema
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	s := &Schema{
+		enum:      raw.Enum,
+		required:  raw.Required,
+		minItems:  raw.MinItems,
+		maxItems:  raw.MaxItems,
+		minLength: raw.MinLength,
+		maxLength: raw.MaxLength,
+		minimum:   raw.Minimum,
+		maximum:   raw.Maximum,
+	}
+
+	if len(raw.Type) > 0 {
+		if err := json.Unmarshal(raw.Type, &s.types); err != nil {
+			var t string
+			if err := json.Unmarshal(raw.Type, &t); err != nil {
+				return nil, fmt.Errorf("%w: type must be a string or an array of strings", ErrInvalidSchema)
+			}
+			s.types = []string{t}
+		}
+		for _, t := range s.types {
+			if !slices.Contains(validTypes, t) {
+				return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidSchema, t)
+			}
+		}
+	}
+
+	if len(raw.Const) > 0 {
+		var c any
+		if err := json.Unmarshal(raw.Const, &c); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+		}
+		s.constant = &c
+	}
+
+	if len(raw.Properties) > 0 {
+		s.properties = make(map[string]*Schema, len(raw.Properties))
+		for name, prop := range raw.Properties {
+			sub, err := Compile(prop)
+			if err != nil {
+				return nil, fmt.Errorf("properties.%s: %w", name, err)
+			}
+			s.properties[name] = sub
+		}
+	}
+
+	if len(raw.AdditionalProperties) > 0 {
+		sub, err := Compile(raw.AdditionalProperties)
+		if err != nil {
+			return nil, fmt.Errorf("additionalProperties: %w", err)
+		}
+		s.additionalProperties = sub
+	}
+
+	if len(raw.Items) > 0 {
+		sub, err := Compile(raw.Items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		s.items = sub
+	}
+
+	if raw.Pattern != nil {
+		re, err := regexp.Compile(*raw.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+		}
+		s.pattern = re
+	}
+
+	return s, nil
+}
+
+// Validate checks that the JSON value matches the schema.
+// The returned error points to the first part of the value that doesn't match.
+func (s *Schema) Validate(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return s.validate("$", v)
+}
+
+func (s *Schema) validate(path string, v any) error {
+	if s.types != nil && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(v, t) }) {
+		if len(s.types) == 0 {
+			return fmt.Errorf("%s: no value is allowed", path)
+		}
+		return fmt.Errorf("%s: must be of type %s", path, strings.Join(s.types, " or "))
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e any) bool { return reflect.DeepEqual(e, v) }) {
+		return fmt.Errorf("%s: must be one of the enum values", path)
+	}
+	if s.constant != nil && !reflect.DeepEqual(*s.constant, v) {
+		return fmt.Errorf("%s: must be the const value", path)
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			return fmt.Errorf("%s: must have at least %d characters", path, *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return fmt.Errorf("%s: must have at most %d characters", path, *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Errorf("%s: must match the pattern %s", path, s.pattern)
+		}
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			return fmt.Errorf("%s: must be at least %v", path, *s.minimum)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			return fmt.Errorf("%s: must be at most %v", path, *s.maximum)
+		}
+	case []any:
+		if s.minItems != nil && len(v) < *s.minItems {
+			return fmt.Errorf("%s: must have at least %d items", path, *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			return fmt.Errorf("%s: must have at most %d items", path, *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				if err := s.items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %s", path, name)
+			}
+		}
+		// Properties are checked in order so the error is always the same for the same value.
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, ok := s.properties[name]
+			if !ok {
+				sub = s.additionalProperties
+			}
+			if sub == nil {
+				continue
+			}
+			if err := sub.validate(path+"."+name, v[name]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasType reports whether the decoded JSON value is of the JSON Schema type.
+func hasType(v any, t string) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || (t == "integer" && v == math.Trunc(v))
+	case string:
+		return t == "string"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	default:
+		return false
+	}
+}
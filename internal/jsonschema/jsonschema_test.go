@@ -0,0 +1,216 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"in-memory-storage/internal/jsonschema"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	testCases := map[string]struct {
+		schema      string
+		expectedErr bool
+	}{
+		"it should return an error if the schema is not JSON": {
+			schema:      `{"type":`,
+			expectedErr: true,
+		},
+		"it should return an error if the type is unknown": {
+			schema:      `{"type": "date"}`,
+			expectedErr: true,
+		},
+		"it should return an error if a nested schema is invalid": {
+			schema:      `{"properties": {"name": {"type": 1}}}`,
+			expectedErr: true,
+		},
+		"it should return an error if the pattern is invalid": {
+			schema:      `{"pattern": "("}`,
+			expectedErr: true,
+		},
+		"it should return an error if a keyword is not supported": {
+			schema:      `{"type": "object", "anyOf": [{"required": ["id"]}, {"required": ["name"]}]}`,
+			expectedErr: true,
+		},
+		"it should return an error if a nested keyword is not supported": {
+			schema:      `{"items": {"type": "string", "format": "email"}}`,
+			expectedErr: true,
+		},
+		"success with annotations": {
+			schema: `{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "User", "description": "A user", "type": "object"}`,
+		},
+		"success": {
+			schema: `{"type": ["object", "null"], "properties": {"tags": {"items": {"type": "string"}}}}`,
+		},
+		"success with a boolean schema": {
+			schema: `true`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonschema.Compile([]byte(tc.schema))
+			if tc.expectedErr {
+				assert.ErrorIs(t, err, jsonschema.ErrInvalidSchema)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := jsonschema.Compile([]byte(`{
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string", "minLength": 1, "maxLength": 5},
+			"role": {"enum": ["admin", "user"]},
+			"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+		},
+		"additionalProperties": false
+	}`))
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		value       string
+		expectedErr string
+	}{
+		"it should return an error if the type doesn't match": {
+			value:       `[1]`,
+			expectedErr: "$: must be of type object",
+		},
+		"it should return an error if a required property is missing": {
+			value:       `{"id": 1}`,
+			expectedErr: "$: missing required property name",
+		},
+		"it should return an error if a number is not an integer": {
+			value:       `{"id": 1.5, "name": "bob"}`,
+			expectedErr: "$.id: must be of type integer",
+		},
+		"it should return an error if a number is below the minimum": {
+			value:       `{"id": 0, "name": "bob"}`,
+			expectedErr: "$.id: must be at least 1",
+		},
+		"it should return an error if a string is too long": {
+			value:       `{"id": 1, "name": "robert"}`,
+			expectedErr: "$.name: must have at most 5 characters",
+		},
+		"it should return an error if a value is not in the enum": {
+			value:       `{"id": 1, "name": "bob", "role": "root"}`,
+			expectedErr: "$.role: must be one of the enum values",
+		},
+		"it should return an error if a string doesn't match the pattern": {
+			value:       `{"id": 1, "name": "bob", "email": "bob"}`,
+			expectedErr: "$.email: must match the pattern",
+		},
+		"it should return an error if an item doesn't match": {
+			value:       `{"id": 1, "name": "bob", "tags": ["a", 2]}`,
+			expectedErr: "$.tags[1]: must be of type string",
+		},
+		"it should return an error if there are too many items": {
+			value:       `{"id": 1, "name": "bob", "tags": ["a", "b", "c"]}`,
+			expectedErr: "$.tags: must have at most 2 items",
+		},
+		"it should return an error if there are additional properties": {
+			value:       `{"id": 1, "name": "bob", "age": 3}`,
+			expectedErr: "$.age: no value is allowed",
+		},
+		"success": {
+			value: `{"id": 1, "name": "bob", "role": "admin", "email": "bob@example.com", "tags": ["a"]}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := schema.Validate([]byte(tc.value))
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	registry, err := jsonschema.NewRegistry(map[string]json.RawMessage{
+		"events:":        json.RawMessage(`{"type": "object"}`),
+		"events:clicks:": json.RawMessage(`{"type": "integer"}`),
+	})
+	assert.NoError(t, err)
+
+	// The longest prefix applies
+	assert.NoError(t, registry.Validate("events:clicks:today", []byte(`3`)))
+	assert.Error(t, registry.Validate("events:clicks:today", []byte(`{}`)))
+	assert.NoError(t, registry.Validate("events:views", []byte(`{}`)))
+	assert.Error(t, registry.Validate("events:views", []byte(`3`)))
+
+	// Keys without a prefix are not validated
+	assert.NoError(t, registry.Validate("other", []byte(`"anything"`)))
+
+	_, err = jsonschema.NewRegistry(map[string]json.RawMessage{"bad:": json.RawMessage(`{"type": "date"}`)})
+	assert.ErrorIs(t, err, jsonschema.ErrInvalidSchema)
+}
+
+func TestRegistry_Keyspace(t *testing.T) {
+	registry, err := jsonschema.NewRegistry(map[string]json.RawMessage{
+		"users:":  json.RawMessage(`{"type": "object", "required": ["name"]}`),
+		"scores:": json.RawMessage(`{"type": "integer"}`),
+	})
+	assert.NoError(t, err)
+	keyspace := storage.NewKeyspace()
+	store := storage.NewListStore[json.RawMessage](storage.WithKeyspace(keyspace))
+	keyspace = registry.Keyspace(keyspace)
+
+	testCases := map[string]struct {
+		src, dst    string
+		expectedErr error
+	}{
+		"it should not rename a key to a key with a schema": {
+			src:         "inbox",
+			dst:         "users:list",
+			expectedErr: jsonschema.ErrSchemaChange,
+		},
+		"it should not rename a key to a key with another schema": {
+			src:         "scores:list",
+			dst:         "users:list",
+			expectedErr: jsonschema.ErrSchemaChange,
+		},
+		"it should not rename a key with a schema to a key without": {
+			src:         "users:list",
+			dst:         "inbox",
+			expectedErr: jsonschema.ErrSchemaChange,
+		},
+		"it should rename a key to a key with the same schema": {
+			src: "users:list",
+			dst: "users:other",
+		},
+		"it should rename keys without schema": {
+			src: "inbox",
+			dst: "outbox",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, store.Set(tc.src, []json.RawMessage{json.RawMessage(`3`)}, 0))
+
+			err := keyspace.Rename(tc.src, tc.dst)
+			assert.Equal(t, tc.expectedErr, err)
+			if tc.expectedErr != nil {
+				assert.Equal(t, storage.TypeList, keyspace.Type(tc.src))
+				assert.Equal(t, storage.TypeNone, keyspace.Type(tc.dst))
+			} else {
+				assert.Equal(t, storage.TypeNone, keyspace.Type(tc.src))
+				assert.Equal(t, storage.TypeList, keyspace.Type(tc.dst))
+			}
+			_, _ = keyspace.Delete(tc.src, tc.dst)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package jsonschema
+
+import (
+	"errors"
+
+	"in-memory-storage/storage"
+)
+
+// ErrSchemaChange is returned when a key is renamed to a key with another schema.
+var ErrSchemaChange = errors.New("can't rename a key to a key with another schema")
+
+// Keyspace wraps the keyspace so a key can only be renamed to a key with the same schema,
+// otherwise a JSON list could be moved to a key whose schema its values don't match.
+// The keyspace doesn't know which lists hold JSON values, so this applies to the keys of every type.
+func (r *Registry) Keyspace(ks storage.Keyspace) storage.Keyspace {
+	return &schemaKeyspace{Keyspace: ks, schemas: r}
+}
+
+type schemaKeyspace struct {
+	storage.Keyspace
+	schemas *Registry
+}
+
+// Rename will move the value of src to dst, if both keys have the same schema or none.
+func (k *schemaKeyspace) Rename(src, dst string) error {
+	srcSchema, _ := k.schemas.Lookup(src)
+	dstSchema, _ := k.schemas.Lookup(dst)
+	if srcSchema != dstSchema {
+		return ErrSchemaChange
+	}
+	return k.Keyspace.Rename(src, dst)
+}
@@ -0,0 +1,66 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Registry holds the schemas that apply to the keys starting with a given prefix.
+// A key is validated against the schema of the longest prefix it starts with.
+type Registry struct {
+	schemas map[string]*Schema
+}
+
+// NewRegistry compiles the schemas of the given prefixes.
+func NewRegistry(schemas map[string]json.RawMessage) (*Registry, error) {
+	r := &Registry{schemas: make(map[string]*Schema, len(schemas))}
+	for prefix, raw := range schemas {
+		s, err := Compile(raw)
+		if err != nil {
+			return nil, fmt.Errorf("schema for prefix %q: %w", prefix, err)
+		}
+		r.schemas[prefix] = s
+	}
+	return r, nil
+}
+
+// LoadRegistry reads a JSON file mapping key prefixes to their schemas.
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var schemas map[string]json.RawMessage
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	return NewRegistry(schemas)
+}
+
+// Lookup returns the schema of the longest prefix the key starts with.
+// It returns false if no schema applies to the key.
+func (r *Registry) Lookup(key string) (*Schema, bool) {
+	var (
+		match  *Schema
+		length = -1
+	)
+	for prefix, s := range r.schemas {
+		if strings.HasPrefix(key, prefix) && len(prefix) > length {
+			match, length = s, len(prefix)
+		}
+	}
+	return match, match != nil
+}
+
+// Validate checks the JSON value against the schema of the key, if any.
+func (r *Registry) Validate(key string, data []byte) error {
+	s, ok := r.Lookup(key)
+	if !ok {
+		return nil
+	}
+	return s.Validate(data)
+}
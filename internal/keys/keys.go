@@ -0,0 +1,61 @@
+package keys
+
+import "time"
+
+type TypeResponse struct {
+	Key string `json:"key"`
+	// Type is string, list, hash, set, zset or stream, or none if the key doesn't exist.
+	Type string `json:"type"`
+}
+
+type ExistsResponse struct {
+	Count int `json:"count"`
+}
+
+type DeleteResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// RenameRequest moves the value of Source to Destination, overwriting it if it exists.
+type RenameRequest struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+type CountResponse struct {
+	Count int `json:"count"`
+}
+
+type ScanResponse struct {
+	Keys []string `json:"keys"`
+	// Cursor is the cursor of the next page, empty once all the keys have been returned.
+	Cursor string `json:"cursor"`
+}
+
+type TTLResponse struct {
+	Key string `json:"key"`
+	// TTL and TTLMs are the seconds and milliseconds left before the key expires, or -1 if it doesn't expire.
+	TTL       int64  `json:"ttl"`
+	TTLMs     int64  `json:"ttl_ms"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// ExpireRequest sets the TTL of a key, given in seconds, in milliseconds or as an absolute time.
+// With Sliding, every read of the key starts the TTL again.
+type ExpireRequest struct {
+	Key       string     `json:"key"`
+	TTL       int64      `json:"ttl,omitempty"`
+	TTLMs     int64      `json:"ttl_ms,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Sliding   bool       `json:"sliding,omitempty"`
+}
+
+// WatchEvent is the data of the events sent to the watchers of the keys.
+type WatchEvent struct {
+	// Event is set, update, remove, push, pop, expire or expired.
+	Event string `json:"event"`
+	Key   string `json:"key"`
+	// Type is string or list.
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+}
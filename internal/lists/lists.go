@@ -1,30 +1,103 @@
 package lists
 
+import "time"
+
+// Ends of a list that can be pushed to and popped from.
+const (
+	SideLeft  = "left"
+	SideRight = "right"
+)
+
 type GetResponse[T any] struct {
 	List      []T    `json:"list"`
 	ExpiresAt string `json:"expires_at,omitempty"`
+	// Version can be used to watch the list in a transaction.
+	Version uint64 `json:"version"`
+}
+
+// RangeResponse is returned when the list is read with the start and stop query parameters.
+type RangeResponse[T any] struct {
+	List []T `json:"list"`
+}
+
+// LenResponse is returned when the list is read with the len query parameter.
+type LenResponse struct {
+	Length int `json:"length"`
 }
 
 type SetRequest[T any] struct {
-	Key  string `json:"key"`
-	List []T    `json:"list"`
-	TTL  int64  `json:"ttl,omitempty"`
+	Key       string     `json:"key"`
+	List      []T        `json:"list"`
+	TTL       int64      `json:"ttl,omitempty"`
+	TTLMs     int64      `json:"ttl_ms,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 type PopResponse[T any] struct {
 	Value T `json:"value"`
+	// Key is the list the value was popped from, only set by blocking pops.
+	Key string `json:"key,omitempty"`
+}
+
+// PopManyResponse is returned when a count is given to pop several values at once.
+type PopManyResponse[T any] struct {
+	Values []T `json:"values"`
+}
+
+// MoveRequest moves an item from the From side of Source to the To side of Destination.
+type MoveRequest struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	// From is the side of the source to pop from, right by default.
+	From string `json:"from,omitempty"`
+	// To is the side of the destination to push to, left by default.
+	To string `json:"to,omitempty"`
+}
+
+type ReserveRequest struct {
+	Key string `json:"key"`
+	// Visibility is the number of seconds the item stays reserved before it's put back in the list.
+	Visibility int64 `json:"visibility"`
+}
+
+type ReserveResponse[T any] struct {
+	ID        string `json:"id"`
+	Value     T      `json:"value"`
+	VisibleAt string `json:"visible_at"`
+}
+
+// AckRequest acknowledges or gives back a reserved item.
+type AckRequest struct {
+	Key string `json:"key"`
+	ID  string `json:"id"`
 }
 
+// UpdateRequest creates or replaces a list. The list keeps its TTL unless a new one is given.
 type UpdateRequest[T any] struct {
-	Key  string `json:"key"`
-	List []T    `json:"list"`
+	Key       string     `json:"key"`
+	List      []T        `json:"list"`
+	TTL       int64      `json:"ttl,omitempty"`
+	TTLMs     int64      `json:"ttl_ms,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 type PushRequest[T any] struct {
 	Key   string `json:"key"`
-	Value T      `json:"value"`
+	Value T      `json:"value,omitempty"`
+	// Values are pushed after Value, one after the other.
+	Values []T `json:"values,omitempty"`
+	// Side is the end of the list to push to, right by default.
+	Side string `json:"side,omitempty"`
 }
 
 type PopRequest struct {
 	Key string `json:"key"`
+	// Side is the end of the list to pop from, left by default.
+	Side string `json:"side,omitempty"`
+	// Count pops up to that many values, returned in a PopManyResponse.
+	Count int `json:"count,omitempty"`
+	// Timeout, in seconds, makes the pop wait for a value if the list is empty or doesn't exist.
+	Timeout int64 `json:"timeout,omitempty"`
+	// Keys are other lists to pop from, after Key, when the pop waits for a value.
+	Keys []string `json:"keys,omitempty"`
 }
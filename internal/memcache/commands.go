@@ -0,0 +1,509 @@
+package memcache
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"in-memory-storage/storage"
+)
+
+const (
+	// maxRelativeExptime is the longest expiration time, in seconds, that is relative to now.
+	// Longer ones are Unix times, as in memcached.
+	maxRelativeExptime = 60 * 60 * 24 * 30
+	// flushScanCount is the size of the pages of keys scanned by flush_all.
+	flushScanCount = 1000
+	version        = "1.0.0"
+)
+
+// commands are the commands of the server, other than quit. They write their own reply.
+var commands = map[string]func(s *Server, c *conn, args []string){
+	"get":       (*Server).get,
+	"gets":      (*Server).gets,
+	"set":       (*Server).set,
+	"add":       (*Server).add,
+	"replace":   (*Server).replace,
+	"append":    (*Server).append,
+	"prepend":   (*Server).prepend,
+	"cas":       (*Server).cas,
+	"delete":    (*Server).delete,
+	"incr":      (*Server).incr,
+	"decr":      (*Server).decr,
+	"touch":     (*Server).touch,
+	"flush_all": (*Server).flushAll,
+	"stats":     (*Server).statsCmd,
+	"version":   (*Server).version,
+	"verbosity": (*Server).verbosity,
+}
+
+// storageRequest is a parsed storage command: set, add, replace, append, prepend or cas.
+type storageRequest struct {
+	key     string
+	flags   uint32
+	exptime int64
+	cas     uint64
+	noreply bool
+	data    string
+}
+
+func (s *Server) get(c *conn, args []string) {
+	s.retrieve(c, args, false)
+}
+
+func (s *Server) gets(c *conn, args []string) {
+	s.retrieve(c, args, true)
+}
+
+// retrieve replies with the value of every key found, with its flags, and its version
+// as the CAS unique if withCAS is set.
+func (s *Server) retrieve(c *conn, keys []string, withCAS bool) {
+	if len(keys) == 0 {
+		c.reply("ERROR")
+		return
+	}
+	for _, key := range keys {
+		if len(key) > maxKeyLen {
+			c.reply("CLIENT_ERROR bad command line format")
+			return
+		}
+	}
+
+	for _, key := range keys {
+		s.stats.cmdGet.Add(1)
+		v, err := s.strings.Get(key)
+		if err != nil {
+			s.stats.getMisses.Add(1)
+			continue
+		}
+		s.stats.getHits.Add(1)
+		if withCAS {
+			c.reply("VALUE %s %d %d %d", key, v.Flags, len(v.Value), v.Version)
+		} else {
+			c.reply("VALUE %s %d %d", key, v.Flags, len(v.Value))
+		}
+		c.reply(v.Value)
+	}
+	c.reply("END")
+}
+
+func (s *Server) set(c *conn, args []string) {
+	s.storeValue(c, "set", args, storage.WithSetMode(storage.SetAlways))
+}
+
+// add only stores the value if the key doesn't exist.
+func (s *Server) add(c *conn, args []string) {
+	s.storeValue(c, "add", args, storage.WithSetMode(storage.SetIfNotExists))
+}
+
+// replace only stores the value if the key exists.
+func (s *Server) replace(c *conn, args []string) {
+	s.storeValue(c, "replace", args, storage.WithSetMode(storage.SetIfExists))
+}
+
+// storeValue sets the key with the options. The store fails with ErrAlreadyExists or ErrNotFound
+// when the mode is not met, which is replied as NOT_STORED.
+func (s *Server) storeValue(c *conn, name string, args []string, opts ...storage.SetOption) {
+	req, ok := s.readStorage(c, args, false)
+	if !ok {
+		return
+	}
+	s.stats.cmdSet.Add(1)
+
+	opts = append(opts, storage.WithFlags(req.flags))
+	err := s.strings.Set(req.key, req.data, ttlUntil(expiration(req.exptime)), opts...)
+	switch {
+	case err == nil:
+		c.replyUnless(req.noreply, "STORED")
+	case errors.Is(err, storage.ErrAlreadyExists), isMissing(err):
+		c.replyUnless(req.noreply, "NOT_STORED")
+	default:
+		serverError(c, name, err)
+	}
+}
+
+func (s *Server) append(c *conn, args []string) {
+	s.concat(c, "append", args, false)
+}
+
+func (s *Server) prepend(c *conn, args []string) {
+	s.concat(c, "prepend", args, true)
+}
+
+// concat adds the data at the end or at the start of the value of an existing key.
+// The flags and the expiration time of the request are ignored, as in memcached, so the key keeps its own.
+func (s *Server) concat(c *conn, name string, args []string, prepend bool) {
+	req, ok := s.readStorage(c, args, false)
+	if !ok {
+		return
+	}
+	s.stats.cmdSet.Add(1)
+
+	for {
+		v, err := s.strings.Get(req.key)
+		if err != nil {
+			c.replyUnless(req.noreply, "NOT_STORED")
+			return
+		}
+
+		val := v.Value + req.data
+		if prepend {
+			val = req.data + v.Value
+		}
+		// Another client may have written the key since it was read, so it's retried.
+		err = s.strings.UpdateIfVersion(req.key, val, v.Version)
+		switch {
+		case err == nil:
+			c.replyUnless(req.noreply, "STORED")
+			return
+		case isMissing(err):
+			c.replyUnless(req.noreply, "NOT_STORED")
+			return
+		case !errors.Is(err, storage.ErrVersionMismatch):
+			serverError(c, name, err)
+			return
+		}
+	}
+}
+
+// cas stores the value only if the version of the key is the CAS unique returned by gets.
+func (s *Server) cas(c *conn, args []string) {
+	req, ok := s.readStorage(c, args, true)
+	if !ok {
+		return
+	}
+	s.stats.cmdSet.Add(1)
+
+	err := s.strings.Set(req.key, req.data, ttlUntil(expiration(req.exptime)),
+		storage.WithSetMode(storage.SetAlways), storage.WithVersion(req.cas), storage.WithFlags(req.flags))
+	switch {
+	case err == nil:
+		s.stats.casHits.Add(1)
+		c.replyUnless(req.noreply, "STORED")
+	case isMissing(err):
+		s.stats.casMisses.Add(1)
+		c.replyUnless(req.noreply, "NOT_FOUND")
+	case errors.Is(err, storage.ErrVersionMismatch):
+		s.stats.casBadval.Add(1)
+		c.replyUnless(req.noreply, "EXISTS")
+	default:
+		serverError(c, "cas", err)
+	}
+}
+
+// readStorage parses a storage command and reads its data block. It writes the error
+// reply and returns false if the command is not valid.
+func (s *Server) readStorage(c *conn, args []string, withCAS bool) (storageRequest, bool) {
+	var req storageRequest
+	n := 4
+	if withCAS {
+		n = 5
+	}
+	if len(args) != n && len(args) != n+1 {
+		c.reply("ERROR")
+		return req, false
+	}
+
+	// The size is parsed first, as the data block has to be read even if the rest is not valid.
+	size, err := strconv.Atoi(args[3])
+	if err != nil || size < 0 {
+		c.reply("CLIENT_ERROR bad command line format")
+		return req, false
+	}
+	if size > maxItemSize {
+		if _, err := c.r.Discard(size + 2); err != nil {
+			c.closing = true
+			return req, false
+		}
+		c.reply("SERVER_ERROR object too large for cache")
+		return req, false
+	}
+	buf := make([]byte, size+2)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		c.closing = true
+		return req, false
+	}
+	if buf[size] != '\r' || buf[size+1] != '\n' {
+		// The rest of the stream can't be parsed, so the connection is closed.
+		c.reply("CLIENT_ERROR bad data chunk")
+		c.closing = true
+		return req, false
+	}
+
+	req.key, req.data = args[0], string(buf[:size])
+	flags, flagsErr := strconv.ParseUint(args[1], 10, 32)
+	req.flags = uint32(flags)
+	req.exptime, err = strconv.ParseInt(args[2], 10, 64)
+	if withCAS && err == nil {
+		req.cas, err = strconv.ParseUint(args[4], 10, 64)
+	}
+	if len(args) == n+1 {
+		req.noreply = args[n] == "noreply"
+		if !req.noreply {
+			err = errors.New("invalid noreply")
+		}
+	}
+	if len(req.key) > maxKeyLen || flagsErr != nil || err != nil {
+		c.reply("CLIENT_ERROR bad command line format")
+		return req, false
+	}
+	return req, true
+}
+
+func (s *Server) delete(c *conn, args []string) {
+	noreply, ok := parseNoreply(c, args, 1)
+	if !ok {
+		return
+	}
+	err := s.strings.Remove(args[0])
+	switch {
+	case err == nil:
+		s.stats.deleteHits.Add(1)
+		c.replyUnless(noreply, "DELETED")
+	case isMissing(err):
+		s.stats.deleteMisses.Add(1)
+		c.replyUnless(noreply, "NOT_FOUND")
+	default:
+		serverError(c, "delete", err)
+	}
+}
+
+func (s *Server) incr(c *conn, args []string) {
+	s.incrBy(c, "incr", args, true)
+}
+
+func (s *Server) decr(c *conn, args []string) {
+	s.incrBy(c, "decr", args, false)
+}
+
+// incrBy changes the value of the key, a 64-bit unsigned integer, by the delta. As in memcached,
+// increments wrap around and decrements stop at 0.
+func (s *Server) incrBy(c *conn, name string, args []string, incr bool) {
+	noreply, ok := parseNoreply(c, args, 2)
+	if !ok {
+		return
+	}
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		c.reply("CLIENT_ERROR invalid numeric delta argument")
+		return
+	}
+	hits, misses := &s.stats.incrHits, &s.stats.incrMisses
+	if !incr {
+		hits, misses = &s.stats.decrHits, &s.stats.decrMisses
+	}
+
+	for {
+		v, err := s.strings.Get(args[0])
+		if err != nil {
+			misses.Add(1)
+			c.replyUnless(noreply, "NOT_FOUND")
+			return
+		}
+		n, err := strconv.ParseUint(v.Value, 10, 64)
+		if err != nil {
+			c.reply("CLIENT_ERROR cannot increment or decrement non-numeric value")
+			return
+		}
+
+		switch {
+		case incr:
+			n += delta
+		case delta > n:
+			n = 0
+		default:
+			n -= delta
+		}
+		val := strconv.FormatUint(n, 10)
+		// Another client may have written the key since it was read, so it's retried.
+		err = s.strings.UpdateIfVersion(args[0], val, v.Version)
+		switch {
+		case err == nil:
+			hits.Add(1)
+			c.replyUnless(noreply, val)
+			return
+		case isMissing(err):
+			misses.Add(1)
+			c.replyUnless(noreply, "NOT_FOUND")
+			return
+		case !errors.Is(err, storage.ErrVersionMismatch):
+			serverError(c, name, err)
+			return
+		}
+	}
+}
+
+// touch changes the expiration time of the key.
+func (s *Server) touch(c *conn, args []string) {
+	noreply, ok := parseNoreply(c, args, 2)
+	if !ok {
+		return
+	}
+	exptime, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		c.reply("CLIENT_ERROR invalid exptime argument")
+		return
+	}
+	s.stats.cmdTouch.Add(1)
+
+	err = s.strings.ExpireAt(args[0], expiration(exptime))
+	switch {
+	case err == nil:
+		s.stats.touchHits.Add(1)
+		c.replyUnless(noreply, "TOUCHED")
+	case isMissing(err):
+		s.stats.touchMisses.Add(1)
+		c.replyUnless(noreply, "NOT_FOUND")
+	default:
+		serverError(c, "touch", err)
+	}
+}
+
+// flushAll removes all the strings, or makes them expire after the delay if one is given.
+// The keys holding other types of values are not removed.
+func (s *Server) flushAll(c *conn, args []string) {
+	var delay int64
+	noreply := len(args) > 0 && args[len(args)-1] == "noreply"
+	if noreply {
+		args = args[:len(args)-1]
+	}
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		if delay, err = strconv.ParseInt(args[0], 10, 64); err != nil || delay < 0 {
+			c.reply("CLIENT_ERROR bad command line format")
+			return
+		}
+	default:
+		c.reply("ERROR")
+		return
+	}
+	s.stats.cmdFlush.Add(1)
+
+	at := expiration(delay)
+	cursor := ""
+	for {
+		keys, next, err := s.strings.Scan(cursor, "", flushScanCount)
+		if err != nil {
+			serverError(c, "flush_all", err)
+			return
+		}
+		for _, key := range keys {
+			if delay == 0 {
+				_ = s.strings.Remove(key)
+				continue
+			}
+			// The keys that expire before the delay keep their expiration.
+			if ttl, err := s.strings.TTL(key); err == nil && (ttl == storage.NoTTL || time.Now().Add(ttl).After(at)) {
+				_ = s.strings.ExpireAt(key, at)
+			}
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	c.replyUnless(noreply, "OK")
+}
+
+// statsCmd replies with the general statistics. The other groups of statistics are not supported.
+func (s *Server) statsCmd(c *conn, args []string) {
+	if len(args) > 0 {
+		c.reply("ERROR")
+		return
+	}
+	now := time.Now()
+	c.reply("STAT pid %d", os.Getpid())
+	c.reply("STAT uptime %d", int64(now.Sub(s.started).Seconds()))
+	c.reply("STAT time %d", now.Unix())
+	c.reply("STAT version %s", version)
+	c.reply("STAT curr_connections %d", s.connCount())
+	c.reply("STAT total_connections %d", s.stats.totalConns.Load())
+	c.reply("STAT cmd_get %d", s.stats.cmdGet.Load())
+	c.reply("STAT cmd_set %d", s.stats.cmdSet.Load())
+	c.reply("STAT cmd_flush %d", s.stats.cmdFlush.Load())
+	c.reply("STAT cmd_touch %d", s.stats.cmdTouch.Load())
+	c.reply("STAT get_hits %d", s.stats.getHits.Load())
+	c.reply("STAT get_misses %d", s.stats.getMisses.Load())
+	c.reply("STAT delete_misses %d", s.stats.deleteMisses.Load())
+	c.reply("STAT delete_hits %d", s.stats.deleteHits.Load())
+	c.reply("STAT incr_misses %d", s.stats.incrMisses.Load())
+	c.reply("STAT incr_hits %d", s.stats.incrHits.Load())
+	c.reply("STAT decr_misses %d", s.stats.decrMisses.Load())
+	c.reply("STAT decr_hits %d", s.stats.decrHits.Load())
+	c.reply("STAT cas_misses %d", s.stats.casMisses.Load())
+	c.reply("STAT cas_hits %d", s.stats.casHits.Load())
+	c.reply("STAT cas_badval %d", s.stats.casBadval.Load())
+	c.reply("STAT touch_hits %d", s.stats.touchHits.Load())
+	c.reply("STAT touch_misses %d", s.stats.touchMisses.Load())
+	c.reply("END")
+}
+
+func (s *Server) version(c *conn, args []string) {
+	c.reply("VERSION " + version)
+}
+
+// verbosity is accepted for compatibility, but it doesn't change the logs.
+func (s *Server) verbosity(c *conn, args []string) {
+	noreply, ok := parseNoreply(c, args, 1)
+	if !ok {
+		return
+	}
+	c.replyUnless(noreply, "OK")
+}
+
+// expiration converts a memcached expiration time to the time the key expires at. 0 never expires,
+// which is the zero time, up to 30 days is a number of seconds from now and longer is a Unix time.
+// A negative expiration time has already expired.
+func expiration(exptime int64) time.Time {
+	now := time.Now()
+	switch {
+	case exptime == 0:
+		return time.Time{}
+	case exptime < 0:
+		return now
+	case exptime <= maxRelativeExptime:
+		return now.Add(time.Duration(exptime) * time.Second)
+	default:
+		return time.Unix(exptime, 0)
+	}
+}
+
+// ttlUntil returns the TTL of a key that expires at the time, or 0 if the time is zero.
+// A time in the past gives the shortest TTL, so the key is set but has already expired.
+func ttlUntil(at time.Time) time.Duration {
+	if at.IsZero() {
+		return 0
+	}
+	return max(time.Until(at), time.Nanosecond)
+}
+
+// parseNoreply checks that the command has n arguments, optionally followed by noreply,
+// and reports whether it's there. It writes the error reply if the arguments are not valid.
+func parseNoreply(c *conn, args []string, n int) (noreply bool, ok bool) {
+	switch {
+	case len(args) == n:
+		return false, true
+	case len(args) == n+1 && args[n] == "noreply":
+		return true, true
+	default:
+		c.reply("ERROR")
+		return false, false
+	}
+}
+
+// isMissing reports whether the error means the key doesn't exist in the store.
+func isMissing(err error) bool {
+	return errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrExpired)
+}
+
+// serverError writes the reply of an error of the store.
+func serverError(c *conn, cmd string, err error) {
+	if !errors.Is(err, storage.ErrWrongType) {
+		logError(cmd, err)
+	}
+	c.reply("SERVER_ERROR " + err.Error())
+}
@@ -0,0 +1,334 @@
+// Package memcache provides a TCP server that speaks the memcached text protocol,
+// so services that only have memcached clients can use the StringStore.
+package memcache
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"in-memory-storage/storage"
+)
+
+const (
+	// maxLineLen is the maximum length of a command line, the same as memcached.
+	maxLineLen = 2048
+	// maxKeyLen is the maximum length of a key, the same as memcached.
+	maxKeyLen = 250
+	// maxItemSize is the maximum size of a value, the default item size of memcached.
+	maxItemSize = 1 << 20
+)
+
+// ErrServerClosed is returned by Start and Serve after the server has been stopped.
+var ErrServerClosed = errors.New("memcache: server closed")
+
+// errLineTooLong is returned when a client sends a command line longer than maxLineLen.
+var errLineTooLong = errors.New("line too long")
+
+// Server is a memcached server over a StringStore.
+type Server struct {
+	addr    string
+	strings storage.StringStore
+	apiKey  string
+	started time.Time
+	stats   stats
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// stats are the counters reported by the stats command.
+type stats struct {
+	totalConns   atomic.Uint64
+	cmdGet       atomic.Uint64
+	cmdSet       atomic.Uint64
+	cmdTouch     atomic.Uint64
+	cmdFlush     atomic.Uint64
+	getHits      atomic.Uint64
+	getMisses    atomic.Uint64
+	deleteHits   atomic.Uint64
+	deleteMisses atomic.Uint64
+	incrHits     atomic.Uint64
+	incrMisses   atomic.Uint64
+	decrHits     atomic.Uint64
+	decrMisses   atomic.Uint64
+	casHits      atomic.Uint64
+	casMisses    atomic.Uint64
+	casBadval    atomic.Uint64
+	touchHits    atomic.Uint64
+	touchMisses  atomic.Uint64
+}
+
+// conn is the state of a client connection.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+
+	authed bool
+	// closing is set when the rest of the stream can't be parsed, to close the connection.
+	closing bool
+}
+
+// NewServer creates a new memcached server with the provided port.
+// Clients must authenticate with the API key, unless it's empty.
+// It returns an error if the port or the store is missing.
+func NewServer(port string, stringStore storage.StringStore, apiKey string) (*Server, error) {
+	if port == "" {
+		return nil, errors.New("missing port")
+	}
+	if stringStore == nil {
+		return nil, errors.New("missing string store")
+	}
+
+	return &Server{
+		addr:      ":" + port,
+		strings:   stringStore,
+		apiKey:    apiKey,
+		started:   time.Now(),
+		listeners: map[net.Listener]struct{}{},
+		conns:     map[*conn]struct{}{},
+	}, nil
+}
+
+// Start listens on the port of the server and serves the connections.
+// It returns ErrServerClosed once the server is stopped.
+func (s *Server) Start() error {
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts the connections of the listener and serves them, each in its own goroutine.
+// It closes the listener when the server is stopped and returns ErrServerClosed.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l) {
+		_ = l.Close()
+		return ErrServerClosed
+	}
+
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		c := &conn{
+			Conn:   nc,
+			r:      bufio.NewReader(nc),
+			w:      bufio.NewWriter(nc),
+			authed: s.apiKey == "",
+		}
+		if !s.trackConn(c) {
+			_ = nc.Close()
+			return ErrServerClosed
+		}
+		s.stats.totalConns.Add(1)
+		go s.serveConn(c)
+	}
+}
+
+// Stop gracefully stops the server: it stops accepting connections and closes them once
+// they have replied to the command being run. The remaining connections are closed if
+// the context is done first.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		_ = l.Close()
+	}
+	for c := range s.conns {
+		// Reads fail right away, so the connections close after replying to the current command.
+		_ = c.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for c := range s.conns {
+			_ = c.Close()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) serveConn(c *conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		_ = c.Close()
+	}()
+
+	for {
+		line, err := readLine(c.r)
+		if err != nil {
+			if errors.Is(err, errLineTooLong) {
+				c.reply("CLIENT_ERROR line too long")
+				_ = c.w.Flush()
+			}
+			return
+		}
+
+		quit := s.dispatch(c, strings.Fields(line))
+		// Replies are flushed once all the pipelined commands have been read.
+		if c.r.Buffered() == 0 || quit {
+			if err := c.w.Flush(); err != nil {
+				return
+			}
+		}
+		if quit || s.isClosed() {
+			return
+		}
+	}
+}
+
+// dispatch runs the command and writes its reply. It returns true if the connection must be closed.
+func (s *Server) dispatch(c *conn, fields []string) bool {
+	if len(fields) == 0 {
+		c.reply("ERROR")
+		return false
+	}
+	name := fields[0]
+	if name == "quit" {
+		return true
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		c.reply("ERROR")
+		return false
+	}
+	if c.authed {
+		cmd(s, c, fields[1:])
+	} else {
+		s.authenticate(c, name, fields[1:])
+	}
+	return c.closing
+}
+
+// authenticate checks the credentials sent as in memcached: the first command must be a set of any
+// key, whose value is the username and the password separated by a space. Any username is accepted,
+// and the password is the API key.
+func (s *Server) authenticate(c *conn, name string, args []string) {
+	if name != "set" {
+		c.reply("CLIENT_ERROR unauthenticated")
+		return
+	}
+	req, ok := s.readStorage(c, args, false)
+	if !ok {
+		return
+	}
+
+	_, password, _ := strings.Cut(req.data, " ")
+	if subtle.ConstantTimeCompare([]byte(password), []byte(s.apiKey)) != 1 {
+		c.reply("CLIENT_ERROR authentication failure")
+		return
+	}
+	c.authed = true
+	c.reply("STORED")
+}
+
+// reply writes a line of the reply.
+func (c *conn) reply(format string, args ...any) {
+	if len(args) > 0 {
+		format = fmt.Sprintf(format, args...)
+	}
+	_, _ = c.w.WriteString(format)
+	_, _ = c.w.WriteString("\r\n")
+}
+
+// replyUnless writes a line of the reply, unless the client has asked for no reply.
+func (c *conn) replyUnless(noreply bool, line string) {
+	if !noreply {
+		c.reply(line)
+	}
+}
+
+// readLine reads a line terminated by CRLF, or only LF, and returns it without the terminator.
+func readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxLineLen {
+			return "", errLineTooLong
+		}
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r"), nil
+}
+
+func (s *Server) track(l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) trackConn(c *conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) connCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// logError logs the errors of the store that don't map to a memcached reply.
+func logError(cmd string, err error) {
+	log.Printf("ERROR: failed to run %s: %v", cmd, err)
+}
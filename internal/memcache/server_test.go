@@ -0,0 +1,289 @@
+package memcache_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"in-memory-storage/internal/memcache"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// client is a raw memcached client that returns the replies as they're written on the wire.
+type client struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func newServer(t *testing.T, apiKey string) (*memcache.Server, string, storage.StringStore) {
+	t.Helper()
+	keyspace := storage.NewKeyspace()
+	stringStore := storage.NewStringStore(storage.WithKeyspace(keyspace))
+	listStore := storage.NewListStore[string](storage.WithKeyspace(keyspace))
+	require.NoError(t, listStore.Set("list", []string{"a"}, 0))
+	srv, err := memcache.NewServer("11211", stringStore, apiKey)
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Stop(context.Background()) })
+
+	return srv, l.Addr().String(), stringStore
+}
+
+func dial(t *testing.T, addr string) *client {
+	t.Helper()
+	nc, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = nc.Close() })
+	require.NoError(t, nc.SetDeadline(time.Now().Add(5*time.Second)))
+	return &client{Conn: nc, r: bufio.NewReader(nc)}
+}
+
+// do sends the command and reads the lines of its reply, up to the line that ends it.
+func (c *client) do(t *testing.T, cmd string) string {
+	t.Helper()
+	_, err := c.Write([]byte(cmd))
+	require.NoError(t, err)
+
+	var reply strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		require.NoError(t, err)
+		reply.WriteString(line)
+		if !strings.HasPrefix(line, "VALUE ") && !strings.HasPrefix(line, "STAT ") {
+			return reply.String()
+		}
+		if strings.HasPrefix(line, "VALUE ") {
+			fields := strings.Fields(line)
+			size, err := strconv.Atoi(fields[3])
+			require.NoError(t, err)
+			buf := make([]byte, size+2)
+			_, err = io.ReadFull(c.r, buf)
+			require.NoError(t, err)
+			reply.Write(buf)
+		}
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	testCases := map[string]struct {
+		port        string
+		stringStore storage.StringStore
+		expectedErr error
+	}{
+		"it should return an error if the port is missing": {
+			stringStore: storage.NewStringStore(),
+			expectedErr: errors.New("missing port"),
+		},
+		"it should return an error if the string store is missing": {
+			port:        "11211",
+			expectedErr: errors.New("missing string store"),
+		},
+		"it should create the server": {
+			port:        "11211",
+			stringStore: storage.NewStringStore(),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			srv, err := memcache.NewServer(tc.port, tc.stringStore, "")
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedErr == nil, srv != nil)
+		})
+	}
+}
+
+func TestServer_Commands(t *testing.T) {
+	testCases := map[string]struct {
+		commands []string
+		expected []string
+	}{
+		"it should set and get values": {
+			commands: []string{"set a 0 0 5\r\nhello\r\n", "get a b\r\n", "get missing\r\n"},
+			expected: []string{"STORED\r\n", "VALUE a 0 5\r\nhello\r\nEND\r\n", "END\r\n"},
+		},
+		"it should only add missing keys": {
+			commands: []string{"add a 0 0 1\r\nx\r\n", "add a 0 0 1\r\ny\r\n", "get a\r\n"},
+			expected: []string{"STORED\r\n", "NOT_STORED\r\n", "VALUE a 0 1\r\nx\r\nEND\r\n"},
+		},
+		"it should only replace existing keys": {
+			commands: []string{"replace a 0 0 1\r\nx\r\n", "set a 0 0 1\r\nx\r\n", "replace a 0 0 1\r\ny\r\n", "get a\r\n"},
+			expected: []string{"NOT_STORED\r\n", "STORED\r\n", "STORED\r\n", "VALUE a 0 1\r\ny\r\nEND\r\n"},
+		},
+		"it should append and prepend to existing keys": {
+			commands: []string{
+				"append a 0 0 1\r\nx\r\n", "set a 0 0 1\r\nb\r\n", "append a 0 0 1\r\nc\r\n",
+				"prepend a 0 0 1\r\na\r\n", "get a\r\n",
+			},
+			expected: []string{"NOT_STORED\r\n", "STORED\r\n", "STORED\r\n", "STORED\r\n", "VALUE a 0 3\r\nabc\r\nEND\r\n"},
+		},
+		"it should compare and swap with the CAS unique of gets": {
+			commands: []string{
+				"cas a 0 0 1\r\n", "cas a 0 0 1 1\r\nx\r\n", "set a 0 0 1\r\nx\r\n", "gets a\r\n",
+				"cas a 0 0 1 1\r\ny\r\n", "cas a 0 0 1 99\r\nz\r\n", "get a\r\n",
+			},
+			expected: []string{
+				"ERROR\r\n", "NOT_FOUND\r\n", "STORED\r\n", "VALUE a 0 1 1\r\nx\r\nEND\r\n",
+				"STORED\r\n", "EXISTS\r\n", "VALUE a 0 1\r\ny\r\nEND\r\n",
+			},
+		},
+		"it should delete keys": {
+			commands: []string{"set a 0 0 1\r\nx\r\n", "delete a\r\n", "delete a\r\n", "get a\r\n"},
+			expected: []string{"STORED\r\n", "DELETED\r\n", "NOT_FOUND\r\n", "END\r\n"},
+		},
+		"it should increment and decrement numbers": {
+			commands: []string{
+				"incr n 1\r\n", "set n 0 0 2\r\n10\r\n", "incr n 5\r\n", "decr n 20\r\n",
+				"incr n 18446744073709551615\r\n", "incr n -1\r\n", "set s 0 0 1\r\nx\r\n", "incr s 1\r\n",
+			},
+			expected: []string{
+				"NOT_FOUND\r\n", "STORED\r\n", "15\r\n", "0\r\n", "18446744073709551615\r\n",
+				"CLIENT_ERROR invalid numeric delta argument\r\n", "STORED\r\n",
+				"CLIENT_ERROR cannot increment or decrement non-numeric value\r\n",
+			},
+		},
+		"it should expire keys": {
+			commands: []string{
+				"set a 0 -1 1\r\nx\r\n", "get a\r\n", "set b 0 100 1\r\nx\r\n", "touch b -1\r\n", "get b\r\n",
+				"touch b 100\r\n",
+			},
+			expected: []string{"STORED\r\n", "END\r\n", "STORED\r\n", "TOUCHED\r\n", "END\r\n", "NOT_FOUND\r\n"},
+		},
+		"it should not reply with noreply": {
+			commands: []string{"set a 0 0 1 noreply\r\nx\r\n" + "delete missing noreply\r\n" + "get a\r\n"},
+			expected: []string{"VALUE a 0 1\r\nx\r\nEND\r\n"},
+		},
+		"it should flush all the strings": {
+			commands: []string{"set a 0 0 1\r\nx\r\n", "set b 0 0 1\r\ny\r\n", "flush_all\r\n", "get a b\r\n", "gets list\r\n"},
+			expected: []string{"STORED\r\n", "STORED\r\n", "OK\r\n", "END\r\n", "END\r\n"},
+		},
+		"it should not replace keys holding another type of value": {
+			commands: []string{"set list 0 0 1\r\nx\r\n"},
+			expected: []string{"SERVER_ERROR operation against a key holding the wrong kind of value\r\n"},
+		},
+		"it should reply with an error to invalid commands": {
+			commands: []string{
+				"unknown\r\n", "get\r\n", "set a 0 0 x\r\n", "set a x 0 1\r\nx\r\n", "set a 0 0 1\r\nxyz\r\n",
+			},
+			expected: []string{
+				"ERROR\r\n", "ERROR\r\n", "CLIENT_ERROR bad command line format\r\n",
+				"CLIENT_ERROR bad command line format\r\n", "CLIENT_ERROR bad data chunk\r\n",
+			},
+		},
+		"it should reply with the version": {
+			commands: []string{"version\r\n", "verbosity 1\r\n"},
+			expected: []string{"VERSION 1.0.0\r\n", "OK\r\n"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, addr, _ := newServer(t, "")
+			c := dial(t, addr)
+			for i, cmd := range tc.commands {
+				assert.Equal(t, tc.expected[i], c.do(t, cmd), cmd)
+			}
+		})
+	}
+}
+
+func TestServer_Expiration(t *testing.T) {
+	_, addr, stringStore := newServer(t, "")
+	c := dial(t, addr)
+	at := time.Now().Add(time.Hour).Unix()
+
+	assert.Equal(t, "STORED\r\n", c.do(t, "set relative 0 60 1\r\nx\r\n"))
+	assert.Equal(t, "STORED\r\n", c.do(t, "set absolute 0 "+strconv.FormatInt(at, 10)+" 1\r\nx\r\n"))
+	assert.Equal(t, "STORED\r\n", c.do(t, "set never 0 0 1\r\nx\r\n"))
+
+	v, err := stringStore.Get("relative")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), v.ExpiresAt, time.Second)
+	v, err = stringStore.Get("absolute")
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(at, 0), v.ExpiresAt.Truncate(time.Second))
+	v, err = stringStore.Get("never")
+	require.NoError(t, err)
+	assert.True(t, v.ExpiresAt.IsZero())
+
+	assert.Equal(t, "TOUCHED\r\n", c.do(t, "touch relative 0\r\n"))
+	ttl, err := stringStore.TTL("relative")
+	require.NoError(t, err)
+	assert.Equal(t, storage.NoTTL, ttl)
+}
+
+func TestServer_Flags(t *testing.T) {
+	_, addr, stringStore := newServer(t, "")
+	c := dial(t, addr)
+
+	assert.Equal(t, "STORED\r\n", c.do(t, "set a 4294967295 0 1\r\nx\r\n"))
+	assert.Equal(t, "VALUE a 4294967295 1\r\nx\r\nEND\r\n", c.do(t, "get a\r\n"))
+	assert.Equal(t, "CLIENT_ERROR bad command line format\r\n", c.do(t, "set a 4294967296 0 1\r\ny\r\n"))
+
+	// The flags are kept by the commands changing the value of the item
+	assert.Equal(t, "STORED\r\n", c.do(t, "set n 7 0 1\r\n1\r\n"))
+	assert.Equal(t, "STORED\r\n", c.do(t, "append n 9 0 1\r\n0\r\n"))
+	assert.Equal(t, "11\r\n", c.do(t, "incr n 1\r\n"))
+	assert.Equal(t, "TOUCHED\r\n", c.do(t, "touch n 100\r\n"))
+	v, err := stringStore.Get("n")
+	require.NoError(t, err)
+	assert.Equal(t, "VALUE n 7 2 "+strconv.FormatUint(v.Version, 10)+"\r\n11\r\nEND\r\n", c.do(t, "gets n\r\n"))
+
+	// A value written by another API has no flags
+	require.NoError(t, stringStore.Set("n", "12", 0, storage.WithSetMode(storage.SetAlways)))
+	assert.Equal(t, "VALUE n 0 2\r\n12\r\nEND\r\n", c.do(t, "get n\r\n"))
+
+	// Deleted items lose their flags
+	assert.Equal(t, "DELETED\r\n", c.do(t, "delete a\r\n"))
+	require.NoError(t, stringStore.Set("a", "x", 0))
+	assert.Equal(t, "VALUE a 0 1\r\nx\r\nEND\r\n", c.do(t, "get a\r\n"))
+}
+
+func TestServer_Auth(t *testing.T) {
+	_, addr, _ := newServer(t, "secret")
+	c := dial(t, addr)
+
+	assert.Equal(t, "CLIENT_ERROR unauthenticated\r\n", c.do(t, "get a\r\n"))
+	assert.Equal(t, "CLIENT_ERROR authentication failure\r\n", c.do(t, "set auth 0 0 10\r\nuser wrong\r\n"))
+	assert.Equal(t, "STORED\r\n", c.do(t, "set auth 0 0 11\r\nuser secret\r\n"))
+	assert.Equal(t, "END\r\n", c.do(t, "get auth\r\n"))
+}
+
+func TestServer_Stats(t *testing.T) {
+	_, addr, _ := newServer(t, "")
+	c := dial(t, addr)
+	c.do(t, "set a 0 0 1\r\nx\r\n")
+	c.do(t, "get a missing\r\n")
+
+	stats := c.do(t, "stats\r\n")
+	assert.Contains(t, stats, "STAT cmd_get 2\r\n")
+	assert.Contains(t, stats, "STAT cmd_set 1\r\n")
+	assert.Contains(t, stats, "STAT get_hits 1\r\n")
+	assert.Contains(t, stats, "STAT get_misses 1\r\n")
+	assert.Contains(t, stats, "STAT curr_connections 1\r\n")
+	assert.True(t, strings.HasSuffix(stats, "END\r\n"))
+}
+
+func TestServer_Stop(t *testing.T) {
+	srv, addr, _ := newServer(t, "")
+	c := dial(t, addr)
+	assert.Equal(t, "VERSION 1.0.0\r\n", c.do(t, "version\r\n"))
+
+	assert.NoError(t, srv.Stop(context.Background()))
+	_, err := c.r.ReadString('\n')
+	assert.Error(t, err)
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err)
+}
@@ -0,0 +1,1941 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"in-memory-storage/storage"
+)
+
+// Operations written to the log.
+const (
+	opSet     = "set"
+	opUpdate  = "update"
+	opRemove  = "remove"
+	opPush    = "push"
+	opPop     = "pop"
+	opLPush   = "lpush"
+	opRPush   = "rpush"
+	opLPop    = "lpop"
+	opRPop    = "rpop"
+	opLSet    = "lset"
+	opLTrim   = "ltrim"
+	opLInsert = "linsert"
+	opLRem    = "lrem"
+	opLMove   = "lmove"
+	// opReserve, opAck and opNack take the reservation as reservationArgs, and opRequeue
+	// the ids of the reserved items put back in the list, in the order they were pushed.
+	opReserve = "reserve"
+	opAck     = "ack"
+	opNack    = "nack"
+	opRequeue = "requeue"
+	opHSet    = "hset"
+	opHDel    = "hdel"
+	opSAdd    = "sadd"
+	opSRem    = "srem"
+	opZAdd    = "zadd"
+	opZRem    = "zrem"
+	// opZRemRangeByRank takes its range as the start and stop of listArgs.
+	opZRemRangeByRank = "zremrangebyrank"
+	// The stream operations take their arguments as streamArgs. opXAdd is replayed with the ID of the entry,
+	// and opXPending sets the pending entries of a group, with their delivery time, after XReadGroup and XClaim.
+	opXAdd          = "xadd"
+	opXTrim         = "xtrim"
+	opXDel          = "xdel"
+	opXGroupCreate  = "xgroupcreate"
+	opXGroupDestroy = "xgroupdestroy"
+	opXAck          = "xack"
+	opXPending      = "xpending"
+	// opExpire sets the expiration of a key, or removes it if the record has none.
+	opExpire = "expire"
+	// opRename moves a key of the keyspace to the key given as value.
+	opRename = "rename"
+	// opTx groups the records of a transaction, so it's replayed as a whole.
+	opTx = "tx"
+)
+
+// listArgs are the arguments of the list operations that take more than a value.
+type listArgs[T any] struct {
+	Index  int  `json:"index,omitempty"`
+	Start  int  `json:"start,omitempty"`
+	Stop   int  `json:"stop,omitempty"`
+	Count  int  `json:"count,omitempty"`
+	Before bool `json:"before,omitempty"`
+	Pivot  T    `json:"pivot,omitempty"`
+	Value  T    `json:"value,omitempty"`
+	// Dest, SrcLeft and DstLeft are the arguments of LMove, whose source is the key of the record.
+	Dest    string `json:"dest,omitempty"`
+	SrcLeft bool   `json:"src_left,omitempty"`
+	DstLeft bool   `json:"dst_left,omitempty"`
+}
+
+// reservationArgs are the arguments of the reservation operations.
+type reservationArgs struct {
+	ID        string    `json:"id"`
+	VisibleAt time.Time `json:"visible_at,omitempty"`
+}
+
+// streamArgs are the arguments of the stream operations.
+type streamArgs struct {
+	ID        storage.StreamID   `json:"id,omitzero"`
+	Fields    map[string]string  `json:"fields,omitempty"`
+	MaxLen    int                `json:"max_len,omitempty"`
+	IDs       []storage.StreamID `json:"ids,omitempty"`
+	Group     string             `json:"group,omitempty"`
+	Consumer  string             `json:"consumer,omitempty"`
+	FromStart bool               `json:"from_start,omitempty"`
+	// LastDelivered and Pending are the arguments of XSetPending, for opXPending.
+	LastDelivered storage.StreamID       `json:"last_delivered,omitzero"`
+	Pending       []storage.PendingEntry `json:"pending,omitempty"`
+}
+
+// expiresAt returns the expiration time for the given ttl, or nil if it never expires.
+func expiresAt(ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	t := time.Now().Add(ttl)
+	return &t
+}
+
+// newRecord builds a log record, encoding the value as JSON.
+func newRecord(store, op, key string, val any, exp *time.Time) (record, error) {
+	rec := record{Store: store, Op: op, Key: key, ExpiresAt: exp}
+	if val != nil {
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return record{}, fmt.Errorf("failed to encode value for key %s: %w", key, err)
+		}
+		rec.Value = raw
+	}
+	return rec, nil
+}
+
+// currentRecord returns a record that sets the key to its current value,
+// or removes it if it doesn't exist or has expired.
+func currentRecord[T any](store, key string, get func(key string) (*storage.Value[T], error)) (record, error) {
+	v, err := get(key)
+	if err != nil {
+		if ignoreReplayErr(err) == nil {
+			return newRecord(store, opRemove, key, nil, nil)
+		}
+		return record{}, err
+	}
+	return valueRecord(store, key, *v)
+}
+
+// valueRecord returns a set record for the value, with its expiration.
+func valueRecord[T any](store, key string, v storage.Value[T]) (record, error) {
+	var exp *time.Time
+	if !v.ExpiresAt.IsZero() {
+		exp = &v.ExpiresAt
+	}
+	rec, err := newRecord(store, opSet, key, v.Value, exp)
+	rec.Sliding, rec.Flags = v.Sliding, v.Flags
+	return rec, err
+}
+
+// expireRecord returns an expire record for the expiration time and the sliding expiration of the key.
+// A zero time and a zero sliding expiration make the key persistent.
+func expireRecord(store, key string, at time.Time, sliding time.Duration) record {
+	rec := record{Store: store, Op: opExpire, Key: key, Sliding: sliding}
+	if !at.IsZero() {
+		rec.ExpiresAt = &at
+	}
+	return rec
+}
+
+// snapshotRecords returns a set record for every entry of the snapshot, sorted by key.
+func snapshotRecords[T any](store string, data map[string]storage.Value[T]) ([]record, error) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	recs := make([]record, 0, len(keys))
+	for _, key := range keys {
+		rec, err := valueRecord(store, key, data[key])
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// logSnapshot writes a set record for every entry of the snapshot.
+// The caller must hold the log mutex.
+func logSnapshot[T any](l *OpLog, store string, data map[string]storage.Value[T]) {
+	recs, err := snapshotRecords(store, data)
+	if err != nil {
+		log.Printf("ERROR: failed to log restored entries: %v", err)
+		return
+	}
+	for _, rec := range recs {
+		if err := l.append(rec); err != nil {
+			log.Printf("ERROR: failed to log restored entries: %v", err)
+			return
+		}
+	}
+}
+
+// replaySet applies a set record by restoring the value with its exact expiration time.
+func replaySet[T any](rec record, restore func(map[string]storage.Value[T]) int) error {
+	var val T
+	if err := json.Unmarshal(rec.Value, &val); err != nil {
+		return fmt.Errorf("invalid value for key %s: %w", rec.Key, err)
+	}
+	v := storage.Value[T]{Value: val, Sliding: rec.Sliding, Flags: rec.Flags}
+	if rec.ExpiresAt != nil {
+		v.ExpiresAt = *rec.ExpiresAt
+	}
+	// Restore drops the value if it has expired in the meantime.
+	restore(map[string]storage.Value[T]{rec.Key: v})
+	return nil
+}
+
+// expirer is implemented by the stores whose keys can expire.
+type expirer interface {
+	ExpireAt(key string, t time.Time) error
+	ExpireSliding(key string, ttl time.Duration) error
+	Persist(key string) error
+}
+
+// replayExpire applies an expire record. A sliding expiration starts again from the time of the replay.
+func replayExpire(rec record, s expirer) error {
+	var err error
+	switch {
+	case rec.Sliding > 0:
+		err = s.ExpireSliding(rec.Key, rec.Sliding)
+	case rec.ExpiresAt != nil:
+		err = s.ExpireAt(rec.Key, *rec.ExpiresAt)
+	default:
+		err = s.Persist(rec.Key)
+	}
+	return ignoreReplayErr(err)
+}
+
+// ignoreReplayErr ignores the errors that are expected when replaying the log,
+// like updating a key that has expired since it was written.
+func ignoreReplayErr(err error) error {
+	if errors.Is(err, storage.ErrNotFound) ||
+		errors.Is(err, storage.ErrExpired) ||
+		errors.Is(err, storage.ErrEmptyList) ||
+		errors.Is(err, storage.ErrIndexOutOfRange) ||
+		errors.Is(err, storage.ErrPivotNotFound) ||
+		errors.Is(err, storage.ErrReservationNotFound) ||
+		errors.Is(err, storage.ErrGroupNotFound) ||
+		errors.Is(err, storage.ErrGroupExists) {
+		return nil
+	}
+	return err
+}
+
+// loggedStringStore is a StringStore that writes its mutating operations to an OpLog.
+type loggedStringStore struct {
+	storage.StringStore
+	log  *OpLog
+	name string
+}
+
+// LogStringStore wraps the given store so every mutating operation is written to
+// the log under the given name. Reads go straight to the store.
+func LogStringStore(l *OpLog, name string, store storage.StringStore) storage.StringStore {
+	s := &loggedStringStore{StringStore: store, log: l, name: name}
+	l.register(name, s)
+	return s
+}
+
+// Set will store the given key/value pair and log it.
+func (s *loggedStringStore) Set(key, val string, ttl time.Duration, opts ...storage.SetOption) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	exp := expiresAt(ttl)
+	if err := s.StringStore.Set(key, val, ttl, opts...); err != nil {
+		return err
+	}
+	if len(opts) > 0 {
+		// A replaced key may have kept its previous expiration.
+		return s.writeCurrent(key)
+	}
+	return s.write(opSet, key, val, exp)
+}
+
+// Update will update the value for the given key and log it.
+func (s *loggedStringStore) Update(key, val string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.StringStore.Update(key, val); err != nil {
+		return err
+	}
+	return s.write(opUpdate, key, val, nil)
+}
+
+// Remove will delete the value linked to the given key and log it.
+func (s *loggedStringStore) Remove(key string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.StringStore.Remove(key); err != nil {
+		return err
+	}
+	return s.write(opRemove, key, nil, nil)
+}
+
+// SetNX will store the key/value pair only if the key doesn't exist and log it.
+func (s *loggedStringStore) SetNX(key, val string, ttl time.Duration) (bool, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	exp := expiresAt(ttl)
+	ok, err := s.StringStore.SetNX(key, val, ttl)
+	if err != nil || !ok {
+		return ok, err
+	}
+	return ok, s.write(opSet, key, val, exp)
+}
+
+// SetXX will store the key/value pair only if the key already exists and log it.
+func (s *loggedStringStore) SetXX(key, val string, ttl time.Duration) (bool, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	exp := expiresAt(ttl)
+	ok, err := s.StringStore.SetXX(key, val, ttl)
+	if err != nil || !ok {
+		return ok, err
+	}
+	return ok, s.write(opSet, key, val, exp)
+}
+
+// CompareAndSwap will replace the value of the key only if it's the old one and log the new value.
+func (s *loggedStringStore) CompareAndSwap(key, old, new string) (bool, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	ok, err := s.StringStore.CompareAndSwap(key, old, new)
+	if err != nil || !ok {
+		return ok, err
+	}
+	return ok, s.writeCurrent(key)
+}
+
+// UpdateIfVersion will update the value of the key only if it's still at the given version and log it.
+func (s *loggedStringStore) UpdateIfVersion(key, val string, version uint64) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.StringStore.UpdateIfVersion(key, val, version); err != nil {
+		return err
+	}
+	return s.writeCurrent(key)
+}
+
+// Incr will increment the integer value of the key by one and log the new value.
+func (s *loggedStringStore) Incr(key string) (int64, error) {
+	return s.IncrBy(key, 1)
+}
+
+// Decr will decrement the integer value of the key by one and log the new value.
+func (s *loggedStringStore) Decr(key string) (int64, error) {
+	return s.IncrBy(key, -1)
+}
+
+// IncrBy will increment the integer value of the key by delta and log the new value.
+func (s *loggedStringStore) IncrBy(key string, delta int64) (int64, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	val, err := s.StringStore.IncrBy(key, delta)
+	if err != nil {
+		return 0, err
+	}
+	return val, s.writeCurrent(key)
+}
+
+// IncrByFloat will increment the numeric value of the key by delta and log the new value.
+func (s *loggedStringStore) IncrByFloat(key string, delta float64) (float64, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	val, err := s.StringStore.IncrByFloat(key, delta)
+	if err != nil {
+		return 0, err
+	}
+	return val, s.writeCurrent(key)
+}
+
+// Expire will set the TTL of the key and log its expiration time.
+func (s *loggedStringStore) Expire(key string, ttl time.Duration) error {
+	return s.ExpireAt(key, time.Now().Add(ttl))
+}
+
+// ExpireAt will set the expiration time of the key and log it.
+func (s *loggedStringStore) ExpireAt(key string, t time.Time) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.StringStore.ExpireAt(key, t); err != nil {
+		return err
+	}
+	return s.log.append(expireRecord(s.name, key, t, 0))
+}
+
+// ExpireSliding will set the sliding expiration of the key and log it.
+func (s *loggedStringStore) ExpireSliding(key string, ttl time.Duration) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.StringStore.ExpireSliding(key, ttl); err != nil {
+		return err
+	}
+	return s.log.append(expireRecord(s.name, key, time.Time{}, ttl))
+}
+
+// Persist will remove the expiration of the key and log it.
+func (s *loggedStringStore) Persist(key string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.StringStore.Persist(key); err != nil {
+		return err
+	}
+	return s.log.append(expireRecord(s.name, key, time.Time{}, 0))
+}
+
+// Restore will load the given values into the store and log them.
+func (s *loggedStringStore) Restore(data map[string]storage.Value[string]) int {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	restored := s.StringStore.Restore(data)
+	logSnapshot(s.log, s.name, data)
+	return restored
+}
+
+func (s *loggedStringStore) write(op, key string, val any, exp *time.Time) error {
+	rec, err := newRecord(s.name, op, key, val, exp)
+	if err != nil {
+		return err
+	}
+	return s.log.append(rec)
+}
+
+// writeCurrent logs the current value of the key as a set record, so the result of
+// operations that depend on the previous value is replayed as is, with its expiration time.
+// The caller must hold the log mutex.
+func (s *loggedStringStore) writeCurrent(key string) error {
+	v, err := s.StringStore.Get(key)
+	if err != nil {
+		// The key expired right after the operation, so there is nothing to replay.
+		return ignoreReplayErr(err)
+	}
+	rec, err := valueRecord(s.name, key, *v)
+	if err != nil {
+		return err
+	}
+	return s.log.append(rec)
+}
+
+func (s *loggedStringStore) replay(rec record) error {
+	switch rec.Op {
+	case opSet:
+		return replaySet(rec, s.StringStore.Restore)
+	case opUpdate:
+		var val string
+		if err := json.Unmarshal(rec.Value, &val); err != nil {
+			return fmt.Errorf("invalid value for key %s: %w", rec.Key, err)
+		}
+		return ignoreReplayErr(s.StringStore.Update(rec.Key, val))
+	case opRemove:
+		return ignoreReplayErr(s.StringStore.Remove(rec.Key))
+	case opExpire:
+		return replayExpire(rec, s.StringStore)
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+}
+
+func (s *loggedStringStore) records() ([]record, error) {
+	return snapshotRecords(s.name, s.StringStore.Snapshot())
+}
+
+// loggedListStore is a ListStore that writes its mutating operations to an OpLog.
+type loggedListStore[T any] struct {
+	storage.ListStore[T]
+	log  *OpLog
+	name string
+}
+
+// LogListStore wraps the given store so every mutating operation is written to
+// the log under the given name. Reads go straight to the store.
+// The reservations are logged too, including the items put back in the lists by
+// the background sweeper, so they can still be acknowledged after a restart.
+func LogListStore[T any](l *OpLog, name string, store storage.ListStore[T]) storage.ListStore[T] {
+	s := &loggedListStore[T]{ListStore: store, log: l, name: name}
+	l.register(name, s)
+	if r, ok := store.(storage.ReservationStore[T]); ok {
+		r.OnRequeue(&l.mu, s.logRequeue)
+	}
+	return s
+}
+
+// reservations returns the wrapped store as a ReservationStore, which the stores of NewListStore are.
+func (s *loggedListStore[T]) reservations() (storage.ReservationStore[T], error) {
+	r, ok := s.ListStore.(storage.ReservationStore[T])
+	if !ok {
+		return nil, fmt.Errorf("%T can't save and restore the reservations", s.ListStore)
+	}
+	return r, nil
+}
+
+// Set will store the given list and log it.
+func (s *loggedListStore[T]) Set(key string, list []T, ttl time.Duration, opts ...storage.SetOption) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	exp := expiresAt(ttl)
+	if err := s.ListStore.Set(key, list, ttl, opts...); err != nil {
+		return err
+	}
+	if len(opts) > 0 {
+		return s.writeCurrent(key)
+	}
+	return s.write(opSet, key, list, exp)
+}
+
+// Update will replace the list for the given key and log it.
+func (s *loggedListStore[T]) Update(key string, list []T) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ListStore.Update(key, list); err != nil {
+		return err
+	}
+	return s.write(opUpdate, key, list, nil)
+}
+
+// Remove will delete the list linked to the given key and log it.
+func (s *loggedListStore[T]) Remove(key string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ListStore.Remove(key); err != nil {
+		return err
+	}
+	return s.write(opRemove, key, nil, nil)
+}
+
+// Push will add the given value to the list and log it.
+func (s *loggedListStore[T]) Push(key string, val T) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ListStore.Push(key, val); err != nil {
+		return err
+	}
+	return s.write(opPush, key, val, nil)
+}
+
+// Pop will retrieve and remove the first item from the list and log it.
+func (s *loggedListStore[T]) Pop(key string) (T, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	val, err := s.ListStore.Pop(key)
+	if err != nil {
+		return val, err
+	}
+	return val, s.write(opPop, key, nil, nil)
+}
+
+// LPush will add the given values to the start of the list and log them.
+func (s *loggedListStore[T]) LPush(key string, vals ...T) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	n, err := s.ListStore.LPush(key, vals...)
+	if err != nil {
+		return n, err
+	}
+	return n, s.write(opLPush, key, vals, nil)
+}
+
+// RPush will add the given values to the end of the list and log them.
+func (s *loggedListStore[T]) RPush(key string, vals ...T) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	n, err := s.ListStore.RPush(key, vals...)
+	if err != nil {
+		return n, err
+	}
+	return n, s.write(opRPush, key, vals, nil)
+}
+
+// LPop will retrieve and remove the first item from the list and log it.
+func (s *loggedListStore[T]) LPop(key string) (T, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	val, err := s.ListStore.LPop(key)
+	if err != nil {
+		return val, err
+	}
+	return val, s.write(opLPop, key, 1, nil)
+}
+
+// RPop will retrieve and remove the last item from the list and log it.
+func (s *loggedListStore[T]) RPop(key string) (T, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	val, err := s.ListStore.RPop(key)
+	if err != nil {
+		return val, err
+	}
+	return val, s.write(opRPop, key, 1, nil)
+}
+
+// LPopN will retrieve and remove up to n items from the start of the list and log
+// the number of items removed.
+func (s *loggedListStore[T]) LPopN(key string, n int) ([]T, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	vals, err := s.ListStore.LPopN(key, n)
+	if err != nil {
+		return vals, err
+	}
+	return vals, s.write(opLPop, key, len(vals), nil)
+}
+
+// RPopN will retrieve and remove up to n items from the end of the list and log
+// the number of items removed.
+func (s *loggedListStore[T]) RPopN(key string, n int) ([]T, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	vals, err := s.ListStore.RPopN(key, n)
+	if err != nil {
+		return vals, err
+	}
+	return vals, s.write(opRPop, key, len(vals), nil)
+}
+
+// BLPop will retrieve and remove the first item of the first non-empty list, waiting for one if needed, and log it.
+func (s *loggedListStore[T]) BLPop(ctx context.Context, timeout time.Duration, keys ...string) (string, T, error) {
+	return s.blockingPop(ctx, timeout, true, keys)
+}
+
+// BRPop will retrieve and remove the last item of the first non-empty list, waiting for one if needed, and log it.
+func (s *loggedListStore[T]) BRPop(ctx context.Context, timeout time.Duration, keys ...string) (string, T, error) {
+	return s.blockingPop(ctx, timeout, false, keys)
+}
+
+// blockingPop can't hold the log mutex while it waits, as it would block every other write.
+// It waits for the lists outside of the mutex and then pops without waiting under it,
+// so the pop is logged in the same order as it's applied to the lists.
+func (s *loggedListStore[T]) blockingPop(ctx context.Context, timeout time.Duration, front bool, keys []string) (string, T, error) {
+	var zero T
+	waiter, ok := s.ListStore.(storage.ListWaiter)
+	if !ok {
+		return "", zero, fmt.Errorf("%T can't wait for the lists without popping them", s.ListStore)
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		if key, val, ok, err := s.popFirst(front, keys); ok {
+			return key, val, err
+		}
+
+		// Another client may pop the items before us once woken up, so the wait starts again
+		// with the time left.
+		wait := time.Duration(0)
+		if timeout > 0 {
+			if wait = time.Until(deadline); wait <= 0 {
+				return "", zero, storage.ErrTimeout
+			}
+		}
+		if err := waiter.WaitNonEmpty(ctx, wait, keys...); err != nil {
+			return "", zero, err
+		}
+	}
+}
+
+// popFirst pops an item from the first non-empty list among the keys and logs it.
+// It returns false if all of them are empty or don't exist.
+func (s *loggedListStore[T]) popFirst(front bool, keys []string) (string, T, bool, error) {
+	pop, op := s.ListStore.RPop, opRPop
+	if front {
+		pop, op = s.ListStore.LPop, opLPop
+	}
+
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	for _, key := range keys {
+		val, err := pop(key)
+		if err == nil {
+			return key, val, true, s.write(op, key, 1, nil)
+		}
+	}
+	var zero T
+	return "", zero, false, nil
+}
+
+// LMove will move an item from the source list to the destination list and log it.
+func (s *loggedListStore[T]) LMove(src, dst string, srcLeft, dstLeft bool) (T, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	val, err := s.ListStore.LMove(src, dst, srcLeft, dstLeft)
+	if err != nil {
+		return val, err
+	}
+	return val, s.write(opLMove, src, listArgs[T]{Dest: dst, SrcLeft: srcLeft, DstLeft: dstLeft}, nil)
+}
+
+// LMoveIf will move an item from the source list to the destination list if check accepts it and log it.
+func (s *loggedListStore[T]) LMoveIf(src, dst string, srcLeft, dstLeft bool, check func(T) error) (T, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	val, err := s.ListStore.LMoveIf(src, dst, srcLeft, dstLeft, check)
+	if err != nil {
+		return val, err
+	}
+	return val, s.write(opLMove, src, listArgs[T]{Dest: dst, SrcLeft: srcLeft, DstLeft: dstLeft}, nil)
+}
+
+// RPopLPush will move the last item of the source list to the start of the destination list and log it.
+func (s *loggedListStore[T]) RPopLPush(src, dst string) (T, error) {
+	return s.LMove(src, dst, false, true)
+}
+
+// Reserve will reserve the first item of the list and log the reservation.
+func (s *loggedListStore[T]) Reserve(key string, visibility time.Duration) (*storage.Reservation[T], error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	r, err := s.ListStore.Reserve(key, visibility)
+	if err != nil {
+		return r, err
+	}
+	return r, s.write(opReserve, key, reservationArgs{ID: r.ID, VisibleAt: r.VisibleAt}, nil)
+}
+
+// Ack will acknowledge the reserved item and log it.
+func (s *loggedListStore[T]) Ack(key, id string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ListStore.Ack(key, id); err != nil {
+		return err
+	}
+	return s.write(opAck, key, reservationArgs{ID: id}, nil)
+}
+
+// Nack will put the reserved item back in the list and log it.
+func (s *loggedListStore[T]) Nack(key, id string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	err := s.ListStore.Nack(key, id)
+	if errors.Is(err, storage.ErrReservationNotFound) {
+		return err
+	}
+	// The reservation is released even if the list has been removed, so it's logged anyway.
+	if logErr := s.write(opNack, key, reservationArgs{ID: id}, nil); logErr != nil {
+		return logErr
+	}
+	return err
+}
+
+// logRequeue logs the reserved items put back in the list once their visibility timeout is over.
+// It's called by the store while reserving, or by its sweeper, both holding the log mutex.
+func (s *loggedListStore[T]) logRequeue(key string, ids []string) {
+	if err := s.write(opRequeue, key, ids, nil); err != nil {
+		log.Printf("ERROR: failed to log the items put back in list %s: %v", key, err)
+	}
+}
+
+// SetIndex will replace the item at the given position and log it.
+func (s *loggedListStore[T]) SetIndex(key string, i int, val T) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ListStore.SetIndex(key, i, val); err != nil {
+		return err
+	}
+	return s.write(opLSet, key, listArgs[T]{Index: i, Value: val}, nil)
+}
+
+// Trim will keep only the items within the given range and log it.
+func (s *loggedListStore[T]) Trim(key string, start, stop int) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ListStore.Trim(key, start, stop); err != nil {
+		return err
+	}
+	return s.write(opLTrim, key, listArgs[T]{Start: start, Stop: stop}, nil)
+}
+
+// Insert will add the value next to the pivot and log it.
+func (s *loggedListStore[T]) Insert(key string, before bool, pivot, val T) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	n, err := s.ListStore.Insert(key, before, pivot, val)
+	if err != nil {
+		return n, err
+	}
+	return n, s.write(opLInsert, key, listArgs[T]{Before: before, Pivot: pivot, Value: val}, nil)
+}
+
+// RemoveValue will remove the items equal to the value and log it if any was removed.
+func (s *loggedListStore[T]) RemoveValue(key string, count int, val T) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	removed, err := s.ListStore.RemoveValue(key, count, val)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	return removed, s.write(opLRem, key, listArgs[T]{Count: count, Value: val}, nil)
+}
+
+// Expire will set the TTL of the list and log its expiration time.
+func (s *loggedListStore[T]) Expire(key string, ttl time.Duration) error {
+	return s.ExpireAt(key, time.Now().Add(ttl))
+}
+
+// ExpireAt will set the expiration time of the list and log it.
+func (s *loggedListStore[T]) ExpireAt(key string, t time.Time) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ListStore.ExpireAt(key, t); err != nil {
+		return err
+	}
+	return s.log.append(expireRecord(s.name, key, t, 0))
+}
+
+// ExpireSliding will set the sliding expiration of the list and log it.
+func (s *loggedListStore[T]) ExpireSliding(key string, ttl time.Duration) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ListStore.ExpireSliding(key, ttl); err != nil {
+		return err
+	}
+	return s.log.append(expireRecord(s.name, key, time.Time{}, ttl))
+}
+
+// Persist will remove the expiration of the list and log it.
+func (s *loggedListStore[T]) Persist(key string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ListStore.Persist(key); err != nil {
+		return err
+	}
+	return s.log.append(expireRecord(s.name, key, time.Time{}, 0))
+}
+
+// Restore will load the given lists into the store and log them.
+func (s *loggedListStore[T]) Restore(data map[string]storage.Value[[]T]) int {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	restored := s.ListStore.Restore(data)
+	logSnapshot(s.log, s.name, data)
+	return restored
+}
+
+func (s *loggedListStore[T]) write(op, key string, val any, exp *time.Time) error {
+	rec, err := newRecord(s.name, op, key, val, exp)
+	if err != nil {
+		return err
+	}
+	return s.log.append(rec)
+}
+
+// writeCurrent logs the current value of the list. The caller must hold the log mutex.
+func (s *loggedListStore[T]) writeCurrent(key string) error {
+	rec, err := currentRecord(s.name, key, s.ListStore.Get)
+	if err != nil {
+		return err
+	}
+	return s.log.append(rec)
+}
+
+func (s *loggedListStore[T]) replay(rec record) error {
+	switch rec.Op {
+	case opSet:
+		return replaySet(rec, s.ListStore.Restore)
+	case opUpdate:
+		var list []T
+		if err := json.Unmarshal(rec.Value, &list); err != nil {
+			return fmt.Errorf("invalid value for key %s: %w", rec.Key, err)
+		}
+		return ignoreReplayErr(s.ListStore.Update(rec.Key, list))
+	case opRemove:
+		return ignoreReplayErr(s.ListStore.Remove(rec.Key))
+	case opExpire:
+		return replayExpire(rec, s.ListStore)
+	case opPush:
+		var val T
+		if err := json.Unmarshal(rec.Value, &val); err != nil {
+			return fmt.Errorf("invalid value for key %s: %w", rec.Key, err)
+		}
+		return ignoreReplayErr(s.ListStore.Push(rec.Key, val))
+	case opPop:
+		_, err := s.ListStore.Pop(rec.Key)
+		return ignoreReplayErr(err)
+	case opLPush, opRPush:
+		var vals []T
+		if err := json.Unmarshal(rec.Value, &vals); err != nil {
+			return fmt.Errorf("invalid value for key %s: %w", rec.Key, err)
+		}
+		push := s.ListStore.RPush
+		if rec.Op == opLPush {
+			push = s.ListStore.LPush
+		}
+		_, err := push(rec.Key, vals...)
+		return ignoreReplayErr(err)
+	case opLPop, opRPop:
+		var n int
+		if err := json.Unmarshal(rec.Value, &n); err != nil {
+			return fmt.Errorf("invalid count for key %s: %w", rec.Key, err)
+		}
+		pop := s.ListStore.RPopN
+		if rec.Op == opLPop {
+			pop = s.ListStore.LPopN
+		}
+		_, err := pop(rec.Key, n)
+		return ignoreReplayErr(err)
+	case opReserve, opAck, opNack:
+		var args reservationArgs
+		if err := json.Unmarshal(rec.Value, &args); err != nil {
+			return fmt.Errorf("invalid reservation for key %s: %w", rec.Key, err)
+		}
+		return ignoreReplayErr(s.replayReservation(rec.Op, rec.Key, args))
+	case opRequeue:
+		var ids []string
+		if err := json.Unmarshal(rec.Value, &ids); err != nil {
+			return fmt.Errorf("invalid reservations for key %s: %w", rec.Key, err)
+		}
+		// Putting back the items one by one in the logged order leaves them as the sweeper did.
+		for _, id := range ids {
+			if err := ignoreReplayErr(s.ListStore.Nack(rec.Key, id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case opLSet, opLTrim, opLInsert, opLRem, opLMove:
+		var args listArgs[T]
+		if err := json.Unmarshal(rec.Value, &args); err != nil {
+			return fmt.Errorf("invalid arguments for key %s: %w", rec.Key, err)
+		}
+		return ignoreReplayErr(s.replayArgs(rec.Op, rec.Key, args))
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+}
+
+// replayArgs applies a logged list operation that takes several arguments.
+func (s *loggedListStore[T]) replayArgs(op, key string, args listArgs[T]) error {
+	var err error
+	switch op {
+	case opLSet:
+		err = s.ListStore.SetIndex(key, args.Index, args.Value)
+	case opLTrim:
+		err = s.ListStore.Trim(key, args.Start, args.Stop)
+	case opLInsert:
+		_, err = s.ListStore.Insert(key, args.Before, args.Pivot, args.Value)
+	case opLRem:
+		_, err = s.ListStore.RemoveValue(key, args.Count, args.Value)
+	case opLMove:
+		_, err = s.ListStore.LMove(key, args.Dest, args.SrcLeft, args.DstLeft)
+	}
+	return err
+}
+
+// replayReservation applies a logged reservation operation.
+func (s *loggedListStore[T]) replayReservation(op, key string, args reservationArgs) error {
+	switch op {
+	case opReserve:
+		r, err := s.reservations()
+		if err != nil {
+			return err
+		}
+		return r.RestoreReservation(key, storage.Reservation[T]{ID: args.ID, VisibleAt: args.VisibleAt})
+	case opAck:
+		return s.ListStore.Ack(key, args.ID)
+	default:
+		return s.ListStore.Nack(key, args.ID)
+	}
+}
+
+// records returns the lists with their reserved items put back at the start, followed by
+// the reservations, which reserve the items again in the order they were reserved.
+// The caller must hold the log mutex, so the reservations don't change in between.
+func (s *loggedListStore[T]) records() ([]record, error) {
+	data := s.ListStore.Snapshot()
+	recs, err := snapshotRecords(s.name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.reservations()
+	if err != nil {
+		return nil, err
+	}
+	reservations := r.Reservations()
+	keys := make([]string, 0, len(reservations))
+	for key := range reservations {
+		// The items reserved from a list that no longer exists are not in the snapshot.
+		if _, ok := data[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, r := range reservations[key] {
+			rec, err := newRecord(s.name, opReserve, key, reservationArgs{ID: r.ID, VisibleAt: r.VisibleAt}, nil)
+			if err != nil {
+				return nil, err
+			}
+			recs = append(recs, rec)
+		}
+	}
+	return recs, nil
+}
+
+// loggedHashStore is a HashStore that writes its mutating operations to an OpLog.
+type loggedHashStore struct {
+	storage.HashStore
+	log  *OpLog
+	name string
+}
+
+// LogHashStore wraps the given store so every mutating operation is written to
+// the log under the given name. Reads go straight to the store.
+func LogHashStore(l *OpLog, name string, store storage.HashStore) storage.HashStore {
+	s := &loggedHashStore{HashStore: store, log: l, name: name}
+	l.register(name, s)
+	return s
+}
+
+// Set will store the given hash and log it.
+func (s *loggedHashStore) Set(key string, fields map[string]string, ttl time.Duration) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	exp := expiresAt(ttl)
+	if err := s.HashStore.Set(key, fields, ttl); err != nil {
+		return err
+	}
+	return s.write(opSet, key, fields, exp)
+}
+
+// Remove will delete the hash linked to the given key and log it.
+func (s *loggedHashStore) Remove(key string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.HashStore.Remove(key); err != nil {
+		return err
+	}
+	return s.write(opRemove, key, nil, nil)
+}
+
+// HSet will set the value of a field in the hash and log it.
+func (s *loggedHashStore) HSet(key, field, val string) (bool, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	created, err := s.HashStore.HSet(key, field, val)
+	if err != nil {
+		return created, err
+	}
+	return created, s.write(opHSet, key, map[string]string{field: val}, nil)
+}
+
+// HDel will remove the given fields from the hash and log them if any was removed.
+func (s *loggedHashStore) HDel(key string, fields ...string) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	removed, err := s.HashStore.HDel(key, fields...)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	return removed, s.write(opHDel, key, fields, nil)
+}
+
+// HIncrBy will increment the integer value of a field by delta and log the new value.
+func (s *loggedHashStore) HIncrBy(key, field string, delta int64) (int64, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	val, err := s.HashStore.HIncrBy(key, field, delta)
+	if err != nil {
+		return val, err
+	}
+	return val, s.write(opHSet, key, map[string]string{field: strconv.FormatInt(val, 10)}, nil)
+}
+
+// Restore will load the given hashes into the store and log them.
+func (s *loggedHashStore) Restore(data map[string]storage.Value[map[string]string]) int {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	restored := s.HashStore.Restore(data)
+	logSnapshot(s.log, s.name, data)
+	return restored
+}
+
+func (s *loggedHashStore) write(op, key string, val any, exp *time.Time) error {
+	rec, err := newRecord(s.name, op, key, val, exp)
+	if err != nil {
+		return err
+	}
+	return s.log.append(rec)
+}
+
+func (s *loggedHashStore) replay(rec record) error {
+	switch rec.Op {
+	case opSet:
+		return replaySet(rec, s.HashStore.Restore)
+	case opRemove:
+		return ignoreReplayErr(s.HashStore.Remove(rec.Key))
+	case opHSet:
+		var fields map[string]string
+		if err := json.Unmarshal(rec.Value, &fields); err != nil {
+			return fmt.Errorf("invalid fields for key %s: %w", rec.Key, err)
+		}
+		for field, val := range fields {
+			if _, err := s.HashStore.HSet(rec.Key, field, val); err != nil {
+				return ignoreReplayErr(err)
+			}
+		}
+		return nil
+	case opHDel:
+		var fields []string
+		if err := json.Unmarshal(rec.Value, &fields); err != nil {
+			return fmt.Errorf("invalid fields for key %s: %w", rec.Key, err)
+		}
+		_, err := s.HashStore.HDel(rec.Key, fields...)
+		return ignoreReplayErr(err)
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+}
+
+func (s *loggedHashStore) records() ([]record, error) {
+	return snapshotRecords(s.name, s.HashStore.Snapshot())
+}
+
+// loggedSetStore is a SetStore that writes its mutating operations to an OpLog.
+type loggedSetStore[T comparable] struct {
+	storage.SetStore[T]
+	log  *OpLog
+	name string
+}
+
+// LogSetStore wraps the given store so every mutating operation is written to
+// the log under the given name. Reads go straight to the store.
+func LogSetStore[T comparable](l *OpLog, name string, store storage.SetStore[T]) storage.SetStore[T] {
+	s := &loggedSetStore[T]{SetStore: store, log: l, name: name}
+	l.register(name, s)
+	return s
+}
+
+// Set will store the given set and log it.
+func (s *loggedSetStore[T]) Set(key string, members []T, ttl time.Duration) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	exp := expiresAt(ttl)
+	if err := s.SetStore.Set(key, members, ttl); err != nil {
+		return err
+	}
+	return s.write(opSet, key, members, exp)
+}
+
+// Remove will delete the set linked to the given key and log it.
+func (s *loggedSetStore[T]) Remove(key string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.SetStore.Remove(key); err != nil {
+		return err
+	}
+	return s.write(opRemove, key, nil, nil)
+}
+
+// Add will add the given members to the set and log them if any was added.
+func (s *loggedSetStore[T]) Add(key string, members ...T) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	added, err := s.SetStore.Add(key, members...)
+	if err != nil || added == 0 {
+		return added, err
+	}
+	return added, s.write(opSAdd, key, members, nil)
+}
+
+// RemoveMembers will remove the given members from the set and log them if any was removed.
+func (s *loggedSetStore[T]) RemoveMembers(key string, members ...T) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	removed, err := s.SetStore.RemoveMembers(key, members...)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	return removed, s.write(opSRem, key, members, nil)
+}
+
+// Pop will remove a random member from the set and log the member removed.
+func (s *loggedSetStore[T]) Pop(key string) (T, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	member, err := s.SetStore.Pop(key)
+	if err != nil {
+		return member, err
+	}
+	return member, s.write(opSRem, key, []T{member}, nil)
+}
+
+// UnionStore will store the union of the given sets in dst and log the result.
+func (s *loggedSetStore[T]) UnionStore(dst string, keys ...string) (int, error) {
+	return s.storeResult(s.SetStore.UnionStore, dst, keys)
+}
+
+// IntersectStore will store the intersection of the given sets in dst and log the result.
+func (s *loggedSetStore[T]) IntersectStore(dst string, keys ...string) (int, error) {
+	return s.storeResult(s.SetStore.IntersectStore, dst, keys)
+}
+
+// DiffStore will store the difference of the given sets in dst and log the result.
+func (s *loggedSetStore[T]) DiffStore(dst string, keys ...string) (int, error) {
+	return s.storeResult(s.SetStore.DiffStore, dst, keys)
+}
+
+// storeResult applies one of the operations that store their result in dst, and logs dst as it is
+// afterwards, so the result is replayed as is instead of being computed again.
+func (s *loggedSetStore[T]) storeResult(op func(dst string, keys ...string) (int, error), dst string, keys []string) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	n, err := op(dst, keys...)
+	if err != nil {
+		return n, err
+	}
+	rec, err := currentRecord(s.name, dst, s.SetStore.Get)
+	if err != nil {
+		return n, err
+	}
+	return n, s.log.append(rec)
+}
+
+// Restore will load the given sets into the store and log them.
+func (s *loggedSetStore[T]) Restore(data map[string]storage.Value[[]T]) int {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	restored := s.SetStore.Restore(data)
+	logSnapshot(s.log, s.name, data)
+	return restored
+}
+
+func (s *loggedSetStore[T]) write(op, key string, val any, exp *time.Time) error {
+	rec, err := newRecord(s.name, op, key, val, exp)
+	if err != nil {
+		return err
+	}
+	return s.log.append(rec)
+}
+
+func (s *loggedSetStore[T]) replay(rec record) error {
+	switch rec.Op {
+	case opSet:
+		return replaySet(rec, s.SetStore.Restore)
+	case opRemove:
+		return ignoreReplayErr(s.SetStore.Remove(rec.Key))
+	case opSAdd, opSRem:
+		var members []T
+		if err := json.Unmarshal(rec.Value, &members); err != nil {
+			return fmt.Errorf("invalid members for key %s: %w", rec.Key, err)
+		}
+		update := s.SetStore.RemoveMembers
+		if rec.Op == opSAdd {
+			update = s.SetStore.Add
+		}
+		_, err := update(rec.Key, members...)
+		return ignoreReplayErr(err)
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+}
+
+func (s *loggedSetStore[T]) records() ([]record, error) {
+	return snapshotRecords(s.name, s.SetStore.Snapshot())
+}
+
+// loggedZSetStore is a ZSetStore that writes its mutating operations to an OpLog.
+type loggedZSetStore struct {
+	storage.ZSetStore
+	log  *OpLog
+	name string
+}
+
+// LogZSetStore wraps the given store so every mutating operation is written to
+// the log under the given name. Reads go straight to the store.
+func LogZSetStore(l *OpLog, name string, store storage.ZSetStore) storage.ZSetStore {
+	s := &loggedZSetStore{ZSetStore: store, log: l, name: name}
+	l.register(name, s)
+	return s
+}
+
+// Set will store the given sorted set and log it.
+func (s *loggedZSetStore) Set(key string, members []storage.ScoredMember, ttl time.Duration) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	exp := expiresAt(ttl)
+	if err := s.ZSetStore.Set(key, members, ttl); err != nil {
+		return err
+	}
+	return s.write(opSet, key, members, exp)
+}
+
+// Remove will delete the sorted set linked to the given key and log it.
+func (s *loggedZSetStore) Remove(key string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.ZSetStore.Remove(key); err != nil {
+		return err
+	}
+	return s.write(opRemove, key, nil, nil)
+}
+
+// ZAdd will add the members to the sorted set, or update their scores, and log them.
+func (s *loggedZSetStore) ZAdd(key string, members ...storage.ScoredMember) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	added, err := s.ZSetStore.ZAdd(key, members...)
+	if err != nil {
+		return added, err
+	}
+	return added, s.write(opZAdd, key, members, nil)
+}
+
+// ZIncrBy will increment the score of the member by delta and log the new score.
+func (s *loggedZSetStore) ZIncrBy(key, member string, delta float64) (float64, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	score, err := s.ZSetStore.ZIncrBy(key, member, delta)
+	if err != nil {
+		return score, err
+	}
+	return score, s.write(opZAdd, key, []storage.ScoredMember{{Member: member, Score: score}}, nil)
+}
+
+// ZRem will remove the members from the sorted set and log them if any was removed.
+func (s *loggedZSetStore) ZRem(key string, members ...string) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	removed, err := s.ZSetStore.ZRem(key, members...)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	return removed, s.write(opZRem, key, members, nil)
+}
+
+// ZRemRangeByRank will remove the members within the range of ranks and log it if any was removed.
+func (s *loggedZSetStore) ZRemRangeByRank(key string, start, stop int) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	removed, err := s.ZSetStore.ZRemRangeByRank(key, start, stop)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	return removed, s.write(opZRemRangeByRank, key, listArgs[string]{Start: start, Stop: stop}, nil)
+}
+
+// ZRemRangeByScore will remove the members within the range of scores and log the sorted set
+// as it is afterwards, as the bounds can be infinite, which can't be encoded as JSON.
+func (s *loggedZSetStore) ZRemRangeByScore(key string, min, max float64) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	removed, err := s.ZSetStore.ZRemRangeByScore(key, min, max)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	rec, err := currentRecord(s.name, key, s.ZSetStore.Get)
+	if err != nil {
+		return removed, err
+	}
+	return removed, s.log.append(rec)
+}
+
+// Restore will load the given sorted sets into the store and log them.
+func (s *loggedZSetStore) Restore(data map[string]storage.Value[[]storage.ScoredMember]) int {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	restored := s.ZSetStore.Restore(data)
+	logSnapshot(s.log, s.name, data)
+	return restored
+}
+
+func (s *loggedZSetStore) write(op, key string, val any, exp *time.Time) error {
+	rec, err := newRecord(s.name, op, key, val, exp)
+	if err != nil {
+		return err
+	}
+	return s.log.append(rec)
+}
+
+func (s *loggedZSetStore) replay(rec record) error {
+	switch rec.Op {
+	case opSet:
+		return replaySet(rec, s.ZSetStore.Restore)
+	case opRemove:
+		return ignoreReplayErr(s.ZSetStore.Remove(rec.Key))
+	case opZAdd:
+		var members []storage.ScoredMember
+		if err := json.Unmarshal(rec.Value, &members); err != nil {
+			return fmt.Errorf("invalid members for key %s: %w", rec.Key, err)
+		}
+		_, err := s.ZSetStore.ZAdd(rec.Key, members...)
+		return ignoreReplayErr(err)
+	case opZRem:
+		var members []string
+		if err := json.Unmarshal(rec.Value, &members); err != nil {
+			return fmt.Errorf("invalid members for key %s: %w", rec.Key, err)
+		}
+		_, err := s.ZSetStore.ZRem(rec.Key, members...)
+		return ignoreReplayErr(err)
+	case opZRemRangeByRank:
+		var args listArgs[string]
+		if err := json.Unmarshal(rec.Value, &args); err != nil {
+			return fmt.Errorf("invalid arguments for key %s: %w", rec.Key, err)
+		}
+		_, err := s.ZSetStore.ZRemRangeByRank(rec.Key, args.Start, args.Stop)
+		return ignoreReplayErr(err)
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+}
+
+func (s *loggedZSetStore) records() ([]record, error) {
+	return snapshotRecords(s.name, s.ZSetStore.Snapshot())
+}
+
+// loggedStreamStore is a StreamStore that writes its mutating operations to an OpLog.
+type loggedStreamStore struct {
+	storage.StreamStore
+	log  *OpLog
+	name string
+}
+
+// LogStreamStore wraps the given store so every mutating operation is written to
+// the log under the given name. Reads go straight to the store.
+func LogStreamStore(l *OpLog, name string, store storage.StreamStore) storage.StreamStore {
+	s := &loggedStreamStore{StreamStore: store, log: l, name: name}
+	l.register(name, s)
+	return s
+}
+
+// restorer returns the wrapped store as a StreamRestorer, which the stores of NewStreamStore are.
+func (s *loggedStreamStore) restorer() (storage.StreamRestorer, error) {
+	r, ok := s.StreamStore.(storage.StreamRestorer)
+	if !ok {
+		return nil, fmt.Errorf("%T can't add the entries with their IDs", s.StreamStore)
+	}
+	return r, nil
+}
+
+// XAdd will append an entry to the stream and log it with its ID.
+func (s *loggedStreamStore) XAdd(key string, fields map[string]string, maxLen int) (storage.StreamID, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	id, err := s.StreamStore.XAdd(key, fields, maxLen)
+	if err != nil {
+		return id, err
+	}
+	return id, s.write(opXAdd, key, streamArgs{ID: id, Fields: fields, MaxLen: maxLen}, nil)
+}
+
+// XAddID will append an entry with the given ID to the stream and log it.
+func (s *loggedStreamStore) XAddID(key string, id storage.StreamID, fields map[string]string, maxLen int) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	r, err := s.restorer()
+	if err != nil {
+		return err
+	}
+	if err := r.XAddID(key, id, fields, maxLen); err != nil {
+		return err
+	}
+	return s.write(opXAdd, key, streamArgs{ID: id, Fields: fields, MaxLen: maxLen}, nil)
+}
+
+// XTrim will remove the oldest entries of the stream and log it if any was removed.
+func (s *loggedStreamStore) XTrim(key string, maxLen int) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	removed, err := s.StreamStore.XTrim(key, maxLen)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	return removed, s.write(opXTrim, key, streamArgs{MaxLen: maxLen}, nil)
+}
+
+// XDel will remove the entries from the stream and log them if any was removed.
+func (s *loggedStreamStore) XDel(key string, ids ...storage.StreamID) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	removed, err := s.StreamStore.XDel(key, ids...)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	return removed, s.write(opXDel, key, streamArgs{IDs: ids}, nil)
+}
+
+// Remove will delete the stream linked to the given key and log it.
+func (s *loggedStreamStore) Remove(key string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.StreamStore.Remove(key); err != nil {
+		return err
+	}
+	return s.write(opRemove, key, nil, nil)
+}
+
+// XGroupCreate will create the consumer group of the stream and log it.
+func (s *loggedStreamStore) XGroupCreate(key, group string, fromStart bool) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.StreamStore.XGroupCreate(key, group, fromStart); err != nil {
+		return err
+	}
+	return s.write(opXGroupCreate, key, streamArgs{Group: group, FromStart: fromStart}, nil)
+}
+
+// XGroupDestroy will remove the consumer group of the stream and log it.
+func (s *loggedStreamStore) XGroupDestroy(key, group string) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	if err := s.StreamStore.XGroupDestroy(key, group); err != nil {
+		return err
+	}
+	return s.write(opXGroupDestroy, key, streamArgs{Group: group}, nil)
+}
+
+// XReadGroup will deliver the new entries of the group to the consumer and log them as pending,
+// with the time they were delivered at. Reads that deliver nothing are only logged if they add the consumer.
+func (s *loggedStreamStore) XReadGroup(key, group, consumer string, count int) ([]storage.StreamEntry, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	consumers := s.consumers(key, group)
+	entries, err := s.StreamStore.XReadGroup(key, group, consumer, count)
+	if err != nil {
+		return entries, err
+	}
+	if len(entries) == 0 && s.consumers(key, group) == consumers {
+		return entries, nil
+	}
+
+	pending, err := s.pending(key, group, entries)
+	if err != nil {
+		return entries, err
+	}
+	args := streamArgs{Group: group, Consumer: consumer, Pending: pending}
+	if len(entries) > 0 {
+		args.LastDelivered = entries[len(entries)-1].ID
+	}
+	return entries, s.write(opXPending, key, args, nil)
+}
+
+// XAck will acknowledge the pending entries of the group and log them if any was pending.
+func (s *loggedStreamStore) XAck(key, group string, ids ...storage.StreamID) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	acked, err := s.StreamStore.XAck(key, group, ids...)
+	if err != nil || acked == 0 {
+		return acked, err
+	}
+	return acked, s.write(opXAck, key, streamArgs{Group: group, IDs: ids}, nil)
+}
+
+// XClaim will give the idle pending entries to the consumer and log them as pending, with the time
+// they were claimed at, and the requested entries that are not pending anymore as acknowledged.
+func (s *loggedStreamStore) XClaim(
+	key, group, consumer string,
+	minIdle time.Duration,
+	ids ...storage.StreamID,
+) ([]storage.StreamEntry, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	entries, err := s.StreamStore.XClaim(key, group, consumer, minIdle, ids...)
+	if err != nil {
+		return entries, err
+	}
+
+	all, err := s.StreamStore.XPending(key, group, "")
+	if err != nil {
+		return entries, err
+	}
+	pendingIDs := make(map[storage.StreamID]bool, len(all))
+	for _, p := range all {
+		pendingIDs[p.ID] = true
+	}
+	// The claimed entries that were deleted from the stream have been acknowledged.
+	var acked []storage.StreamID
+	for _, id := range ids {
+		if !pendingIDs[id] {
+			acked = append(acked, id)
+		}
+	}
+	if len(entries) == 0 && len(acked) == 0 {
+		return entries, nil
+	}
+
+	pending, err := s.pending(key, group, entries)
+	if err != nil {
+		return entries, err
+	}
+	return entries, s.write(opXPending, key, streamArgs{Group: group, IDs: acked, Pending: pending}, nil)
+}
+
+// XSetPending will set the pending entries of the group and log them.
+func (s *loggedStreamStore) XSetPending(
+	key, group, consumer string,
+	lastDelivered storage.StreamID,
+	pending ...storage.PendingEntry,
+) error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	r, err := s.restorer()
+	if err != nil {
+		return err
+	}
+	if err := r.XSetPending(key, group, consumer, lastDelivered, pending...); err != nil {
+		return err
+	}
+	args := streamArgs{Group: group, Consumer: consumer, LastDelivered: lastDelivered, Pending: pending}
+	return s.write(opXPending, key, args, nil)
+}
+
+// Restore will load the given streams into the store and log them.
+func (s *loggedStreamStore) Restore(data map[string]storage.Value[storage.StreamData]) int {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	restored := s.StreamStore.Restore(data)
+	logSnapshot(s.log, s.name, data)
+	return restored
+}
+
+// consumers returns the number of consumers of the group, or -1 if it's not found.
+// The caller must hold the log mutex.
+func (s *loggedStreamStore) consumers(key, group string) int {
+	groups, err := s.StreamStore.XGroups(key)
+	if err != nil {
+		return -1
+	}
+	for _, g := range groups {
+		if g.Name == group {
+			return g.Consumers
+		}
+	}
+	return -1
+}
+
+// pending returns the pending entries of the group for the delivered entries.
+// The caller must hold the log mutex.
+func (s *loggedStreamStore) pending(key, group string, entries []storage.StreamEntry) ([]storage.PendingEntry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	all, err := s.StreamStore.XPending(key, group, "")
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[storage.StreamID]storage.PendingEntry, len(all))
+	for _, p := range all {
+		byID[p.ID] = p
+	}
+	pending := make([]storage.PendingEntry, 0, len(entries))
+	for _, e := range entries {
+		pending = append(pending, byID[e.ID])
+	}
+	return pending, nil
+}
+
+func (s *loggedStreamStore) write(op, key string, val any, exp *time.Time) error {
+	rec, err := newRecord(s.name, op, key, val, exp)
+	if err != nil {
+		return err
+	}
+	return s.log.append(rec)
+}
+
+func (s *loggedStreamStore) replay(rec record) error {
+	if rec.Op == opSet {
+		return replaySet(rec, s.StreamStore.Restore)
+	}
+	if rec.Op == opRemove {
+		return ignoreReplayErr(s.StreamStore.Remove(rec.Key))
+	}
+
+	var args streamArgs
+	if err := json.Unmarshal(rec.Value, &args); err != nil {
+		return fmt.Errorf("invalid arguments for key %s: %w", rec.Key, err)
+	}
+	r, err := s.restorer()
+	if err != nil {
+		return err
+	}
+	switch rec.Op {
+	case opXAdd:
+		err = r.XAddID(rec.Key, args.ID, args.Fields, args.MaxLen)
+	case opXTrim:
+		_, err = s.StreamStore.XTrim(rec.Key, args.MaxLen)
+	case opXDel:
+		_, err = s.StreamStore.XDel(rec.Key, args.IDs...)
+	case opXGroupCreate:
+		err = s.StreamStore.XGroupCreate(rec.Key, args.Group, args.FromStart)
+	case opXGroupDestroy:
+		err = s.StreamStore.XGroupDestroy(rec.Key, args.Group)
+	case opXAck:
+		_, err = s.StreamStore.XAck(rec.Key, args.Group, args.IDs...)
+	case opXPending:
+		if len(args.IDs) > 0 {
+			if _, err := s.StreamStore.XAck(rec.Key, args.Group, args.IDs...); ignoreReplayErr(err) != nil {
+				return err
+			}
+		}
+		err = r.XSetPending(rec.Key, args.Group, args.Consumer, args.LastDelivered, args.Pending...)
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+	return ignoreReplayErr(err)
+}
+
+func (s *loggedStreamStore) records() ([]record, error) {
+	return snapshotRecords(s.name, s.StreamStore.Snapshot())
+}
+
+// loggedTransactor is a Transactor that writes the keys changed by every transaction to an OpLog.
+type loggedTransactor[T any] struct {
+	storage.Transactor[T]
+	log     *OpLog
+	strings *loggedStringStore
+	lists   *loggedListStore[T]
+}
+
+// LogTransactor wraps the given transactor so the keys written by every transaction are logged
+// in a single record, which is replayed as a whole. The given stores must be the ones returned by
+// LogStringStore and LogListStore for the stores of the transactor.
+func LogTransactor[T any](
+	l *OpLog,
+	t storage.Transactor[T],
+	strings storage.StringStore,
+	lists storage.ListStore[T],
+) (storage.Transactor[T], error) {
+	ss, ok := strings.(*loggedStringStore)
+	if !ok {
+		return nil, errors.New("string store is not logged")
+	}
+	ls, ok := lists.(*loggedListStore[T])
+	if !ok {
+		return nil, errors.New("list store is not logged")
+	}
+	return &loggedTransactor[T]{Transactor: t, log: l, strings: ss, lists: ls}, nil
+}
+
+// Exec will apply the transaction and log the final value of the keys it has written.
+func (t *loggedTransactor[T]) Exec(tx *storage.Tx[T]) ([]any, error) {
+	t.log.mu.Lock()
+	defer t.log.mu.Unlock()
+
+	results, err := t.Transactor.Exec(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	stringKeys, listKeys := tx.Writes()
+	recs := make([]record, 0, len(stringKeys)+len(listKeys))
+	for _, key := range stringKeys {
+		rec, err := currentRecord(t.strings.name, key, t.strings.StringStore.Get)
+		if err != nil {
+			return results, err
+		}
+		recs = append(recs, rec)
+	}
+	for _, key := range listKeys {
+		rec, err := currentRecord(t.lists.name, key, t.lists.ListStore.Get)
+		if err != nil {
+			return results, err
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) == 0 {
+		return results, nil
+	}
+
+	return results, t.log.append(record{Op: opTx, Records: recs})
+}
+
+// keyspaceTarget is the name of the keyspace in the log, for the operations on keys of any type.
+const keyspaceTarget = "keyspace"
+
+// loggedKeyspace is a Keyspace that writes its operations to an OpLog.
+type loggedKeyspace struct {
+	storage.Keyspace
+	log *OpLog
+}
+
+// LogKeyspace wraps the given keyspace so the keys deleted, renamed or expired across its stores are logged.
+// Only the stores of the keyspace wrapped with one of the Log functions, like LogStringStore, are persisted.
+// Like the stores, it must be wrapped before the log is replayed.
+func LogKeyspace(l *OpLog, ks storage.Keyspace) storage.Keyspace {
+	k := &loggedKeyspace{Keyspace: ks, log: l}
+	l.register(keyspaceTarget, k)
+	return k
+}
+
+// Delete will remove the keys and log their removal.
+func (k *loggedKeyspace) Delete(keys ...string) (int, error) {
+	k.log.mu.Lock()
+	defer k.log.mu.Unlock()
+
+	n, err := k.Keyspace.Delete(keys...)
+	if err != nil || n == 0 {
+		return n, err
+	}
+	if len(keys) == 1 {
+		return n, k.log.append(record{Store: keyspaceTarget, Op: opRemove, Key: keys[0]})
+	}
+	recs := make([]record, 0, len(keys))
+	for _, key := range keys {
+		recs = append(recs, record{Store: keyspaceTarget, Op: opRemove, Key: key})
+	}
+	return n, k.log.append(record{Op: opTx, Records: recs})
+}
+
+// Rename will move the value of src to dst and log it.
+func (k *loggedKeyspace) Rename(src, dst string) error {
+	k.log.mu.Lock()
+	defer k.log.mu.Unlock()
+
+	if err := k.Keyspace.Rename(src, dst); err != nil {
+		return err
+	}
+	rec, err := newRecord(keyspaceTarget, opRename, src, dst, nil)
+	if err != nil {
+		return err
+	}
+	return k.log.append(rec)
+}
+
+// Expire will set the TTL of the key and log its expiration time.
+func (k *loggedKeyspace) Expire(key string, ttl time.Duration) error {
+	return k.ExpireAt(key, time.Now().Add(ttl))
+}
+
+// ExpireAt will set the expiration time of the key and log it.
+func (k *loggedKeyspace) ExpireAt(key string, t time.Time) error {
+	k.log.mu.Lock()
+	defer k.log.mu.Unlock()
+
+	if err := k.Keyspace.ExpireAt(key, t); err != nil {
+		return err
+	}
+	return k.log.append(expireRecord(keyspaceTarget, key, t, 0))
+}
+
+// ExpireSliding will set the sliding expiration of the key and log it.
+func (k *loggedKeyspace) ExpireSliding(key string, ttl time.Duration) error {
+	k.log.mu.Lock()
+	defer k.log.mu.Unlock()
+
+	if err := k.Keyspace.ExpireSliding(key, ttl); err != nil {
+		return err
+	}
+	return k.log.append(expireRecord(keyspaceTarget, key, time.Time{}, ttl))
+}
+
+// Persist will remove the expiration of the key and log it.
+func (k *loggedKeyspace) Persist(key string) error {
+	k.log.mu.Lock()
+	defer k.log.mu.Unlock()
+
+	if err := k.Keyspace.Persist(key); err != nil {
+		return err
+	}
+	return k.log.append(expireRecord(keyspaceTarget, key, time.Time{}, 0))
+}
+
+func (k *loggedKeyspace) replay(rec record) error {
+	switch rec.Op {
+	case opRemove:
+		_, err := k.Keyspace.Delete(rec.Key)
+		return ignoreReplayErr(err)
+	case opRename:
+		var dst string
+		if err := json.Unmarshal(rec.Value, &dst); err != nil {
+			return fmt.Errorf("invalid destination for key %s: %w", rec.Key, err)
+		}
+		return ignoreReplayErr(k.Keyspace.Rename(rec.Key, dst))
+	case opExpire:
+		return replayExpire(rec, k.Keyspace)
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+}
+
+// records returns no records, as the keys are rebuilt by the records of their stores.
+func (k *loggedKeyspace) records() ([]record, error) {
+	return nil, nil
+}
@@ -0,0 +1,362 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FsyncPolicy defines when the operation log is flushed to disk.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways syncs the log after every operation. Safest but slowest.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncEverySecond syncs the log once per second in the background.
+	// At most one second of operations can be lost on a power failure.
+	FsyncEverySecond FsyncPolicy = "everysec"
+	// FsyncNever lets the operating system decide when to flush the log.
+	FsyncNever FsyncPolicy = "never"
+)
+
+const (
+	// defaultRewriteMinSize is the minimum size of the log before it's rewritten.
+	defaultRewriteMinSize = 64 << 20
+	// rewriteGrowthFactor triggers a rewrite when the log has grown this many times
+	// over its size after the last rewrite.
+	rewriteGrowthFactor = 2
+	// maintenanceInterval is the gap of time between background syncs and rewrite checks.
+	maintenanceInterval = time.Second
+)
+
+// ParseFsyncPolicy parses a fsync policy name. An empty name defaults to FsyncEverySecond.
+func ParseFsyncPolicy(s string) (FsyncPolicy, error) {
+	switch p := FsyncPolicy(s); p {
+	case "":
+		return FsyncEverySecond, nil
+	case FsyncAlways, FsyncEverySecond, FsyncNever:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid fsync policy %q", s)
+	}
+}
+
+// record is a single entry of the operation log.
+type record struct {
+	Store     string          `json:"store"`
+	Op        string          `json:"op"`
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	// Sliding is the sliding expiration of the key, for the set and expire operations.
+	Sliding time.Duration `json:"sliding,omitempty"`
+	// Flags are the flags of the value, for the set operations.
+	Flags uint32 `json:"flags,omitempty"`
+	// Records are the records of a transaction, for the tx operation.
+	Records []record `json:"records,omitempty"`
+}
+
+// logTarget is a store whose operations are written to the log.
+type logTarget interface {
+	// replay applies a logged operation to the underlying store.
+	replay(rec record) error
+	// records returns the records needed to rebuild the current state of the store.
+	records() ([]record, error)
+}
+
+// OpLog is an append-only log of every mutating operation on the stores.
+// The stores wrapped with LogStringStore, LogListStore and the other Log functions,
+// and the transactions of LogTransactor, write their operations to the log, which
+// is replayed on startup to rebuild them.
+// The log is rewritten in the background when it grows too much, so it
+// only contains the operations needed to rebuild the current state.
+type OpLog struct {
+	path           string
+	policy         FsyncPolicy
+	rewriteMinSize int64
+
+	// Mutex to serialize the operations on the stores and their log records.
+	// It's held while the store is modified so the log has the same order.
+	mu       sync.Mutex
+	f        *os.File
+	size     int64
+	baseSize int64
+	dirty    bool
+	targets  map[string]logTarget
+
+	started  bool
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// OpenOpLog opens the operation log at the given path, creating it if needed.
+func OpenOpLog(path string, policy FsyncPolicy) (*OpLog, error) {
+	if path == "" {
+		return nil, errors.New("missing operation log path")
+	}
+	if _, err := ParseFsyncPolicy(string(policy)); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open operation log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat operation log: %w", err)
+	}
+
+	return &OpLog{
+		path:           path,
+		policy:         policy,
+		rewriteMinSize: defaultRewriteMinSize,
+		f:              f,
+		size:           info.Size(),
+		baseSize:       info.Size(),
+		targets:        map[string]logTarget{},
+		stopCh:         make(chan struct{}),
+		doneCh:         make(chan struct{}),
+	}, nil
+}
+
+// register adds a store to the log under the given name.
+func (l *OpLog) register(name string, target logTarget) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.targets[name] = target
+}
+
+// Replay reads the log and applies every operation to the registered stores.
+// It must be called after wrapping the stores and before serving requests.
+// A partially written last record, left by a crash, is discarded.
+func (l *OpLog) Replay() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read operation log: %w", err)
+	}
+
+	r := bufio.NewReader(l.f)
+	var offset int64
+	replayed := 0
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				log.Printf("WARN: discarding truncated record at the end of the operation log %s", l.path)
+				if err := l.f.Truncate(offset); err != nil {
+					return fmt.Errorf("failed to truncate operation log: %w", err)
+				}
+				l.size = offset
+				l.baseSize = offset
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read operation log: %w", err)
+		}
+
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("invalid record at offset %d of the operation log: %w", offset, err)
+		}
+		if err := l.replay(rec); err != nil {
+			return fmt.Errorf("failed to replay record at offset %d of the operation log: %w", offset, err)
+		}
+
+		offset += int64(len(line))
+		replayed++
+	}
+
+	log.Printf("INFO: replayed %d operations from %s", replayed, l.path)
+
+	return nil
+}
+
+// replay applies the record to its store, or every record of a transaction. The caller must hold the mutex.
+func (l *OpLog) replay(rec record) error {
+	if rec.Op == opTx {
+		for _, r := range rec.Records {
+			if err := l.replay(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	target, ok := l.targets[rec.Store]
+	if !ok {
+		return fmt.Errorf("unknown store %q", rec.Store)
+	}
+	return target.replay(rec)
+}
+
+// append writes a record to the log. The caller must hold the mutex.
+func (l *OpLog) append(rec record) error {
+	line, err := json.Marshal(&rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode log record: %w", err)
+	}
+	line = append(line, '\n')
+
+	n, err := l.f.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write log record: %w", err)
+	}
+
+	if l.policy == FsyncAlways {
+		if err := l.f.Sync(); err != nil {
+			return fmt.Errorf("failed to sync operation log: %w", err)
+		}
+		return nil
+	}
+	l.dirty = true
+
+	return nil
+}
+
+// Rewrite replaces the log with the minimal set of operations needed to
+// rebuild the current state of the stores. Writes are blocked while rewriting.
+func (l *OpLog) Rewrite() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rewrite()
+}
+
+func (l *OpLog) rewrite() error {
+	// Keep the output stable by rewriting the stores in the same order.
+	names := make([]string, 0, len(l.targets))
+	for name := range l.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var size int64
+	err := writeFileAtomic(l.path, func(f *os.File) error {
+		w := bufio.NewWriter(f)
+		for _, name := range names {
+			recs, err := l.targets[name].records()
+			if err != nil {
+				return err
+			}
+			for _, rec := range recs {
+				line, err := json.Marshal(&rec)
+				if err != nil {
+					return fmt.Errorf("failed to encode log record: %w", err)
+				}
+				n, err := w.Write(append(line, '\n'))
+				size += int64(n)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rewrite operation log: %w", err)
+	}
+
+	// The old file has been replaced, so keep appending to the new one.
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to reopen operation log: %w", err)
+	}
+	l.f.Close()
+	l.f = f
+	l.size = size
+	l.baseSize = size
+	l.dirty = false
+
+	return nil
+}
+
+// needsRewrite reports if the log has grown enough to be rewritten.
+// The caller must hold the mutex.
+func (l *OpLog) needsRewrite() bool {
+	return l.size >= l.rewriteMinSize && l.size >= l.baseSize*rewriteGrowthFactor
+}
+
+// Start runs the background maintenance of the log until Close is called:
+// it syncs the log every second with FsyncEverySecond and rewrites it when
+// it has doubled in size since the last rewrite.
+func (l *OpLog) Start() {
+	l.mu.Lock()
+	l.started = true
+	l.mu.Unlock()
+
+	go func() {
+		defer close(l.doneCh)
+
+		ticker := time.NewTicker(maintenanceInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-l.stopCh:
+				return
+			case <-ticker.C:
+				l.maintain()
+			}
+		}
+	}()
+}
+
+func (l *OpLog) maintain() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.policy == FsyncEverySecond && l.dirty {
+		if err := l.f.Sync(); err != nil {
+			log.Printf("ERROR: failed to sync operation log: %v", err)
+		} else {
+			l.dirty = false
+		}
+	}
+
+	if l.needsRewrite() {
+		if err := l.rewrite(); err != nil {
+			log.Printf("ERROR: %v", err)
+		}
+	}
+}
+
+// Close stops the background maintenance, if started, and closes the log
+// after syncing it to disk.
+func (l *OpLog) Close() error {
+	l.stopOnce.Do(func() {
+		close(l.stopCh)
+	})
+
+	l.mu.Lock()
+	started := l.started
+	l.mu.Unlock()
+	if started {
+		<-l.doneCh
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.policy != FsyncNever {
+		if err := l.f.Sync(); err != nil {
+			l.f.Close()
+			return fmt.Errorf("failed to sync operation log: %w", err)
+		}
+	}
+
+	return l.f.Close()
+}
@@ -0,0 +1,930 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"in-memory-storage/internal/persistence"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// openLoggedStores opens the log at path and wraps fresh stores with it, replaying the log.
+func openLoggedStores(t *testing.T, path string) (*persistence.OpLog, storage.StringStore, storage.ListStore[string]) {
+	l, err := persistence.OpenOpLog(path, persistence.FsyncAlways)
+	assert.NoError(t, err)
+
+	stringStore := persistence.LogStringStore(l, "strings", storage.NewStringStore())
+	listStore := persistence.LogListStore(l, "string_lists", storage.NewListStore[string]())
+	assert.NoError(t, l.Replay())
+
+	return l, stringStore, listStore
+}
+
+func TestParseFsyncPolicy(t *testing.T) {
+	testCases := map[string]struct {
+		policy         string
+		expectedPolicy persistence.FsyncPolicy
+		expectedErr    bool
+	}{
+		"it should default to every second": {
+			policy:         "",
+			expectedPolicy: persistence.FsyncEverySecond,
+		},
+		"it should parse always": {
+			policy:         "always",
+			expectedPolicy: persistence.FsyncAlways,
+		},
+		"it should parse every second": {
+			policy:         "everysec",
+			expectedPolicy: persistence.FsyncEverySecond,
+		},
+		"it should parse never": {
+			policy:         "never",
+			expectedPolicy: persistence.FsyncNever,
+		},
+		"it should return an error if the policy is invalid": {
+			policy:      "sometimes",
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			policy, err := persistence.ParseFsyncPolicy(tc.policy)
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedPolicy, policy)
+			}
+		})
+	}
+}
+
+func TestOpLog_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, stringStore, listStore := openLoggedStores(t, path)
+	assert.NoError(t, stringStore.Set("key", "value", 0))
+	assert.NoError(t, stringStore.Update("key", "new-value"))
+	assert.NoError(t, stringStore.Set("ttl-key", "ttl-value", time.Hour))
+	assert.NoError(t, stringStore.Set("removed-key", "value", 0))
+	assert.NoError(t, stringStore.Remove("removed-key"))
+	assert.NoError(t, stringStore.Set("expired-key", "value", time.Millisecond))
+	assert.NoError(t, listStore.Set("list", []string{"a", "b"}, 0))
+	assert.NoError(t, listStore.Push("list", "c"))
+	_, err := listStore.Pop("list")
+	assert.NoError(t, err)
+
+	assert.NoError(t, stringStore.Set("flags-key", "1", 0, storage.WithFlags(42)))
+	_, err = stringStore.Incr("flags-key")
+	assert.NoError(t, err)
+
+	// Failed operations are not logged
+	assert.Equal(t, storage.ErrAlreadyExists, stringStore.Set("key", "other-value", 0))
+	assert.NoError(t, l.Close())
+
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	l, stringStore, listStore = openLoggedStores(t, path)
+	defer l.Close()
+
+	val, err := stringStore.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-value", val.Value)
+
+	val, err = stringStore.Get("ttl-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "ttl-value", val.Value)
+	assert.False(t, val.ExpiresAt.IsZero())
+
+	val, err = stringStore.Get("flags-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", val.Value)
+	assert.Equal(t, uint32(42), val.Flags)
+
+	_, err = stringStore.Get("removed-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+	_, err = stringStore.Get("expired-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	list, err := listStore.Get("list")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, list.Value)
+}
+
+func TestOpLog_ReplayCounters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, stringStore, _ := openLoggedStores(t, path)
+	assert.NoError(t, stringStore.Set("counter", "10", time.Hour))
+	_, err := stringStore.Incr("counter")
+	assert.NoError(t, err)
+	_, err = stringStore.IncrBy("counter", 5)
+	assert.NoError(t, err)
+	_, err = stringStore.Decr("counter")
+	assert.NoError(t, err)
+	_, err = stringStore.IncrByFloat("price", 1.5)
+	assert.NoError(t, err)
+
+	// Failed operations are not logged
+	assert.NoError(t, stringStore.Set("text", "foo", 0))
+	_, err = stringStore.Incr("text")
+	assert.Equal(t, storage.ErrNotInteger, err)
+	assert.NoError(t, l.Close())
+
+	l, stringStore, _ = openLoggedStores(t, path)
+	defer l.Close()
+
+	val, err := stringStore.Get("counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "15", val.Value)
+	assert.False(t, val.ExpiresAt.IsZero())
+
+	val, err = stringStore.Get("price")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.5", val.Value)
+
+	val, err = stringStore.Get("text")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", val.Value)
+}
+
+func TestOpLog_ReplayConditionalWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, stringStore, _ := openLoggedStores(t, path)
+	ok, err := stringStore.SetNX("setnx", "first", 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = stringStore.SetNX("setnx", "second", 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, stringStore.Set("setxx", "first", time.Hour))
+	ok, err = stringStore.SetXX("setxx", "second", 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, stringStore.Set("cas", "first", time.Hour))
+	ok, err = stringStore.CompareAndSwap("cas", "first", "second")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, stringStore.Set("versioned", "first", 0))
+	val, err := stringStore.Get("versioned")
+	assert.NoError(t, err)
+	assert.NoError(t, stringStore.UpdateIfVersion("versioned", "second", val.Version))
+	assert.Equal(t, storage.ErrVersionMismatch, stringStore.UpdateIfVersion("versioned", "third", val.Version))
+
+	assert.NoError(t, stringStore.Set("upsert", "first", time.Hour))
+	upsert := []storage.SetOption{storage.WithSetMode(storage.SetAlways), storage.WithKeepTTL()}
+	assert.NoError(t, stringStore.Set("upsert", "second", 0, upsert...))
+	assert.NoError(t, l.Close())
+
+	l, stringStore, _ = openLoggedStores(t, path)
+	defer l.Close()
+
+	val, err = stringStore.Get("setnx")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", val.Value)
+
+	val, err = stringStore.Get("setxx")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", val.Value)
+	assert.True(t, val.ExpiresAt.IsZero())
+
+	val, err = stringStore.Get("cas")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", val.Value)
+	assert.False(t, val.ExpiresAt.IsZero())
+
+	val, err = stringStore.Get("versioned")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", val.Value)
+
+	val, err = stringStore.Get("upsert")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", val.Value)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), val.ExpiresAt, time.Second)
+}
+
+func TestOpLog_ReplayDoubleEndedLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, _, listStore := openLoggedStores(t, path)
+	assert.NoError(t, listStore.Set("list", []string{"c"}, 0))
+	_, err := listStore.LPush("list", "b", "a")
+	assert.NoError(t, err)
+	_, err = listStore.RPush("list", "d", "e", "f")
+	assert.NoError(t, err)
+	_, err = listStore.LPop("list")
+	assert.NoError(t, err)
+	_, err = listStore.RPop("list")
+	assert.NoError(t, err)
+	_, err = listStore.RPopN("list", 2)
+	assert.NoError(t, err)
+
+	// Failed operations are not logged
+	_, err = listStore.LPopN("list", 0)
+	assert.Equal(t, storage.ErrInvalidCount, err)
+	assert.NoError(t, l.Close())
+
+	l, _, listStore = openLoggedStores(t, path)
+	defer l.Close()
+
+	val, err := listStore.Get("list")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, val.Value)
+}
+
+func TestOpLog_ReplayBlockingPops(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+	ctx := context.Background()
+
+	l, _, listStore := openLoggedStores(t, path)
+	assert.NoError(t, listStore.Set("ready", []string{"a", "b"}, 0))
+	assert.NoError(t, listStore.Set("waiting", []string{}, 0))
+
+	// Pops that don't wait
+	_, _, err := listStore.BLPop(ctx, time.Second, "waiting", "ready")
+	assert.NoError(t, err)
+
+	// Pops woken up by a push
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, err := listStore.RPush("waiting", "c", "d")
+		assert.NoError(t, err)
+	}()
+	key, val, err := listStore.BRPop(ctx, time.Second, "waiting")
+	assert.NoError(t, err)
+	assert.Equal(t, "waiting", key)
+	assert.Equal(t, "d", val)
+	assert.NoError(t, l.Close())
+
+	l, _, listStore = openLoggedStores(t, path)
+
+	list, err := listStore.Get("ready")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, list.Value)
+
+	list, err = listStore.Get("waiting")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, list.Value)
+
+	// Pops woken up while the list is moved are logged in the order they're applied
+	const values = 200
+	assert.NoError(t, listStore.Set("jobs", []string{}, 0))
+	assert.NoError(t, listStore.Set("done", []string{}, 0))
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				_, _, err := listStore.BLPop(ctx, 50*time.Millisecond, "jobs")
+				if errors.Is(err, storage.ErrTimeout) {
+					return
+				}
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	for i := 0; i < values; i += 4 {
+		_, err := listStore.RPush("jobs", strconv.Itoa(i), strconv.Itoa(i+1), strconv.Itoa(i+2), strconv.Itoa(i+3))
+		assert.NoError(t, err)
+		_, _ = listStore.LMove("jobs", "done", true, false)
+	}
+	wg.Wait()
+	want, err := listStore.Get("done")
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+
+	l, _, listStore = openLoggedStores(t, path)
+	defer l.Close()
+
+	list, err = listStore.Get("done")
+	assert.NoError(t, err)
+	assert.Equal(t, want.Value, list.Value)
+	list, err = listStore.Get("jobs")
+	assert.NoError(t, err)
+	assert.Empty(t, list.Value)
+}
+
+func TestOpLog_ReplayReliableQueues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, _, listStore := openLoggedStores(t, path)
+	assert.NoError(t, listStore.Set("queue", []string{"a", "b", "c", "d"}, 0))
+	assert.NoError(t, listStore.Set("processing", []string{}, 0))
+	_, err := listStore.RPopLPush("queue", "processing")
+	assert.NoError(t, err)
+
+	acked, err := listStore.Reserve("queue", time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, listStore.Ack("queue", acked.ID))
+
+	nacked, err := listStore.Reserve("queue", time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, listStore.Nack("queue", nacked.ID))
+
+	// The expired reservation is put back when reserving again
+	_, err = listStore.Reserve("queue", time.Millisecond)
+	assert.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	_, err = listStore.Reserve("queue", time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+
+	l, _, listStore = openLoggedStores(t, path)
+	defer l.Close()
+
+	list, err := listStore.Get("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, list.Value)
+
+	list, err = listStore.Get("processing")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, list.Value)
+}
+
+func TestOpLog_ReplayReservations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+	openListStore := func() (*persistence.OpLog, storage.ListStore[string]) {
+		l, err := persistence.OpenOpLog(path, persistence.FsyncAlways)
+		assert.NoError(t, err)
+		store := storage.NewListStore[string](storage.WithSweeper(5 * time.Millisecond))
+		t.Cleanup(func() { _ = store.Close() })
+		logged := persistence.LogListStore(l, "string_lists", store)
+		assert.NoError(t, l.Replay())
+		return l, logged
+	}
+
+	l, listStore := openListStore()
+	assert.NoError(t, listStore.Set("queue", []string{"a", "b", "c", "d"}, 0))
+	expired, err := listStore.Reserve("queue", 10*time.Millisecond)
+	assert.NoError(t, err)
+	pending, err := listStore.Reserve("queue", time.Minute)
+	assert.NoError(t, err)
+
+	// The sweeper puts back the expired reservation, which is then popped
+	assert.Eventually(t, func() bool {
+		n, err := listStore.Len("queue")
+		return err == nil && n == 3
+	}, time.Second, 5*time.Millisecond)
+	val, err := listStore.LPop("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, expired.Value, val)
+	assert.NoError(t, l.Close())
+
+	l, listStore = openListStore()
+
+	list, err := listStore.Get("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, list.Value)
+
+	// The pending reservation can still be acknowledged after a restart
+	assert.Equal(t, storage.ErrReservationNotFound, listStore.Ack("queue", expired.ID))
+	rewritten, err := listStore.Reserve("queue", time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Rewrite())
+	assert.NoError(t, listStore.Ack("queue", pending.ID))
+	assert.NoError(t, l.Close())
+
+	l, listStore = openListStore()
+	defer l.Close()
+
+	list, err = listStore.Get("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, list.Value)
+	assert.Equal(t, storage.ErrReservationNotFound, listStore.Ack("queue", pending.ID))
+	assert.NoError(t, listStore.Nack("queue", rewritten.ID))
+
+	list, err = listStore.Get("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, list.Value)
+}
+
+func TestOpLog_ReplayListEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, _, listStore := openLoggedStores(t, path)
+	assert.NoError(t, listStore.Set("list", []string{"a", "x", "b", "x", "c", "d"}, 0))
+	assert.NoError(t, listStore.SetIndex("list", -1, "e"))
+	_, err := listStore.RemoveValue("list", 0, "x")
+	assert.NoError(t, err)
+	_, err = listStore.Insert("list", false, "a", "")
+	assert.NoError(t, err)
+	assert.NoError(t, listStore.Trim("list", 0, -2))
+
+	// Failed operations are not logged
+	_, err = listStore.Insert("list", true, "z", "y")
+	assert.Equal(t, storage.ErrPivotNotFound, err)
+	assert.NoError(t, l.Close())
+
+	l, _, listStore = openLoggedStores(t, path)
+	defer l.Close()
+
+	val, err := listStore.Get("list")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "", "b", "c"}, val.Value)
+}
+
+func TestOpLog_ReplayExpirations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, stringStore, listStore := openLoggedStores(t, path)
+	assert.NoError(t, stringStore.Set("session", "value", 0))
+	assert.NoError(t, stringStore.Expire("session", time.Hour))
+	assert.NoError(t, stringStore.Set("sliding", "value", 0))
+	assert.NoError(t, stringStore.ExpireSliding("sliding", time.Minute))
+	assert.NoError(t, stringStore.Set("expired", "value", 0))
+	assert.NoError(t, stringStore.Expire("expired", time.Millisecond))
+	assert.NoError(t, listStore.Set("list", []string{"a"}, time.Hour))
+	assert.NoError(t, listStore.Persist("list"))
+	// Failed operations are not logged
+	assert.Equal(t, storage.ErrNotFound, stringStore.Expire("missing", time.Hour))
+	assert.NoError(t, l.Close())
+	time.Sleep(5 * time.Millisecond)
+
+	l, stringStore, listStore = openLoggedStores(t, path)
+	defer l.Close()
+
+	ttl, err := stringStore.TTL("session")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Second))
+
+	val, err := stringStore.Get("sliding")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, val.Sliding)
+
+	_, err = stringStore.Get("expired")
+	assert.Error(t, err)
+
+	ttl, err = listStore.TTL("list")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.NoTTL, ttl)
+
+	// The sliding expiration is kept when the log is rewritten
+	assert.NoError(t, l.Rewrite())
+	assert.NoError(t, l.Close())
+	l, stringStore, _ = openLoggedStores(t, path)
+	defer l.Close()
+	val, err = stringStore.Get("sliding")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, val.Sliding)
+}
+
+func TestOpLog_ReplayHashes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+	openHashStore := func() (*persistence.OpLog, storage.HashStore) {
+		l, err := persistence.OpenOpLog(path, persistence.FsyncAlways)
+		assert.NoError(t, err)
+		store := persistence.LogHashStore(l, "hashes", storage.NewHashStore())
+		assert.NoError(t, l.Replay())
+		return l, store
+	}
+
+	l, store := openHashStore()
+	assert.NoError(t, store.Set("user", map[string]string{"name": "ada", "city": "london"}, time.Hour))
+	_, err := store.HSet("user", "name", "grace")
+	assert.NoError(t, err)
+	_, err = store.HIncrBy("user", "visits", 3)
+	assert.NoError(t, err)
+	_, err = store.HDel("user", "city", "missing")
+	assert.NoError(t, err)
+	_, err = store.HSet("removed", "field", "value")
+	assert.NoError(t, err)
+	assert.NoError(t, store.Remove("removed"))
+
+	// Failed operations are not logged
+	_, err = store.HIncrBy("user", "name", 1)
+	assert.Equal(t, storage.ErrNotInteger, err)
+	assert.NoError(t, l.Close())
+
+	l, store = openHashStore()
+	defer l.Close()
+
+	val, err := store.Get("user")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "grace", "visits": "3"}, val.Value)
+	assert.False(t, val.ExpiresAt.IsZero())
+
+	_, err = store.Get("removed")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	assert.NoError(t, l.Rewrite())
+	assert.NoError(t, l.Close())
+
+	l, store = openHashStore()
+	defer l.Close()
+
+	val, err = store.Get("user")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "grace", "visits": "3"}, val.Value)
+}
+
+func TestOpLog_ReplaySets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+	openSetStore := func() (*persistence.OpLog, storage.SetStore[string]) {
+		l, err := persistence.OpenOpLog(path, persistence.FsyncAlways)
+		assert.NoError(t, err)
+		store := persistence.LogSetStore(l, "string_sets", storage.NewSetStore[string]())
+		assert.NoError(t, l.Replay())
+		return l, store
+	}
+
+	l, store := openSetStore()
+	assert.NoError(t, store.Set("tags", []string{"a", "b"}, time.Hour))
+	_, err := store.Add("tags", "c", "d")
+	assert.NoError(t, err)
+	_, err = store.RemoveMembers("tags", "a")
+	assert.NoError(t, err)
+	popped, err := store.Pop("tags")
+	assert.NoError(t, err)
+	_, err = store.Add("other", "c", "e")
+	assert.NoError(t, err)
+	_, err = store.UnionStore("union", "tags", "other")
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+
+	l, store = openSetStore()
+	defer l.Close()
+
+	expected := []string{"b", "c", "d"}
+	expected = slices.DeleteFunc(expected, func(m string) bool { return m == popped })
+	members, err := store.Members("tags")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, expected, members)
+
+	val, err := store.Get("tags")
+	assert.NoError(t, err)
+	assert.False(t, val.ExpiresAt.IsZero())
+
+	union := slices.Compact(slices.Sorted(slices.Values(append(expected, "c", "e"))))
+	members, err = store.Members("union")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, union, members)
+}
+
+func TestOpLog_ReplaySortedSets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+	openZSetStore := func() (*persistence.OpLog, storage.ZSetStore) {
+		l, err := persistence.OpenOpLog(path, persistence.FsyncAlways)
+		assert.NoError(t, err)
+		store := persistence.LogZSetStore(l, "zsets", storage.NewZSetStore())
+		assert.NoError(t, l.Replay())
+		return l, store
+	}
+
+	l, store := openZSetStore()
+	assert.NoError(t, store.Set("board", []storage.ScoredMember{{Member: "a", Score: 1}, {Member: "b", Score: 2}}, time.Hour))
+	_, err := store.ZAdd("board", storage.ScoredMember{Member: "c", Score: 3}, storage.ScoredMember{Member: "d", Score: 4})
+	assert.NoError(t, err)
+	_, err = store.ZIncrBy("board", "a", 10)
+	assert.NoError(t, err)
+	_, err = store.ZRem("board", "b")
+	assert.NoError(t, err)
+	_, err = store.ZRemRangeByRank("board", 0, 0)
+	assert.NoError(t, err)
+	_, err = store.ZRemRangeByScore("board", math.Inf(-1), 4)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+
+	l, store = openZSetStore()
+
+	val, err := store.Get("board")
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.ScoredMember{{Member: "a", Score: 11}}, val.Value)
+	assert.False(t, val.ExpiresAt.IsZero())
+
+	// An increment that overflows changes neither the sorted set nor the log, which can still be rewritten
+	_, err = store.ZIncrBy("board", "a", math.MaxFloat64)
+	assert.NoError(t, err)
+	_, err = store.ZIncrBy("board", "a", math.MaxFloat64)
+	assert.ErrorIs(t, err, storage.ErrInvalidScore)
+	assert.NoError(t, l.Rewrite())
+	assert.NoError(t, l.Close())
+
+	l, store = openZSetStore()
+	defer l.Close()
+
+	score, err := store.ZScore("board", "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 11+math.MaxFloat64, score)
+}
+
+func TestOpLog_ReplayStreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+	openStreamStore := func() (*persistence.OpLog, storage.StreamStore) {
+		l, err := persistence.OpenOpLog(path, persistence.FsyncAlways)
+		assert.NoError(t, err)
+		store := persistence.LogStreamStore(l, "streams", storage.NewStreamStore())
+		assert.NoError(t, l.Replay())
+		return l, store
+	}
+
+	l, store := openStreamStore()
+	ids := make([]storage.StreamID, 5)
+	for i := range ids {
+		id, err := store.XAdd("events", map[string]string{"n": strconv.Itoa(i)}, 0)
+		assert.NoError(t, err)
+		ids[i] = id
+	}
+	_, err := store.XTrim("events", 4)
+	assert.NoError(t, err)
+	assert.NoError(t, store.XGroupCreate("events", "workers", true))
+	assert.NoError(t, store.XGroupCreate("events", "auditors", false))
+	assert.NoError(t, store.XGroupDestroy("events", "auditors"))
+	_, err = store.XReadGroup("events", "workers", "alice", 2)
+	assert.NoError(t, err)
+	_, err = store.XReadGroup("events", "workers", "bob", 2)
+	assert.NoError(t, err)
+	// An empty read still adds the consumer to the group
+	_, err = store.XReadGroup("events", "workers", "carol", 1)
+	assert.NoError(t, err)
+	_, err = store.XAck("events", "workers", ids[1])
+	assert.NoError(t, err)
+	_, err = store.XDel("events", ids[3])
+	assert.NoError(t, err)
+	claimed, err := store.XClaim("events", "workers", "carol", 0, ids[2], ids[3])
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+
+	want, err := store.Get("events")
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+
+	l, store = openStreamStore()
+	val, err := store.Get("events")
+	assert.NoError(t, err)
+	assert.Equal(t, normalizeStream(want.Value), normalizeStream(val.Value))
+	assert.Equal(t, []string{"alice", "bob", "carol"}, val.Value.Groups["workers"].Consumers)
+
+	// The rewritten log rebuilds the same streams
+	assert.NoError(t, l.Rewrite())
+	assert.NoError(t, l.Close())
+
+	l, store = openStreamStore()
+	defer l.Close()
+
+	val, err = store.Get("events")
+	assert.NoError(t, err)
+	assert.Equal(t, normalizeStream(want.Value), normalizeStream(val.Value))
+	_, err = store.XAdd("events", map[string]string{"n": "5"}, 0)
+	assert.NoError(t, err)
+}
+
+// normalizeStream drops the monotonic clock and the location of the delivery times, which aren't logged.
+func normalizeStream(data storage.StreamData) storage.StreamData {
+	for name, g := range data.Groups {
+		for i := range g.Pending {
+			g.Pending[i].DeliveredAt = g.Pending[i].DeliveredAt.UTC().Round(0)
+		}
+		data.Groups[name] = g
+	}
+	return data
+}
+
+func TestOpLog_ReplayKeyspace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	open := func() (*persistence.OpLog, storage.Keyspace, storage.StringStore, storage.ListStore[string]) {
+		l, err := persistence.OpenOpLog(path, persistence.FsyncAlways)
+		assert.NoError(t, err)
+
+		ks := storage.NewKeyspace()
+		stringStore := persistence.LogStringStore(l, "strings", storage.NewStringStore(storage.WithKeyspace(ks)))
+		listStore := persistence.LogListStore(l, "string_lists", storage.NewListStore[string](storage.WithKeyspace(ks)))
+		loggedKs := persistence.LogKeyspace(l, ks)
+		assert.NoError(t, l.Replay())
+
+		return l, loggedKs, stringStore, listStore
+	}
+
+	l, ks, stringStore, listStore := open()
+	assert.NoError(t, stringStore.Set("key", "value", 0))
+	assert.NoError(t, stringStore.Set("deleted", "value", 0))
+	assert.NoError(t, listStore.Set("list", []string{"a", "b"}, time.Hour))
+	n, err := ks.Delete("deleted", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	// The list replaces the string when renamed over it
+	assert.NoError(t, ks.Rename("list", "key"))
+	assert.NoError(t, stringStore.Set("persistent", "value", time.Hour))
+	assert.NoError(t, ks.Persist("persistent"))
+
+	// Changing the TTL of a key only logs its expiration, not its value
+	big := make([]string, 1000)
+	for i := range big {
+		big[i] = strconv.Itoa(i)
+	}
+	assert.NoError(t, listStore.Set("big", big, 0))
+	before, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Expire("big", time.Hour))
+	assert.NoError(t, ks.ExpireSliding("persistent", time.Hour))
+	after, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Less(t, after.Size()-before.Size(), int64(300))
+	_, err = ks.Delete("big")
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+
+	l, ks, stringStore, listStore = open()
+	defer l.Close()
+
+	assert.Equal(t, 2, ks.Count())
+	assert.Equal(t, storage.TypeList, ks.Type("key"))
+	ttl, err := ks.TTL("persistent")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Minute))
+	_, err = stringStore.Get("key")
+	assert.Equal(t, storage.ErrNotFound, err)
+	val, err := listStore.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, val.Value)
+	assert.False(t, val.ExpiresAt.IsZero())
+}
+
+func TestOpLog_ReplayTransactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	openTransactor := func() (*persistence.OpLog, storage.StringStore, storage.ListStore[string], storage.Transactor[string]) {
+		l, err := persistence.OpenOpLog(path, persistence.FsyncAlways)
+		assert.NoError(t, err)
+
+		rawStrings, rawLists := storage.NewStringStore(), storage.NewListStore[string]()
+		transactor, err := storage.NewTransactor(rawStrings, rawLists)
+		assert.NoError(t, err)
+
+		stringStore := persistence.LogStringStore(l, "strings", rawStrings)
+		listStore := persistence.LogListStore(l, "string_lists", rawLists)
+		transactor, err = persistence.LogTransactor(l, transactor, stringStore, listStore)
+		assert.NoError(t, err)
+		assert.NoError(t, l.Replay())
+
+		return l, stringStore, listStore, transactor
+	}
+
+	l, stringStore, listStore, transactor := openTransactor()
+	assert.NoError(t, listStore.Set("queue", []string{"job1", "job2"}, time.Hour))
+	assert.NoError(t, stringStore.Set("old-key", "value", 0))
+
+	tx := storage.NewTx[string]()
+	tx.Pop("queue")
+	tx.SetString("current-job", "job1", 0)
+	tx.RemoveString("old-key")
+	_, err := transactor.Exec(tx)
+	assert.NoError(t, err)
+
+	// Failed transactions are not logged
+	tx = storage.NewTx[string]()
+	tx.SetString("other-key", "value", 0)
+	tx.Pop("missing-queue")
+	_, err = transactor.Exec(tx)
+	assert.Error(t, err)
+	assert.NoError(t, l.Close())
+
+	l, stringStore, listStore, _ = openTransactor()
+	defer l.Close()
+
+	list, err := listStore.Get("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job2"}, list.Value)
+	assert.False(t, list.ExpiresAt.IsZero())
+
+	val, err := stringStore.Get("current-job")
+	assert.NoError(t, err)
+	assert.Equal(t, "job1", val.Value)
+
+	_, err = stringStore.Get("old-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+	_, err = stringStore.Get("other-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestLogTransactor(t *testing.T) {
+	t.Run("it should return an error if the stores are not logged", func(t *testing.T) {
+		l, err := persistence.OpenOpLog(filepath.Join(t.TempDir(), "appendonly.log"), persistence.FsyncNever)
+		assert.NoError(t, err)
+		defer l.Close()
+
+		stringStore, listStore := storage.NewStringStore(), storage.NewListStore[string]()
+		transactor, err := storage.NewTransactor(stringStore, listStore)
+		assert.NoError(t, err)
+
+		_, err = persistence.LogTransactor(l, transactor, stringStore, listStore)
+		assert.Error(t, err)
+	})
+}
+
+func TestOpLog_ReplayTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, stringStore, _ := openLoggedStores(t, path)
+	assert.NoError(t, stringStore.Set("key", "value", 0))
+	assert.NoError(t, l.Close())
+
+	// Simulate a crash in the middle of writing a record
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"store":"strings","op":"set","key":"partial`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	l, stringStore, _ = openLoggedStores(t, path)
+	_, err = stringStore.Get("partial")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	// New records are appended after the last valid one
+	assert.NoError(t, stringStore.Set("other-key", "value", 0))
+	assert.NoError(t, l.Close())
+
+	l, stringStore, _ = openLoggedStores(t, path)
+	defer l.Close()
+	_, err = stringStore.Get("key")
+	assert.NoError(t, err)
+	_, err = stringStore.Get("other-key")
+	assert.NoError(t, err)
+}
+
+func TestOpLog_ReplayInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+	assert.NoError(t, os.WriteFile(path, []byte("{\"store\":\"unknown\",\"op\":\"set\",\"key\":\"k\"}\n"), 0o600))
+
+	l, err := persistence.OpenOpLog(path, persistence.FsyncNever)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	persistence.LogStringStore(l, "strings", storage.NewStringStore())
+	assert.Error(t, l.Replay())
+}
+
+func TestOpLog_Rewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, stringStore, listStore := openLoggedStores(t, path)
+	assert.NoError(t, stringStore.Set("counter", "0", 0))
+	assert.NoError(t, listStore.Set("queue", []string{}, 0))
+	for i := 1; i <= 100; i++ {
+		assert.NoError(t, stringStore.Update("counter", strconv.Itoa(i)))
+		assert.NoError(t, listStore.Push("queue", strconv.Itoa(i)))
+		if i%2 == 0 {
+			_, err := listStore.Pop("queue")
+			assert.NoError(t, err)
+		}
+	}
+
+	before, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Rewrite())
+	after, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size())
+
+	// Operations after the rewrite are appended to the new log
+	assert.NoError(t, stringStore.Update("counter", "101"))
+	assert.NoError(t, l.Close())
+
+	l, stringStore, listStore = openLoggedStores(t, path)
+	defer l.Close()
+
+	val, err := stringStore.Get("counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "101", val.Value)
+
+	list, err := listStore.Get("queue")
+	assert.NoError(t, err)
+	assert.Len(t, list.Value, 50)
+	assert.Equal(t, "51", list.Value[0])
+}
+
+func TestOpLog_StartClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.log")
+
+	l, err := persistence.OpenOpLog(path, persistence.FsyncEverySecond)
+	assert.NoError(t, err)
+	stringStore := persistence.LogStringStore(l, "strings", storage.NewStringStore())
+	assert.NoError(t, l.Replay())
+
+	l.Start()
+	assert.NoError(t, stringStore.Set("key", "value", 0))
+	assert.NoError(t, l.Close())
+
+	l, stringStore, _ = openLoggedStores(t, path)
+	defer l.Close()
+	val, err := stringStore.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", val.Value)
+}
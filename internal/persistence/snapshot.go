@@ -0,0 +1,335 @@
+// Package persistence provides durability for the in-memory stores.
+// It writes point-in-time snapshots of the stores to disk and restores them on startup.
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"in-memory-storage/storage"
+)
+
+// snapshotVersion is the version of the snapshot file format.
+const snapshotVersion = 1
+
+// maxSnapshotAttempts is the number of times Save takes the snapshots of the stores
+// before giving up on a key that keeps changing type in between.
+const maxSnapshotAttempts = 3
+
+// snapshot is the content of a snapshot file.
+type snapshot struct {
+	Version     int                                `json:"version"`
+	CreatedAt   time.Time                          `json:"created_at"`
+	Strings     map[string]storage.Value[string]   `json:"strings"`
+	StringLists map[string]storage.Value[[]string] `json:"string_lists"`
+	// JSONLists is only written when the snapshotter has a JSON list store.
+	JSONLists map[string]storage.Value[[]json.RawMessage] `json:"json_lists,omitempty"`
+	// Hashes is only written when the snapshotter has a hash store.
+	Hashes map[string]storage.Value[map[string]string] `json:"hashes,omitempty"`
+	// StringSets is only written when the snapshotter has a set store.
+	StringSets map[string]storage.Value[[]string] `json:"string_sets,omitempty"`
+	// ZSets is only written when the snapshotter has a sorted set store.
+	ZSets map[string]storage.Value[[]storage.ScoredMember] `json:"zsets,omitempty"`
+	// Streams is only written when the snapshotter has a stream store.
+	Streams map[string]storage.Value[storage.StreamData] `json:"streams,omitempty"`
+}
+
+// conflict returns a key found in two sections of the snapshot. The stores are snapshotted
+// one after the other, so a key that changes type in between ends up in both.
+func (snap *snapshot) conflict() (string, bool) {
+	seen := make(map[string]struct{})
+	for _, keys := range []iter.Seq[string]{
+		maps.Keys(snap.Strings),
+		maps.Keys(snap.StringLists),
+		maps.Keys(snap.JSONLists),
+		maps.Keys(snap.Hashes),
+		maps.Keys(snap.StringSets),
+		maps.Keys(snap.ZSets),
+		maps.Keys(snap.Streams),
+	} {
+		for key := range keys {
+			if _, ok := seen[key]; ok {
+				return key, true
+			}
+			seen[key] = struct{}{}
+		}
+	}
+	return "", false
+}
+
+// Snapshotter periodically writes a snapshot of the stores to a file
+// and loads it back on startup.
+type Snapshotter struct {
+	path            string
+	interval        time.Duration
+	stringStore     storage.StringStore
+	stringListStore storage.ListStore[string]
+	jsonListStore   storage.ListStore[json.RawMessage]
+	hashStore       storage.HashStore
+	stringSetStore  storage.SetStore[string]
+	zsetStore       storage.ZSetStore
+	streamStore     storage.StreamStore
+
+	// Mutex to avoid writing two snapshots at the same time
+	mu sync.Mutex
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// SnapshotterOption configures the optional stores of the Snapshotter.
+type SnapshotterOption func(*Snapshotter)
+
+// WithJSONListStore adds the lists of JSON values to the snapshots.
+func WithJSONListStore(store storage.ListStore[json.RawMessage]) SnapshotterOption {
+	return func(s *Snapshotter) {
+		s.jsonListStore = store
+	}
+}
+
+// WithHashStore adds the hashes to the snapshots.
+func WithHashStore(store storage.HashStore) SnapshotterOption {
+	return func(s *Snapshotter) {
+		s.hashStore = store
+	}
+}
+
+// WithSetStore adds the sets of strings to the snapshots.
+func WithSetStore(store storage.SetStore[string]) SnapshotterOption {
+	return func(s *Snapshotter) {
+		s.stringSetStore = store
+	}
+}
+
+// WithZSetStore adds the sorted sets to the snapshots.
+func WithZSetStore(store storage.ZSetStore) SnapshotterOption {
+	return func(s *Snapshotter) {
+		s.zsetStore = store
+	}
+}
+
+// WithStreamStore adds the streams, with their consumer groups, to the snapshots.
+func WithStreamStore(store storage.StreamStore) SnapshotterOption {
+	return func(s *Snapshotter) {
+		s.streamStore = store
+	}
+}
+
+// NewSnapshotter creates a new Snapshotter writing to the given path.
+// If interval is zero, snapshots are only written when calling Save or Stop.
+// It returns an error if the path or any of the stores is missing.
+func NewSnapshotter(
+	path string,
+	interval time.Duration,
+	stringStore storage.StringStore,
+	stringListStore storage.ListStore[string],
+	opts ...SnapshotterOption,
+) (*Snapshotter, error) {
+	if path == "" {
+		return nil, errors.New("missing snapshot path")
+	}
+	if stringStore == nil {
+		return nil, errors.New("missing string store")
+	}
+	if stringListStore == nil {
+		return nil, errors.New("missing string list store")
+	}
+
+	s := &Snapshotter{
+		path:            path,
+		interval:        interval,
+		stringStore:     stringStore,
+		stringListStore: stringListStore,
+		stopCh:          make(chan struct{}),
+		doneCh:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Load restores the stores from the snapshot file.
+// Entries that have expired since the snapshot was taken are dropped.
+// It's not an error if the file does not exist yet, but it is if a key is found in two stores.
+func (s *Snapshotter) Load() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	var snap snapshot
+	if err := json.NewDecoder(f).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	if key, ok := snap.conflict(); ok {
+		return fmt.Errorf("snapshot holds key %q in two stores", key)
+	}
+
+	strs := s.stringStore.Restore(snap.Strings)
+	lists := s.stringListStore.Restore(snap.StringLists)
+	if s.jsonListStore != nil {
+		lists += s.jsonListStore.Restore(snap.JSONLists)
+	}
+	hashes := 0
+	if s.hashStore != nil {
+		hashes = s.hashStore.Restore(snap.Hashes)
+	}
+	sets := 0
+	if s.stringSetStore != nil {
+		sets = s.stringSetStore.Restore(snap.StringSets)
+	}
+	zsets := 0
+	if s.zsetStore != nil {
+		zsets = s.zsetStore.Restore(snap.ZSets)
+	}
+	streams := 0
+	if s.streamStore != nil {
+		streams = s.streamStore.Restore(snap.Streams)
+	}
+	log.Printf("INFO: restored %d strings, %d lists, %d hashes, %d sets, %d sorted sets and %d streams from snapshot %s",
+		strs, lists, hashes, sets, zsets, streams, s.path)
+
+	return nil
+}
+
+// Save writes a snapshot of the stores to the file.
+// The snapshot is written to a temporary file first and then renamed,
+// so the file is never left half written.
+// The snapshots of the stores are taken again if a key changed type while they were taken.
+func (s *Snapshotter) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var snap snapshot
+	for attempt := 1; ; attempt++ {
+		snap = s.take()
+		key, ok := snap.conflict()
+		if !ok {
+			break
+		}
+		if attempt == maxSnapshotAttempts {
+			return fmt.Errorf("key %q keeps changing type while taking the snapshot", key)
+		}
+	}
+
+	return writeFileAtomic(s.path, func(f *os.File) error {
+		return json.NewEncoder(f).Encode(&snap)
+	})
+}
+
+// take returns a snapshot of the stores. The caller must hold the lock.
+func (s *Snapshotter) take() snapshot {
+	snap := snapshot{
+		Version:     snapshotVersion,
+		CreatedAt:   time.Now().UTC(),
+		Strings:     s.stringStore.Snapshot(),
+		StringLists: s.stringListStore.Snapshot(),
+	}
+	if s.jsonListStore != nil {
+		snap.JSONLists = s.jsonListStore.Snapshot()
+	}
+	if s.hashStore != nil {
+		snap.Hashes = s.hashStore.Snapshot()
+	}
+	if s.stringSetStore != nil {
+		snap.StringSets = s.stringSetStore.Snapshot()
+	}
+	if s.zsetStore != nil {
+		snap.ZSets = s.zsetStore.Snapshot()
+	}
+	if s.streamStore != nil {
+		snap.Streams = s.streamStore.Snapshot()
+	}
+	return snap
+}
+
+// Start writes a snapshot every interval in the background until Stop is called.
+// It does nothing if the interval is zero.
+func (s *Snapshotter) Start() {
+	if s.interval <= 0 {
+		close(s.doneCh)
+		return
+	}
+
+	go func() {
+		defer close(s.doneCh)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				if err := s.Save(); err != nil {
+					log.Printf("ERROR: failed to save snapshot: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the background snapshots and writes a final snapshot.
+// It must be called after Start.
+func (s *Snapshotter) Stop() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	<-s.doneCh
+
+	return s.Save()
+}
+
+// writeFileAtomic writes a file by calling write on a temporary file in the
+// same directory, syncing it to disk and renaming it to the final path.
+func writeFileAtomic(path string, write func(f *os.File) error) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	// Remove the temporary file if anything goes wrong before the rename.
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", path, err)
+	}
+
+	// Sync the directory so the rename itself is durable.
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,348 @@
+package persistence_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"in-memory-storage/internal/persistence"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSnapshotter(t *testing.T) {
+	stringStore := storage.NewStringStore()
+	listStore := storage.NewListStore[string]()
+
+	testCases := map[string]struct {
+		path        string
+		stringStore storage.StringStore
+		listStore   storage.ListStore[string]
+		expectedErr error
+	}{
+		"it should return an error if the path is missing": {
+			path:        "",
+			stringStore: stringStore,
+			listStore:   listStore,
+			expectedErr: errors.New("missing snapshot path"),
+		},
+		"it should return an error if the string store is missing": {
+			path:        "dump.json",
+			listStore:   listStore,
+			expectedErr: errors.New("missing string store"),
+		},
+		"it should return an error if the string list store is missing": {
+			path:        "dump.json",
+			stringStore: stringStore,
+			expectedErr: errors.New("missing string list store"),
+		},
+		"it should create a new snapshotter": {
+			path:        "dump.json",
+			stringStore: stringStore,
+			listStore:   listStore,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			s, err := persistence.NewSnapshotter(tc.path, 0, tc.stringStore, tc.listStore)
+			assert.Equal(t, tc.expectedErr, err)
+			if tc.expectedErr != nil {
+				assert.Nil(t, s)
+			} else {
+				assert.NotNil(t, s)
+			}
+		})
+	}
+}
+
+func TestSnapshotter_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+
+	stringStore := storage.NewStringStore()
+	listStore := storage.NewListStore[string]()
+	assert.NoError(t, stringStore.Set("key", "value", 0))
+	assert.NoError(t, stringStore.Set("ttl-key", "ttl-value", time.Hour))
+	assert.NoError(t, stringStore.Set("flags-key", "value", 0, storage.WithFlags(42)))
+	assert.NoError(t, listStore.Set("list", []string{"a", "b"}, 0))
+
+	s, err := persistence.NewSnapshotter(path, 0, stringStore, listStore)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Save())
+
+	// Restore into new stores, as it would happen after a restart.
+	newStringStore := storage.NewStringStore()
+	newListStore := storage.NewListStore[string]()
+	s, err = persistence.NewSnapshotter(path, 0, newStringStore, newListStore)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Load())
+
+	val, err := newStringStore.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", val.Value)
+
+	val, err = newStringStore.Get("ttl-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "ttl-value", val.Value)
+	assert.False(t, val.ExpiresAt.IsZero())
+
+	val, err = newStringStore.Get("flags-key")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(42), val.Flags)
+
+	list, err := newListStore.Get("list")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, list.Value)
+}
+
+func TestSnapshotter_SaveAndLoadReservations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+
+	listStore := storage.NewListStore[string]()
+	assert.NoError(t, listStore.Set("queue", []string{"a", "b", "c"}, 0))
+	acked, err := listStore.Reserve("queue", time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, listStore.Ack("queue", acked.ID))
+	_, err = listStore.Reserve("queue", time.Minute)
+	assert.NoError(t, err)
+
+	s, err := persistence.NewSnapshotter(path, 0, storage.NewStringStore(), listStore)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Save())
+
+	// The items that are not acknowledged are delivered again after a restart
+	newListStore := storage.NewListStore[string]()
+	s, err = persistence.NewSnapshotter(path, 0, storage.NewStringStore(), newListStore)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Load())
+
+	list, err := newListStore.Get("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, list.Value)
+}
+
+func TestSnapshotter_SaveAndLoadJSONLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+
+	jsonListStore := storage.NewListStore[json.RawMessage]()
+	assert.NoError(t, jsonListStore.Set("list", []json.RawMessage{json.RawMessage(`{"a":1}`), json.RawMessage(`[2]`)}, 0))
+
+	s, err := persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithJSONListStore(jsonListStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Save())
+
+	newJSONListStore := storage.NewListStore[json.RawMessage]()
+	s, err = persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithJSONListStore(newJSONListStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Load())
+
+	list, err := newJSONListStore.Get("list")
+	assert.NoError(t, err)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`{"a":1}`), json.RawMessage(`[2]`)}, list.Value)
+}
+
+func TestSnapshotter_SaveAndLoadHashes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+
+	hashStore := storage.NewHashStore()
+	assert.NoError(t, hashStore.Set("user", map[string]string{"name": "ada"}, time.Hour))
+
+	s, err := persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithHashStore(hashStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Save())
+
+	newHashStore := storage.NewHashStore()
+	s, err = persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithHashStore(newHashStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Load())
+
+	val, err := newHashStore.Get("user")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "ada"}, val.Value)
+	assert.False(t, val.ExpiresAt.IsZero())
+}
+
+func TestSnapshotter_SaveAndLoadSets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+
+	setStore := storage.NewSetStore[string]()
+	assert.NoError(t, setStore.Set("tags", []string{"a", "b"}, 0))
+
+	s, err := persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithSetStore(setStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Save())
+
+	newSetStore := storage.NewSetStore[string]()
+	s, err = persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithSetStore(newSetStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Load())
+
+	members, err := newSetStore.Members("tags")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, members)
+}
+
+func TestSnapshotter_SaveAndLoadSortedSets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+
+	zsetStore := storage.NewZSetStore()
+	members := []storage.ScoredMember{{Member: "a", Score: 1.5}, {Member: "b", Score: 2}}
+	assert.NoError(t, zsetStore.Set("board", members, 0))
+
+	s, err := persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithZSetStore(zsetStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Save())
+
+	newZSetStore := storage.NewZSetStore()
+	s, err = persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithZSetStore(newZSetStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Load())
+
+	val, err := newZSetStore.Get("board")
+	assert.NoError(t, err)
+	assert.Equal(t, members, val.Value)
+
+	// The scores can't overflow to infinity, which JSON can't encode
+	_, err = newZSetStore.ZIncrBy("board", "a", math.MaxFloat64)
+	assert.NoError(t, err)
+	_, err = newZSetStore.ZIncrBy("board", "a", math.MaxFloat64)
+	assert.ErrorIs(t, err, storage.ErrInvalidScore)
+	assert.NoError(t, s.Save())
+}
+
+func TestSnapshotter_SaveAndLoadStreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+
+	streamStore := storage.NewStreamStore()
+	id, err := streamStore.XAdd("events", map[string]string{"type": "signup"}, 0)
+	assert.NoError(t, err)
+	_, err = streamStore.XAdd("events", map[string]string{"type": "login"}, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, streamStore.XGroupCreate("events", "workers", true))
+	_, err = streamStore.XReadGroup("events", "workers", "alice", 1)
+	assert.NoError(t, err)
+	pending, err := streamStore.XPending("events", "workers", "")
+	assert.NoError(t, err)
+
+	s, err := persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithStreamStore(streamStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Save())
+
+	newStreamStore := storage.NewStreamStore()
+	s, err = persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string](),
+		persistence.WithStreamStore(newStreamStore))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Load())
+
+	n, err := newStreamStore.XLen("events")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	restored, err := newStreamStore.XPending("events", "workers", "")
+	assert.NoError(t, err)
+	assert.Len(t, restored, 1)
+	assert.Equal(t, id, restored[0].ID)
+	assert.Equal(t, "alice", restored[0].Consumer)
+	assert.True(t, pending[0].DeliveredAt.Equal(restored[0].DeliveredAt))
+
+	// The next read of the group starts after the delivered entry
+	entries, err := newStreamStore.XReadGroup("events", "workers", "bob", 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "login", entries[0].Fields["type"])
+}
+
+func TestSnapshotter_Load(t *testing.T) {
+	t.Run("it should not fail if the file does not exist", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.json")
+		s, err := persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string]())
+		assert.NoError(t, err)
+		assert.NoError(t, s.Load())
+	})
+
+	t.Run("it should return an error if the file is invalid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.json")
+		assert.NoError(t, os.WriteFile(path, []byte("invalid json"), 0o600))
+
+		s, err := persistence.NewSnapshotter(path, 0, storage.NewStringStore(), storage.NewListStore[string]())
+		assert.NoError(t, err)
+		assert.Error(t, s.Load())
+	})
+
+	t.Run("it should return an error if a key is in two stores", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.json")
+		content := `{"version":1,"strings":{"key":{"Value":"v"}},"string_lists":{"key":{"Value":["a"]}}}`
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		stringStore := storage.NewStringStore()
+		s, err := persistence.NewSnapshotter(path, 0, stringStore, storage.NewListStore[string]())
+		assert.NoError(t, err)
+		assert.ErrorContains(t, s.Load(), `"key"`)
+
+		_, err = stringStore.Get("key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should drop entries that expired since the snapshot was taken", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.json")
+		expired := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+		content := `{"version":1,"strings":{` +
+			`"expired-key":{"Value":"v","ExpiresAt":"` + expired + `"},` +
+			`"key":{"Value":"v"}},` +
+			`"string_lists":{"expired-list":{"Value":["a"],"ExpiresAt":"` + expired + `"}}}`
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		stringStore := storage.NewStringStore()
+		listStore := storage.NewListStore[string]()
+		s, err := persistence.NewSnapshotter(path, 0, stringStore, listStore)
+		assert.NoError(t, err)
+		assert.NoError(t, s.Load())
+
+		_, err = stringStore.Get("expired-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+		_, err = stringStore.Get("key")
+		assert.NoError(t, err)
+		_, err = listStore.Get("expired-list")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestSnapshotter_StartStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+	stringStore := storage.NewStringStore()
+
+	s, err := persistence.NewSnapshotter(path, time.Millisecond, stringStore, storage.NewListStore[string]())
+	assert.NoError(t, err)
+
+	s.Start()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	// Stop writes a final snapshot with the latest changes.
+	assert.NoError(t, stringStore.Set("key", "value", 0))
+	assert.NoError(t, s.Stop())
+
+	newStringStore := storage.NewStringStore()
+	s, err = persistence.NewSnapshotter(path, 0, newStringStore, storage.NewListStore[string]())
+	assert.NoError(t, err)
+	assert.NoError(t, s.Load())
+
+	val, err := newStringStore.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", val.Value)
+}
@@ -0,0 +1,19 @@
+package pubsub
+
+type PublishRequest struct {
+	Channel string `json:"channel"`
+	Message string `json:"message"`
+}
+
+type PublishResponse struct {
+	// Receivers is the number of subscriptions that received the message.
+	Receivers int `json:"receivers"`
+}
+
+// MessageEvent is the data of the message events sent to the subscribers.
+type MessageEvent struct {
+	Channel string `json:"channel"`
+	// Pattern is the pattern the channel matched, if the message was received through a pattern.
+	Pattern string `json:"pattern,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,283 @@
+package resp
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"in-memory-storage/internal/jsonschema"
+	"in-memory-storage/storage"
+)
+
+// Error replies shared by several commands, with the same messages as Redis.
+const (
+	errSyntax     = "ERR syntax error"
+	errNotInteger = "ERR value is not an integer or out of range"
+	errWrongType  = "WRONGTYPE Operation against a key holding the wrong kind of value"
+	errNoSuchKey  = "ERR no such key"
+)
+
+// command is a command of the server, looked up by its lowercase name.
+type command struct {
+	// arity is the number of arguments, including the command name,
+	// or -n if the command takes at least n arguments.
+	arity int
+	// noAuth is set for the commands that can be run before authenticating.
+	noAuth bool
+	// run writes the reply of the command, given its arguments without the command name.
+	run func(s *Server, c *conn, args []string)
+}
+
+var commands = map[string]command{
+	// Connection
+	"ping":    {arity: -1, run: (*Server).ping},
+	"echo":    {arity: 2, run: (*Server).echo},
+	"auth":    {arity: -2, noAuth: true, run: (*Server).auth},
+	"hello":   {arity: -1, noAuth: true, run: (*Server).hello},
+	"quit":    {arity: -1, noAuth: true},
+	"select":  {arity: 2, run: (*Server).selectDB},
+	"client":  {arity: -2, run: (*Server).client},
+	"command": {arity: -1, run: (*Server).command},
+
+	// Keys
+	"del":       {arity: -2, run: (*Server).del},
+	"unlink":    {arity: -2, run: (*Server).del},
+	"exists":    {arity: -2, run: (*Server).exists},
+	"type":      {arity: 2, run: (*Server).keyType},
+	"rename":    {arity: 3, run: (*Server).rename},
+	"dbsize":    {arity: 1, run: (*Server).dbSize},
+	"keys":      {arity: 2, run: (*Server).keys},
+	"scan":      {arity: -2, run: (*Server).scan},
+	"expire":    {arity: 3, run: (*Server).expire},
+	"pexpire":   {arity: 3, run: (*Server).pexpire},
+	"expireat":  {arity: 3, run: (*Server).expireAt},
+	"pexpireat": {arity: 3, run: (*Server).pexpireAt},
+	"persist":   {arity: 2, run: (*Server).persist},
+	"ttl":       {arity: 2, run: (*Server).ttl},
+	"pttl":      {arity: 2, run: (*Server).pttl},
+
+	// Strings
+	"get":         {arity: 2, run: (*Server).get},
+	"mget":        {arity: -2, run: (*Server).mget},
+	"set":         {arity: -3, run: (*Server).set},
+	"setnx":       {arity: 3, run: (*Server).setNX},
+	"setex":       {arity: 4, run: (*Server).setEX},
+	"psetex":      {arity: 4, run: (*Server).psetEX},
+	"strlen":      {arity: 2, run: (*Server).strlen},
+	"incr":        {arity: 2, run: (*Server).incr},
+	"decr":        {arity: 2, run: (*Server).decr},
+	"incrby":      {arity: 3, run: (*Server).incrBy},
+	"decrby":      {arity: 3, run: (*Server).decrBy},
+	"incrbyfloat": {arity: 3, run: (*Server).incrByFloat},
+
+	// Lists
+	"lpush":     {arity: -3, run: (*Server).lpush},
+	"rpush":     {arity: -3, run: (*Server).rpush},
+	"lpushx":    {arity: -3, run: (*Server).lpushX},
+	"rpushx":    {arity: -3, run: (*Server).rpushX},
+	"lpop":      {arity: -2, run: (*Server).lpop},
+	"rpop":      {arity: -2, run: (*Server).rpop},
+	"blpop":     {arity: -3, run: (*Server).blpop},
+	"brpop":     {arity: -3, run: (*Server).brpop},
+	"lmove":     {arity: 5, run: (*Server).lmove},
+	"rpoplpush": {arity: 3, run: (*Server).rpoplpush},
+	"lrange":    {arity: 4, run: (*Server).lrange},
+	"llen":      {arity: 2, run: (*Server).llen},
+	"lindex":    {arity: 3, run: (*Server).lindex},
+	"lset":      {arity: 4, run: (*Server).lset},
+	"ltrim":     {arity: 4, run: (*Server).ltrim},
+	"lrem":      {arity: 4, run: (*Server).lrem},
+	"linsert":   {arity: 5, run: (*Server).linsert},
+}
+
+func (s *Server) ping(c *conn, args []string) {
+	switch len(args) {
+	case 0:
+		c.w.simple("PONG")
+	case 1:
+		c.w.bulk(args[0])
+	default:
+		c.w.error("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+func (s *Server) echo(c *conn, args []string) {
+	c.w.bulk(args[0])
+}
+
+func (s *Server) auth(c *conn, args []string) {
+	if s.apiKey == "" {
+		c.w.error("ERR AUTH <password> called without any password configured for the default user")
+		return
+	}
+	switch len(args) {
+	case 1:
+		if s.authenticate(c, "default", args[0]) {
+			c.w.ok()
+		}
+	case 2:
+		if s.authenticate(c, args[0], args[1]) {
+			c.w.ok()
+		}
+	default:
+		c.w.error(errSyntax)
+	}
+}
+
+// hello switches the protocol version of the connection, optionally authenticating it,
+// and replies with the details of the server.
+func (s *Server) hello(c *conn, args []string) {
+	proto := c.w.proto
+	if len(args) > 0 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil {
+			c.w.error("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if v != 2 && v != 3 {
+			c.w.error("NOPROTO unsupported protocol version")
+			return
+		}
+		proto = v
+	}
+
+	var user, password, name string
+	auth := false
+	for i := 1; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "auth":
+			if i+2 >= len(args) {
+				c.w.error(errSyntax)
+				return
+			}
+			user, password, auth = args[i+1], args[i+2], true
+			i += 2
+		case "setname":
+			if i+1 >= len(args) {
+				c.w.error(errSyntax)
+				return
+			}
+			name = args[i+1]
+			i++
+		default:
+			c.w.error(errSyntax)
+			return
+		}
+	}
+
+	if auth && !s.authenticate(c, user, password) {
+		return
+	}
+	if !c.authed {
+		c.w.error("NOAUTH HELLO must be called with the client already authenticated, " +
+			"otherwise the HELLO <proto> AUTH <user> <pass> option can be used")
+		return
+	}
+	if name != "" {
+		c.name = name
+	}
+	c.w.proto = proto
+
+	c.w.mapHeader(7)
+	c.w.bulk("server")
+	c.w.bulk("in-memory-storage")
+	c.w.bulk("version")
+	c.w.bulk("1.0.0")
+	c.w.bulk("proto")
+	c.w.int(int64(proto))
+	c.w.bulk("id")
+	c.w.int(c.id)
+	c.w.bulk("mode")
+	c.w.bulk("standalone")
+	c.w.bulk("role")
+	c.w.bulk("master")
+	c.w.bulk("modules")
+	c.w.array(0)
+}
+
+// selectDB only accepts the database 0, as there is a single keyspace.
+func (s *Server) selectDB(c *conn, args []string) {
+	if args[0] != "0" {
+		c.w.error("ERR DB index is out of range")
+		return
+	}
+	c.w.ok()
+}
+
+// client implements the subcommands of CLIENT used by the client libraries when they connect.
+func (s *Server) client(c *conn, args []string) {
+	switch strings.ToLower(args[0]) {
+	case "setname":
+		if len(args) != 2 {
+			c.w.error(errSyntax)
+			return
+		}
+		c.name = args[1]
+		c.w.ok()
+	case "getname":
+		if c.name == "" {
+			c.w.null()
+			return
+		}
+		c.w.bulk(c.name)
+	case "id":
+		c.w.int(c.id)
+	case "setinfo":
+		c.w.ok()
+	default:
+		c.w.error("ERR unknown subcommand '" + args[0] + "'")
+	}
+}
+
+// command replies with no command details. redis-cli calls it on startup for its hints.
+func (s *Server) command(c *conn, args []string) {
+	c.w.array(0)
+}
+
+// storeError writes the Redis error matching an error of the stores.
+func storeError(c *conn, cmd string, err error) {
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		c.w.error(errWrongType)
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrExpired):
+		c.w.error(errNoSuchKey)
+	case errors.Is(err, storage.ErrNotInteger):
+		c.w.error(errNotInteger)
+	case errors.Is(err, storage.ErrNotFloat):
+		c.w.error("ERR value is not a valid float")
+	case errors.Is(err, storage.ErrOverflow):
+		c.w.error("ERR increment or decrement would overflow")
+	case errors.Is(err, storage.ErrIndexOutOfRange):
+		c.w.error("ERR index out of range")
+	case errors.Is(err, jsonschema.ErrSchemaChange):
+		c.w.error("ERR " + err.Error())
+	default:
+		logError(cmd, err)
+		c.w.error("ERR " + err.Error())
+	}
+}
+
+// isMissing reports whether the error means the key doesn't exist in the store.
+func isMissing(err error) bool {
+	return errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrExpired)
+}
+
+// missing writes the reply of a read of a key that is not in the store: the empty reply, as Redis
+// treats missing keys as empty values, or WRONGTYPE if the key holds another type of value.
+func (s *Server) missing(c *conn, key string, empty func()) {
+	if s.keyspace.Type(key) != storage.TypeNone {
+		c.w.error(errWrongType)
+		return
+	}
+	empty()
+}
+
+// parseInt parses an integer argument, writing the error reply if it's not valid.
+func parseInt(c *conn, arg string) (int64, bool) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		c.w.error(errNotInteger)
+		return 0, false
+	}
+	return n, true
+}
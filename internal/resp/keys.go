@@ -0,0 +1,202 @@
+package resp
+
+import (
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"in-memory-storage/storage"
+)
+
+const (
+	defaultScanCount = 10
+	// keysScanCount is the size of the pages scanned by KEYS.
+	keysScanCount = 1000
+)
+
+func (s *Server) del(c *conn, args []string) {
+	n, err := s.keyspace.Delete(args...)
+	if err != nil {
+		storeError(c, "del", err)
+		return
+	}
+	c.w.int(int64(n))
+}
+
+func (s *Server) exists(c *conn, args []string) {
+	c.w.int(int64(s.keyspace.Exists(args...)))
+}
+
+func (s *Server) keyType(c *conn, args []string) {
+	c.w.simple(string(s.keyspace.Type(args[0])))
+}
+
+func (s *Server) rename(c *conn, args []string) {
+	if err := s.keyspace.Rename(args[0], args[1]); err != nil {
+		storeError(c, "rename", err)
+		return
+	}
+	c.w.ok()
+}
+
+func (s *Server) dbSize(c *conn, args []string) {
+	c.w.int(int64(s.keyspace.Count()))
+}
+
+// keys returns all the keys matching the pattern, scanning the keyspace page by page.
+func (s *Server) keys(c *conn, args []string) {
+	var keys []string
+	cursor := ""
+	for {
+		page, next, err := s.keyspace.Scan(cursor, args[0], keysScanCount)
+		if err != nil {
+			storeError(c, "keys", err)
+			return
+		}
+		keys = append(keys, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	c.w.bulks(keys)
+}
+
+// scan pages through the keyspace. The cursors of the keyspace are keys, so they're sent
+// hex encoded to the client, with 0 as the first and the last cursor as in Redis.
+func (s *Server) scan(c *conn, args []string) {
+	cursor := ""
+	if args[0] != "0" {
+		key, err := hex.DecodeString(args[0])
+		if err != nil || len(key) == 0 {
+			c.w.error("ERR invalid cursor")
+			return
+		}
+		cursor = string(key)
+	}
+
+	match, count := "", defaultScanCount
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			c.w.error(errSyntax)
+			return
+		}
+		switch strings.ToLower(args[i]) {
+		case "match":
+			match = args[i+1]
+		case "count":
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				c.w.error(errNotInteger)
+				return
+			}
+			if n <= 0 {
+				c.w.error(errSyntax)
+				return
+			}
+			count = n
+		default:
+			c.w.error(errSyntax)
+			return
+		}
+	}
+
+	keys, next, err := s.keyspace.Scan(cursor, match, count)
+	if err != nil {
+		storeError(c, "scan", err)
+		return
+	}
+
+	nextCursor := "0"
+	if next != "" {
+		nextCursor = hex.EncodeToString([]byte(next))
+	}
+	c.w.array(2)
+	c.w.bulk(nextCursor)
+	c.w.bulks(keys)
+}
+
+func (s *Server) expire(c *conn, args []string) {
+	s.expireIn(c, "expire", args, time.Second)
+}
+
+func (s *Server) pexpire(c *conn, args []string) {
+	s.expireIn(c, "pexpire", args, time.Millisecond)
+}
+
+// expireIn sets a TTL given in the unit. A TTL that is not positive removes the key.
+func (s *Server) expireIn(c *conn, cmd string, args []string, unit time.Duration) {
+	n, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		c.w.error("ERR invalid expire time in '" + cmd + "' command")
+		return
+	}
+	s.replyExpire(c, cmd, s.keyspace.Expire(args[0], time.Duration(n)*unit))
+}
+
+func (s *Server) expireAt(c *conn, args []string) {
+	n, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	s.replyExpire(c, "expireat", s.keyspace.ExpireAt(args[0], time.Unix(n, 0)))
+}
+
+func (s *Server) pexpireAt(c *conn, args []string) {
+	n, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	s.replyExpire(c, "pexpireat", s.keyspace.ExpireAt(args[0], time.UnixMilli(n)))
+}
+
+// replyExpire replies 1 if the expiration was set and 0 if the key doesn't exist.
+func (s *Server) replyExpire(c *conn, cmd string, err error) {
+	if err != nil {
+		if isMissing(err) {
+			c.w.int(0)
+			return
+		}
+		storeError(c, cmd, err)
+		return
+	}
+	c.w.int(1)
+}
+
+// persist replies 1 if the TTL of the key was removed, and 0 if it doesn't exist or has no TTL.
+func (s *Server) persist(c *conn, args []string) {
+	ttl, err := s.keyspace.TTL(args[0])
+	if err != nil || ttl == storage.NoTTL {
+		c.w.int(0)
+		return
+	}
+	s.replyExpire(c, "persist", s.keyspace.Persist(args[0]))
+}
+
+func (s *Server) ttl(c *conn, args []string) {
+	s.ttlIn(c, args[0], time.Second)
+}
+
+func (s *Server) pttl(c *conn, args []string) {
+	s.ttlIn(c, args[0], time.Millisecond)
+}
+
+// ttlIn replies with the TTL in the unit, rounded, -1 if the key has no TTL or -2 if it doesn't exist.
+func (s *Server) ttlIn(c *conn, key string, unit time.Duration) {
+	ttl, err := s.keyspace.TTL(key)
+	switch {
+	case isMissing(err):
+		c.w.int(-2)
+	case err != nil:
+		storeError(c, "ttl", err)
+	case ttl == storage.NoTTL:
+		c.w.int(-1)
+	default:
+		c.w.int(int64(ttl.Round(unit) / unit))
+	}
+}
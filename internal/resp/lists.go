@@ -0,0 +1,401 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"in-memory-storage/storage"
+)
+
+func (s *Server) lpush(c *conn, args []string) {
+	s.push(c, "lpush", args[0], args[1:], true, true)
+}
+
+func (s *Server) rpush(c *conn, args []string) {
+	s.push(c, "rpush", args[0], args[1:], false, true)
+}
+
+func (s *Server) lpushX(c *conn, args []string) {
+	s.push(c, "lpushx", args[0], args[1:], true, false)
+}
+
+func (s *Server) rpushX(c *conn, args []string) {
+	s.push(c, "rpushx", args[0], args[1:], false, false)
+}
+
+// push adds the values to the start or the end of the list and replies with its length.
+// A missing list is created with the values if create is set, otherwise the reply is 0.
+func (s *Server) push(c *conn, cmd, key string, vals []string, left, create bool) {
+	for {
+		var (
+			n   int
+			err error
+		)
+		if left {
+			n, err = s.lists.LPush(key, vals...)
+		} else {
+			n, err = s.lists.RPush(key, vals...)
+		}
+		if err == nil {
+			c.w.int(int64(n))
+			return
+		}
+		if !isMissing(err) {
+			storeError(c, cmd, err)
+			return
+		}
+		if !create {
+			s.missing(c, key, func() { c.w.int(0) })
+			return
+		}
+
+		list := slices.Clone(vals)
+		if left {
+			// LPUSH adds the values one after the other, so they end up in reverse order.
+			slices.Reverse(list)
+		}
+		err = s.lists.Set(key, list, 0)
+		if err == nil {
+			c.w.int(int64(len(list)))
+			return
+		}
+		// The list was created by another client in the meantime, so push to it.
+		if !errors.Is(err, storage.ErrAlreadyExists) {
+			storeError(c, cmd, err)
+			return
+		}
+	}
+}
+
+func (s *Server) lpop(c *conn, args []string) {
+	s.pop(c, "lpop", args, true)
+}
+
+func (s *Server) rpop(c *conn, args []string) {
+	s.pop(c, "rpop", args, false)
+}
+
+// pop replies with the first or the last item of the list, or with an array of up to
+// count items if a count is given. A missing or empty list replies with null.
+func (s *Server) pop(c *conn, cmd string, args []string, left bool) {
+	key := args[0]
+	if len(args) > 2 {
+		c.w.error(errSyntax)
+		return
+	}
+	if len(args) == 1 {
+		var (
+			val string
+			err error
+		)
+		if left {
+			val, err = s.lists.LPop(key)
+		} else {
+			val, err = s.lists.RPop(key)
+		}
+		switch {
+		case err == nil:
+			c.w.bulk(val)
+		case isMissing(err):
+			s.missing(c, key, c.w.null)
+		case errors.Is(err, storage.ErrEmptyList):
+			c.w.null()
+		default:
+			storeError(c, cmd, err)
+		}
+		return
+	}
+
+	n, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	if n < 0 {
+		c.w.error("ERR value is out of range, must be positive")
+		return
+	}
+	if n == 0 {
+		if _, err := s.lists.Len(key); err != nil {
+			if isMissing(err) {
+				s.missing(c, key, c.w.nullArray)
+				return
+			}
+			storeError(c, cmd, err)
+			return
+		}
+		c.w.array(0)
+		return
+	}
+
+	var (
+		vals []string
+		err  error
+	)
+	if left {
+		vals, err = s.lists.LPopN(key, int(n))
+	} else {
+		vals, err = s.lists.RPopN(key, int(n))
+	}
+	switch {
+	case err == nil:
+		c.w.bulks(vals)
+	case isMissing(err):
+		s.missing(c, key, c.w.nullArray)
+	case errors.Is(err, storage.ErrEmptyList):
+		c.w.nullArray()
+	default:
+		storeError(c, cmd, err)
+	}
+}
+
+func (s *Server) blpop(c *conn, args []string) {
+	s.blockingPop(c, "blpop", args, true)
+}
+
+func (s *Server) brpop(c *conn, args []string) {
+	s.blockingPop(c, "brpop", args, false)
+}
+
+// blockingPop pops from the first non-empty list among the keys, waiting for up to the timeout
+// in seconds, or forever if it's 0. It replies with the key and the item, or with null on timeout.
+func (s *Server) blockingPop(c *conn, cmd string, args []string, left bool) {
+	keys, arg := args[:len(args)-1], args[len(args)-1]
+	secs, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(secs) || math.IsInf(secs, 0) || secs > math.MaxInt64/float64(time.Second) {
+		c.w.error("ERR timeout is not a float or out of range")
+		return
+	}
+	if secs < 0 {
+		c.w.error("ERR timeout is negative")
+		return
+	}
+	// The store only waits for lists, so it would block forever on keys holding other types of values.
+	for _, key := range keys {
+		if t := s.keyspace.Type(key); t != storage.TypeNone && t != storage.TypeList {
+			c.w.error(errWrongType)
+			return
+		}
+	}
+
+	timeout := time.Duration(secs * float64(time.Second))
+	if secs > 0 {
+		timeout = max(timeout, time.Nanosecond)
+	}
+	// Blocking commands are released with a null reply when the server is stopped.
+	var key, val string
+	if left {
+		key, val, err = s.lists.BLPop(s.ctx, timeout, keys...)
+	} else {
+		key, val, err = s.lists.BRPop(s.ctx, timeout, keys...)
+	}
+	switch {
+	case err == nil:
+		c.w.bulks([]string{key, val})
+	case errors.Is(err, storage.ErrTimeout), errors.Is(err, context.Canceled):
+		c.w.nullArray()
+	default:
+		storeError(c, cmd, err)
+	}
+}
+
+func (s *Server) lmove(c *conn, args []string) {
+	srcLeft, ok := parseDirection(c, args[2])
+	if !ok {
+		return
+	}
+	dstLeft, ok := parseDirection(c, args[3])
+	if !ok {
+		return
+	}
+	s.move(c, "lmove", args[0], args[1], srcLeft, dstLeft)
+}
+
+func (s *Server) rpoplpush(c *conn, args []string) {
+	s.move(c, "rpoplpush", args[0], args[1], false, true)
+}
+
+// move moves an item from the source list to the destination list and replies with it,
+// or with null if the source list is missing or empty. A missing destination list is created.
+func (s *Server) move(c *conn, cmd, src, dst string, srcLeft, dstLeft bool) {
+	for {
+		val, err := s.lists.LMove(src, dst, srcLeft, dstLeft)
+		switch {
+		case err == nil:
+			c.w.bulk(val)
+			return
+		case errors.Is(err, storage.ErrEmptyList):
+			c.w.null()
+			return
+		case !isMissing(err):
+			storeError(c, cmd, err)
+			return
+		}
+
+		// Either list is missing: reply with null if it's the source, otherwise create the
+		// destination and try again.
+		n, err := s.lists.Len(src)
+		if err != nil {
+			if isMissing(err) {
+				s.missing(c, src, c.w.null)
+				return
+			}
+			storeError(c, cmd, err)
+			return
+		}
+		if n == 0 {
+			c.w.null()
+			return
+		}
+		if err := s.lists.Set(dst, nil, 0); err != nil && !errors.Is(err, storage.ErrAlreadyExists) {
+			storeError(c, cmd, err)
+			return
+		}
+	}
+}
+
+func (s *Server) lrange(c *conn, args []string) {
+	start, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	stop, ok := parseInt(c, args[2])
+	if !ok {
+		return
+	}
+	vals, err := s.lists.Range(args[0], int(start), int(stop))
+	if err != nil {
+		if isMissing(err) {
+			s.missing(c, args[0], func() { c.w.array(0) })
+			return
+		}
+		storeError(c, "lrange", err)
+		return
+	}
+	c.w.bulks(vals)
+}
+
+func (s *Server) llen(c *conn, args []string) {
+	n, err := s.lists.Len(args[0])
+	if err != nil {
+		if isMissing(err) {
+			s.missing(c, args[0], func() { c.w.int(0) })
+			return
+		}
+		storeError(c, "llen", err)
+		return
+	}
+	c.w.int(int64(n))
+}
+
+func (s *Server) lindex(c *conn, args []string) {
+	i, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	val, err := s.lists.Index(args[0], int(i))
+	switch {
+	case err == nil:
+		c.w.bulk(val)
+	case isMissing(err):
+		s.missing(c, args[0], c.w.null)
+	case errors.Is(err, storage.ErrIndexOutOfRange):
+		c.w.null()
+	default:
+		storeError(c, "lindex", err)
+	}
+}
+
+func (s *Server) lset(c *conn, args []string) {
+	i, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	err := s.lists.SetIndex(args[0], int(i), args[2])
+	switch {
+	case err == nil:
+		c.w.ok()
+	case isMissing(err):
+		s.missing(c, args[0], func() { c.w.error(errNoSuchKey) })
+	default:
+		storeError(c, "lset", err)
+	}
+}
+
+func (s *Server) ltrim(c *conn, args []string) {
+	start, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	stop, ok := parseInt(c, args[2])
+	if !ok {
+		return
+	}
+	err := s.lists.Trim(args[0], int(start), int(stop))
+	switch {
+	case err == nil:
+		c.w.ok()
+	case isMissing(err):
+		s.missing(c, args[0], c.w.ok)
+	default:
+		storeError(c, "ltrim", err)
+	}
+}
+
+func (s *Server) lrem(c *conn, args []string) {
+	count, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	n, err := s.lists.RemoveValue(args[0], int(count), args[2])
+	switch {
+	case err == nil:
+		c.w.int(int64(n))
+	case isMissing(err):
+		s.missing(c, args[0], func() { c.w.int(0) })
+	default:
+		storeError(c, "lrem", err)
+	}
+}
+
+// linsert replies with the length of the list after the insert, -1 if the pivot
+// is not in the list or 0 if the list is missing.
+func (s *Server) linsert(c *conn, args []string) {
+	var before bool
+	switch strings.ToLower(args[1]) {
+	case "before":
+		before = true
+	case "after":
+	default:
+		c.w.error(errSyntax)
+		return
+	}
+	n, err := s.lists.Insert(args[0], before, args[2], args[3])
+	switch {
+	case err == nil:
+		c.w.int(int64(n))
+	case isMissing(err):
+		s.missing(c, args[0], func() { c.w.int(0) })
+	case errors.Is(err, storage.ErrPivotNotFound):
+		c.w.int(-1)
+	default:
+		storeError(c, "linsert", err)
+	}
+}
+
+// parseDirection parses the LEFT or RIGHT argument of LMOVE, writing the error reply if it's not valid.
+func parseDirection(c *conn, arg string) (left bool, ok bool) {
+	switch strings.ToLower(arg) {
+	case "left":
+		return true, true
+	case "right":
+		return false, true
+	default:
+		c.w.error(errSyntax)
+		return false, false
+	}
+}
@@ -0,0 +1,202 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxArgs is the maximum number of arguments of a command.
+	maxArgs = 1 << 20
+	// maxBulkLen is the maximum length of an argument, the same as Redis.
+	maxBulkLen = 512 << 20
+	// maxInlineLen is the maximum length of an inline command.
+	maxInlineLen = 64 << 10
+	// maxUnauthArgs and maxUnauthBulkLen are the limits of the commands of the clients that have not
+	// authenticated yet, the same as Redis, so they can't make the server allocate large buffers.
+	maxUnauthArgs    = 10
+	maxUnauthBulkLen = 16 << 10
+	// bulkChunkLen is the size of the buffer allocated up front for an argument. Longer arguments
+	// grow their buffer as they're read, instead of trusting the length sent by the client.
+	bulkChunkLen = 64 << 10
+)
+
+// errProtocol is returned when a client sends something that is not a valid command.
+// The connection is closed after replying with it, as the rest of the stream can't be parsed.
+var errProtocol = errors.New("Protocol error")
+
+// readCommand reads the next command: an array of bulk strings, as sent by the client libraries,
+// or an inline command, a line of space-separated arguments as typed in telnet.
+// An empty inline command, or an array with no elements, returns no arguments and no error.
+// The clients that have not authenticated are limited to small commands.
+func readCommand(r *bufio.Reader, authed bool) ([]string, error) {
+	prefix, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if prefix[0] != '*' {
+		line, err := readLine(r, maxInlineLen)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Fields(line), nil
+	}
+
+	line, err := readLine(r, maxInlineLen)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > maxArgs {
+		return nil, fmt.Errorf("%w: invalid multibulk length", errProtocol)
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	if !authed && n > maxUnauthArgs {
+		return nil, fmt.Errorf("%w: unauthenticated multibulk length", errProtocol)
+	}
+
+	args := make([]string, 0, min(n, 1024))
+	for range n {
+		line, err := readLine(r, maxInlineLen)
+		if err != nil {
+			return nil, err
+		}
+		if line == "" || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got '%.1s'", errProtocol, line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxBulkLen {
+			return nil, fmt.Errorf("%w: invalid bulk length", errProtocol)
+		}
+		if !authed && size > maxUnauthBulkLen {
+			return nil, fmt.Errorf("%w: unauthenticated bulk length", errProtocol)
+		}
+
+		var buf bytes.Buffer
+		buf.Grow(min(size+2, bulkChunkLen))
+		if _, err := io.CopyN(&buf, r, int64(size)+2); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		b := buf.Bytes()
+		if b[size] != '\r' || b[size+1] != '\n' {
+			return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", errProtocol)
+		}
+		args = append(args, string(b[:size]))
+	}
+	return args, nil
+}
+
+// readLine reads a line terminated by CRLF, or only LF as sent by some telnet clients,
+// and returns it without the terminator.
+func readLine(r *bufio.Reader, limit int) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > limit {
+			return "", fmt.Errorf("%w: too big request", errProtocol)
+		}
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return "", err
+		}
+	}
+	line = line[:len(line)-1]
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return string(line), nil
+}
+
+// writer writes the replies of a connection in the protocol version it has chosen with HELLO.
+// RESP2 has no null, map or set types, so they are written as their RESP2 equivalents.
+type writer struct {
+	*bufio.Writer
+	proto int
+}
+
+func (w *writer) simple(s string) {
+	w.WriteByte('+')
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func (w *writer) ok() {
+	w.simple("OK")
+}
+
+// error writes an error reply. The message starts with the error code, like ERR or WRONGTYPE.
+func (w *writer) error(msg string) {
+	w.WriteByte('-')
+	w.WriteString(msg)
+	w.WriteString("\r\n")
+}
+
+func (w *writer) int(n int64) {
+	w.WriteByte(':')
+	w.WriteString(strconv.FormatInt(n, 10))
+	w.WriteString("\r\n")
+}
+
+func (w *writer) bulk(s string) {
+	w.WriteByte('$')
+	w.WriteString(strconv.Itoa(len(s)))
+	w.WriteString("\r\n")
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+// null writes a missing value, a null bulk string in RESP2.
+func (w *writer) null() {
+	if w.proto == 3 {
+		w.WriteString("_\r\n")
+		return
+	}
+	w.WriteString("$-1\r\n")
+}
+
+// nullArray writes a missing array, a null array in RESP2.
+func (w *writer) nullArray() {
+	if w.proto == 3 {
+		w.WriteString("_\r\n")
+		return
+	}
+	w.WriteString("*-1\r\n")
+}
+
+// array writes the header of an array of n elements, which must be written next.
+func (w *writer) array(n int) {
+	w.WriteByte('*')
+	w.WriteString(strconv.Itoa(n))
+	w.WriteString("\r\n")
+}
+
+// mapHeader writes the header of a map of n pairs, a flat array of keys and values in RESP2.
+func (w *writer) mapHeader(n int) {
+	if w.proto == 3 {
+		w.WriteByte('%')
+		w.WriteString(strconv.Itoa(n))
+		w.WriteString("\r\n")
+		return
+	}
+	w.array(2 * n)
+}
+
+func (w *writer) bulks(vals []string) {
+	w.array(len(vals))
+	for _, val := range vals {
+		w.bulk(val)
+	}
+}
@@ -0,0 +1,274 @@
+// Package resp provides a TCP server that speaks the Redis serialization protocol (RESP2 and RESP3),
+// so redis-cli and the Redis client libraries can be used against the stores.
+// It maps the Redis string, list and generic key commands onto the storage interfaces.
+package resp
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"in-memory-storage/storage"
+)
+
+// ErrServerClosed is returned by Start and Serve after the server has been stopped.
+var ErrServerClosed = errors.New("resp: server closed")
+
+// Server is a RESP server over a StringStore and a ListStore.
+type Server struct {
+	addr     string
+	strings  storage.StringStore
+	lists    storage.ListStore[string]
+	keyspace storage.Keyspace
+	apiKey   string
+
+	// ctx is cancelled when the server is stopped, to release the blocking commands.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+	lastID    atomic.Int64
+}
+
+// conn is the state of a client connection.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *writer
+
+	id     int64
+	name   string
+	authed bool
+}
+
+// NewServer creates a new RESP server with the provided port.
+// Clients must authenticate with AUTH or HELLO using the API key, unless it's empty.
+// It returns an error if the port or any of the stores is missing.
+func NewServer(
+	port string,
+	stringStore storage.StringStore,
+	listStore storage.ListStore[string],
+	keyspace storage.Keyspace,
+	apiKey string,
+) (*Server, error) {
+	if port == "" {
+		return nil, errors.New("missing port")
+	}
+	if stringStore == nil {
+		return nil, errors.New("missing string store")
+	}
+	if listStore == nil {
+		return nil, errors.New("missing string list store")
+	}
+	if keyspace == nil {
+		return nil, errors.New("missing keyspace")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		addr:      ":" + port,
+		strings:   stringStore,
+		lists:     listStore,
+		keyspace:  keyspace,
+		apiKey:    apiKey,
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: map[net.Listener]struct{}{},
+		conns:     map[*conn]struct{}{},
+	}, nil
+}
+
+// Start listens on the port of the server and serves the connections.
+// It returns ErrServerClosed once the server is stopped.
+func (s *Server) Start() error {
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts the connections of the listener and serves them, each in its own goroutine.
+// It closes the listener when the server is stopped and returns ErrServerClosed.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l) {
+		_ = l.Close()
+		return ErrServerClosed
+	}
+
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		c := &conn{
+			Conn:   nc,
+			r:      bufio.NewReader(nc),
+			w:      &writer{Writer: bufio.NewWriter(nc), proto: 2},
+			id:     s.lastID.Add(1),
+			authed: s.apiKey == "",
+		}
+		if !s.trackConn(c) {
+			_ = nc.Close()
+			return ErrServerClosed
+		}
+		go s.serveConn(c)
+	}
+}
+
+// Stop gracefully stops the server: it stops accepting connections, closes the idle ones and
+// releases the blocking commands, then waits for the commands being run to reply.
+// The remaining connections are closed if the context is done first.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		_ = l.Close()
+	}
+	for c := range s.conns {
+		// Reads fail right away, so the connections close after replying to the current command.
+		_ = c.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for c := range s.conns {
+			_ = c.Close()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) serveConn(c *conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		_ = c.Close()
+	}()
+
+	for {
+		args, err := readCommand(c.r, c.authed)
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				c.w.error("ERR " + err.Error())
+				_ = c.w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.dispatch(c, args)
+		// Replies are flushed once all the pipelined commands have been read.
+		if c.r.Buffered() == 0 || quit {
+			if err := c.w.Flush(); err != nil {
+				return
+			}
+		}
+		if quit || s.isClosed() {
+			return
+		}
+	}
+}
+
+// dispatch runs the command and writes its reply. It returns true if the connection must be closed.
+func (s *Server) dispatch(c *conn, args []string) bool {
+	name := strings.ToLower(args[0])
+	cmd, ok := commands[name]
+	if !ok {
+		c.w.error("ERR unknown command '" + args[0] + "'")
+		return false
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		c.w.error("ERR wrong number of arguments for '" + name + "' command")
+		return false
+	}
+	if !c.authed && !cmd.noAuth {
+		c.w.error("NOAUTH Authentication required.")
+		return false
+	}
+
+	if name == "quit" {
+		c.w.ok()
+		return true
+	}
+	cmd.run(s, c, args[1:])
+	return false
+}
+
+// authenticate checks the credentials of AUTH and HELLO. Only the default user exists.
+func (s *Server) authenticate(c *conn, user, password string) bool {
+	if user != "default" || subtle.ConstantTimeCompare([]byte(password), []byte(s.apiKey)) != 1 {
+		c.w.error("WRONGPASS invalid username-password pair or user is disabled.")
+		return false
+	}
+	c.authed = true
+	return true
+}
+
+func (s *Server) track(l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) trackConn(c *conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// logError logs the errors of the stores that don't map to a Redis error.
+func logError(cmd string, err error) {
+	log.Printf("ERROR: failed to run %s: %v", cmd, err)
+}
@@ -0,0 +1,414 @@
+package resp_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"in-memory-storage/internal/resp"
+	"in-memory-storage/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// client is a raw RESP client that returns the replies as they're written on the wire.
+type client struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func newServer(t *testing.T, apiKey string) (*resp.Server, string) {
+	t.Helper()
+	keyspace := storage.NewKeyspace()
+	stringStore := storage.NewStringStore(storage.WithKeyspace(keyspace))
+	listStore := storage.NewListStore[string](storage.WithKeyspace(keyspace))
+	srv, err := resp.NewServer("6379", stringStore, listStore, keyspace, apiKey)
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Stop(context.Background()) })
+
+	return srv, l.Addr().String()
+}
+
+func dial(t *testing.T, addr string) *client {
+	t.Helper()
+	nc, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = nc.Close() })
+	require.NoError(t, nc.SetDeadline(time.Now().Add(5*time.Second)))
+	return &client{Conn: nc, r: bufio.NewReader(nc)}
+}
+
+func (c *client) send(t *testing.T, args ...string) {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	_, err := c.Write([]byte(b.String()))
+	require.NoError(t, err)
+}
+
+func (c *client) do(t *testing.T, args ...string) string {
+	t.Helper()
+	c.send(t, args...)
+	return c.read(t)
+}
+
+// read reads a whole reply, including the elements of arrays and maps.
+func (c *client) read(t *testing.T) string {
+	t.Helper()
+	line, err := c.r.ReadString('\n')
+	require.NoError(t, err)
+
+	switch line[0] {
+	case '$':
+		n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		require.NoError(t, err)
+		if n < 0 {
+			return line
+		}
+		buf := make([]byte, n+2)
+		_, err = io.ReadFull(c.r, buf)
+		require.NoError(t, err)
+		return line + string(buf)
+	case '*', '%':
+		n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		require.NoError(t, err)
+		if line[0] == '%' {
+			n *= 2
+		}
+		for range n {
+			line += c.read(t)
+		}
+		return line
+	default:
+		return line
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	keyspace := storage.NewKeyspace()
+	stringStore := storage.NewStringStore(storage.WithKeyspace(keyspace))
+	listStore := storage.NewListStore[string](storage.WithKeyspace(keyspace))
+
+	testCases := map[string]struct {
+		port        string
+		stringStore storage.StringStore
+		listStore   storage.ListStore[string]
+		keyspace    storage.Keyspace
+		expectedErr error
+	}{
+		"it should return an error if the port is missing": {
+			stringStore: stringStore,
+			listStore:   listStore,
+			keyspace:    keyspace,
+			expectedErr: errors.New("missing port"),
+		},
+		"it should return an error if the string store is missing": {
+			port:        "6379",
+			listStore:   listStore,
+			keyspace:    keyspace,
+			expectedErr: errors.New("missing string store"),
+		},
+		"it should return an error if the list store is missing": {
+			port:        "6379",
+			stringStore: stringStore,
+			keyspace:    keyspace,
+			expectedErr: errors.New("missing string list store"),
+		},
+		"it should return an error if the keyspace is missing": {
+			port:        "6379",
+			stringStore: stringStore,
+			listStore:   listStore,
+			expectedErr: errors.New("missing keyspace"),
+		},
+		"it should create the server": {
+			port:        "6379",
+			stringStore: stringStore,
+			listStore:   listStore,
+			keyspace:    keyspace,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			srv, err := resp.NewServer(tc.port, tc.stringStore, tc.listStore, tc.keyspace, "")
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedErr == nil, srv != nil)
+		})
+	}
+}
+
+func TestServer_Commands(t *testing.T) {
+	testCases := map[string]struct {
+		commands [][]string
+		expected []string
+	}{
+		"it should reply to PING and ECHO": {
+			commands: [][]string{{"PING"}, {"ping", "hello"}, {"ECHO", "hi"}},
+			expected: []string{"+PONG\r\n", "$5\r\nhello\r\n", "$2\r\nhi\r\n"},
+		},
+		"it should reply with an error to unknown commands and wrong arity": {
+			commands: [][]string{{"FLUSHALL"}, {"GET"}},
+			expected: []string{
+				"-ERR unknown command 'FLUSHALL'\r\n",
+				"-ERR wrong number of arguments for 'get' command\r\n",
+			},
+		},
+		"it should set and get strings": {
+			commands: [][]string{{"SET", "key", "value"}, {"GET", "key"}, {"GET", "missing"}, {"STRLEN", "key"}},
+			expected: []string{"+OK\r\n", "$5\r\nvalue\r\n", "$-1\r\n", ":5\r\n"},
+		},
+		"it should replace strings unless NX is given": {
+			commands: [][]string{
+				{"SET", "key", "a"}, {"SET", "key", "b"}, {"SET", "key", "c", "NX"},
+				{"SET", "other", "d", "XX"}, {"GET", "key"},
+			},
+			expected: []string{"+OK\r\n", "+OK\r\n", "$-1\r\n", "$-1\r\n", "$1\r\nb\r\n"},
+		},
+		"it should reply with an error to invalid SET options": {
+			commands: [][]string{
+				{"SET", "key", "a", "NX", "XX"}, {"SET", "key", "a", "EX", "0"},
+				{"SET", "key", "a", "EX", "10", "KEEPTTL"}, {"SET", "key", "a", "PX", "ten"},
+			},
+			expected: []string{
+				"-ERR syntax error\r\n",
+				"-ERR invalid expire time in 'set' command\r\n",
+				"-ERR syntax error\r\n",
+				"-ERR value is not an integer or out of range\r\n",
+			},
+		},
+		"it should set TTLs and keep them with KEEPTTL": {
+			commands: [][]string{
+				{"SET", "key", "a", "EX", "100"}, {"TTL", "key"}, {"SET", "key", "b", "KEEPTTL"},
+				{"TTL", "key"}, {"PERSIST", "key"}, {"TTL", "key"}, {"TTL", "missing"},
+			},
+			expected: []string{"+OK\r\n", ":100\r\n", "+OK\r\n", ":100\r\n", ":1\r\n", ":-1\r\n", ":-2\r\n"},
+		},
+		"it should expire keys": {
+			commands: [][]string{
+				{"SET", "key", "a"}, {"EXPIRE", "key", "50"}, {"PTTL", "key"}, {"EXPIRE", "missing", "50"},
+				{"EXPIRE", "key", "0"}, {"EXISTS", "key"},
+			},
+			expected: []string{"+OK\r\n", ":1\r\n", ":50000\r\n", ":0\r\n", ":1\r\n", ":0\r\n"},
+		},
+		"it should increment strings": {
+			commands: [][]string{
+				{"INCR", "n"}, {"INCRBY", "n", "10"}, {"DECR", "n"}, {"INCRBYFLOAT", "n", "0.5"},
+				{"SET", "s", "a"}, {"INCR", "s"},
+			},
+			expected: []string{
+				":1\r\n", ":11\r\n", ":10\r\n", "$4\r\n10.5\r\n", "+OK\r\n",
+				"-ERR value is not an integer or out of range\r\n",
+			},
+		},
+		"it should delete keys": {
+			commands: [][]string{{"SET", "a", "1"}, {"RPUSH", "b", "1"}, {"DEL", "a", "b", "c"}, {"DBSIZE"}},
+			expected: []string{"+OK\r\n", ":1\r\n", ":2\r\n", ":0\r\n"},
+		},
+		"it should push, range and pop lists": {
+			commands: [][]string{
+				{"LPUSH", "list", "a", "b"}, {"RPUSH", "list", "c"}, {"LRANGE", "list", "0", "-1"},
+				{"LPOP", "list"}, {"RPOP", "list", "5"}, {"LPOP", "list"}, {"LLEN", "list"},
+				{"LRANGE", "missing", "0", "-1"},
+			},
+			expected: []string{
+				":2\r\n", ":3\r\n", "*3\r\n$1\r\nb\r\n$1\r\na\r\n$1\r\nc\r\n",
+				"$1\r\nb\r\n", "*2\r\n$1\r\nc\r\n$1\r\na\r\n", "$-1\r\n", ":0\r\n", "*0\r\n",
+			},
+		},
+		"it should only push to existing lists with LPUSHX and RPUSHX": {
+			commands: [][]string{{"LPUSHX", "list", "a"}, {"RPUSH", "list", "a"}, {"RPUSHX", "list", "b"}},
+			expected: []string{":0\r\n", ":1\r\n", ":2\r\n"},
+		},
+		"it should move items between lists": {
+			commands: [][]string{
+				{"RPUSH", "src", "a", "b"}, {"LMOVE", "src", "dst", "LEFT", "RIGHT"}, {"RPOPLPUSH", "src", "dst"},
+				{"LRANGE", "dst", "0", "-1"}, {"RPOPLPUSH", "src", "dst"}, {"LMOVE", "src", "dst", "UP", "LEFT"},
+			},
+			expected: []string{
+				":2\r\n", "$1\r\na\r\n", "$1\r\nb\r\n", "*2\r\n$1\r\nb\r\n$1\r\na\r\n", "$-1\r\n",
+				"-ERR syntax error\r\n",
+			},
+		},
+		"it should update lists by position and value": {
+			commands: [][]string{
+				{"RPUSH", "list", "a", "b", "c", "b"}, {"LINDEX", "list", "-1"}, {"LINDEX", "list", "10"},
+				{"LSET", "list", "0", "z"}, {"LSET", "missing", "0", "z"}, {"LINSERT", "list", "BEFORE", "c", "x"},
+				{"LINSERT", "list", "AFTER", "none", "x"}, {"LREM", "list", "0", "b"}, {"LTRIM", "list", "0", "1"},
+				{"LRANGE", "list", "0", "-1"},
+			},
+			expected: []string{
+				":4\r\n", "$1\r\nb\r\n", "$-1\r\n", "+OK\r\n", "-ERR no such key\r\n", ":5\r\n", ":-1\r\n",
+				":2\r\n", "+OK\r\n", "*2\r\n$1\r\nz\r\n$1\r\nx\r\n",
+			},
+		},
+		"it should reply with WRONGTYPE to commands against keys of another type": {
+			commands: [][]string{
+				{"SET", "key", "a"}, {"LPUSH", "key", "a"}, {"LRANGE", "key", "0", "-1"},
+				{"RPUSH", "list", "a"}, {"GET", "list"}, {"TYPE", "key"}, {"TYPE", "list"},
+			},
+			expected: []string{
+				"+OK\r\n",
+				"-WRONGTYPE Operation against a key holding the wrong kind of value\r\n",
+				"-WRONGTYPE Operation against a key holding the wrong kind of value\r\n",
+				":1\r\n",
+				"-WRONGTYPE Operation against a key holding the wrong kind of value\r\n",
+				"+string\r\n", "+list\r\n",
+			},
+		},
+		"it should pop from lists with a timeout": {
+			commands: [][]string{{"RPUSH", "list", "a"}, {"BLPOP", "empty", "list", "1"}, {"BRPOP", "list", "0.01"}},
+			expected: []string{":1\r\n", "*2\r\n$4\r\nlist\r\n$1\r\na\r\n", "*-1\r\n"},
+		},
+		"it should scan the keys": {
+			commands: [][]string{
+				{"SET", "a", "1"}, {"SET", "b", "2"}, {"RPUSH", "c", "3"}, {"SCAN", "0", "COUNT", "2"},
+				{"SCAN", "62", "MATCH", "[bc]"}, {"KEYS", "*"},
+			},
+			expected: []string{
+				"+OK\r\n", "+OK\r\n", ":1\r\n", "*2\r\n$2\r\n62\r\n*2\r\n$1\r\na\r\n$1\r\nb\r\n",
+				"*2\r\n$1\r\n0\r\n*1\r\n$1\r\nc\r\n", "*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n",
+			},
+		},
+		"it should switch to RESP3 with HELLO": {
+			commands: [][]string{{"HELLO", "3"}, {"GET", "missing"}, {"LPOP", "missing", "2"}},
+			expected: []string{
+				"%7\r\n$6\r\nserver\r\n$17\r\nin-memory-storage\r\n$7\r\nversion\r\n$5\r\n1.0.0\r\n" +
+					"$5\r\nproto\r\n:3\r\n$2\r\nid\r\n:1\r\n$4\r\nmode\r\n$10\r\nstandalone\r\n" +
+					"$4\r\nrole\r\n$6\r\nmaster\r\n$7\r\nmodules\r\n*0\r\n",
+				"_\r\n", "_\r\n",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, addr := newServer(t, "")
+			c := dial(t, addr)
+			for i, args := range tc.commands {
+				assert.Equal(t, tc.expected[i], c.do(t, args...), strings.Join(args, " "))
+			}
+		})
+	}
+}
+
+func TestServer_Auth(t *testing.T) {
+	_, addr := newServer(t, "secret")
+
+	t.Run("it should require authentication", func(t *testing.T) {
+		c := dial(t, addr)
+		assert.Equal(t, "-NOAUTH Authentication required.\r\n", c.do(t, "PING"))
+		assert.Equal(t, "-WRONGPASS invalid username-password pair or user is disabled.\r\n", c.do(t, "AUTH", "wrong"))
+		assert.Equal(t, "+OK\r\n", c.do(t, "AUTH", "secret"))
+		assert.Equal(t, "+PONG\r\n", c.do(t, "PING"))
+	})
+
+	t.Run("it should authenticate with HELLO", func(t *testing.T) {
+		c := dial(t, addr)
+		assert.Contains(t, c.do(t, "HELLO", "2", "AUTH", "default", "secret"), "in-memory-storage")
+		assert.Equal(t, "+OK\r\n", c.do(t, "SET", "key", "value"))
+	})
+}
+
+func TestServer_Pipelining(t *testing.T) {
+	_, addr := newServer(t, "")
+	c := dial(t, addr)
+
+	// Inline commands, as sent by telnet, are accepted too.
+	_, err := c.Write([]byte("SET key value\r\nGET key\r\nPING\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "+OK\r\n", c.read(t))
+	assert.Equal(t, "$5\r\nvalue\r\n", c.read(t))
+	assert.Equal(t, "+PONG\r\n", c.read(t))
+}
+
+func TestServer_Protocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiKey   string
+		request  string
+		expected string
+	}{
+		{
+			name:     "it should skip a null array",
+			request:  "*-1\r\nPING\r\n",
+			expected: "+PONG\r\n",
+		},
+		{
+			name:     "it should skip an empty array",
+			request:  "*0\r\nPING\r\n",
+			expected: "+PONG\r\n",
+		},
+		{
+			name:     "it should reject an invalid multibulk length",
+			request:  "*x\r\n",
+			expected: "-ERR Protocol error: invalid multibulk length\r\n",
+		},
+		{
+			name:     "it should reject a bulk string not terminated by CRLF",
+			request:  "*1\r\n$4\r\nPINGxx",
+			expected: "-ERR Protocol error: bulk string not terminated by CRLF\r\n",
+		},
+		{
+			name:     "it should limit the number of arguments before authentication",
+			apiKey:   "secret",
+			request:  "*11\r\n",
+			expected: "-ERR Protocol error: unauthenticated multibulk length\r\n",
+		},
+		{
+			name:     "it should limit the length of the arguments before authentication",
+			apiKey:   "secret",
+			request:  "*1\r\n$536870912\r\n",
+			expected: "-ERR Protocol error: unauthenticated bulk length\r\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, addr := newServer(t, tc.apiKey)
+			c := dial(t, addr)
+			_, err := c.Write([]byte(tc.request))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, c.read(t))
+		})
+	}
+
+	t.Run("it should accept long arguments after authentication", func(t *testing.T) {
+		_, addr := newServer(t, "secret")
+		c := dial(t, addr)
+		assert.Equal(t, "+OK\r\n", c.do(t, "AUTH", "secret"))
+		value := strings.Repeat("a", 100<<10)
+		assert.Equal(t, "+OK\r\n", c.do(t, "SET", "key", value))
+		assert.Equal(t, "$"+strconv.Itoa(len(value))+"\r\n"+value+"\r\n", c.do(t, "GET", "key"))
+	})
+}
+
+func TestServer_Stop(t *testing.T) {
+	srv, addr := newServer(t, "")
+	c := dial(t, addr)
+	assert.Equal(t, "+PONG\r\n", c.do(t, "PING"))
+
+	// The blocking pop is released when the server is stopped.
+	c.send(t, "BLPOP", "list", "0")
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, srv.Stop(context.Background()))
+	assert.Equal(t, "*-1\r\n", c.read(t))
+
+	_, err := c.r.ReadString('\n')
+	assert.Error(t, err)
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err)
+}
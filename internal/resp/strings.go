@@ -0,0 +1,216 @@
+package resp
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"in-memory-storage/storage"
+)
+
+func (s *Server) get(c *conn, args []string) {
+	v, err := s.strings.Get(args[0])
+	if err != nil {
+		if isMissing(err) {
+			s.missing(c, args[0], c.w.null)
+			return
+		}
+		storeError(c, "get", err)
+		return
+	}
+	c.w.bulk(v.Value)
+}
+
+// mget replies with the value of every key, or null for the keys that are not strings.
+func (s *Server) mget(c *conn, args []string) {
+	c.w.array(len(args))
+	for _, key := range args {
+		v, err := s.strings.Get(key)
+		if err != nil {
+			c.w.null()
+			continue
+		}
+		c.w.bulk(v.Value)
+	}
+}
+
+// set supports the NX, XX, EX, PX, EXAT, PXAT and KEEPTTL options. Unlike Redis,
+// a key that holds another type of value is not replaced.
+func (s *Server) set(c *conn, args []string) {
+	key, val := args[0], args[1]
+	var nx, xx, keepTTL, expires bool
+	var ttl time.Duration
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToLower(args[i]); opt {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "keepttl":
+			keepTTL = true
+		case "ex", "px", "exat", "pxat":
+			if expires || i+1 >= len(args) {
+				c.w.error(errSyntax)
+				return
+			}
+			n, ok := parseInt(c, args[i+1])
+			if !ok {
+				return
+			}
+			if n <= 0 || n > math.MaxInt64/int64(time.Second) {
+				c.w.error("ERR invalid expire time in 'set' command")
+				return
+			}
+			switch opt {
+			case "ex":
+				ttl = time.Duration(n) * time.Second
+			case "px":
+				ttl = time.Duration(n) * time.Millisecond
+			case "exat":
+				ttl = time.Until(time.Unix(n, 0))
+			case "pxat":
+				ttl = time.Until(time.UnixMilli(n))
+			}
+			// A time in the past sets a key that has already expired.
+			ttl = max(ttl, time.Nanosecond)
+			expires = true
+			i++
+		default:
+			c.w.error(errSyntax)
+			return
+		}
+	}
+	if (nx && xx) || (keepTTL && expires) {
+		c.w.error(errSyntax)
+		return
+	}
+
+	mode := storage.SetAlways
+	switch {
+	case nx:
+		mode = storage.SetIfNotExists
+	case xx:
+		mode = storage.SetIfExists
+	}
+	opts := []storage.SetOption{storage.WithSetMode(mode)}
+	if keepTTL {
+		opts = append(opts, storage.WithKeepTTL())
+	}
+
+	err := s.strings.Set(key, val, ttl, opts...)
+	switch {
+	case err == nil:
+		c.w.ok()
+	case errors.Is(err, storage.ErrAlreadyExists), errors.Is(err, storage.ErrNotFound):
+		// The condition of NX or XX is not met.
+		c.w.null()
+	default:
+		storeError(c, "set", err)
+	}
+}
+
+func (s *Server) setNX(c *conn, args []string) {
+	ok, err := s.strings.SetNX(args[0], args[1], 0)
+	if err != nil {
+		storeError(c, "setnx", err)
+		return
+	}
+	if ok {
+		c.w.int(1)
+		return
+	}
+	c.w.int(0)
+}
+
+func (s *Server) setEX(c *conn, args []string) {
+	s.setWithTTL(c, "setex", args, time.Second)
+}
+
+func (s *Server) psetEX(c *conn, args []string) {
+	s.setWithTTL(c, "psetex", args, time.Millisecond)
+}
+
+// setWithTTL sets the key with a TTL in the unit, given before the value.
+func (s *Server) setWithTTL(c *conn, cmd string, args []string, unit time.Duration) {
+	n, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	if n <= 0 || n > math.MaxInt64/int64(unit) {
+		c.w.error("ERR invalid expire time in '" + cmd + "' command")
+		return
+	}
+	err := s.strings.Set(args[0], args[2], time.Duration(n)*unit, storage.WithSetMode(storage.SetAlways))
+	if err != nil {
+		storeError(c, cmd, err)
+		return
+	}
+	c.w.ok()
+}
+
+func (s *Server) strlen(c *conn, args []string) {
+	v, err := s.strings.Get(args[0])
+	if err != nil {
+		if isMissing(err) {
+			s.missing(c, args[0], func() { c.w.int(0) })
+			return
+		}
+		storeError(c, "strlen", err)
+		return
+	}
+	c.w.int(int64(len(v.Value)))
+}
+
+func (s *Server) incr(c *conn, args []string) {
+	s.incrBy1(c, "incr", args[0], 1)
+}
+
+func (s *Server) decr(c *conn, args []string) {
+	s.incrBy1(c, "decr", args[0], -1)
+}
+
+func (s *Server) incrBy(c *conn, args []string) {
+	n, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	s.incrBy1(c, "incrby", args[0], n)
+}
+
+func (s *Server) decrBy(c *conn, args []string) {
+	n, ok := parseInt(c, args[1])
+	if !ok {
+		return
+	}
+	if n == math.MinInt64 {
+		c.w.error("ERR decrement would overflow")
+		return
+	}
+	s.incrBy1(c, "decrby", args[0], -n)
+}
+
+// incrBy1 increments the key by delta and replies with the new value.
+func (s *Server) incrBy1(c *conn, cmd, key string, delta int64) {
+	val, err := s.strings.IncrBy(key, delta)
+	if err != nil {
+		storeError(c, cmd, err)
+		return
+	}
+	c.w.int(val)
+}
+
+func (s *Server) incrByFloat(c *conn, args []string) {
+	delta, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		c.w.error("ERR value is not a valid float")
+		return
+	}
+	val, err := s.strings.IncrByFloat(args[0], delta)
+	if err != nil {
+		storeError(c, "incrbyfloat", err)
+		return
+	}
+	c.w.bulk(strconv.FormatFloat(val, 'f', -1, 64))
+}
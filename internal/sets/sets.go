@@ -0,0 +1,58 @@
+package sets
+
+import "time"
+
+type GetResponse struct {
+	Members   []string `json:"members"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+}
+
+type SetRequest struct {
+	Key       string     `json:"key"`
+	Members   []string   `json:"members"`
+	TTL       int64      `json:"ttl,omitempty"`
+	TTLMs     int64      `json:"ttl_ms,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type AddRequest struct {
+	Key     string   `json:"key"`
+	Members []string `json:"members"`
+}
+
+type AddResponse struct {
+	Added int `json:"added"`
+}
+
+type RemoveMembersResponse struct {
+	Removed int `json:"removed"`
+}
+
+type IsMemberResponse struct {
+	IsMember bool `json:"is_member"`
+}
+
+type CardResponse struct {
+	Card int `json:"card"`
+}
+
+type PopRequest struct {
+	Key string `json:"key"`
+}
+
+type MemberResponse struct {
+	Member string `json:"member"`
+}
+
+type MembersResponse struct {
+	Members []string `json:"members"`
+}
+
+type StoreRequest struct {
+	Keys        []string `json:"keys"`
+	Destination string   `json:"destination"`
+}
+
+type StoreResponse struct {
+	Card int `json:"card"`
+}
@@ -0,0 +1,90 @@
+package streams
+
+type Entry struct {
+	ID     string            `json:"id"`
+	Fields map[string]string `json:"fields"`
+}
+
+type AddRequest struct {
+	Key    string            `json:"key"`
+	Fields map[string]string `json:"fields"`
+	// MaxLen removes the oldest entries so the stream keeps at most that many, when given.
+	MaxLen int `json:"max_len,omitempty"`
+}
+
+type AddResponse struct {
+	ID string `json:"id"`
+}
+
+type RangeResponse struct {
+	Entries []Entry `json:"entries"`
+}
+
+type LenResponse struct {
+	Len int `json:"len"`
+}
+
+type TrimRequest struct {
+	Key    string `json:"key"`
+	MaxLen int    `json:"max_len"`
+}
+
+type RemoveResponse struct {
+	Removed int `json:"removed"`
+}
+
+type CreateGroupRequest struct {
+	Key   string `json:"key"`
+	Group string `json:"group"`
+	// FromStart delivers the entries already in the stream, otherwise only the new ones are delivered.
+	FromStart bool `json:"from_start,omitempty"`
+}
+
+type Group struct {
+	Name            string `json:"name"`
+	LastDeliveredID string `json:"last_delivered_id"`
+	Consumers       int    `json:"consumers"`
+	Pending         int    `json:"pending"`
+}
+
+type GroupsResponse struct {
+	Groups []Group `json:"groups"`
+}
+
+type ReadGroupRequest struct {
+	Key      string `json:"key"`
+	Group    string `json:"group"`
+	Consumer string `json:"consumer"`
+	// Count is the maximum number of entries delivered, 1 if not given.
+	Count int `json:"count,omitempty"`
+}
+
+type AckRequest struct {
+	Key   string   `json:"key"`
+	Group string   `json:"group"`
+	IDs   []string `json:"ids"`
+}
+
+type AckResponse struct {
+	Acknowledged int `json:"acknowledged"`
+}
+
+type PendingEntry struct {
+	ID          string `json:"id"`
+	Consumer    string `json:"consumer"`
+	DeliveredAt string `json:"delivered_at"`
+	Deliveries  int    `json:"deliveries"`
+}
+
+type PendingResponse struct {
+	Pending []PendingEntry `json:"pending"`
+}
+
+type ClaimRequest struct {
+	Key      string `json:"key"`
+	Group    string `json:"group"`
+	Consumer string `json:"consumer"`
+	// MinIdleMs only claims the entries that have not been delivered for at least that many milliseconds.
+	MinIdleMs int64    `json:"min_idle_ms,omitempty"`
+	IDs       []string `json:"ids"`
+}
@@ -1,17 +1,63 @@
 package strings
 
+import "time"
+
 type GetResponse struct {
 	Value     string `json:"value"`
 	ExpiresAt string `json:"expires_at,omitempty"`
+	// Version can be sent back in the If-Match header of an update to make it conditional,
+	// or used to watch the key in a transaction.
+	Version uint64 `json:"version"`
 }
 
 type SetRequest struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
-	TTL   int64  `json:"ttl,omitempty"`
+	// TTL is in seconds. TTLMs, in milliseconds, and ExpiresAt, an RFC 3339 time,
+	// can be given instead for finer expirations. At most one of them can be set.
+	TTL       int64      `json:"ttl,omitempty"`
+	TTLMs     int64      `json:"ttl_ms,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
+// UpdateRequest creates or replaces the value of a key. The key keeps its TTL unless a new one is given.
 type UpdateRequest struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	Key       string     `json:"key"`
+	Value     string     `json:"value"`
+	TTL       int64      `json:"ttl,omitempty"`
+	TTLMs     int64      `json:"ttl_ms,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type SetResponse struct {
+	Set bool `json:"set"`
+}
+
+type CompareAndSwapRequest struct {
+	Key      string `json:"key"`
+	Expected string `json:"expected"`
+	Value    string `json:"value"`
+}
+
+type CompareAndSwapResponse struct {
+	Swapped bool `json:"swapped"`
+}
+
+type IncrByRequest struct {
+	Key string `json:"key"`
+	// By defaults to 1 when omitted. Use a negative value to decrement.
+	By *int64 `json:"by,omitempty"`
+}
+
+type IncrByResponse struct {
+	Value int64 `json:"value"`
+}
+
+type IncrByFloatRequest struct {
+	Key string  `json:"key"`
+	By  float64 `json:"by"`
+}
+
+type IncrByFloatResponse struct {
+	Value float64 `json:"value"`
 }
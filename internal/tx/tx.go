@@ -0,0 +1,60 @@
+package tx
+
+import "time"
+
+// Key types of the operations and watches.
+const (
+	TypeString = "string"
+	TypeList   = "list"
+)
+
+// Operations of a transaction. Get, set, update and remove are available for both types,
+// incrby only for strings and push and pop only for lists.
+const (
+	OpGet    = "get"
+	OpSet    = "set"
+	OpUpdate = "update"
+	OpRemove = "remove"
+	OpIncrBy = "incrby"
+	OpPush   = "push"
+	OpPop    = "pop"
+)
+
+type Watch struct {
+	Type    string `json:"type"`
+	Key     string `json:"key"`
+	Version uint64 `json:"version"`
+}
+
+type Op struct {
+	Op   string `json:"op"`
+	Type string `json:"type"`
+	Key  string `json:"key"`
+	// Value is the string to set or update, or the value to push to a list.
+	Value string `json:"value,omitempty"`
+	// Values is the list to set or update.
+	Values []string `json:"values,omitempty"`
+	// TTL is in seconds, or TTLMs in milliseconds, or ExpiresAt an absolute time, for the set operations.
+	TTL       int64      `json:"ttl,omitempty"`
+	TTLMs     int64      `json:"ttl_ms,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// By defaults to 1 when omitted.
+	By *int64 `json:"by,omitempty"`
+}
+
+type Request struct {
+	Watch []Watch `json:"watch,omitempty"`
+	Ops   []Op    `json:"ops"`
+}
+
+type Result struct {
+	// Value is the string or list returned by get, the new value returned by incrby
+	// or the value returned by pop. It's null for the other operations and for missing keys.
+	Value     any    `json:"value"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Version   uint64 `json:"version,omitempty"`
+}
+
+type Response struct {
+	Results []Result `json:"results"`
+}
@@ -0,0 +1,56 @@
+package zsets
+
+import "time"
+
+type Member struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+type GetResponse struct {
+	Members   []Member `json:"members"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+}
+
+type SetRequest struct {
+	Key       string     `json:"key"`
+	Members   []Member   `json:"members"`
+	TTL       int64      `json:"ttl,omitempty"`
+	TTLMs     int64      `json:"ttl_ms,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type AddRequest struct {
+	Key     string   `json:"key"`
+	Members []Member `json:"members"`
+}
+
+type AddResponse struct {
+	Added int `json:"added"`
+}
+
+type RemoveResponse struct {
+	Removed int `json:"removed"`
+}
+
+type IncrByRequest struct {
+	Key    string  `json:"key"`
+	Member string  `json:"member"`
+	By     float64 `json:"by"`
+}
+
+type ScoreResponse struct {
+	Score float64 `json:"score"`
+}
+
+type RankResponse struct {
+	Rank int `json:"rank"`
+}
+
+type CardResponse struct {
+	Card int `json:"card"`
+}
+
+type RangeResponse struct {
+	Members []Member `json:"members"`
+}
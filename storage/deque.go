@@ -0,0 +1,133 @@
+package storage
+
+// minDequeCapacity is the smallest ring buffer kept by a deque, so short lists don't resize on every push.
+const minDequeCapacity = 8
+
+// deque is a double-ended queue backed by a ring buffer, used to store lists.
+// Values can be pushed and popped at both ends in amortized O(1). Popped slots are cleared
+// and the buffer shrinks when it's mostly empty, so queues don't keep their past values alive.
+type deque[T any] struct {
+	buf  []T
+	head int
+	n    int
+}
+
+// newDeque returns a deque holding a copy of the given values.
+func newDeque[T any](values []T) *deque[T] {
+	d := &deque[T]{buf: make([]T, max(minDequeCapacity, len(values)))}
+	d.n = copy(d.buf, values)
+	return d
+}
+
+// len returns the number of values in the deque.
+func (d *deque[T]) len() int {
+	return d.n
+}
+
+// pushBack adds the value at the end of the deque.
+func (d *deque[T]) pushBack(v T) {
+	d.grow()
+	d.buf[d.index(d.n)] = v
+	d.n++
+}
+
+// pushFront adds the value at the start of the deque.
+func (d *deque[T]) pushFront(v T) {
+	d.grow()
+	d.head = (d.head - 1 + len(d.buf)) % len(d.buf)
+	d.buf[d.head] = v
+	d.n++
+}
+
+// popFront removes and returns the first value. It returns false if the deque is empty.
+func (d *deque[T]) popFront() (T, bool) {
+	var zero T
+	if d.n == 0 {
+		return zero, false
+	}
+	v := d.buf[d.head]
+	d.buf[d.head] = zero
+	d.head = d.index(1)
+	d.n--
+	d.shrink()
+	return v, true
+}
+
+// popBack removes and returns the last value. It returns false if the deque is empty.
+func (d *deque[T]) popBack() (T, bool) {
+	var zero T
+	if d.n == 0 {
+		return zero, false
+	}
+	i := d.index(d.n - 1)
+	v := d.buf[i]
+	d.buf[i] = zero
+	d.n--
+	d.shrink()
+	return v, true
+}
+
+// at returns the value at position i, which must be within [0, len()).
+func (d *deque[T]) at(i int) T {
+	return d.buf[d.index(i)]
+}
+
+// set replaces the value at position i, which must be within [0, len()).
+func (d *deque[T]) set(i int, v T) {
+	d.buf[d.index(i)] = v
+}
+
+// slice returns a copy of the values of the deque, in order.
+func (d *deque[T]) slice() []T {
+	values := make([]T, d.n)
+	d.copyTo(values)
+	return values
+}
+
+// sub returns a copy of the values within [start, end), which must be a valid range.
+func (d *deque[T]) sub(start, end int) []T {
+	values := make([]T, 0, end-start)
+	for i := start; i < end; i++ {
+		values = append(values, d.at(i))
+	}
+	return values
+}
+
+// clone returns a copy of the deque that can be modified independently.
+func (d *deque[T]) clone() *deque[T] {
+	return newDeque(d.slice())
+}
+
+// index returns the position in the buffer of the i-th value.
+func (d *deque[T]) index(i int) int {
+	return (d.head + i) % len(d.buf)
+}
+
+// grow doubles the buffer when it's full.
+func (d *deque[T]) grow() {
+	if d.n < len(d.buf) {
+		return
+	}
+	d.resize(len(d.buf) * 2)
+}
+
+// shrink halves the buffer when it's only a quarter full.
+func (d *deque[T]) shrink() {
+	if len(d.buf) <= minDequeCapacity || d.n > len(d.buf)/4 {
+		return
+	}
+	d.resize(len(d.buf) / 2)
+}
+
+func (d *deque[T]) resize(capacity int) {
+	buf := make([]T, capacity)
+	d.copyTo(buf)
+	d.buf = buf
+	d.head = 0
+}
+
+// copyTo copies the values of the deque, in order, to the start of dst.
+func (d *deque[T]) copyTo(dst []T) {
+	n := copy(dst, d.buf[d.head:min(d.head+d.n, len(d.buf))])
+	copy(dst[n:], d.buf[:d.n-n])
+}
@@ -11,4 +11,58 @@ var (
 	ErrEmptyList = errors.New("list is empty")
 	// ErrExpired is returned when trying to access an expired item
 	ErrExpired = errors.New("expired")
+	// ErrInvalidTTL is returned when setting a sliding expiration that is not positive
+	ErrInvalidTTL = errors.New("ttl must be positive")
+	// ErrFieldNotFound is returned when the requested field is not found in a hash
+	ErrFieldNotFound = errors.New("field not found")
+	// ErrNotInteger is returned when trying to increment a value that is not an integer
+	ErrNotInteger = errors.New("value is not an integer")
+	// ErrNotFloat is returned when trying to increment a value that is not a number
+	ErrNotFloat = errors.New("value is not a valid float")
+	// ErrOverflow is returned when an increment would overflow the value or make it not finite
+	ErrOverflow = errors.New("increment would overflow")
+	// ErrVersionMismatch is returned when a conditional update is given an outdated version
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrInvalidCount is returned when popping a number of items that is not positive
+	ErrInvalidCount = errors.New("count must be positive")
+	// ErrIndexOutOfRange is returned when accessing a position outside of a list
+	ErrIndexOutOfRange = errors.New("index out of range")
+	// ErrPivotNotFound is returned when inserting next to a value that is not in the list
+	ErrPivotNotFound = errors.New("pivot not found")
+	// ErrTimeout is returned when a blocking operation has waited for the whole timeout
+	ErrTimeout = errors.New("timed out")
+	// ErrInvalidVisibility is returned when reserving an item with a visibility timeout that is not positive
+	ErrInvalidVisibility = errors.New("visibility timeout must be positive")
+	// ErrReservationNotFound is returned when acknowledging an item that is not reserved
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrTxAborted is returned when a watched key has changed before the transaction is applied
+	ErrTxAborted = errors.New("transaction aborted, a watched key has changed")
+	// ErrNotTransactional is returned when creating a Transactor over stores that don't support transactions
+	ErrNotTransactional = errors.New("store does not support transactions")
+	// ErrEmptySet is returned when trying to pop a member from an empty set
+	ErrEmptySet = errors.New("set is empty")
+	// ErrNoKeys is returned when an operation over several keys receives none
+	ErrNoKeys = errors.New("no keys provided")
+	// ErrMemberNotFound is returned when the requested member is not found in a sorted set
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrWrongType is returned when creating a key that holds another type of value in the keyspace
+	ErrWrongType = errors.New("operation against a key holding the wrong kind of value")
+	// ErrInvalidScore is returned when the score of a sorted set member is not a finite number
+	ErrInvalidScore = errors.New("score is not a finite number")
+	// ErrSlowConsumer is returned when a subscription is dropped because its buffer was full
+	ErrSlowConsumer = errors.New("subscription dropped, it didn't keep up with the messages")
+	// ErrPubSubClosed is returned when a subscription is closed because the pub/sub was closed
+	ErrPubSubClosed = errors.New("pub/sub closed")
+	// ErrSubscriptionClosed is returned when subscribing with a subscription that is closed
+	ErrSubscriptionClosed = errors.New("subscription closed")
+	// ErrInvalidStreamID is returned when parsing a stream entry ID that is not written as ms-seq
+	ErrInvalidStreamID = errors.New("invalid stream id")
+	// ErrStreamIDTooSmall is returned when adding an entry whose ID is not greater than the last ID of the stream
+	ErrStreamIDTooSmall = errors.New("stream id must be greater than the last id of the stream")
+	// ErrInvalidMaxLen is returned when trimming a stream to a negative length
+	ErrInvalidMaxLen = errors.New("max length cannot be negative")
+	// ErrGroupNotFound is returned when the requested consumer group is not found in a stream
+	ErrGroupNotFound = errors.New("consumer group not found")
+	// ErrGroupExists is returned when creating a consumer group that already exists in a stream
+	ErrGroupExists = errors.New("consumer group already exists")
 )
@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of change of a key notified to the observers of a store.
+type EventType string
+
+const (
+	// EventSet is emitted when a key is created or replaced, also when it's the destination of a rename.
+	EventSet EventType = "set"
+	// EventUpdate is emitted when the value of a key is changed in place, keeping its TTL,
+	// like by Update, CompareAndSwap, IncrBy, SetIndex or Trim.
+	EventUpdate EventType = "update"
+	// EventRemove is emitted when a key is removed, deleted from the keyspace, renamed
+	// or given an expiration time in the past.
+	EventRemove EventType = "remove"
+	// EventPush is emitted when values are pushed to a list.
+	EventPush EventType = "push"
+	// EventPop is emitted when values are popped from a list.
+	EventPop EventType = "pop"
+	// EventExpire is emitted when the expiration of a key is set or removed.
+	EventExpire EventType = "expire"
+	// EventExpired is emitted when an expired key is removed, either when it's accessed
+	// or by the background sweeper.
+	EventExpired EventType = "expired"
+)
+
+// Event is a change of a key of a store.
+type Event struct {
+	Type    EventType
+	Key     string
+	KeyType KeyType
+	Time    time.Time
+}
+
+// Observer receives the events of the stores created with WithObserver. It's called while the store is
+// locked, right after each change and in the same order, so it must return quickly and must not use the stores.
+type Observer func(Event)
+
+// WithObserver makes the store notify the changes of its keys to the observer.
+// Only the StringStore and the ListStore emit events. Snapshot restores are not notified.
+func WithObserver(observer Observer) Option {
+	return func(o *options) {
+		o.observers = append(o.observers, observer)
+	}
+}
+
+// notifier emits the events of a store to its observers.
+type notifier struct {
+	keyType   KeyType
+	observers []Observer
+	// held buffers the events while a transaction is applied, so they're only emitted if it succeeds.
+	held *[]heldEvent
+}
+
+type heldEvent struct {
+	n *notifier
+	e Event
+}
+
+func newNotifier(keyType KeyType, o options) notifier {
+	return notifier{keyType: keyType, observers: o.observers}
+}
+
+// enabled reports whether the store has observers, so the events don't have to be built otherwise.
+func (n *notifier) enabled() bool {
+	return len(n.observers) > 0
+}
+
+// notify emits an event of the key. The caller must hold the lock of the store.
+func (n *notifier) notify(typ EventType, key string) {
+	if !n.enabled() {
+		return
+	}
+	e := Event{Type: typ, Key: key, KeyType: n.keyType, Time: time.Now()}
+	if n.held != nil {
+		*n.held = append(*n.held, heldEvent{n: n, e: e})
+		return
+	}
+	n.emit(e)
+}
+
+func (n *notifier) emit(e Event) {
+	for _, observer := range n.observers {
+		observer(e)
+	}
+}
+
+// notifyExpired emits EventExpired if err is ErrExpired, which is returned once an expired key is removed.
+// The caller must hold the lock of the store.
+func (n *notifier) notifyExpired(key string, err error) {
+	if err == ErrExpired {
+		n.notify(EventExpired, key)
+	}
+}
+
+// onExpired emits EventExpired for a key removed by the background sweeper.
+func (n *notifier) onExpired(key string) {
+	n.notify(EventExpired, key)
+}
+
+// notifyExpire emits the event of a change of the expiration of the key, with the results of expireKey:
+// EventExpire if it has been changed, EventRemove if the key has been removed by an expiration
+// in the past, or EventExpired if it had already expired.
+func notifyExpire(n *notifier, key string, ok bool, err error) {
+	switch {
+	case ok:
+		n.notify(EventExpire, key)
+	case err == nil:
+		n.notify(EventRemove, key)
+	default:
+		n.notifyExpired(key, err)
+	}
+}
+
+// holdEvents buffers the events of the notifiers, in the order they happen across all of them,
+// until the returned function is called, which emits them if emit is true and drops them otherwise.
+// The caller must hold the locks of the stores until then.
+func holdEvents(notifiers ...*notifier) func(emit bool) {
+	var held []heldEvent
+	for _, n := range notifiers {
+		n.held = &held
+	}
+	return func(emit bool) {
+		for _, n := range notifiers {
+			n.held = nil
+		}
+		if !emit {
+			return
+		}
+		for _, h := range held {
+			h.n.emit(h.e)
+		}
+	}
+}
+
+// reapExpired removes the key if it has expired and emits EventExpired. The stores with observers call it
+// before the operations that treat expired keys as missing without returning ErrExpired,
+// so their lazy expirations are notified too. The caller must hold the lock of the store.
+func reapExpired[T any](store map[string]Value[T], key string, n *notifier) {
+	if !n.enabled() {
+		return
+	}
+	_, err := getValid(store, key)
+	n.notifyExpired(key, err)
+}
+
+// DefaultWatchBufferSize is the number of events buffered by each watch when
+// WithWatchBufferSize is not given.
+const DefaultWatchBufferSize = 1024
+
+// Watcher sends the events of the stores it observes to the watches of the keys.
+// Pass its Notify method to WithObserver when creating the stores.
+//
+// Like the subscriptions of a PubSub, each watch buffers a bounded number of events,
+// and a watch whose buffer is full is dropped, so it never slows down the stores.
+type Watcher interface {
+	// Watch returns the events of the keys that match the glob pattern, with the syntax of Scan.
+	// An empty pattern matches every key. The channel is closed when the context is done,
+	// when the watcher is closed, or when the watch doesn't keep up with the events.
+	Watch(ctx context.Context, pattern string) <-chan Event
+	// Notify sends the event to the watches of its key. It never blocks.
+	Notify(e Event)
+	// Close closes all the watches. New watches are created closed.
+	Close() error
+}
+
+// WatcherOption configures optional behaviour of the Watcher.
+type WatcherOption func(*watcher)
+
+// WithWatchBufferSize sets the number of events buffered by each watch.
+// Defaults to DefaultWatchBufferSize.
+func WithWatchBufferSize(n int) WatcherOption {
+	return func(w *watcher) {
+		if n > 0 {
+			w.bufferSize = n
+		}
+	}
+}
+
+type watcher struct {
+	mu         sync.Mutex
+	bufferSize int
+	watches    map[*watch]struct{}
+	closed     bool
+}
+
+type watch struct {
+	pattern string
+	events  chan Event
+	// done is closed once the watch is removed, to stop waiting for its context.
+	done chan struct{}
+}
+
+// NewWatcher creates a new Watcher with the provided options.
+func NewWatcher(opts ...WatcherOption) Watcher {
+	w := &watcher{
+		bufferSize: DefaultWatchBufferSize,
+		watches:    map[*watch]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+func (w *watcher) Watch(ctx context.Context, pattern string) <-chan Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wt := &watch{
+		pattern: pattern,
+		events:  make(chan Event, w.bufferSize),
+		done:    make(chan struct{}),
+	}
+	if w.closed {
+		close(wt.events)
+		return wt.events
+	}
+	w.watches[wt] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.mu.Lock()
+			w.remove(wt)
+			w.mu.Unlock()
+		case <-wt.done:
+		}
+	}()
+	return wt.events
+}
+
+func (w *watcher) Notify(e Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for wt := range w.watches {
+		if wt.pattern != "" && !matchGlob(wt.pattern, e.Key) {
+			continue
+		}
+		select {
+		case wt.events <- e:
+		default:
+			// The watch doesn't keep up with the events
+			w.remove(wt)
+		}
+	}
+}
+
+func (w *watcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	for wt := range w.watches {
+		w.remove(wt)
+	}
+	return nil
+}
+
+// remove closes the watch if it has not been removed yet. The caller must hold the lock.
+func (w *watcher) remove(wt *watch) {
+	if _, ok := w.watches[wt]; !ok {
+		return
+	}
+	delete(w.watches, wt)
+	close(wt.events)
+	close(wt.done)
+}
@@ -0,0 +1,276 @@
+package storage_test
+
+import (
+	"context"
+	"in-memory-storage/storage"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is an Observer that keeps the type and the key of the events.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) observe(e storage.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, string(e.Type)+" "+string(e.KeyType)+" "+e.Key)
+}
+
+// take returns the events recorded since the last call.
+func (r *recorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+func TestStringStore_Events(t *testing.T) {
+	testCases := map[string]struct {
+		run            func(s storage.StringStore)
+		expectedEvents []string
+	}{
+		"it should notify sets and updates": {
+			run: func(s storage.StringStore) {
+				_ = s.Set("a", "1", 0)
+				_ = s.Set("a", "2", 0, storage.WithSetMode(storage.SetAlways))
+				_ = s.Update("a", "3")
+				_, _ = s.CompareAndSwap("a", "3", "4")
+				_, _ = s.SetNX("b", "1", 0)
+				_, _ = s.SetXX("b", "2", 0)
+			},
+			expectedEvents: []string{
+				"set string a", "set string a", "update string a", "update string a", "set string b", "set string b",
+			},
+		},
+		"it should not notify the writes that fail": {
+			run: func(s storage.StringStore) {
+				_ = s.Set("a", "1", 0)
+				_ = s.Set("a", "2", 0)
+				_ = s.Update("missing", "1")
+				_, _ = s.CompareAndSwap("a", "x", "y")
+				_, _ = s.SetNX("a", "1", 0)
+				_ = s.Remove("missing")
+			},
+			expectedEvents: []string{"set string a"},
+		},
+		"it should notify increments as sets of new keys": {
+			run: func(s storage.StringStore) {
+				_, _ = s.Incr("n")
+				_, _ = s.IncrByFloat("n", 1.5)
+			},
+			expectedEvents: []string{"set string n", "update string n"},
+		},
+		"it should notify removals and changes of the expiration": {
+			run: func(s storage.StringStore) {
+				_ = s.Set("a", "1", 0)
+				_ = s.Expire("a", time.Minute)
+				_ = s.Persist("a")
+				_ = s.Remove("a")
+				_ = s.Set("b", "1", 0)
+				_ = s.ExpireAt("b", time.Now().Add(-time.Second))
+			},
+			expectedEvents: []string{
+				"set string a", "expire string a", "expire string a", "remove string a", "set string b", "remove string b",
+			},
+		},
+		"it should notify lazy expirations": {
+			run: func(s storage.StringStore) {
+				_ = s.Set("a", "1", time.Millisecond)
+				_ = s.Set("b", "1", time.Millisecond)
+				time.Sleep(5 * time.Millisecond)
+				_, _ = s.Get("a")
+				_ = s.Set("b", "2", 0)
+			},
+			expectedEvents: []string{"set string a", "set string b", "expired string a", "expired string b", "set string b"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := &recorder{}
+			s := storage.NewStringStore(storage.WithObserver(r.observe))
+
+			tc.run(s)
+
+			assert.Equal(t, tc.expectedEvents, r.take())
+		})
+	}
+}
+
+func TestListStore_Events(t *testing.T) {
+	testCases := map[string]struct {
+		run            func(s storage.ListStore[string])
+		expectedEvents []string
+	}{
+		"it should notify pushes and pops": {
+			run: func(s storage.ListStore[string]) {
+				_ = s.Set("a", []string{"1"}, 0)
+				_ = s.Push("a", "2")
+				_, _ = s.LPush("a", "0")
+				_, _ = s.Pop("a")
+				_, _ = s.RPopN("a", 2)
+				_, _ = s.Pop("a")
+			},
+			expectedEvents: []string{"set list a", "push list a", "push list a", "pop list a", "pop list a"},
+		},
+		"it should notify moves between lists": {
+			run: func(s storage.ListStore[string]) {
+				_ = s.Set("a", []string{"1"}, 0)
+				_ = s.Set("b", nil, 0)
+				_, _ = s.RPopLPush("a", "b")
+			},
+			expectedEvents: []string{"set list a", "set list b", "pop list a", "push list b"},
+		},
+		"it should notify the changes in place": {
+			run: func(s storage.ListStore[string]) {
+				_ = s.Set("a", []string{"1", "2", "3"}, 0)
+				_ = s.Update("a", []string{"1", "2", "3", "4"})
+				_ = s.SetIndex("a", 0, "0")
+				_, _ = s.Insert("a", true, "2", "x")
+				_, _ = s.RemoveValue("a", 0, "x")
+				_, _ = s.RemoveValue("a", 0, "missing")
+				_ = s.Trim("a", 0, 1)
+				_ = s.Remove("a")
+			},
+			expectedEvents: []string{
+				"set list a", "update list a", "update list a", "update list a", "update list a", "update list a",
+				"remove list a",
+			},
+		},
+		"it should notify reservations": {
+			run: func(s storage.ListStore[string]) {
+				_ = s.Set("a", []string{"1"}, 0)
+				r, _ := s.Reserve("a", time.Minute)
+				_ = s.Nack("a", r.ID)
+			},
+			expectedEvents: []string{"set list a", "pop list a", "push list a"},
+		},
+		"it should notify lazy expirations": {
+			run: func(s storage.ListStore[string]) {
+				_ = s.Set("a", []string{"1"}, time.Millisecond)
+				time.Sleep(5 * time.Millisecond)
+				_ = s.Push("a", "2")
+			},
+			expectedEvents: []string{"set list a", "expired list a"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := &recorder{}
+			s := storage.NewListStore[string](storage.WithObserver(r.observe))
+
+			tc.run(s)
+
+			assert.Equal(t, tc.expectedEvents, r.take())
+		})
+	}
+}
+
+func TestStores_ActiveExpirationEvents(t *testing.T) {
+	r := &recorder{}
+	s := storage.NewStringStore(storage.WithObserver(r.observe), storage.WithSweeper(5*time.Millisecond))
+	defer s.Close()
+	assert.NoError(t, s.Set("a", "1", time.Millisecond))
+
+	assert.Eventually(t, func() bool {
+		return s.SweepStats().Reclaimed == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"set string a", "expired string a"}, r.take())
+}
+
+func TestKeyspace_Events(t *testing.T) {
+	r := &recorder{}
+	ks := storage.NewKeyspace()
+	strings := storage.NewStringStore(storage.WithKeyspace(ks), storage.WithObserver(r.observe))
+	lists := storage.NewListStore[string](storage.WithKeyspace(ks), storage.WithObserver(r.observe))
+	assert.NoError(t, strings.Set("a", "1", 0))
+	assert.NoError(t, lists.Set("b", []string{"1"}, 0))
+	r.take()
+
+	assert.NoError(t, ks.Rename("a", "b"))
+	assert.Equal(t, []string{"remove list b", "remove string a", "set string b"}, r.take())
+
+	assert.NoError(t, ks.Expire("b", time.Minute))
+	n, err := ks.Delete("b", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"expire string b", "remove string b"}, r.take())
+}
+
+func TestTransactor_Events(t *testing.T) {
+	r := &recorder{}
+	strings := storage.NewStringStore(storage.WithObserver(r.observe))
+	lists := storage.NewListStore[string](storage.WithObserver(r.observe))
+	transactor, err := storage.NewTransactor(strings, lists)
+	assert.NoError(t, err)
+	assert.NoError(t, lists.Set("queue", []string{"job"}, 0))
+	r.take()
+
+	// Nothing is notified if the transaction fails
+	tx := storage.NewTx[string]()
+	tx.SetString("a", "1", 0)
+	tx.UpdateString("missing", "1")
+	_, err = transactor.Exec(tx)
+	assert.Error(t, err)
+	assert.Empty(t, r.take())
+
+	tx = storage.NewTx[string]()
+	tx.Pop("queue")
+	tx.SetString("last", "job", 0)
+	tx.RemoveList("queue")
+	_, err = transactor.Exec(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pop list queue", "set string last", "remove list queue"}, r.take())
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	w := storage.NewWatcher()
+	s := storage.NewStringStore(storage.WithObserver(w.Notify))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	users := w.Watch(ctx, "user:*")
+	all := w.Watch(context.Background(), "")
+
+	assert.NoError(t, s.Set("user:1", "alice", 0))
+	assert.NoError(t, s.Set("order:1", "book", 0))
+
+	e := <-users
+	assert.Equal(t, storage.EventSet, e.Type)
+	assert.Equal(t, "user:1", e.Key)
+	assert.Equal(t, storage.TypeString, e.KeyType)
+	assert.WithinDuration(t, time.Now(), e.Time, time.Second)
+	assert.Equal(t, "user:1", (<-all).Key)
+	assert.Equal(t, "order:1", (<-all).Key)
+
+	// The watch is closed once its context is done
+	cancel()
+	_, ok := <-users
+	assert.False(t, ok)
+
+	assert.NoError(t, w.Close())
+	_, ok = <-all
+	assert.False(t, ok)
+	_, ok = <-w.Watch(context.Background(), "")
+	assert.False(t, ok)
+}
+
+func TestWatcher_SlowWatch(t *testing.T) {
+	w := storage.NewWatcher(storage.WithWatchBufferSize(1))
+	slow := w.Watch(context.Background(), "")
+
+	w.Notify(storage.Event{Type: storage.EventSet, Key: "a"})
+	w.Notify(storage.Event{Type: storage.EventSet, Key: "b"})
+
+	// The buffered event is still received before the watch is closed
+	assert.Equal(t, "a", (<-slow).Key)
+	_, ok := <-slow
+	assert.False(t, ok)
+}
@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type hashStore struct {
+	store map[string]Value[map[string]string]
+	// Mutex to handle concurrent access to memory
+	mu *sync.RWMutex
+
+	// keyspace is the keyspace the store has joined, if any
+	keyspace *keyspace
+	sweeper  *sweeper
+}
+
+// NewHashStore initializes a new hash store.
+// Use WithSweeper to actively remove expired hashes in the background.
+func NewHashStore(opts ...Option) HashStore {
+	hs := &hashStore{
+		store: map[string]Value[map[string]string]{},
+	}
+	o := newOptions(opts)
+	hs.keyspace = o.keyspace
+	hs.mu = o.keyspace.join(hs)
+	hs.sweeper = startSweeper(hs, o)
+	return hs
+}
+
+// Set will store the given hash with an optional TTL for the whole key.
+// It will check if the key already exists and return an error.
+func (hs *hashStore) Set(key string, fields map[string]string, ttl time.Duration) error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if err := hs.keyspace.claim(hs, key); err != nil {
+		return err
+	}
+	return set(hs.store, key, copyFields(fields), ttl)
+}
+
+// Get will return a copy of the hash for the given key.
+// It will return an error if the hash is not found or if it has expired.
+func (hs *hashStore) Get(key string) (*Value[map[string]string], error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	v, err := readValid(hs.store, key)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Value = copyFields(v.Value)
+	return &v, nil
+}
+
+// Remove will delete the hash linked to the given key.
+// It will return an error if the hash is not found.
+func (hs *hashStore) Remove(key string) error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	return remove(hs.store, key)
+}
+
+// HSet will set the value of a field in the hash, creating the hash if it does not exist.
+// It returns true if the field is new and false if an existing field was updated.
+func (hs *hashStore) HSet(key, field, val string) (bool, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	v, err := getValid(hs.store, key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrExpired) {
+			return false, err
+		}
+		if err := hs.keyspace.claim(hs, key); err != nil {
+			return false, err
+		}
+		v = Value[map[string]string]{Value: map[string]string{}}
+		hs.store[key] = v
+	}
+
+	_, exists := v.Value[field]
+	v.Value[field] = val
+
+	return !exists, nil
+}
+
+// HGet will return the value of a field in the hash.
+// It will return an error if the hash is not found, has expired or does not contain the field.
+func (hs *hashStore) HGet(key, field string) (string, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	v, err := readValid(hs.store, key)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := v.Value[field]
+	if !ok {
+		return "", ErrFieldNotFound
+	}
+
+	return val, nil
+}
+
+// HDel will remove the given fields from the hash and return how many were removed.
+// The hash is removed once it has no fields left.
+// It will return an error if the hash is not found or if it has expired.
+func (hs *hashStore) HDel(key string, fields ...string) (int, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	v, err := getValid(hs.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, field := range fields {
+		if _, ok := v.Value[field]; ok {
+			delete(v.Value, field)
+			removed++
+		}
+	}
+
+	if len(v.Value) == 0 {
+		delete(hs.store, key)
+	}
+
+	return removed, nil
+}
+
+// HGetAll will return a copy of all the fields and values of the hash.
+// It will return an error if the hash is not found or if it has expired.
+func (hs *hashStore) HGetAll(key string) (map[string]string, error) {
+	v, err := hs.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return v.Value, nil
+}
+
+// HExists will check if the hash contains the given field.
+// It will return an error if the hash is not found or if it has expired.
+func (hs *hashStore) HExists(key, field string) (bool, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	v, err := readValid(hs.store, key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := v.Value[field]
+	return ok, nil
+}
+
+// HLen will return the number of fields in the hash.
+// It will return an error if the hash is not found or if it has expired.
+func (hs *hashStore) HLen(key string) (int, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	v, err := readValid(hs.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(v.Value), nil
+}
+
+// HIncrBy will increment the integer value of a field by delta and return the new value.
+// The hash and the field are created if they don't exist, starting from 0.
+// It will return an error if the field value is not an integer or if the increment overflows.
+func (hs *hashStore) HIncrBy(key, field string, delta int64) (int64, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	v, err := getValid(hs.store, key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrExpired) {
+			return 0, err
+		}
+		if err := hs.keyspace.claim(hs, key); err != nil {
+			return 0, err
+		}
+		v = Value[map[string]string]{Value: map[string]string{}}
+		hs.store[key] = v
+	}
+
+	var current int64
+	if s, ok := v.Value[field]; ok {
+		current, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, ErrNotInteger
+		}
+	}
+
+	if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+		return 0, ErrOverflow
+	}
+
+	current += delta
+	v.Value[field] = strconv.FormatInt(current, 10)
+
+	return current, nil
+}
+
+// Snapshot will return a point-in-time copy of all the hashes that have not expired.
+func (hs *hashStore) Snapshot() map[string]Value[map[string]string] {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	data := snapshot(hs.store)
+	for key, v := range data {
+		v.Value = copyFields(v.Value)
+		data[key] = v
+	}
+	return data
+}
+
+// Restore will load the given hashes into the store, overwriting existing keys.
+// Hashes that have already expired are dropped. It returns the number of hashes loaded.
+// Keys held by another store of the keyspace are skipped, as a key holds one type of value.
+func (hs *hashStore) Restore(data map[string]Value[map[string]string]) int {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	// Copy the hashes as they are modified in place by HSet and HDel.
+	copied := make(map[string]Value[map[string]string], len(data))
+	for key, v := range data {
+		if hs.keyspace.claim(hs, key) != nil {
+			continue
+		}
+		v.Value = copyFields(v.Value)
+		copied[key] = v
+	}
+	return restore(hs.store, copied)
+}
+
+// SweepStats returns the counters of the background sweeper.
+// All the counters are zero if the sweeper is not enabled.
+func (hs *hashStore) SweepStats() SweepStats {
+	return hs.sweeper.stats()
+}
+
+// Close stops the background sweeper, if enabled.
+// The store remains usable after closing it.
+func (hs *hashStore) Close() error {
+	hs.sweeper.stop()
+	return nil
+}
+
+func (hs *hashStore) sweep(sampleSize int) (sampled, expired int) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	return sweepExpired(hs.store, sampleSize, nil)
+}
+
+func copyFields(fields map[string]string) map[string]string {
+	c := make(map[string]string, len(fields))
+	for field, val := range fields {
+		c[field] = val
+	}
+	return c
+}
+
+func (hs *hashStore) keyType() KeyType {
+	return TypeHash
+}
+
+func (hs *hashStore) has(key string) bool {
+	return hasValid(hs.store, key)
+}
+
+func (hs *hashStore) drop(key string) bool {
+	return dropValid(hs.store, key)
+}
+
+func (hs *hashStore) move(src, dst string) {
+	moveKey(hs.store, src, dst)
+}
+
+func (hs *hashStore) count() int {
+	return countValid(hs.store)
+}
+
+func (hs *hashStore) scan(cursor, match string, count int) []string {
+	return scanKeys(hs.store, cursor, match, count)
+}
+
+func (hs *hashStore) expire(key string, at time.Time, sliding time.Duration) error {
+	_, err := expireKey(hs.store, key, at, sliding)
+	return err
+}
+
+func (hs *hashStore) ttl(key string) (time.Duration, error) {
+	return ttlOf(hs.store, key)
+}
@@ -0,0 +1,340 @@
+package storage_test
+
+import (
+	"in-memory-storage/storage"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashStore_Set(t *testing.T) {
+	store := storage.NewHashStore()
+
+	// Populate existing values
+	err := store.Set("existing-key", map[string]string{"name": "foo"}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		key         string
+		fields      map[string]string
+		expectedVal *storage.Value[map[string]string]
+		expectedErr error
+	}{
+		"it should return an error if key already exists": {
+			key:         "existing-key",
+			fields:      map[string]string{"name": "bar"},
+			expectedVal: &storage.Value[map[string]string]{Value: map[string]string{"name": "foo"}},
+			expectedErr: storage.ErrAlreadyExists,
+		},
+		"it should set the value": {
+			key:         "new-key",
+			fields:      map[string]string{"name": "bar", "age": "42"},
+			expectedVal: &storage.Value[map[string]string]{Value: map[string]string{"name": "bar", "age": "42"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := store.Set(tc.key, tc.fields, 0)
+			assert.Equal(t, tc.expectedErr, err)
+
+			val, err := store.Get(tc.key)
+			assert.Nil(t, err)
+
+			assert.Equal(t, tc.expectedVal, val)
+		})
+	}
+}
+
+func TestHashStore_Get(t *testing.T) {
+	store := storage.NewHashStore()
+
+	err := store.Set("existing-key", map[string]string{"name": "foo"}, 0)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", map[string]string{"name": "foo"}, time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.Get("new-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should return an error if the key has expired", func(t *testing.T) {
+		_, err := store.Get("expired-key")
+		assert.Equal(t, storage.ErrExpired, err)
+	})
+
+	t.Run("it should return a copy of the hash", func(t *testing.T) {
+		val, err := store.Get("existing-key")
+		assert.Nil(t, err)
+		val.Value["name"] = "changed"
+
+		fields, err := store.HGetAll("existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"name": "foo"}, fields)
+	})
+}
+
+func TestHashStore_HSet(t *testing.T) {
+	store := storage.NewHashStore()
+
+	err := store.Set("existing-key", map[string]string{"name": "foo"}, time.Minute)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", map[string]string{"name": "foo"}, time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	testCases := map[string]struct {
+		key             string
+		field           string
+		val             string
+		expectedCreated bool
+		expectedFields  map[string]string
+	}{
+		"it should create the hash if it does not exist": {
+			key:             "new-key",
+			field:           "name",
+			val:             "bar",
+			expectedCreated: true,
+			expectedFields:  map[string]string{"name": "bar"},
+		},
+		"it should add a new field": {
+			key:             "existing-key",
+			field:           "age",
+			val:             "42",
+			expectedCreated: true,
+			expectedFields:  map[string]string{"name": "foo", "age": "42"},
+		},
+		"it should update an existing field": {
+			key:            "existing-key",
+			field:          "name",
+			val:            "bar",
+			expectedFields: map[string]string{"name": "bar", "age": "42"},
+		},
+		"it should replace an expired hash": {
+			key:             "expired-key",
+			field:           "age",
+			val:             "42",
+			expectedCreated: true,
+			expectedFields:  map[string]string{"age": "42"},
+		},
+	}
+
+	// Run sequentially as cases depend on each other
+	for _, name := range []string{
+		"it should create the hash if it does not exist",
+		"it should add a new field",
+		"it should update an existing field",
+		"it should replace an expired hash",
+	} {
+		tc := testCases[name]
+		t.Run(name, func(t *testing.T) {
+			created, err := store.HSet(tc.key, tc.field, tc.val)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedCreated, created)
+
+			fields, err := store.HGetAll(tc.key)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedFields, fields)
+		})
+	}
+
+	t.Run("it should keep the TTL of the hash", func(t *testing.T) {
+		val, err := store.Get("existing-key")
+		assert.Nil(t, err)
+		assert.False(t, val.ExpiresAt.IsZero())
+	})
+}
+
+func TestHashStore_HGet(t *testing.T) {
+	store := storage.NewHashStore()
+
+	err := store.Set("existing-key", map[string]string{"name": "foo"}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		key         string
+		field       string
+		expectedVal string
+		expectedErr error
+	}{
+		"it should return an error if key not found": {
+			key:         "new-key",
+			field:       "name",
+			expectedErr: storage.ErrNotFound,
+		},
+		"it should return an error if field not found": {
+			key:         "existing-key",
+			field:       "age",
+			expectedErr: storage.ErrFieldNotFound,
+		},
+		"it should get the field value": {
+			key:         "existing-key",
+			field:       "name",
+			expectedVal: "foo",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			val, err := store.HGet(tc.key, tc.field)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedVal, val)
+		})
+	}
+}
+
+func TestHashStore_HDel(t *testing.T) {
+	store := storage.NewHashStore()
+
+	err := store.Set("existing-key", map[string]string{"name": "foo", "age": "42", "city": "bcn"}, 0)
+	assert.Nil(t, err)
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.HDel("new-key", "name")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should remove the existing fields", func(t *testing.T) {
+		removed, err := store.HDel("existing-key", "name", "age", "unknown")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, removed)
+
+		fields, err := store.HGetAll("existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"city": "bcn"}, fields)
+	})
+
+	t.Run("it should remove the hash when it has no fields left", func(t *testing.T) {
+		removed, err := store.HDel("existing-key", "city")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, removed)
+
+		_, err = store.Get("existing-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestHashStore_HExistsAndHLen(t *testing.T) {
+	store := storage.NewHashStore()
+
+	err := store.Set("existing-key", map[string]string{"name": "foo", "age": "42"}, 0)
+	assert.Nil(t, err)
+
+	exists, err := store.HExists("existing-key", "name")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	exists, err = store.HExists("existing-key", "city")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	_, err = store.HExists("new-key", "name")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	length, err := store.HLen("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, length)
+
+	_, err = store.HLen("new-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestHashStore_HIncrBy(t *testing.T) {
+	store := storage.NewHashStore()
+
+	err := store.Set("existing-key", map[string]string{"count": "10", "name": "foo", "max": "9223372036854775807"}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		key         string
+		field       string
+		delta       int64
+		expectedVal int64
+		expectedErr error
+	}{
+		"it should increment an existing field": {
+			key:         "existing-key",
+			field:       "count",
+			delta:       5,
+			expectedVal: 15,
+		},
+		"it should decrement with a negative delta": {
+			key:         "existing-key",
+			field:       "count",
+			delta:       -20,
+			expectedVal: -5,
+		},
+		"it should create a missing field": {
+			key:         "existing-key",
+			field:       "new-count",
+			delta:       3,
+			expectedVal: 3,
+		},
+		"it should create a missing hash": {
+			key:         "new-key",
+			field:       "count",
+			delta:       1,
+			expectedVal: 1,
+		},
+		"it should return an error if the field is not an integer": {
+			key:         "existing-key",
+			field:       "name",
+			delta:       1,
+			expectedErr: storage.ErrNotInteger,
+		},
+		"it should return an error if the increment overflows": {
+			key:         "existing-key",
+			field:       "max",
+			delta:       1,
+			expectedErr: storage.ErrOverflow,
+		},
+	}
+
+	// Run sequentially as cases depend on each other
+	for _, name := range []string{
+		"it should increment an existing field",
+		"it should decrement with a negative delta",
+		"it should create a missing field",
+		"it should create a missing hash",
+		"it should return an error if the field is not an integer",
+		"it should return an error if the increment overflows",
+	} {
+		tc := testCases[name]
+		t.Run(name, func(t *testing.T) {
+			val, err := store.HIncrBy(tc.key, tc.field, tc.delta)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedVal, val)
+		})
+	}
+}
+
+func TestHashStore_ConcurrentHIncrBy(t *testing.T) {
+	store := storage.NewHashStore()
+	const n = 100
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.HIncrBy("counters", "count", 1)
+			assert.Nil(t, err)
+			_, err = store.HSet("counters", "field-"+strconv.Itoa(i), "value")
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	val, err := store.HGet("counters", "count")
+	assert.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(n), val)
+
+	length, err := store.HLen("counters")
+	assert.Nil(t, err)
+	assert.Equal(t, n+1, length)
+}
@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// KeyType is the type of the value held by a key of a Keyspace.
+type KeyType string
+
+const (
+	// TypeNone is the type of the keys that don't exist.
+	TypeNone   KeyType = "none"
+	TypeString KeyType = "string"
+	TypeList   KeyType = "list"
+	TypeHash   KeyType = "hash"
+	TypeSet    KeyType = "set"
+	TypeZSet   KeyType = "zset"
+	TypeStream KeyType = "stream"
+)
+
+// Keyspace owns the keys of the stores created with WithKeyspace, so a key holds a single type of value
+// across all of them. Creating a key that another store of the keyspace already holds fails with ErrWrongType.
+type Keyspace interface {
+	// Type returns the type of the value held by the key, or TypeNone if it doesn't exist.
+	Type(key string) KeyType
+	// Exists returns how many of the keys exist. A key given twice is counted twice.
+	Exists(keys ...string) int
+	// Delete removes the keys, whatever their type, and returns how many existed.
+	Delete(keys ...string) (int, error)
+	// Rename moves the value of src to dst, with its TTL, overwriting dst if it exists.
+	// It returns ErrNotFound if src doesn't exist.
+	Rename(src, dst string) error
+	// Count returns the number of keys in the keyspace.
+	Count() int
+	// Scan is the same as StringStore.Scan for the keys of all the stores of the keyspace.
+	Scan(cursor, match string, count int) ([]string, string, error)
+	// Expire, ExpireAt, ExpireSliding, Persist and TTL are the same as the StringStore methods
+	// for a key of any type.
+	Expire(key string, ttl time.Duration) error
+	ExpireAt(key string, t time.Time) error
+	ExpireSliding(key string, ttl time.Duration) error
+	Persist(key string) error
+	TTL(key string) (time.Duration, error)
+
+	// base returns the keyspace shared by the stores, so it can only be implemented
+	// by NewKeyspace and the types wrapping it.
+	base() *keyspace
+}
+
+// keyHolder is implemented by the stores that can join a Keyspace.
+// Its methods are called with the lock of the keyspace held.
+type keyHolder interface {
+	keyType() KeyType
+	// has reports whether the store holds the key, removing it if it has expired.
+	has(key string) bool
+	// drop removes the key and reports whether it existed.
+	drop(key string) bool
+	// move renames src to dst, which the store must not hold.
+	move(src, dst string)
+	// count returns the number of keys that have not expired.
+	count() int
+	// scan returns the smallest count+1 keys after the cursor that match the pattern and have not expired,
+	// in any order.
+	scan(cursor, match string, count int) []string
+	// expire sets the expiration time and the sliding expiration of the key.
+	expire(key string, at time.Time, sliding time.Duration) error
+	// ttl returns the time left before the key expires, or NoTTL if it doesn't expire.
+	ttl(key string) (time.Duration, error)
+}
+
+type keyspace struct {
+	// Mutex shared by all the stores of the keyspace, so their keys can be checked and changed at once
+	mu      sync.RWMutex
+	holders []keyHolder
+}
+
+// NewKeyspace creates an empty keyspace. Stores join it when created with WithKeyspace.
+func NewKeyspace() Keyspace {
+	return &keyspace{}
+}
+
+func (ks *keyspace) base() *keyspace {
+	return ks
+}
+
+// join adds the store to the keyspace and returns the lock it has to use.
+// Stores created without keyspace get a lock of their own.
+func (ks *keyspace) join(h keyHolder) *sync.RWMutex {
+	if ks == nil {
+		return &sync.RWMutex{}
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.holders = append(ks.holders, h)
+	return &ks.mu
+}
+
+// claim returns ErrWrongType if a store of the keyspace other than h holds the key.
+// It must be called, with the lock held, before h creates the key. It does nothing without keyspace.
+func (ks *keyspace) claim(h keyHolder, key string) error {
+	if ks == nil {
+		return nil
+	}
+	for _, other := range ks.holders {
+		if other != h && other.has(key) {
+			return ErrWrongType
+		}
+	}
+	return nil
+}
+
+// holder returns the store holding the key, or nil if none does. The caller must hold the lock.
+func (ks *keyspace) holder(key string) keyHolder {
+	for _, h := range ks.holders {
+		if h.has(key) {
+			return h
+		}
+	}
+	return nil
+}
+
+func (ks *keyspace) Type(key string) KeyType {
+	// A write lock is required as expired keys are removed on access.
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	h := ks.holder(key)
+	if h == nil {
+		return TypeNone
+	}
+	return h.keyType()
+}
+
+func (ks *keyspace) Exists(keys ...string) int {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	n := 0
+	for _, key := range keys {
+		if ks.holder(key) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (ks *keyspace) Delete(keys ...string) (int, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	n := 0
+	for _, key := range keys {
+		if h := ks.holder(key); h != nil && h.drop(key) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (ks *keyspace) Rename(src, dst string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	h := ks.holder(src)
+	if h == nil {
+		return ErrNotFound
+	}
+	if src == dst {
+		return nil
+	}
+
+	if other := ks.holder(dst); other != nil {
+		other.drop(dst)
+	}
+	h.move(src, dst)
+	return nil
+}
+
+func (ks *keyspace) Count() int {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	n := 0
+	for _, h := range ks.holders {
+		n += h.count()
+	}
+	return n
+}
+
+func (ks *keyspace) Scan(cursor, match string, count int) ([]string, string, error) {
+	if count <= 0 {
+		return nil, "", ErrInvalidCount
+	}
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	// A key is held by a single store, so the keys of the stores never overlap.
+	var keys []string
+	for _, h := range ks.holders {
+		keys = append(keys, h.scan(cursor, match, count)...)
+	}
+	page, next := scanPage(keys, count)
+	return page, next, nil
+}
+
+func (ks *keyspace) Expire(key string, ttl time.Duration) error {
+	return ks.expire(key, time.Now().Add(ttl), 0)
+}
+
+func (ks *keyspace) ExpireAt(key string, t time.Time) error {
+	return ks.expire(key, t, 0)
+}
+
+func (ks *keyspace) ExpireSliding(key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+	return ks.expire(key, time.Now().Add(ttl), ttl)
+}
+
+func (ks *keyspace) Persist(key string) error {
+	return ks.expire(key, time.Time{}, 0)
+}
+
+func (ks *keyspace) TTL(key string) (time.Duration, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	h := ks.holder(key)
+	if h == nil {
+		return 0, ErrNotFound
+	}
+	return h.ttl(key)
+}
+
+// expire sets the expiration of the key in the store holding it.
+func (ks *keyspace) expire(key string, at time.Time, sliding time.Duration) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	h := ks.holder(key)
+	if h == nil {
+		return ErrNotFound
+	}
+	return h.expire(key, at, sliding)
+}
+
+// WithKeyspace makes the store join the keyspace, sharing its keys and its lock with the other stores of it.
+func WithKeyspace(ks Keyspace) Option {
+	return func(o *options) {
+		o.keyspace = ks.base()
+	}
+}
+
+// countValid returns the number of entries that have not expired.
+func countValid[T any](store map[string]Value[T]) int {
+	now := time.Now()
+	n := 0
+	for _, v := range store {
+		if v.ExpiresAt.IsZero() || !v.ExpiresAt.Before(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// moveKey renames src to dst, keeping its value and expiration time. The caller must hold the lock.
+func moveKey[T any](store map[string]Value[T], src, dst string) {
+	store[dst] = store[src]
+	delete(store, src)
+}
+
+// hasValid reports whether the store holds the key, removing it if it has expired.
+func hasValid[T any](store map[string]Value[T], key string) bool {
+	_, err := getValid(store, key)
+	return err == nil
+}
+
+// dropValid removes the key and reports whether it existed and had not expired.
+func dropValid[T any](store map[string]Value[T], key string) bool {
+	if !hasValid(store, key) {
+		return false
+	}
+	delete(store, key)
+	return true
+}
@@ -0,0 +1,265 @@
+package storage_test
+
+import (
+	"errors"
+	"in-memory-storage/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type keyspaceStores struct {
+	keyspace storage.Keyspace
+	strings  storage.StringStore
+	lists    storage.ListStore[string]
+	hashes   storage.HashStore
+	sets     storage.SetStore[string]
+	zsets    storage.ZSetStore
+}
+
+func newKeyspaceStores() keyspaceStores {
+	ks := storage.NewKeyspace()
+	return keyspaceStores{
+		keyspace: ks,
+		strings:  storage.NewStringStore(storage.WithKeyspace(ks)),
+		lists:    storage.NewListStore[string](storage.WithKeyspace(ks)),
+		hashes:   storage.NewHashStore(storage.WithKeyspace(ks)),
+		sets:     storage.NewSetStore[string](storage.WithKeyspace(ks)),
+		zsets:    storage.NewZSetStore(storage.WithKeyspace(ks)),
+	}
+}
+
+func TestKeyspace_WrongType(t *testing.T) {
+	s := newKeyspaceStores()
+	assert.NoError(t, s.strings.Set("key", "value", 0))
+
+	testCases := map[string]func() error{
+		"list set": func() error {
+			return s.lists.Set("key", []string{"a"}, 0)
+		},
+		"hash set": func() error {
+			_, err := s.hashes.HSet("key", "field", "value")
+			return err
+		},
+		"hash incr": func() error {
+			_, err := s.hashes.HIncrBy("key", "field", 1)
+			return err
+		},
+		"set add": func() error {
+			_, err := s.sets.Add("key", "member")
+			return err
+		},
+		"set store": func() error {
+			assert.NoError(t, s.sets.Set("members", []string{"a"}, 0))
+			_, err := s.sets.UnionStore("key", "members")
+			return err
+		},
+		"sorted set add": func() error {
+			_, err := s.zsets.ZAdd("key", storage.ScoredMember{Member: "a", Score: 1})
+			return err
+		},
+	}
+
+	for name, create := range testCases {
+		t.Run("it should not create a key of another type with "+name, func(t *testing.T) {
+			assert.Equal(t, storage.ErrWrongType, create())
+			assert.Equal(t, storage.TypeString, s.keyspace.Type("key"))
+		})
+	}
+
+	t.Run("it should not create a string over a key of another type", func(t *testing.T) {
+		assert.NoError(t, s.lists.Set("list", []string{"a"}, 0))
+
+		assert.Equal(t, storage.ErrWrongType, s.strings.Set("list", "value", 0))
+		_, err := s.strings.SetNX("list", "value", 0)
+		assert.Equal(t, storage.ErrWrongType, err)
+		_, err = s.strings.IncrBy("list", 1)
+		assert.Equal(t, storage.ErrWrongType, err)
+	})
+
+	t.Run("it should allow the key once the other type has expired", func(t *testing.T) {
+		assert.NoError(t, s.strings.Set("short", "value", time.Millisecond))
+		time.Sleep(5 * time.Millisecond)
+
+		assert.NoError(t, s.lists.Set("short", []string{"a"}, 0))
+		assert.Equal(t, storage.TypeList, s.keyspace.Type("short"))
+	})
+
+	t.Run("it should not restore a key of another type", func(t *testing.T) {
+		restored := s.lists.Restore(map[string]storage.Value[[]string]{
+			"key":      {Value: []string{"a"}},
+			"restored": {Value: []string{"a"}},
+		})
+
+		assert.Equal(t, 1, restored)
+		assert.Equal(t, storage.TypeString, s.keyspace.Type("key"))
+		assert.Equal(t, storage.TypeList, s.keyspace.Type("restored"))
+	})
+
+	t.Run("it should not check the keys of stores without keyspace", func(t *testing.T) {
+		other := storage.NewListStore[string]()
+		assert.NoError(t, other.Set("key", []string{"a"}, 0))
+	})
+}
+
+func TestKeyspace_Type(t *testing.T) {
+	s := newKeyspaceStores()
+	assert.NoError(t, s.strings.Set("string", "value", 0))
+	assert.NoError(t, s.lists.Set("list", []string{"a"}, 0))
+	assert.NoError(t, s.hashes.Set("hash", map[string]string{"f": "v"}, 0))
+	assert.NoError(t, s.sets.Set("set", []string{"a"}, 0))
+	assert.NoError(t, s.zsets.Set("zset", []storage.ScoredMember{{Member: "a", Score: 1}}, 0))
+	assert.NoError(t, s.strings.Set("expired", "value", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	testCases := map[string]storage.KeyType{
+		"string":  storage.TypeString,
+		"list":    storage.TypeList,
+		"hash":    storage.TypeHash,
+		"set":     storage.TypeSet,
+		"zset":    storage.TypeZSet,
+		"expired": storage.TypeNone,
+		"missing": storage.TypeNone,
+	}
+
+	for key, expected := range testCases {
+		t.Run(key, func(t *testing.T) {
+			assert.Equal(t, expected, s.keyspace.Type(key))
+		})
+	}
+}
+
+func TestKeyspace_ExistsDeleteCount(t *testing.T) {
+	s := newKeyspaceStores()
+	assert.NoError(t, s.strings.Set("string", "value", 0))
+	assert.NoError(t, s.lists.Set("list", []string{"a"}, 0))
+	assert.NoError(t, s.hashes.Set("hash", map[string]string{"f": "v"}, 0))
+	assert.NoError(t, s.strings.Set("expired", "value", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Equal(t, 3, s.keyspace.Count())
+	assert.Equal(t, 3, s.keyspace.Exists("string", "list", "missing", "expired", "string"))
+
+	n, err := s.keyspace.Delete("string", "list", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 0, s.keyspace.Exists("string", "list"))
+	assert.Equal(t, 1, s.keyspace.Count())
+
+	_, err = s.strings.Get("string")
+	assert.Equal(t, storage.ErrNotFound, err)
+	_, err = s.lists.Get("list")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestKeyspace_Rename(t *testing.T) {
+	s := newKeyspaceStores()
+
+	t.Run("it should return an error if the source doesn't exist", func(t *testing.T) {
+		assert.Equal(t, storage.ErrNotFound, s.keyspace.Rename("missing", "dst"))
+	})
+
+	t.Run("it should move the value with its TTL", func(t *testing.T) {
+		assert.NoError(t, s.strings.Set("src", "value", time.Minute))
+		before, err := s.strings.Get("src")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.keyspace.Rename("src", "dst"))
+
+		_, err = s.strings.Get("src")
+		assert.Equal(t, storage.ErrNotFound, err)
+		after, err := s.strings.Get("dst")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", after.Value)
+		assert.Equal(t, before.ExpiresAt, after.ExpiresAt)
+		assert.Greater(t, after.Version, before.Version)
+	})
+
+	t.Run("it should overwrite a destination of another type", func(t *testing.T) {
+		assert.NoError(t, s.lists.Set("queue", []string{"a", "b"}, 0))
+		assert.NoError(t, s.zsets.Set("scores", []storage.ScoredMember{{Member: "a", Score: 1}}, 0))
+
+		assert.NoError(t, s.keyspace.Rename("queue", "scores"))
+
+		assert.Equal(t, storage.TypeList, s.keyspace.Type("scores"))
+		list, err := s.lists.Get("scores")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, list.Value)
+		_, err = s.zsets.Get("scores")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should keep the key when renamed to itself", func(t *testing.T) {
+		assert.NoError(t, s.hashes.Set("hash", map[string]string{"f": "v"}, 0))
+		assert.NoError(t, s.keyspace.Rename("hash", "hash"))
+		assert.Equal(t, storage.TypeHash, s.keyspace.Type("hash"))
+	})
+}
+
+func TestKeyspace_Transactor(t *testing.T) {
+	s := newKeyspaceStores()
+	transactor, err := storage.NewTransactor(s.strings, s.lists)
+	assert.NoError(t, err)
+	assert.NoError(t, s.lists.Set("queue", []string{"job"}, 0))
+
+	tx := storage.NewTx[string]()
+	tx.SetString("current", "job", 0)
+	tx.SetString("queue", "job", 0)
+
+	_, err = transactor.Exec(tx)
+	var txErr *storage.TxError
+	assert.True(t, errors.As(err, &txErr))
+	assert.Equal(t, 1, txErr.Index)
+	assert.Equal(t, storage.ErrWrongType, txErr.Err)
+	assert.Equal(t, storage.TypeNone, s.keyspace.Type("current"))
+}
+
+func TestKeyspace_Scan(t *testing.T) {
+	s := newKeyspaceStores()
+	assert.NoError(t, s.strings.Set("k:string", "value", 0))
+	assert.NoError(t, s.lists.Set("k:list", []string{"a"}, 0))
+	assert.NoError(t, s.hashes.Set("k:hash", map[string]string{"f": "v"}, 0))
+	assert.NoError(t, s.sets.Set("k:set", []string{"a"}, 0))
+	assert.NoError(t, s.zsets.Set("other", []storage.ScoredMember{{Member: "a", Score: 1}}, 0))
+
+	keys, cursor, err := s.keyspace.Scan("", "k:*", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k:hash", "k:list", "k:set"}, keys)
+
+	keys, cursor, err = s.keyspace.Scan(cursor, "k:*", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k:string"}, keys)
+	assert.Empty(t, cursor)
+}
+
+func TestKeyspace_Expire(t *testing.T) {
+	s := newKeyspaceStores()
+	assert.NoError(t, s.hashes.Set("hash", map[string]string{"f": "v"}, 0))
+	assert.NoError(t, s.zsets.Set("zset", []storage.ScoredMember{{Member: "a", Score: 1}}, 0))
+
+	assert.Equal(t, storage.ErrNotFound, s.keyspace.Expire("missing", time.Minute))
+	_, err := s.keyspace.TTL("missing")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	assert.NoError(t, s.keyspace.Expire("hash", time.Minute))
+	ttl, err := s.keyspace.TTL("hash")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+	assert.NoError(t, s.keyspace.Persist("hash"))
+	ttl, err = s.keyspace.TTL("hash")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.NoTTL, ttl)
+
+	assert.NoError(t, s.keyspace.ExpireSliding("zset", 40*time.Millisecond))
+	for range 3 {
+		time.Sleep(20 * time.Millisecond)
+		_, err := s.zsets.ZCard("zset")
+		assert.NoError(t, err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, storage.TypeNone, s.keyspace.Type("zset"))
+
+	assert.NoError(t, s.keyspace.ExpireAt("hash", time.Now().Add(-time.Second)))
+	assert.Equal(t, storage.TypeNone, s.keyspace.Type("hash"))
+}
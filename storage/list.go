@@ -1,52 +1,92 @@
 package storage
 
 import (
-	"errors"
+	"context"
+	"reflect"
+	"slices"
 	"sync"
 	"time"
 )
 
 type listStore[T any] struct {
-	store map[string]Value[[]T]
+	// Lists are stored in deques so both ends can be pushed and popped in O(1)
+	store map[string]Value[*deque[T]]
 	// Mutex to handle concurrent access to memory
-	mu sync.RWMutex
+	mu       *sync.RWMutex
+	versions versionCounter
+	// waiters are the channels of the blocking pops waiting for values in each list
+	waiters map[string][]chan struct{}
+	// reservations are the items reserved from each list, by id
+	reservations map[string]map[string]*Reservation[T]
+	reserveSeq   uint64
+	// requeueLock and onRequeue are set with OnRequeue
+	requeueLock sync.Locker
+	onRequeue   RequeueFunc
+
+	// keyspace is the keyspace the store has joined, if any
+	keyspace *keyspace
+	sweeper  *sweeper
+	events   notifier
 }
 
-// NewListStore initializes a list store for the given data type
-func NewListStore[T any]() ListStore[T] {
-	return &listStore[T]{
-		store: map[string]Value[[]T]{}, // TODO: Here we could init with existing data
+// NewListStore initializes a list store for the given data type.
+// Use WithSweeper to actively remove expired lists in the background,
+// and WithObserver to be notified of the changes of the lists.
+func NewListStore[T any](opts ...Option) ListStore[T] {
+	ls := &listStore[T]{
+		store:        map[string]Value[*deque[T]]{},
+		waiters:      map[string][]chan struct{}{},
+		reservations: map[string]map[string]*Reservation[T]{},
 	}
+	o := newOptions(opts)
+	ls.keyspace = o.keyspace
+	ls.mu = o.keyspace.join(ls)
+	ls.events = newNotifier(TypeList, o)
+	ls.sweeper = startSweeper(ls, o)
+	return ls
 }
 
 // Set will store the given key/value pair.
-// It will check if the key already exists and return an error.
-func (ls *listStore[T]) Set(key string, list []T, ttl time.Duration) error {
+// It will check if the key already exists and return an error, unless another mode
+// is given with WithSetMode. Use WithKeepTTL to keep the TTL of a list that is replaced.
+func (ls *listStore[T]) Set(key string, list []T, ttl time.Duration, opts ...SetOption) error {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
-	return set(ls.store, key, list, ttl)
+	return ls.setLocked(key, list, ttl, newSetOptions(opts))
 }
 
-// Get will return the value for the given key.
-// It will return an error if the list is not found.
+// setLocked is Set for callers that already hold the lock, like transactions.
+func (ls *listStore[T]) setLocked(key string, list []T, ttl time.Duration, o setOptions) error {
+	reapExpired(ls.store, key, &ls.events)
+	if err := ls.keyspace.claim(ls, key); err != nil {
+		return err
+	}
+	if err := setWith(ls.store, key, newDeque(list), ttl, o); err != nil {
+		return err
+	}
+	stamp(ls.store, key, &ls.versions)
+	ls.events.notify(EventSet, key)
+	ls.wake(key)
+	return nil
+}
+
+// Get will return a copy of the list for the given key.
+// It will return an error if the list is not found or if it has expired.
 func (ls *listStore[T]) Get(key string) (*Value[[]T], error) {
-	ls.mu.RLock()
-	defer ls.mu.RUnlock()
-	value, err := get(ls.store, key)
+	// A write lock is required as expired keys are removed on access.
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.getLocked(key)
+}
+
+// getLocked is Get for callers that already hold the lock, like transactions.
+func (ls *listStore[T]) getLocked(key string) (*Value[[]T], error) {
+	v, err := readValid(ls.store, key)
 	if err != nil {
+		ls.events.notifyExpired(key, err)
 		return nil, err
 	}
-
-	// If the value has an expiration time and it is in the past, remove it
-	// and return an error indicating it has expired.
-	if !value.ExpiresAt.IsZero() && value.ExpiresAt.Before(time.Now()) {
-		if err := remove(ls.store, key); err != nil {
-			return nil, errors.New("failed to remove expired key: " + err.Error())
-		}
-		return nil, ErrExpired
-	}
-
-	return &value, nil
+	return &Value[[]T]{Value: v.Value.slice(), ExpiresAt: v.ExpiresAt, Version: v.Version, Sliding: v.Sliding}, nil
 }
 
 // Update will update the value for the given key.
@@ -54,19 +94,23 @@ func (ls *listStore[T]) Get(key string) (*Value[[]T], error) {
 func (ls *listStore[T]) Update(key string, list []T) error {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
+	return ls.updateLocked(key, list)
+}
 
-	// Check if the key exists and if it has expired.
-	v, ok := ls.store[key]
-	if !ok {
-		return ErrNotFound
+// updateLocked is Update for callers that already hold the lock, like transactions.
+func (ls *listStore[T]) updateLocked(key string, list []T) error {
+	if _, err := getValid(ls.store, key); err != nil {
+		ls.events.notifyExpired(key, err)
+		return err
 	}
 
-	if !v.ExpiresAt.IsZero() && v.ExpiresAt.Before(time.Now()) {
-		delete(ls.store, key)
-		return ErrExpired
+	if err := update(ls.store, key, newDeque(list)); err != nil {
+		return err
 	}
-
-	return update(ls.store, key, list)
+	stamp(ls.store, key, &ls.versions)
+	ls.events.notify(EventUpdate, key)
+	ls.wake(key)
+	return nil
 }
 
 // Remove will delete the value linked to the given key.
@@ -74,61 +118,695 @@ func (ls *listStore[T]) Update(key string, list []T) error {
 func (ls *listStore[T]) Remove(key string) error {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
-	return remove(ls.store, key)
+	return ls.removeLocked(key)
 }
 
-// Push will add the given value to the existing list.
+// removeLocked is Remove for callers that already hold the lock, like transactions.
+func (ls *listStore[T]) removeLocked(key string) error {
+	if err := remove(ls.store, key); err != nil {
+		return err
+	}
+	ls.events.notify(EventRemove, key)
+	return nil
+}
+
+// Push will add the given value to the end of the existing list.
 // It will return an error if the list is not found or if it has expired.
 func (ls *listStore[T]) Push(key string, val T) error {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
+	_, err := ls.pushLocked(key, false, val)
+	return err
+}
 
-	v, ok := ls.store[key]
-	if !ok {
-		return ErrNotFound
-	}
+// LPush will add the given values to the start of the existing list, one after the other,
+// so they end up in reverse order. It returns the length of the list after the push.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) LPush(key string, vals ...T) (int, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.pushLocked(key, true, vals...)
+}
 
-	// If the value has an expiration time and it is in the past, remove it
-	// and return an error indicating it has expired.
-	if !v.ExpiresAt.IsZero() && v.ExpiresAt.Before(time.Now()) {
-		delete(ls.store, key)
-		return ErrExpired
+// RPush will add the given values to the end of the existing list, in order.
+// It returns the length of the list after the push.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) RPush(key string, vals ...T) (int, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.pushLocked(key, false, vals...)
+}
+
+// pushLocked adds the values to the start or to the end of the list.
+// The caller must hold the lock.
+func (ls *listStore[T]) pushLocked(key string, front bool, vals ...T) (int, error) {
+	v, err := getValid(ls.store, key)
+	if err != nil {
+		ls.events.notifyExpired(key, err)
+		return 0, err
 	}
 
-	v.Value = append(v.Value, val)
-	ls.store[key] = v
+	for _, val := range vals {
+		if front {
+			v.Value.pushFront(val)
+		} else {
+			v.Value.pushBack(val)
+		}
+	}
+	stamp(ls.store, key, &ls.versions)
+	ls.events.notify(EventPush, key)
+	ls.wake(key)
 
-	return nil
+	return v.Value.len(), nil
 }
 
 // Pop will retrieve and remove the first item from the list. Applying FIFO.
 // It will check that the list exists, that it's not empty and that it has not expired.
 func (ls *listStore[T]) Pop(key string) (T, error) {
+	return ls.LPop(key)
+}
+
+// LPop will retrieve and remove the first item from the list.
+// It will check that the list exists, that it's not empty and that it has not expired.
+func (ls *listStore[T]) LPop(key string) (T, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.popLocked(key, true)
+}
+
+// RPop will retrieve and remove the last item from the list.
+// It will check that the list exists, that it's not empty and that it has not expired.
+func (ls *listStore[T]) RPop(key string) (T, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.popLocked(key, false)
+}
+
+// LPopN will retrieve and remove up to n items from the start of the list, in order.
+// It will check that n is positive, that the list exists, that it's not empty and that it has not expired.
+func (ls *listStore[T]) LPopN(key string, n int) ([]T, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.popNLocked(key, true, n)
+}
+
+// RPopN will retrieve and remove up to n items from the end of the list, starting from the last one.
+// It will check that n is positive, that the list exists, that it's not empty and that it has not expired.
+func (ls *listStore[T]) RPopN(key string, n int) ([]T, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.popNLocked(key, false, n)
+}
+
+// LMove will atomically retrieve and remove the first or the last item of the source list
+// and add it to the start or the end of the destination list, which can be the same list.
+// It will check that both lists exist and have not expired, and that the source is not empty.
+func (ls *listStore[T]) LMove(src, dst string, srcLeft, dstLeft bool) (T, error) {
+	return ls.LMoveIf(src, dst, srcLeft, dstLeft, nil)
+}
+
+// LMoveIf is the same as LMove, but the item is only moved if check returns nil for it.
+// Otherwise the error of check is returned and both lists are left unchanged.
+// check is called while the store is locked, so it must not use the store.
+func (ls *listStore[T]) LMoveIf(src, dst string, srcLeft, dstLeft bool, check func(T) error) (T, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	var zero T
+	// The destination is checked first so nothing is popped if it can't be pushed.
+	if _, err := getValid(ls.store, dst); err != nil {
+		ls.events.notifyExpired(dst, err)
+		return zero, err
+	}
+	if check != nil {
+		v, err := getValid(ls.store, src)
+		if err != nil {
+			ls.events.notifyExpired(src, err)
+			return zero, err
+		}
+		n := v.Value.len()
+		if n == 0 {
+			return zero, ErrEmptyList
+		}
+		i := n - 1
+		if srcLeft {
+			i = 0
+		}
+		if err := check(v.Value.at(i)); err != nil {
+			return zero, err
+		}
+	}
+	val, err := ls.popLocked(src, srcLeft)
+	if err != nil {
+		return zero, err
+	}
+	if _, err := ls.pushLocked(dst, dstLeft, val); err != nil {
+		return zero, err
+	}
+	return val, nil
+}
+
+// RPopLPush will atomically move the last item of the source list to the start of the destination list.
+// It's the same as LMove(src, dst, false, true).
+func (ls *listStore[T]) RPopLPush(src, dst string) (T, error) {
+	return ls.LMove(src, dst, false, true)
+}
+
+// BLPop will retrieve and remove the first item of the first non-empty list among the given keys,
+// checked in order, and return its key. If all of them are empty or don't exist, it waits until
+// a value is added to any of them, the timeout is reached or the context is cancelled.
+// A zero timeout waits until the context is cancelled.
+// It will return ErrNoKeys if no key is given, ErrTimeout if the timeout is reached
+// and the error of the context if it's cancelled.
+func (ls *listStore[T]) BLPop(ctx context.Context, timeout time.Duration, keys ...string) (string, T, error) {
+	return ls.blockingPop(ctx, timeout, true, keys)
+}
+
+// BRPop is the same as BLPop, but it retrieves and removes the last item of the list.
+func (ls *listStore[T]) BRPop(ctx context.Context, timeout time.Duration, keys ...string) (string, T, error) {
+	return ls.blockingPop(ctx, timeout, false, keys)
+}
+
+func (ls *listStore[T]) blockingPop(ctx context.Context, timeout time.Duration, front bool, keys []string) (string, T, error) {
+	var (
+		key string
+		val T
+	)
+	err := ls.wait(ctx, timeout, keys, func() bool {
+		for _, k := range keys {
+			v, err := ls.popLocked(k, front)
+			if err == nil {
+				key, val = k, v
+				return true
+			}
+		}
+		return false
+	})
+	return key, val, err
+}
+
+// WaitNonEmpty will wait until any of the lists among the given keys has items, without popping them.
+// It returns right away if one of them already has items. It lets the callers that must pop the items
+// under their own lock, like the operation log, wait outside of it and then pop without waiting,
+// which may still fail if another caller popped the items first.
+// It will return ErrNoKeys if no key is given, ErrTimeout if the timeout is reached
+// and the error of the context if it's cancelled. A zero timeout waits until the context is cancelled.
+func (ls *listStore[T]) WaitNonEmpty(ctx context.Context, timeout time.Duration, keys ...string) error {
+	return ls.wait(ctx, timeout, keys, func() bool {
+		for _, key := range keys {
+			v, err := getValid(ls.store, key)
+			if err != nil {
+				ls.events.notifyExpired(key, err)
+				continue
+			}
+			if v.Value.len() > 0 {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// wait calls done under the lock until it returns true, waiting for a value to be added to any of
+// the keys between the calls. It returns ErrTimeout or the error of the context if it stops waiting.
+func (ls *listStore[T]) wait(ctx context.Context, timeout time.Duration, keys []string, done func() bool) error {
+	if len(keys) == 0 {
+		return ErrNoKeys
+	}
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	// The channel is registered for all the keys while waiting, and buffered so
+	// a push never blocks on it. Every push wakes all the waiters of the key and
+	// they race to pop the value, the ones that lose go back to wait.
+	ch := make(chan struct{}, 1)
+	for {
+		ls.mu.Lock()
+		ls.unwait(keys, ch)
+		if done() {
+			ls.mu.Unlock()
+			return nil
+		}
+		for _, key := range keys {
+			ls.waiters[key] = append(ls.waiters[key], ch)
+		}
+		ls.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-deadline:
+			ls.cancelWait(keys, ch)
+			return ErrTimeout
+		case <-ctx.Done():
+			ls.cancelWait(keys, ch)
+			return ctx.Err()
+		}
+	}
+}
+
+// wake notifies the blocking pops waiting for the key that a value has been added.
+// The caller must hold the lock.
+func (ls *listStore[T]) wake(key string) {
+	for _, ch := range ls.waiters[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	delete(ls.waiters, key)
+}
+
+// unwait removes the channel from the waiters of the keys. The caller must hold the lock.
+func (ls *listStore[T]) unwait(keys []string, ch chan struct{}) {
+	for _, key := range keys {
+		waiters := slices.DeleteFunc(ls.waiters[key], func(c chan struct{}) bool { return c == ch })
+		if len(waiters) == 0 {
+			delete(ls.waiters, key)
+		} else {
+			ls.waiters[key] = waiters
+		}
+	}
+}
+
+// cancelWait stops waiting for the keys. As every waiter of a key is woken up by a push,
+// no value is left behind if the blocking pop was woken up at the same time.
+func (ls *listStore[T]) cancelWait(keys []string, ch chan struct{}) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.unwait(keys, ch)
+}
+
+// popLocked removes and returns the first or the last item of the list.
+// The caller must hold the lock.
+func (ls *listStore[T]) popLocked(key string, front bool) (T, error) {
+	vals, err := ls.popNLocked(key, front, 1)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return vals[0], nil
+}
+
+// popNLocked removes and returns up to n items from the start or the end of the list.
+// The caller must hold the lock.
+func (ls *listStore[T]) popNLocked(key string, front bool, n int) ([]T, error) {
+	if n <= 0 {
+		return nil, ErrInvalidCount
+	}
+
+	v, err := getValid(ls.store, key)
+	if err != nil {
+		ls.events.notifyExpired(key, err)
+		return nil, err
+	}
+	if v.Value.len() == 0 {
+		return nil, ErrEmptyList
+	}
+
+	vals := make([]T, 0, min(n, v.Value.len()))
+	for len(vals) < n {
+		var (
+			val T
+			ok  bool
+		)
+		if front {
+			val, ok = v.Value.popFront()
+		} else {
+			val, ok = v.Value.popBack()
+		}
+		if !ok {
+			break
+		}
+		vals = append(vals, val)
+	}
+	stamp(ls.store, key, &ls.versions)
+	ls.events.notify(EventPop, key)
+
+	return vals, nil
+}
+
+// Range will return a copy of the items between the start and stop positions, both included.
+// Negative positions count from the end of the list, so -1 is the last item. Positions out
+// of the list are clamped and an empty slice is returned if the range is empty.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) Range(key string, start, stop int) ([]T, error) {
+	// A write lock is required as expired keys are removed on access.
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	v, err := readValid(ls.store, key)
+	if err != nil {
+		ls.events.notifyExpired(key, err)
+		return nil, err
+	}
+
+	start, stop, ok := normalizeRange(start, stop, v.Value.len())
+	if !ok {
+		return []T{}, nil
+	}
+	return v.Value.sub(start, stop+1), nil
+}
+
+// Index will return the item at the given position. Negative positions count from the end of the list.
+// It will return an error if the position is out of the list, if the list is not found or if it has expired.
+func (ls *listStore[T]) Index(key string, i int) (T, error) {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
+
 	var zero T
+	v, err := readValid(ls.store, key)
+	if err != nil {
+		ls.events.notifyExpired(key, err)
+		return zero, err
+	}
 
-	v, ok := ls.store[key]
+	i, ok := normalizeIndex(i, v.Value.len())
 	if !ok {
-		return zero, ErrNotFound
+		return zero, ErrIndexOutOfRange
 	}
+	return v.Value.at(i), nil
+}
 
-	// If the value has an expiration time and it is in the past, remove it
-	// and return an error indicating it has expired.
-	if !v.ExpiresAt.IsZero() && v.ExpiresAt.Before(time.Now()) {
-		delete(ls.store, key)
-		return zero, ErrExpired
+// SetIndex will replace the item at the given position. Negative positions count from the end of the list.
+// It will return an error if the position is out of the list, if the list is not found or if it has expired.
+func (ls *listStore[T]) SetIndex(key string, i int, val T) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	v, err := getValid(ls.store, key)
+	if err != nil {
+		ls.events.notifyExpired(key, err)
+		return err
 	}
 
-	if len(ls.store[key].Value) == 0 {
-		return zero, ErrEmptyList
+	i, ok := normalizeIndex(i, v.Value.len())
+	if !ok {
+		return ErrIndexOutOfRange
 	}
+	v.Value.set(i, val)
+	stamp(ls.store, key, &ls.versions)
+	ls.events.notify(EventUpdate, key)
+	return nil
+}
 
-	val := ls.store[key].Value[0]
+// Len will return the number of items in the list.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) Len(key string) (int, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
 
-	newList := ls.store[key]
-	newList.Value = ls.store[key].Value[1:]
-	ls.store[key] = newList
+	v, err := readValid(ls.store, key)
+	if err != nil {
+		ls.events.notifyExpired(key, err)
+		return 0, err
+	}
+	return v.Value.len(), nil
+}
 
-	return val, nil
+// Trim will keep only the items between the start and stop positions, both included,
+// using the same positions as Range. The list is left empty if the range is empty.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) Trim(key string, start, stop int) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	v, err := getValid(ls.store, key)
+	if err != nil {
+		ls.events.notifyExpired(key, err)
+		return err
+	}
+
+	var list []T
+	if start, stop, ok := normalizeRange(start, stop, v.Value.len()); ok {
+		list = v.Value.sub(start, stop+1)
+	}
+	if err := update(ls.store, key, newDeque(list)); err != nil {
+		return err
+	}
+	stamp(ls.store, key, &ls.versions)
+	ls.events.notify(EventUpdate, key)
+	return nil
+}
+
+// Insert will add the value right before or after the first item equal to pivot.
+// It returns the length of the list after the insert.
+// It will return an error if the pivot is not in the list, if the list is not found or if it has expired.
+func (ls *listStore[T]) Insert(key string, before bool, pivot, val T) (int, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	v, err := getValid(ls.store, key)
+	if err != nil {
+		ls.events.notifyExpired(key, err)
+		return 0, err
+	}
+
+	list := v.Value.slice()
+	i := slices.IndexFunc(list, func(item T) bool { return equal(item, pivot) })
+	if i < 0 {
+		return 0, ErrPivotNotFound
+	}
+	if !before {
+		i++
+	}
+	if err := update(ls.store, key, newDeque(slices.Insert(list, i, val))); err != nil {
+		return 0, err
+	}
+	stamp(ls.store, key, &ls.versions)
+	ls.events.notify(EventUpdate, key)
+	ls.wake(key)
+	return len(list) + 1, nil
+}
+
+// RemoveValue will remove the items equal to the given value and return how many were removed.
+// A positive count removes up to count items starting from the start of the list, a negative count
+// removes up to -count items starting from the end, and zero removes all of them.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) RemoveValue(key string, count int, val T) (int, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	v, err := getValid(ls.store, key)
+	if err != nil {
+		ls.events.notifyExpired(key, err)
+		return 0, err
+	}
+
+	list := v.Value.slice()
+	limit := count
+	if count < 0 {
+		limit = -count
+		slices.Reverse(list)
+	}
+	kept := list[:0]
+	removed := 0
+	for _, item := range list {
+		if (limit == 0 || removed < limit) && equal(item, val) {
+			removed++
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	if count < 0 {
+		slices.Reverse(kept)
+	}
+
+	if err := update(ls.store, key, newDeque(kept)); err != nil {
+		return 0, err
+	}
+	stamp(ls.store, key, &ls.versions)
+	ls.events.notify(EventUpdate, key)
+	return removed, nil
+}
+
+// Expire will make the list expire after the TTL, removing its sliding expiration.
+// A TTL that is not positive removes the list.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) Expire(key string, ttl time.Duration) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.expire(key, time.Now().Add(ttl), 0)
+}
+
+// ExpireAt will make the list expire at the given time, removing its sliding expiration.
+// A time in the past removes the list and the zero time removes its expiration, like Persist.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) ExpireAt(key string, t time.Time) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.expire(key, t, 0)
+}
+
+// ExpireSliding will make the list expire after the TTL, which starts again on every read of the list.
+// It will return ErrInvalidTTL if the TTL is not positive,
+// or an error if the list is not found or if it has expired.
+func (ls *listStore[T]) ExpireSliding(key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.expire(key, time.Now().Add(ttl), ttl)
+}
+
+// Persist will remove the expiration of the list, so it never expires.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) Persist(key string) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.expire(key, time.Time{}, 0)
+}
+
+// TTL will return the time left before the list expires, or NoTTL if it doesn't expire.
+// Unlike the other reads, it doesn't extend a sliding expiration.
+// It will return an error if the list is not found or if it has expired.
+func (ls *listStore[T]) TTL(key string) (time.Duration, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.ttl(key)
+}
+
+// Scan is the same as StringStore.Scan for the keys of the lists.
+func (ls *listStore[T]) Scan(cursor, match string, count int) ([]string, string, error) {
+	if count <= 0 {
+		return nil, "", ErrInvalidCount
+	}
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	keys, next := scanPage(scanKeys(ls.store, cursor, match, count), count)
+	return keys, next, nil
+}
+
+// Snapshot will return a point-in-time copy of all the lists that have not expired.
+// The lists are copied so they can be used safely while the store keeps changing.
+// The items reserved from a list and not acknowledged yet are put back at its start, in the order they
+// were reserved, so they're delivered again once restored.
+func (ls *listStore[T]) Snapshot() map[string]Value[[]T] {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	data := map[string]Value[[]T]{}
+	for key, v := range snapshot(ls.store) {
+		reserved := ls.sortedReservations(key)
+		list := make([]T, 0, len(reserved)+v.Value.len())
+		for _, r := range reserved {
+			list = append(list, r.Value)
+		}
+		list = append(list, v.Value.slice()...)
+		data[key] = Value[[]T]{Value: list, ExpiresAt: v.ExpiresAt, Version: v.Version, Sliding: v.Sliding}
+	}
+	return data
+}
+
+// Restore will load the given lists into the store, overwriting existing keys.
+// Lists that have already expired are dropped. It returns the number of lists loaded.
+// Keys held by another store of the keyspace are skipped, as a key holds one type of value.
+// The versions of the lists are kept, and lists without version are given a new one.
+func (ls *listStore[T]) Restore(data map[string]Value[[]T]) int {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	deques := make(map[string]Value[*deque[T]], len(data))
+	for key, v := range data {
+		if ls.keyspace.claim(ls, key) != nil {
+			continue
+		}
+		deques[key] = Value[*deque[T]]{Value: newDeque(v.Value), ExpiresAt: v.ExpiresAt, Version: v.Version, Sliding: v.Sliding}
+	}
+	return restoreVersioned(ls.store, deques, &ls.versions)
+}
+
+// SweepStats returns the counters of the background sweeper.
+// All the counters are zero if the sweeper is not enabled.
+func (ls *listStore[T]) SweepStats() SweepStats {
+	return ls.sweeper.stats()
+}
+
+// Close stops the background sweeper, if enabled.
+// The store remains usable after closing it.
+func (ls *listStore[T]) Close() error {
+	ls.sweeper.stop()
+	return nil
+}
+
+// normalizeIndex converts a position, which can be negative, into a valid index for
+// a list of the given length. It returns false if the position is out of the list.
+func normalizeIndex(i, length int) (int, bool) {
+	if i < 0 {
+		i += length
+	}
+	return i, i >= 0 && i < length
+}
+
+// equal reports whether two list items are the same. Items can be of any type,
+// so they are compared deeply instead of with ==.
+func equal[T any](a, b T) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// sweep also puts back the reserved items whose visibility timeout is over.
+func (ls *listStore[T]) sweep(sampleSize int) (sampled, expired int) {
+	ls.requeueAllExpired()
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return sweepExpired(ls.store, sampleSize, ls.events.onExpired)
+}
+
+func (ls *listStore[T]) keyType() KeyType {
+	return TypeList
+}
+
+func (ls *listStore[T]) has(key string) bool {
+	_, err := getValid(ls.store, key)
+	ls.events.notifyExpired(key, err)
+	return err == nil
+}
+
+func (ls *listStore[T]) drop(key string) bool {
+	if !ls.has(key) {
+		return false
+	}
+	delete(ls.store, key)
+	ls.events.notify(EventRemove, key)
+	return true
+}
+
+func (ls *listStore[T]) move(src, dst string) {
+	moveKey(ls.store, src, dst)
+	stamp(ls.store, dst, &ls.versions)
+	ls.events.notify(EventRemove, src)
+	ls.events.notify(EventSet, dst)
+	ls.wake(dst)
+}
+
+func (ls *listStore[T]) count() int {
+	return countValid(ls.store)
+}
+
+func (ls *listStore[T]) scan(cursor, match string, count int) []string {
+	return scanKeys(ls.store, cursor, match, count)
+}
+
+func (ls *listStore[T]) expire(key string, at time.Time, sliding time.Duration) error {
+	ok, err := expireKey(ls.store, key, at, sliding)
+	if ok {
+		stamp(ls.store, key, &ls.versions)
+	}
+	notifyExpire(&ls.events, key, ok, err)
+	return err
+}
+
+func (ls *listStore[T]) ttl(key string) (time.Duration, error) {
+	d, err := ttlOf(ls.store, key)
+	ls.events.notifyExpired(key, err)
+	return d, err
 }
@@ -1,7 +1,9 @@
 package storage_test
 
 import (
+	"context"
 	"in-memory-storage/storage"
+	"sync"
 	"testing"
 	"time"
 
@@ -42,7 +44,7 @@ func TestListStore_Set(t *testing.T) {
 			val, err := store.Get(tc.key)
 			assert.Nil(t, err)
 
-			assert.Equal(t, tc.expectedVal, val)
+			assert.Equal(t, tc.expectedVal.Value, val.Value)
 		})
 	}
 }
@@ -81,7 +83,7 @@ func TestListStore_Set_Int(t *testing.T) {
 			val, err := store.Get(tc.key)
 			assert.Nil(t, err)
 
-			assert.Equal(t, tc.expectedList, val)
+			assert.Equal(t, tc.expectedList.Value, val.Value)
 		})
 	}
 }
@@ -190,7 +192,7 @@ func TestListStore_Update(t *testing.T) {
 			if err == nil {
 				val, err := store.Get(tc.key)
 				assert.Nil(t, err)
-				assert.Equal(t, tc.expectedList, val)
+				assert.Equal(t, tc.expectedList.Value, val.Value)
 			}
 		})
 	}
@@ -279,7 +281,7 @@ func TestListStore_Push(t *testing.T) {
 			if err == nil {
 				list, err := store.Get(tc.key)
 				assert.Nil(t, err)
-				assert.Equal(t, tc.expectedList, list)
+				assert.Equal(t, tc.expectedList.Value, list.Value)
 			}
 		})
 	}
@@ -339,8 +341,632 @@ func TestListStore_Pop(t *testing.T) {
 			if err == nil {
 				list, err := store.Get(tc.key)
 				assert.Nil(t, err)
-				assert.Equal(t, tc.expectedList, list)
+				assert.Equal(t, tc.expectedList.Value, list.Value)
 			}
 		})
 	}
 }
+
+func TestListStore_LPushRPush(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	// Populate existing values
+	err := store.Set("existing-key", []string{"b"}, 0)
+	assert.Nil(t, err)
+
+	n, err := store.LPush("existing-key", "a1", "a2")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = store.RPush("existing-key", "c1", "c2")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, n)
+
+	list, err := store.Get("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a2", "a1", "b", "c1", "c2"}, list.Value)
+
+	_, err = store.LPush("new-key", "a")
+	assert.Equal(t, storage.ErrNotFound, err)
+	_, err = store.RPush("new-key", "a")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestListStore_LPopRPop(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	// Populate existing values
+	err := store.Set("existing-key", []string{"a", "b", "c"}, 0)
+	assert.Nil(t, err)
+
+	val, err := store.RPop("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, "c", val)
+
+	val, err = store.LPop("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", val)
+
+	val, err = store.RPop("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", val)
+
+	_, err = store.LPop("existing-key")
+	assert.Equal(t, storage.ErrEmptyList, err)
+	_, err = store.RPop("existing-key")
+	assert.Equal(t, storage.ErrEmptyList, err)
+	_, err = store.RPop("new-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestListStore_PopN(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	testCases := map[string]struct {
+		list         []string
+		front        bool
+		n            int
+		expectedVals []string
+		expectedList []string
+		expectedErr  error
+	}{
+		"it should return an error if the count is not positive": {
+			list:         []string{"a"},
+			n:            0,
+			expectedErr:  storage.ErrInvalidCount,
+			expectedList: []string{"a"},
+		},
+		"it should return an error if the list is empty": {
+			list:         []string{},
+			n:            1,
+			expectedErr:  storage.ErrEmptyList,
+			expectedList: []string{},
+		},
+		"it should pop from the start of the list": {
+			list:         []string{"a", "b", "c"},
+			front:        true,
+			n:            2,
+			expectedVals: []string{"a", "b"},
+			expectedList: []string{"c"},
+		},
+		"it should pop from the end of the list": {
+			list:         []string{"a", "b", "c"},
+			n:            2,
+			expectedVals: []string{"c", "b"},
+			expectedList: []string{"a"},
+		},
+		"it should pop the whole list if the count is bigger": {
+			list:         []string{"a", "b"},
+			front:        true,
+			n:            5,
+			expectedVals: []string{"a", "b"},
+			expectedList: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := store.Set(name, tc.list, 0)
+			assert.Nil(t, err)
+
+			var vals []string
+			if tc.front {
+				vals, err = store.LPopN(name, tc.n)
+			} else {
+				vals, err = store.RPopN(name, tc.n)
+			}
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedVals, vals)
+
+			list, err := store.Get(name)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedList, list.Value)
+		})
+	}
+
+	_, err := store.LPopN("new-key", 1)
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestListStore_Queue(t *testing.T) {
+	store := storage.NewListStore[int]()
+
+	// Push and pop far more values than the list ever holds, so the ring buffer wraps around,
+	// grows and shrinks, and check the values still come out in order.
+	err := store.Set("queue", []int{}, 0)
+	assert.Nil(t, err)
+
+	next, expected := 0, 0
+	for round := 0; round < 50; round++ {
+		for i := 0; i < round%13+1; i++ {
+			_, err := store.RPush("queue", next)
+			assert.Nil(t, err)
+			next++
+		}
+		for i := 0; i < round%7+1; i++ {
+			val, err := store.LPop("queue")
+			if err == storage.ErrEmptyList {
+				break
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, expected, val)
+			expected++
+		}
+	}
+
+	list, err := store.Get("queue")
+	assert.Nil(t, err)
+	assert.Len(t, list.Value, next-expected)
+	for i, val := range list.Value {
+		assert.Equal(t, expected+i, val)
+	}
+
+	// Popping everything from the back leaves the values in reverse order
+	vals, err := store.RPopN("queue", next)
+	assert.Nil(t, err)
+	assert.Len(t, vals, next-expected)
+	for i, val := range vals {
+		assert.Equal(t, next-1-i, val)
+	}
+}
+
+func TestListStore_BLPop(t *testing.T) {
+	store := storage.NewListStore[string]()
+	ctx := context.Background()
+
+	// Populate existing values
+	err := store.Set("empty-key", []string{}, 0)
+	assert.Nil(t, err)
+	err = store.Set("existing-key", []string{"a", "b"}, 0)
+	assert.Nil(t, err)
+
+	t.Run("it should return an error if no key is given", func(t *testing.T) {
+		_, _, err := store.BLPop(ctx, time.Second)
+		assert.Equal(t, storage.ErrNoKeys, err)
+	})
+
+	t.Run("it should pop from the first non-empty list without waiting", func(t *testing.T) {
+		key, val, err := store.BLPop(ctx, time.Second, "missing-key", "empty-key", "existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, "existing-key", key)
+		assert.Equal(t, "a", val)
+
+		key, val, err = store.BRPop(ctx, time.Second, "existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, "existing-key", key)
+		assert.Equal(t, "b", val)
+	})
+
+	t.Run("it should return an error if the timeout is reached", func(t *testing.T) {
+		start := time.Now()
+		_, _, err := store.BLPop(ctx, 20*time.Millisecond, "empty-key")
+		assert.Equal(t, storage.ErrTimeout, err)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("it should return an error if the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, _, err := store.BLPop(ctx, 0, "empty-key")
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+
+	t.Run("it should be woken up by a push", func(t *testing.T) {
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_, err := store.RPush("empty-key", "pushed")
+			assert.Nil(t, err)
+		}()
+
+		key, val, err := store.BLPop(ctx, time.Second, "other-key", "empty-key")
+		assert.Nil(t, err)
+		assert.Equal(t, "empty-key", key)
+		assert.Equal(t, "pushed", val)
+	})
+
+	t.Run("it should be woken up when the list is created", func(t *testing.T) {
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			err := store.Set("new-key", []string{"created"}, 0)
+			assert.Nil(t, err)
+		}()
+
+		key, val, err := store.BLPop(ctx, time.Second, "new-key")
+		assert.Nil(t, err)
+		assert.Equal(t, "new-key", key)
+		assert.Equal(t, "created", val)
+	})
+}
+
+func TestListStore_BLPop_Concurrent(t *testing.T) {
+	store := storage.NewListStore[int]()
+	ctx := context.Background()
+
+	err := store.Set("queue", []int{}, 0)
+	assert.Nil(t, err)
+
+	// Every value pushed is popped exactly once by the waiting consumers
+	const consumers, values = 8, 200
+	popped := make(chan int, values)
+	var wg sync.WaitGroup
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				_, val, err := store.BLPop(ctx, 100*time.Millisecond, "queue")
+				if err == storage.ErrTimeout {
+					return
+				}
+				assert.Nil(t, err)
+				popped <- val
+			}
+		}()
+	}
+	for i := 0; i < values; i++ {
+		_, err := store.RPush("queue", i)
+		assert.Nil(t, err)
+	}
+	wg.Wait()
+	close(popped)
+
+	seen := map[int]bool{}
+	for val := range popped {
+		assert.False(t, seen[val])
+		seen[val] = true
+	}
+	assert.Len(t, seen, values)
+}
+
+func TestListStore_WaitNonEmpty(t *testing.T) {
+	store := storage.NewListStore[int]()
+	waiter := store.(storage.ListWaiter)
+	ctx := context.Background()
+
+	err := store.Set("queue", []int{}, 0)
+	assert.Nil(t, err)
+
+	assert.Equal(t, storage.ErrNoKeys, waiter.WaitNonEmpty(ctx, time.Second))
+	assert.Equal(t, storage.ErrTimeout, waiter.WaitNonEmpty(ctx, 10*time.Millisecond, "queue", "missing"))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, context.Canceled, waiter.WaitNonEmpty(cancelled, 0, "queue"))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, err := store.RPush("queue", 1)
+		assert.Nil(t, err)
+	}()
+	assert.Nil(t, waiter.WaitNonEmpty(ctx, time.Second, "missing", "queue"))
+
+	// The items are not popped, so it returns right away while the list has items
+	assert.Nil(t, waiter.WaitNonEmpty(ctx, time.Second, "queue"))
+	n, err := store.Len("queue")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestListStore_Range(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	// Populate existing values
+	err := store.Set("existing-key", []string{"a", "b", "c", "d"}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		key          string
+		start, stop  int
+		expectedList []string
+		expectedErr  error
+	}{
+		"it should return an error if key not found": {
+			key:         "new-key",
+			expectedErr: storage.ErrNotFound,
+		},
+		"it should return the whole list": {
+			key:          "existing-key",
+			start:        0,
+			stop:         -1,
+			expectedList: []string{"a", "b", "c", "d"},
+		},
+		"it should return a range": {
+			key:          "existing-key",
+			start:        1,
+			stop:         2,
+			expectedList: []string{"b", "c"},
+		},
+		"it should accept negative positions": {
+			key:          "existing-key",
+			start:        -2,
+			stop:         -1,
+			expectedList: []string{"c", "d"},
+		},
+		"it should clamp positions out of the list": {
+			key:          "existing-key",
+			start:        -10,
+			stop:         10,
+			expectedList: []string{"a", "b", "c", "d"},
+		},
+		"it should return an empty list if the range is empty": {
+			key:          "existing-key",
+			start:        3,
+			stop:         1,
+			expectedList: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			list, err := store.Range(tc.key, tc.start, tc.stop)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedList, list)
+		})
+	}
+}
+
+func TestListStore_IndexSetIndexLen(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	// Populate existing values
+	err := store.Set("existing-key", []string{"a", "b", "c"}, 0)
+	assert.Nil(t, err)
+
+	val, err := store.Index("existing-key", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", val)
+
+	val, err = store.Index("existing-key", -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "c", val)
+
+	_, err = store.Index("existing-key", 3)
+	assert.Equal(t, storage.ErrIndexOutOfRange, err)
+	_, err = store.Index("existing-key", -4)
+	assert.Equal(t, storage.ErrIndexOutOfRange, err)
+	_, err = store.Index("new-key", 0)
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	before, err := store.Get("existing-key")
+	assert.Nil(t, err)
+	err = store.SetIndex("existing-key", -2, "x")
+	assert.Nil(t, err)
+	after, err := store.Get("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "x", "c"}, after.Value)
+	assert.Greater(t, after.Version, before.Version)
+
+	err = store.SetIndex("existing-key", 5, "x")
+	assert.Equal(t, storage.ErrIndexOutOfRange, err)
+	err = store.SetIndex("new-key", 0, "x")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	n, err := store.Len("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	_, err = store.Len("new-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestListStore_Trim(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	testCases := map[string]struct {
+		start, stop  int
+		expectedList []string
+	}{
+		"it should keep the range": {
+			start:        1,
+			stop:         -2,
+			expectedList: []string{"b", "c"},
+		},
+		"it should keep the whole list": {
+			start:        0,
+			stop:         -1,
+			expectedList: []string{"a", "b", "c", "d"},
+		},
+		"it should empty the list if the range is empty": {
+			start:        5,
+			stop:         10,
+			expectedList: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := store.Set(name, []string{"a", "b", "c", "d"}, 0)
+			assert.Nil(t, err)
+
+			err = store.Trim(name, tc.start, tc.stop)
+			assert.Nil(t, err)
+
+			list, err := store.Get(name)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedList, list.Value)
+		})
+	}
+
+	err := store.Trim("new-key", 0, -1)
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestListStore_Insert(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	// Populate existing values
+	err := store.Set("existing-key", []string{"a", "c"}, 0)
+	assert.Nil(t, err)
+
+	n, err := store.Insert("existing-key", true, "c", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = store.Insert("existing-key", false, "c", "d")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+
+	list, err := store.Get("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, list.Value)
+
+	_, err = store.Insert("existing-key", true, "z", "y")
+	assert.Equal(t, storage.ErrPivotNotFound, err)
+	_, err = store.Insert("new-key", true, "a", "b")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestListStore_RemoveValue(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	testCases := map[string]struct {
+		count           int
+		val             string
+		expectedRemoved int
+		expectedList    []string
+	}{
+		"it should remove all the items": {
+			count:           0,
+			val:             "x",
+			expectedRemoved: 3,
+			expectedList:    []string{"a", "b"},
+		},
+		"it should remove the first items": {
+			count:           2,
+			val:             "x",
+			expectedRemoved: 2,
+			expectedList:    []string{"a", "b", "x"},
+		},
+		"it should remove the last items": {
+			count:           -2,
+			val:             "x",
+			expectedRemoved: 2,
+			expectedList:    []string{"x", "a", "b"},
+		},
+		"it should not remove anything if the value is not in the list": {
+			count:           0,
+			val:             "z",
+			expectedRemoved: 0,
+			expectedList:    []string{"x", "a", "x", "b", "x"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := store.Set(name, []string{"x", "a", "x", "b", "x"}, 0)
+			assert.Nil(t, err)
+
+			removed, err := store.RemoveValue(name, tc.count, tc.val)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedRemoved, removed)
+
+			list, err := store.Get(name)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedList, list.Value)
+		})
+	}
+
+	_, err := store.RemoveValue("new-key", 0, "x")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestListStore_RemoveValue_Struct(t *testing.T) {
+	type job struct {
+		ID   int
+		Tags []string
+	}
+	store := storage.NewListStore[job]()
+
+	// Items that are not comparable with == can still be matched
+	err := store.Set("jobs", []job{{ID: 1, Tags: []string{"a"}}, {ID: 2}}, 0)
+	assert.Nil(t, err)
+
+	removed, err := store.RemoveValue("jobs", 0, job{ID: 1, Tags: []string{"a"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, removed)
+
+	list, err := store.Get("jobs")
+	assert.Nil(t, err)
+	assert.Equal(t, []job{{ID: 2}}, list.Value)
+}
+
+func TestListStore_SnapshotRestore(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	// Populate existing values
+	err := store.Set("existing-key", []string{"a", "b"}, 0)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", []string{"c"}, time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	data := store.Snapshot()
+	assert.Len(t, data, 1)
+	assert.Equal(t, []string{"a", "b"}, data["existing-key"].Value)
+
+	// Changes in the store are not reflected in the snapshot
+	err = store.Push("existing-key", "c")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, data["existing-key"].Value)
+
+	newStore := storage.NewListStore[string]()
+	restored := newStore.Restore(data)
+	assert.Equal(t, 1, restored)
+
+	val, err := newStore.Get("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, val.Value)
+}
+
+func TestListStore_Expire(t *testing.T) {
+	store := storage.NewListStore[string]()
+	assert.NoError(t, store.Set("queue", []string{"a"}, 0))
+
+	assert.Equal(t, storage.ErrNotFound, store.Expire("missing", time.Minute))
+
+	assert.NoError(t, store.Expire("queue", time.Minute))
+	ttl, err := store.TTL("queue")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	assert.NoError(t, store.Persist("queue"))
+	ttl, err = store.TTL("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.NoTTL, ttl)
+
+	assert.NoError(t, store.ExpireSliding("queue", 40*time.Millisecond))
+	for range 3 {
+		time.Sleep(20 * time.Millisecond)
+		_, err := store.Len("queue")
+		assert.NoError(t, err)
+	}
+	snapshot := store.Snapshot()
+	assert.Equal(t, 40*time.Millisecond, snapshot["queue"].Sliding)
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = store.Range("queue", 0, -1)
+	assert.Equal(t, storage.ErrExpired, err)
+}
+
+func TestListStore_SetModes(t *testing.T) {
+	store := storage.NewListStore[string]()
+	assert.NoError(t, store.Set("queue", []string{"a"}, 0))
+	assert.NoError(t, store.ExpireSliding("queue", time.Minute))
+
+	err := store.Set("missing", []string{"b"}, 0, storage.WithSetMode(storage.SetIfExists))
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	err = store.Set("queue", []string{"b", "c"}, 0, storage.WithSetMode(storage.SetAlways), storage.WithKeepTTL())
+	assert.NoError(t, err)
+	val, err := store.Get("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, val.Value)
+	assert.Equal(t, time.Minute, val.Sliding)
+
+	err = store.Set("queue", []string{"d"}, time.Hour, storage.WithSetMode(storage.SetIfExists))
+	assert.NoError(t, err)
+	val, err = store.Get("queue")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, val.Value)
+	assert.Zero(t, val.Sliding)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), val.ExpiresAt, time.Second)
+}
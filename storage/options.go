@@ -0,0 +1,106 @@
+package storage
+
+import "time"
+
+const defaultSweepSampleSize = 20
+
+// Option configures optional behaviour of the stores.
+type Option func(*options)
+
+type options struct {
+	sweepInterval   time.Duration
+	sweepSampleSize int
+	keyspace        *keyspace
+	observers       []Observer
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		sweepSampleSize: defaultSweepSampleSize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithSweeper enables the background sweeper, which actively removes expired
+// keys every interval instead of waiting for them to be accessed.
+// The sweeper is stopped by calling Close on the store.
+func WithSweeper(interval time.Duration) Option {
+	return func(o *options) {
+		o.sweepInterval = interval
+	}
+}
+
+// WithSweepSampleSize sets how many keys with a TTL are checked on each sweep
+// iteration. Defaults to 20.
+func WithSweepSampleSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.sweepSampleSize = n
+		}
+	}
+}
+
+// SetMode decides whether Set creates the key, replaces it or both.
+type SetMode int
+
+const (
+	// SetIfNotExists only creates the key, and fails with ErrAlreadyExists if it exists. It's the default.
+	SetIfNotExists SetMode = iota
+	// SetIfExists only replaces the key, and fails with ErrNotFound if it doesn't exist or has expired.
+	SetIfExists
+	// SetAlways creates the key or replaces it.
+	SetAlways
+)
+
+// SetOption configures how Set writes a key.
+type SetOption func(*setOptions)
+
+type setOptions struct {
+	mode         SetMode
+	keepTTL      bool
+	checkVersion bool
+	version      uint64
+	flags        uint32
+}
+
+func newSetOptions(opts []SetOption) setOptions {
+	var o setOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithSetMode sets whether Set creates the key, replaces it or both. Defaults to SetIfNotExists.
+func WithSetMode(mode SetMode) SetOption {
+	return func(o *setOptions) {
+		o.mode = mode
+	}
+}
+
+// WithKeepTTL keeps the expiration of the key when Set replaces it, instead of using the given TTL.
+// The TTL is still used when the key is created.
+func WithKeepTTL() SetOption {
+	return func(o *setOptions) {
+		o.keepTTL = true
+	}
+}
+
+// WithFlags stores the flags with the value, see Value.Flags. It's ignored by the stores other than the StringStore.
+func WithFlags(flags uint32) SetOption {
+	return func(o *setOptions) {
+		o.flags = flags
+	}
+}
+
+// WithVersion only lets Set replace the key if its version is the given one, and fails with
+// ErrVersionMismatch otherwise. As the key must exist, Set fails with ErrNotFound if it doesn't.
+func WithVersion(version uint64) SetOption {
+	return func(o *setOptions) {
+		o.checkVersion = true
+		o.version = version
+	}
+}
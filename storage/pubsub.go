@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"sync"
+)
+
+// DefaultPubSubBufferSize is the number of messages buffered by each subscription when
+// WithBufferSize is not given.
+const DefaultPubSubBufferSize = 1024
+
+// PubSub delivers the messages published to a channel to the subscriptions of the channel
+// and of the glob patterns matching it. Messages are not stored, so they're only received
+// by the subscriptions that exist when they're published.
+//
+// Each subscription buffers a bounded number of messages. A subscription whose buffer is full
+// when a message is published is considered a slow consumer and is closed, with ErrSlowConsumer,
+// so it never slows down the publishers or grows without limit.
+type PubSub interface {
+	// Publish sends the payload to the subscriptions of the channel and of the patterns matching it,
+	// and returns the number of subscriptions that received it. A subscription matching the channel
+	// and some patterns receives the message once for each of them.
+	Publish(channel, payload string) int
+	// Subscribe creates a subscription to the channels.
+	Subscribe(channels ...string) *Subscription
+	// PSubscribe creates a subscription to the glob patterns, with the syntax of Scan.
+	PSubscribe(patterns ...string) *Subscription
+	// Close closes all the subscriptions with ErrPubSubClosed. New subscriptions are created closed.
+	Close() error
+}
+
+// Message is a message received by a subscription.
+type Message struct {
+	Channel string
+	// Pattern is the pattern the channel matched, or empty if the message
+	// was received from a subscription to the channel itself.
+	Pattern string
+	Payload string
+}
+
+// PubSubOption configures optional behaviour of the PubSub.
+type PubSubOption func(*pubSub)
+
+// WithBufferSize sets the number of messages buffered by each subscription.
+// Defaults to DefaultPubSubBufferSize.
+func WithBufferSize(n int) PubSubOption {
+	return func(ps *pubSub) {
+		if n > 0 {
+			ps.bufferSize = n
+		}
+	}
+}
+
+type pubSub struct {
+	mu         sync.Mutex
+	bufferSize int
+	channels   map[string]map[*Subscription]struct{}
+	patterns   map[string]map[*Subscription]struct{}
+	closed     bool
+}
+
+// NewPubSub creates a new PubSub with the provided options.
+func NewPubSub(opts ...PubSubOption) PubSub {
+	ps := &pubSub{
+		bufferSize: DefaultPubSubBufferSize,
+		channels:   map[string]map[*Subscription]struct{}{},
+		patterns:   map[string]map[*Subscription]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(ps)
+	}
+	return ps
+}
+
+func (ps *pubSub) Publish(channel, payload string) int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	n := 0
+	for sub := range ps.channels[channel] {
+		if ps.deliver(sub, Message{Channel: channel, Payload: payload}) {
+			n++
+		}
+	}
+	for pattern, subs := range ps.patterns {
+		if !matchGlob(pattern, channel) {
+			continue
+		}
+		for sub := range subs {
+			if ps.deliver(sub, Message{Channel: channel, Pattern: pattern, Payload: payload}) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (ps *pubSub) Subscribe(channels ...string) *Subscription {
+	sub := ps.newSubscription()
+	_ = sub.Subscribe(channels...)
+	return sub
+}
+
+func (ps *pubSub) PSubscribe(patterns ...string) *Subscription {
+	sub := ps.newSubscription()
+	_ = sub.PSubscribe(patterns...)
+	return sub
+}
+
+func (ps *pubSub) Close() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.closed = true
+	for _, subs := range ps.channels {
+		for sub := range subs {
+			ps.drop(sub, ErrPubSubClosed)
+		}
+	}
+	for _, subs := range ps.patterns {
+		for sub := range subs {
+			ps.drop(sub, ErrPubSubClosed)
+		}
+	}
+	return nil
+}
+
+func (ps *pubSub) newSubscription() *Subscription {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	sub := &Subscription{
+		ps:       ps,
+		messages: make(chan Message, ps.bufferSize),
+		channels: map[string]struct{}{},
+		patterns: map[string]struct{}{},
+	}
+	if ps.closed {
+		sub.closed, sub.err = true, ErrPubSubClosed
+		close(sub.messages)
+	}
+	return sub
+}
+
+// deliver sends the message to the subscription, dropping it if its buffer is full.
+// It reports whether the message was sent. The caller must hold the lock.
+func (ps *pubSub) deliver(sub *Subscription, msg Message) bool {
+	select {
+	case sub.messages <- msg:
+		return true
+	default:
+		ps.drop(sub, ErrSlowConsumer)
+		return false
+	}
+}
+
+// drop unsubscribes the subscription from everything and closes it with the error,
+// which is nil if it was closed by its owner. The caller must hold the lock.
+func (ps *pubSub) drop(sub *Subscription, err error) {
+	if sub.closed {
+		return
+	}
+	for channel := range sub.channels {
+		unsubscribe(ps.channels, channel, sub)
+	}
+	for pattern := range sub.patterns {
+		unsubscribe(ps.patterns, pattern, sub)
+	}
+	sub.closed, sub.err = true, err
+	close(sub.messages)
+}
+
+// unsubscribe removes the subscription from the subscribers of the channel or pattern.
+// The caller must hold the lock.
+func unsubscribe(subs map[string]map[*Subscription]struct{}, name string, sub *Subscription) {
+	delete(subs[name], sub)
+	if len(subs[name]) == 0 {
+		delete(subs, name)
+	}
+}
+
+// Subscription receives the messages of the channels and patterns it's subscribed to.
+// It's safe for concurrent use.
+type Subscription struct {
+	ps       *pubSub
+	messages chan Message
+	// The fields below are guarded by the lock of the PubSub.
+	channels map[string]struct{}
+	patterns map[string]struct{}
+	closed   bool
+	err      error
+}
+
+// Messages returns the channel the messages are received from. It's closed when the
+// subscription is closed, after the messages already buffered have been received.
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+// Subscribe adds the channels to the subscription.
+// It will return ErrSubscriptionClosed if the subscription is closed.
+func (s *Subscription) Subscribe(channels ...string) error {
+	return s.add(s.ps.channels, s.channels, channels)
+}
+
+// PSubscribe adds the glob patterns to the subscription.
+// It will return ErrSubscriptionClosed if the subscription is closed.
+func (s *Subscription) PSubscribe(patterns ...string) error {
+	return s.add(s.ps.patterns, s.patterns, patterns)
+}
+
+// Unsubscribe removes the channels from the subscription, or all of them if none is given.
+// The subscription stays open, so more channels can be added later.
+func (s *Subscription) Unsubscribe(channels ...string) {
+	s.remove(s.ps.channels, s.channels, channels)
+}
+
+// PUnsubscribe removes the patterns from the subscription, or all of them if none is given.
+func (s *Subscription) PUnsubscribe(patterns ...string) {
+	s.remove(s.ps.patterns, s.patterns, patterns)
+}
+
+// Close unsubscribes from everything and closes the channel of the messages.
+// Closing a subscription more than once has no effect.
+func (s *Subscription) Close() error {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	s.ps.drop(s, nil)
+	return nil
+}
+
+// Err returns why the subscription was closed: ErrSlowConsumer if it didn't keep up with
+// the messages, ErrPubSubClosed if the PubSub was closed, and nil if it's open or was
+// closed by Close.
+func (s *Subscription) Err() error {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	return s.err
+}
+
+func (s *Subscription) add(all map[string]map[*Subscription]struct{}, own map[string]struct{}, names []string) error {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+
+	if s.closed {
+		return ErrSubscriptionClosed
+	}
+	for _, name := range names {
+		if all[name] == nil {
+			all[name] = map[*Subscription]struct{}{}
+		}
+		all[name][s] = struct{}{}
+		own[name] = struct{}{}
+	}
+	return nil
+}
+
+func (s *Subscription) remove(all map[string]map[*Subscription]struct{}, own map[string]struct{}, names []string) {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+
+	if len(names) == 0 {
+		for name := range own {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		if _, ok := own[name]; !ok {
+			continue
+		}
+		unsubscribe(all, name, s)
+		delete(own, name)
+	}
+}
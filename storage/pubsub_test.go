@@ -0,0 +1,145 @@
+package storage_test
+
+import (
+	"in-memory-storage/storage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// received drains the messages buffered in the subscription.
+func received(sub *storage.Subscription) []storage.Message {
+	var msgs []storage.Message
+	for {
+		select {
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return msgs
+			}
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestPubSub_Publish(t *testing.T) {
+	testCases := map[string]struct {
+		subscribe         func(ps storage.PubSub) *storage.Subscription
+		channel           string
+		expectedReceivers int
+		expectedMessages  []storage.Message
+	}{
+		"it should deliver the messages of a subscribed channel": {
+			subscribe: func(ps storage.PubSub) *storage.Subscription {
+				return ps.Subscribe("news", "sports")
+			},
+			channel:           "news",
+			expectedReceivers: 1,
+			expectedMessages:  []storage.Message{{Channel: "news", Payload: "hello"}},
+		},
+		"it should not deliver the messages of other channels": {
+			subscribe: func(ps storage.PubSub) *storage.Subscription {
+				return ps.Subscribe("sports")
+			},
+			channel: "news",
+		},
+		"it should deliver the messages of channels matching a pattern": {
+			subscribe: func(ps storage.PubSub) *storage.Subscription {
+				return ps.PSubscribe("news.*")
+			},
+			channel:           "news.tech",
+			expectedReceivers: 1,
+			expectedMessages:  []storage.Message{{Channel: "news.tech", Pattern: "news.*", Payload: "hello"}},
+		},
+		"it should deliver a message once for each match": {
+			subscribe: func(ps storage.PubSub) *storage.Subscription {
+				sub := ps.Subscribe("news.tech")
+				assert.NoError(t, sub.PSubscribe("news.*"))
+				return sub
+			},
+			channel:           "news.tech",
+			expectedReceivers: 2,
+			expectedMessages: []storage.Message{
+				{Channel: "news.tech", Payload: "hello"},
+				{Channel: "news.tech", Pattern: "news.*", Payload: "hello"},
+			},
+		},
+		"it should not deliver the messages of unsubscribed channels": {
+			subscribe: func(ps storage.PubSub) *storage.Subscription {
+				sub := ps.Subscribe("news", "sports")
+				sub.Unsubscribe("news")
+				return sub
+			},
+			channel: "news",
+		},
+		"it should not deliver the messages of unsubscribed patterns": {
+			subscribe: func(ps storage.PubSub) *storage.Subscription {
+				sub := ps.PSubscribe("news.*", "sports.*")
+				sub.PUnsubscribe()
+				return sub
+			},
+			channel: "news.tech",
+		},
+		"it should not deliver messages to closed subscriptions": {
+			subscribe: func(ps storage.PubSub) *storage.Subscription {
+				sub := ps.Subscribe("news")
+				assert.NoError(t, sub.Close())
+				return sub
+			},
+			channel: "news",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ps := storage.NewPubSub()
+			sub := tc.subscribe(ps)
+
+			assert.Equal(t, tc.expectedReceivers, ps.Publish(tc.channel, "hello"))
+			assert.Equal(t, tc.expectedMessages, received(sub))
+		})
+	}
+}
+
+func TestPubSub_SlowConsumer(t *testing.T) {
+	ps := storage.NewPubSub(storage.WithBufferSize(2))
+	slow := ps.Subscribe("news")
+	fast := ps.Subscribe("news")
+
+	assert.Equal(t, 2, ps.Publish("news", "1"))
+	assert.Equal(t, 2, ps.Publish("news", "2"))
+	assert.Len(t, received(fast), 2)
+
+	// The buffer of the slow subscription is full, so it's dropped
+	assert.Equal(t, 1, ps.Publish("news", "3"))
+	assert.Equal(t, []storage.Message{{Channel: "news", Payload: "1"}, {Channel: "news", Payload: "2"}}, received(slow))
+	_, ok := <-slow.Messages()
+	assert.False(t, ok)
+	assert.Equal(t, storage.ErrSlowConsumer, slow.Err())
+	assert.Equal(t, storage.ErrSubscriptionClosed, slow.Subscribe("sports"))
+
+	assert.Equal(t, []storage.Message{{Channel: "news", Payload: "3"}}, received(fast))
+	assert.NoError(t, fast.Err())
+}
+
+func TestPubSub_Close(t *testing.T) {
+	ps := storage.NewPubSub()
+	sub := ps.PSubscribe("*")
+	closed := ps.Subscribe("news")
+	assert.NoError(t, closed.Close())
+	assert.NoError(t, closed.Close())
+
+	assert.NoError(t, ps.Close())
+	_, ok := <-sub.Messages()
+	assert.False(t, ok)
+	assert.Equal(t, storage.ErrPubSubClosed, sub.Err())
+	assert.NoError(t, closed.Err())
+	assert.Equal(t, 0, ps.Publish("news", "hello"))
+
+	// Subscriptions created after closing are already closed
+	late := ps.Subscribe("news")
+	_, ok = <-late.Messages()
+	assert.False(t, ok)
+	assert.Equal(t, storage.ErrPubSubClosed, late.Err())
+}
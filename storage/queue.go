@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reservation is an item popped from a list that is put back at the start of the list
+// unless it's acknowledged before its visibility timeout, so it's not lost if the consumer fails.
+type Reservation[T any] struct {
+	// ID identifies the reservation to acknowledge it.
+	ID    string
+	Value T
+	// VisibleAt is when the item is put back in the list if it's not acknowledged.
+	VisibleAt time.Time
+
+	// seq orders the reservations of the store by the time they were taken.
+	seq uint64
+}
+
+// RequeueFunc is called with the ids of the reserved items put back at the start of a list because their
+// visibility timeout is over, in the order they're pushed.
+type RequeueFunc func(key string, ids []string)
+
+// Reserve will retrieve and remove the first item from the list, keeping it reserved for the visibility timeout.
+// The item must be acknowledged with Ack once processed, or given back with Nack. Otherwise it's put back
+// at the start of the list once the visibility timeout is over, by the background sweeper or the next time
+// the list is reserved from, whichever comes first.
+// It will check that the visibility timeout is positive, that the list exists, that it's not empty
+// and that it has not expired.
+func (ls *listStore[T]) Reserve(key string, visibility time.Duration) (*Reservation[T], error) {
+	if visibility <= 0 {
+		return nil, ErrInvalidVisibility
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.requeueExpired(key)
+	val, err := ls.popLocked(key, true)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newReservationID()
+	if err != nil {
+		return nil, err
+	}
+	r := ls.reserveLocked(key, id, val, time.Now().Add(visibility))
+
+	// A copy is returned so the reservation can't be changed from outside
+	res := *r
+	return &res, nil
+}
+
+// RestoreReservation will remove the first item of the list and keep it reserved as the given reservation,
+// with the same id and visibility timeout. It's used to rebuild the reservations taken before a restart,
+// in the order they were taken, so they can still be acknowledged. The value of the reservation is ignored.
+// It will check that the list exists, that it's not empty and that it has not expired.
+func (ls *listStore[T]) RestoreReservation(key string, r Reservation[T]) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	val, err := ls.popLocked(key, true)
+	if err != nil {
+		return err
+	}
+	ls.reserveLocked(key, r.ID, val, r.VisibleAt)
+	return nil
+}
+
+// reserveLocked keeps the popped value reserved under the id. The caller must hold the lock.
+func (ls *listStore[T]) reserveLocked(key, id string, val T, visibleAt time.Time) *Reservation[T] {
+	ls.reserveSeq++
+	r := &Reservation[T]{ID: id, Value: val, VisibleAt: visibleAt, seq: ls.reserveSeq}
+	if ls.reservations[key] == nil {
+		ls.reservations[key] = map[string]*Reservation[T]{}
+	}
+	ls.reservations[key][id] = r
+	return r
+}
+
+// Reservations will return a copy of the items reserved from each list that are not acknowledged yet,
+// in the order they were reserved.
+func (ls *listStore[T]) Reservations() map[string][]Reservation[T] {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	data := make(map[string][]Reservation[T], len(ls.reservations))
+	for key := range ls.reservations {
+		for _, r := range ls.sortedReservations(key) {
+			data[key] = append(data[key], *r)
+		}
+	}
+	return data
+}
+
+// OnRequeue makes the store call fn every time reserved items are put back in a list because their
+// visibility timeout is over. The background sweeper holds lock while it puts them back, before locking
+// the store, so fn is called in the same order as the operations of the callers holding lock.
+// fn is called while the store is locked, so it must return quickly and must not use the stores.
+func (ls *listStore[T]) OnRequeue(lock sync.Locker, fn RequeueFunc) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.requeueLock = lock
+	ls.onRequeue = fn
+}
+
+// Ack will acknowledge that the reserved item has been processed, so it's never put back in the list.
+// Items whose visibility timeout is over can still be acknowledged until they are put back.
+// It will return an error if the item is not reserved.
+func (ls *listStore[T]) Ack(key, id string) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if _, ok := ls.reservations[key][id]; !ok {
+		return ErrReservationNotFound
+	}
+	ls.release(key, id)
+	return nil
+}
+
+// Nack will put the reserved item back at the start of the list right away, so it's the next one to be popped.
+// It will return an error if the item is not reserved, or if the list has been removed or has expired,
+// in which case the item is dropped.
+func (ls *listStore[T]) Nack(key, id string) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	r, ok := ls.reservations[key][id]
+	if !ok {
+		return ErrReservationNotFound
+	}
+	ls.release(key, id)
+	_, err := ls.pushLocked(key, true, r.Value)
+	return err
+}
+
+// requeueExpired puts the reserved items whose visibility timeout is over back at the start of the list,
+// keeping the order they were reserved in, and wakes up the blocking pops of the list.
+// If the list no longer exists, the items stay reserved, so they can still be acknowledged and they're
+// put back once the list is created again. The caller must hold the lock.
+func (ls *listStore[T]) requeueExpired(key string) {
+	now := time.Now()
+	var expired []*Reservation[T]
+	for _, r := range ls.reservations[key] {
+		if !r.VisibleAt.After(now) {
+			expired = append(expired, r)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	// The last reserved item is pushed first, so the first one ends up at the start.
+	sort.Slice(expired, func(i, j int) bool { return expired[i].seq > expired[j].seq })
+	vals := make([]T, len(expired))
+	ids := make([]string, len(expired))
+	for i, r := range expired {
+		vals[i] = r.Value
+		ids[i] = r.ID
+	}
+	if _, err := ls.pushLocked(key, true, vals...); err != nil {
+		return
+	}
+	for _, id := range ids {
+		ls.release(key, id)
+	}
+	if ls.onRequeue != nil {
+		ls.onRequeue(key, ids)
+	}
+}
+
+// requeueAllExpired puts back the reserved items of every list whose visibility timeout is over,
+// so they're delivered again even if nobody reserves from the list.
+func (ls *listStore[T]) requeueAllExpired() {
+	ls.mu.RLock()
+	lock := ls.requeueLock
+	ls.mu.RUnlock()
+	if lock != nil {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	for key := range ls.reservations {
+		ls.requeueExpired(key)
+	}
+}
+
+// sortedReservations returns the reservations of the list in the order they were taken.
+// The caller must hold the lock.
+func (ls *listStore[T]) sortedReservations(key string) []*Reservation[T] {
+	rs := make([]*Reservation[T], 0, len(ls.reservations[key]))
+	for _, r := range ls.reservations[key] {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].seq < rs[j].seq })
+	return rs
+}
+
+// release forgets the reservation. The caller must hold the lock.
+func (ls *listStore[T]) release(key, id string) {
+	delete(ls.reservations[key], id)
+	if len(ls.reservations[key]) == 0 {
+		delete(ls.reservations, key)
+	}
+}
+
+// newReservationID returns a random id, so reservations can't be guessed or collide across restarts.
+func newReservationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,338 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"in-memory-storage/storage"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListStore_LMove(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	testCases := map[string]struct {
+		srcLeft, dstLeft bool
+		expectedVal      string
+		expectedSrc      []string
+		expectedDst      []string
+	}{
+		"it should move the last item to the start": {
+			srcLeft:     false,
+			dstLeft:     true,
+			expectedVal: "b",
+			expectedSrc: []string{"a"},
+			expectedDst: []string{"b", "x"},
+		},
+		"it should move the first item to the end": {
+			srcLeft:     true,
+			dstLeft:     false,
+			expectedVal: "a",
+			expectedSrc: []string{"b"},
+			expectedDst: []string{"x", "a"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Remove("src")
+			_ = store.Remove("dst")
+			assert.Nil(t, store.Set("src", []string{"a", "b"}, 0))
+			assert.Nil(t, store.Set("dst", []string{"x"}, 0))
+
+			val, err := store.LMove("src", "dst", tc.srcLeft, tc.dstLeft)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedVal, val)
+
+			src, err := store.Get("src")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedSrc, src.Value)
+			dst, err := store.Get("dst")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedDst, dst.Value)
+		})
+	}
+
+	t.Run("it should rotate a list onto itself", func(t *testing.T) {
+		assert.Nil(t, store.Set("ring", []string{"a", "b", "c"}, 0))
+
+		val, err := store.RPopLPush("ring", "ring")
+		assert.Nil(t, err)
+		assert.Equal(t, "c", val)
+
+		ring, err := store.Get("ring")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"c", "a", "b"}, ring.Value)
+	})
+
+	t.Run("it should not pop anything if the destination does not exist", func(t *testing.T) {
+		_, err := store.RPopLPush("ring", "missing")
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		ring, err := store.Get("ring")
+		assert.Nil(t, err)
+		assert.Len(t, ring.Value, 3)
+	})
+
+	t.Run("it should return an error if the source is empty", func(t *testing.T) {
+		assert.Nil(t, store.Set("empty", []string{}, 0))
+		_, err := store.RPopLPush("empty", "ring")
+		assert.Equal(t, storage.ErrEmptyList, err)
+	})
+}
+
+func TestListStore_LMoveIf(t *testing.T) {
+	store := storage.NewListStore[string]()
+	errRejected := errors.New("rejected")
+
+	testCases := map[string]struct {
+		srcLeft     bool
+		check       func(string) error
+		expectedErr error
+		expectedSrc []string
+		expectedDst []string
+	}{
+		"it should move the item if the check accepts it": {
+			check:       func(v string) error { return nil },
+			expectedSrc: []string{"a"},
+			expectedDst: []string{"b", "x"},
+		},
+		"it should check the item at the given side of the source": {
+			srcLeft: true,
+			check: func(v string) error {
+				if v != "a" {
+					return errRejected
+				}
+				return nil
+			},
+			expectedSrc: []string{"b"},
+			expectedDst: []string{"a", "x"},
+		},
+		"it should leave both lists unchanged if the check rejects the item": {
+			check:       func(v string) error { return errRejected },
+			expectedErr: errRejected,
+			expectedSrc: []string{"a", "b"},
+			expectedDst: []string{"x"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Remove("src")
+			_ = store.Remove("dst")
+			assert.Nil(t, store.Set("src", []string{"a", "b"}, 0))
+			assert.Nil(t, store.Set("dst", []string{"x"}, 0))
+
+			_, err := store.LMoveIf("src", "dst", tc.srcLeft, true, tc.check)
+			assert.Equal(t, tc.expectedErr, err)
+
+			src, err := store.Get("src")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedSrc, src.Value)
+			dst, err := store.Get("dst")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedDst, dst.Value)
+		})
+	}
+
+	t.Run("it should return an error if the source is empty", func(t *testing.T) {
+		assert.Nil(t, store.Set("empty", []string{}, 0))
+		_, err := store.LMoveIf("empty", "dst", false, true, func(string) error { return nil })
+		assert.Equal(t, storage.ErrEmptyList, err)
+	})
+}
+
+func TestListStore_Reserve(t *testing.T) {
+	store := storage.NewListStore[string]()
+
+	assert.Nil(t, store.Set("queue", []string{"a", "b", "c"}, 0))
+	assert.Nil(t, store.Set("empty", []string{}, 0))
+
+	t.Run("it should return an error if the visibility timeout is not positive", func(t *testing.T) {
+		_, err := store.Reserve("queue", 0)
+		assert.Equal(t, storage.ErrInvalidVisibility, err)
+	})
+
+	t.Run("it should return an error if the list is empty or not found", func(t *testing.T) {
+		_, err := store.Reserve("empty", time.Second)
+		assert.Equal(t, storage.ErrEmptyList, err)
+		_, err = store.Reserve("missing", time.Second)
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should remove acknowledged items", func(t *testing.T) {
+		r, err := store.Reserve("queue", time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, "a", r.Value)
+		assert.NotEmpty(t, r.ID)
+
+		assert.Nil(t, store.Ack("queue", r.ID))
+		assert.Equal(t, storage.ErrReservationNotFound, store.Ack("queue", r.ID))
+
+		list, err := store.Get("queue")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"b", "c"}, list.Value)
+	})
+
+	t.Run("it should put back items that are not acknowledged", func(t *testing.T) {
+		r, err := store.Reserve("queue", time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, "b", r.Value)
+
+		assert.Nil(t, store.Nack("queue", r.ID))
+		assert.Equal(t, storage.ErrReservationNotFound, store.Nack("queue", r.ID))
+
+		list, err := store.Get("queue")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"b", "c"}, list.Value)
+	})
+
+	t.Run("it should put back items once the visibility timeout is over", func(t *testing.T) {
+		first, err := store.Reserve("queue", 10*time.Millisecond)
+		assert.Nil(t, err)
+		second, err := store.Reserve("queue", 10*time.Millisecond)
+		assert.Nil(t, err)
+		_, err = store.Reserve("queue", time.Millisecond)
+		assert.Equal(t, storage.ErrEmptyList, err)
+
+		time.Sleep(20 * time.Millisecond) // Ensure the reservations have expired
+
+		// The items are put back in the order they were reserved
+		r, err := store.Reserve("queue", time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, first.Value, r.Value)
+		assert.NotEqual(t, first.ID, r.ID)
+
+		list, err := store.Get("queue")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{second.Value}, list.Value)
+
+		assert.Equal(t, storage.ErrReservationNotFound, store.Ack("queue", first.ID))
+		assert.Nil(t, store.Ack("queue", r.ID))
+	})
+
+	t.Run("it should keep the items reserved while their list doesn't exist", func(t *testing.T) {
+		assert.Nil(t, store.Set("recreated", []string{"a"}, 0))
+		r, err := store.Reserve("recreated", time.Millisecond)
+		assert.Nil(t, err)
+		assert.Nil(t, store.Remove("recreated"))
+
+		time.Sleep(2 * time.Millisecond) // Ensure the reservation has expired
+
+		// The item is put back once the list exists again, so it's the first one reserved
+		assert.Nil(t, store.Set("recreated", []string{"b"}, 0))
+		again, err := store.Reserve("recreated", time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, r.Value, again.Value)
+
+		list, err := store.Get("recreated")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"b"}, list.Value)
+	})
+
+	t.Run("it should drop the item when nacking to a removed list", func(t *testing.T) {
+		assert.Nil(t, store.Set("removed", []string{"a"}, 0))
+		r, err := store.Reserve("removed", time.Second)
+		assert.Nil(t, err)
+		assert.Nil(t, store.Remove("removed"))
+
+		assert.Equal(t, storage.ErrNotFound, store.Nack("removed", r.ID))
+		assert.Equal(t, storage.ErrReservationNotFound, store.Nack("removed", r.ID))
+	})
+}
+
+func TestListStore_ReserveSweeper(t *testing.T) {
+	store := storage.NewListStore[string](storage.WithSweeper(5 * time.Millisecond))
+	defer store.Close()
+
+	assert.Nil(t, store.Set("queue", []string{"a"}, 0))
+	r, err := store.Reserve("queue", 20*time.Millisecond)
+	assert.Nil(t, err)
+
+	t.Run("it should put back the items without reserving again and wake up the blocking pops", func(t *testing.T) {
+		key, val, err := store.BLPop(context.Background(), time.Second, "queue")
+		assert.Nil(t, err)
+		assert.Equal(t, "queue", key)
+		assert.Equal(t, r.Value, val)
+
+		assert.Equal(t, storage.ErrReservationNotFound, store.Ack("queue", r.ID))
+	})
+}
+
+func TestListStore_Reservations(t *testing.T) {
+	store := storage.NewListStore[string]()
+	reservations := store.(storage.ReservationStore[string])
+
+	assert.Nil(t, store.Set("queue", []string{"a", "b", "c"}, 0))
+	first, err := store.Reserve("queue", time.Minute)
+	assert.Nil(t, err)
+	second, err := store.Reserve("queue", time.Minute)
+	assert.Nil(t, err)
+
+	t.Run("it should return the reservations in the order they were taken", func(t *testing.T) {
+		reserved := reservations.Reservations()
+		assert.Len(t, reserved["queue"], 2)
+		assert.Equal(t, first.ID, reserved["queue"][0].ID)
+		assert.Equal(t, second.ID, reserved["queue"][1].ID)
+	})
+
+	t.Run("it should put the reserved items back at the start of the snapshot", func(t *testing.T) {
+		data := store.Snapshot()
+		assert.Equal(t, []string{"a", "b", "c"}, data["queue"].Value)
+	})
+
+	t.Run("it should restore the reservations from the snapshot", func(t *testing.T) {
+		restored := storage.NewListStore[string]()
+		restored.Restore(store.Snapshot())
+		for _, r := range reservations.Reservations()["queue"] {
+			assert.Nil(t, restored.(storage.ReservationStore[string]).RestoreReservation("queue", r))
+		}
+
+		list, err := restored.Get("queue")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"c"}, list.Value)
+		assert.Nil(t, restored.Nack("queue", second.ID))
+		assert.Nil(t, restored.Ack("queue", first.ID))
+
+		list, err = restored.Get("queue")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"b", "c"}, list.Value)
+	})
+
+	t.Run("it should return an error if there is no item to reserve again", func(t *testing.T) {
+		assert.Equal(t, storage.ErrNotFound, reservations.RestoreReservation("missing", *first))
+	})
+}
+
+func TestListStore_OnRequeue(t *testing.T) {
+	store := storage.NewListStore[string](storage.WithSweeper(5 * time.Millisecond))
+	defer store.Close()
+
+	var mu sync.Mutex
+	requeued := make(chan []string, 1)
+	store.(storage.ReservationStore[string]).OnRequeue(&mu, func(key string, ids []string) {
+		requeued <- ids
+	})
+
+	assert.Nil(t, store.Set("queue", []string{"a", "b"}, 0))
+	first, err := store.Reserve("queue", time.Millisecond)
+	assert.Nil(t, err)
+	second, err := store.Reserve("queue", time.Millisecond)
+	assert.Nil(t, err)
+
+	t.Run("it should notify the items put back by the sweeper in the order they're pushed", func(t *testing.T) {
+		select {
+		case ids := <-requeued:
+			assert.Equal(t, []string{second.ID, first.ID}, ids)
+		case <-time.After(time.Second):
+			t.Fatal("the reservations were not put back")
+		}
+
+		list, err := store.Get("queue")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a", "b"}, list.Value)
+	})
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"container/heap"
+	"slices"
+	"time"
+)
+
+// scanKeys returns the smallest count+1 keys of the store that come after the cursor, match the glob pattern
+// and have not expired, in no particular order, so scanPage can tell if there's a next page. An empty cursor
+// starts from the first key and an empty pattern matches every key. The keys are kept in a max-heap bounded
+// to count+1 keys, so a page costs O(N log count) instead of sorting every key of the store.
+// The caller must hold the lock, at least for reading.
+func scanKeys[T any](store map[string]Value[T], cursor, match string, count int) []string {
+	// Every key fits in the page when the store has no more than count keys.
+	limit := len(store)
+	if count < len(store) {
+		limit = count + 1
+	}
+
+	now := time.Now()
+	keys := make(keyHeap, 0, limit)
+	for key, v := range store {
+		if cursor != "" && key <= cursor {
+			continue
+		}
+		// The largest kept key is checked first, as it's cheaper than the expiration and the pattern.
+		if len(keys) == limit && key >= keys[0] {
+			continue
+		}
+		if !v.ExpiresAt.IsZero() && v.ExpiresAt.Before(now) {
+			continue
+		}
+		if match != "" && !matchGlob(match, key) {
+			continue
+		}
+		if len(keys) < limit {
+			heap.Push(&keys, key)
+			continue
+		}
+		keys[0] = key
+		heap.Fix(&keys, 0)
+	}
+	return keys
+}
+
+// scanPage sorts the keys and returns the first count of them, with the cursor of the next page,
+// which is the last key returned, or an empty cursor if there are no more keys.
+func scanPage(keys []string, count int) ([]string, string) {
+	slices.Sort(keys)
+	if len(keys) <= count {
+		return keys, ""
+	}
+	page := keys[:count]
+	return page, page[count-1]
+}
+
+// keyHeap is a max-heap of keys, used by scanKeys to keep the smallest ones.
+type keyHeap []string
+
+func (h keyHeap) Len() int           { return len(h) }
+func (h keyHeap) Less(i, j int) bool { return h[i] > h[j] }
+func (h keyHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *keyHeap) Push(x any)        { *h = append(*h, x.(string)) }
+func (h *keyHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// matchGlob reports whether s matches the glob pattern, with the syntax of the Redis KEYS command:
+//   - * matches any sequence of characters, including none.
+//   - ? matches a single character.
+//   - [abc] matches one of the characters, [a-z] one in the range and [^a] any character but those.
+//   - \ escapes the next character so it's matched literally.
+//
+// A [ without its closing ] is matched literally.
+func matchGlob(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+	pi, si := 0, 0
+	// Position of the last * seen in the pattern and of the character it's matched up to,
+	// to backtrack when the rest of the pattern doesn't match.
+	star, starMatch := -1, 0
+
+	for si < len(str) {
+		if pi < len(p) {
+			switch p[pi] {
+			case '*':
+				star, starMatch = pi, si
+				pi++
+				continue
+			case '?':
+				pi++
+				si++
+				continue
+			default:
+				if ok, next := matchRune(p, pi, str[si]); ok {
+					pi = next
+					si++
+					continue
+				}
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		// Let the last * match one more character and try again.
+		starMatch++
+		pi, si = star+1, starMatch
+	}
+
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// matchRune matches r against the element of the pattern at p[i], which is a literal,
+// an escaped character or a class, and returns the position of the next element.
+func matchRune(p []rune, i int, r rune) (bool, int) {
+	switch p[i] {
+	case '\\':
+		if i+1 < len(p) {
+			return p[i+1] == r, i + 2
+		}
+	case '[':
+		if end := classEnd(p, i); end > 0 {
+			return matchClass(p[i+1:end], r), end + 1
+		}
+	}
+	return p[i] == r, i + 1
+}
+
+// classEnd returns the position of the ] closing the class that starts at p[i], or -1 if there is none.
+func classEnd(p []rune, i int) int {
+	for j := i + 1; j < len(p); j++ {
+		switch p[j] {
+		case '\\':
+			j++
+		case ']':
+			return j
+		}
+	}
+	return -1
+}
+
+// matchClass reports whether r is one of the characters of the class, given without its brackets.
+func matchClass(class []rune, r rune) bool {
+	negate := len(class) > 0 && class[0] == '^'
+	if negate {
+		class = class[1:]
+	}
+
+	matched := false
+	for k := 0; k < len(class) && !matched; k++ {
+		switch {
+		case class[k] == '\\' && k+1 < len(class):
+			k++
+			matched = class[k] == r
+		case k+2 < len(class) && class[k+1] == '-':
+			lo, hi := class[k], class[k+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			matched = lo <= r && r <= hi
+			k += 2
+		default:
+			matched = class[k] == r
+		}
+	}
+	return matched != negate
+}
@@ -0,0 +1,162 @@
+package storage_test
+
+import (
+	"fmt"
+	"in-memory-storage/storage"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// scanAll returns all the keys of the scan, page by page.
+func scanAll(t *testing.T, scan func(cursor, match string, count int) ([]string, string, error), match string, count int) []string {
+	t.Helper()
+	var all []string
+	cursor := ""
+	for {
+		keys, next, err := scan(cursor, match, count)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(keys), count)
+		all = append(all, keys...)
+		if next == "" {
+			return all
+		}
+		cursor = next
+	}
+}
+
+func TestStringStore_Scan(t *testing.T) {
+	store := storage.NewStringStore()
+	for _, key := range []string{"user:1", "user:2", "user:10", "session:a", "session:b", "a*b", "a[b", "año"} {
+		assert.NoError(t, store.Set(key, "value", 0))
+	}
+	assert.NoError(t, store.Set("user:expired", "value", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	testCases := map[string]struct {
+		match    string
+		expected []string
+	}{
+		"it should return all the keys without pattern": {
+			match:    "",
+			expected: []string{"a*b", "a[b", "año", "session:a", "session:b", "user:1", "user:10", "user:2"},
+		},
+		"it should match any sequence with *": {
+			match:    "user:*",
+			expected: []string{"user:1", "user:10", "user:2"},
+		},
+		"it should match a single character with ?": {
+			match:    "user:?",
+			expected: []string{"user:1", "user:2"},
+		},
+		"it should match a single multi-byte character with ?": {
+			match:    "a?o",
+			expected: []string{"año"},
+		},
+		"it should match a class": {
+			match:    "session:[ac]",
+			expected: []string{"session:a"},
+		},
+		"it should match a range": {
+			match:    "user:[0-1]*",
+			expected: []string{"user:1", "user:10"},
+		},
+		"it should match a negated class": {
+			match:    "session:[^a]",
+			expected: []string{"session:b"},
+		},
+		"it should match escaped characters literally": {
+			match:    `a\*b`,
+			expected: []string{"a*b"},
+		},
+		"it should match an unclosed class literally": {
+			match:    "a[b",
+			expected: []string{"a[b"},
+		},
+		"it should return nothing if no key matches": {
+			match:    "missing*",
+			expected: nil,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, scanAll(t, store.Scan, tc.match, 3))
+		})
+	}
+
+	t.Run("it should return pages of count keys and the cursor of the next one", func(t *testing.T) {
+		keys, cursor, err := store.Scan("", "user:*", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user:1", "user:10"}, keys)
+		assert.Equal(t, "user:10", cursor)
+
+		keys, cursor, err = store.Scan(cursor, "user:*", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user:2"}, keys)
+		assert.Empty(t, cursor)
+	})
+
+	t.Run("it should return the whole store if the count is larger", func(t *testing.T) {
+		keys, cursor, err := store.Scan("", "", math.MaxInt)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 8)
+		assert.Empty(t, cursor)
+	})
+
+	t.Run("it should return an error if the count is not positive", func(t *testing.T) {
+		_, _, err := store.Scan("", "", 0)
+		assert.Equal(t, storage.ErrInvalidCount, err)
+	})
+}
+
+func TestStringStore_ScanConcurrentWrites(t *testing.T) {
+	store := storage.NewStringStore()
+	stable := make([]string, 0, 100)
+	for i := range 100 {
+		key := fmt.Sprintf("stable:%03d", i)
+		stable = append(stable, key)
+		assert.NoError(t, store.Set(key, "value", 0))
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			key := fmt.Sprintf("churn:%d", i%50)
+			if err := store.Set(key, "value", 0); err != nil {
+				_ = store.Remove(key)
+			}
+		}
+	}()
+
+	keys := scanAll(t, store.Scan, "stable:*", 7)
+	close(done)
+	wg.Wait()
+
+	assert.Equal(t, stable, keys)
+}
+
+func TestListStore_Scan(t *testing.T) {
+	store := storage.NewListStore[string]()
+	assert.NoError(t, store.Set("queue:a", []string{"a"}, 0))
+	assert.NoError(t, store.Set("queue:b", []string{"b"}, 0))
+	assert.NoError(t, store.Set("other", []string{"c"}, 0))
+	assert.NoError(t, store.Set("queue:expired", []string{"d"}, time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Equal(t, []string{"queue:a", "queue:b"}, scanAll(t, store.Scan, "queue:*", 1))
+
+	_, _, err := store.Scan("", "", -1)
+	assert.Equal(t, storage.ErrInvalidCount, err)
+}
@@ -0,0 +1,474 @@
+package storage
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// members holds the members of a set.
+type members[T comparable] map[T]struct{}
+
+func newMembers[T comparable](list []T) members[T] {
+	m := make(members[T], len(list))
+	for _, member := range list {
+		m[member] = struct{}{}
+	}
+	return m
+}
+
+func (m members[T]) list() []T {
+	list := make([]T, 0, len(m))
+	for member := range m {
+		list = append(list, member)
+	}
+	return list
+}
+
+type setStore[T comparable] struct {
+	store map[string]Value[members[T]]
+	// Mutex to handle concurrent access to memory
+	mu *sync.RWMutex
+
+	// keyspace is the keyspace the store has joined, if any
+	keyspace *keyspace
+	sweeper  *sweeper
+}
+
+// NewSetStore initializes a set store for the given data type.
+// Use WithSweeper to actively remove expired sets in the background.
+func NewSetStore[T comparable](opts ...Option) SetStore[T] {
+	ss := &setStore[T]{
+		store: map[string]Value[members[T]]{},
+	}
+	o := newOptions(opts)
+	ss.keyspace = o.keyspace
+	ss.mu = o.keyspace.join(ss)
+	ss.sweeper = startSweeper(ss, o)
+	return ss
+}
+
+// Set will store the given members as a set, discarding duplicates.
+// It will check if the key already exists and return an error.
+func (ss *setStore[T]) Set(key string, list []T, ttl time.Duration) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if err := ss.keyspace.claim(ss, key); err != nil {
+		return err
+	}
+	return set(ss.store, key, newMembers(list), ttl)
+}
+
+// Get will return the members of the set, in no particular order, and its expiration time.
+// It will return an error if the set is not found or if it has expired.
+func (ss *setStore[T]) Get(key string) (*Value[[]T], error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := readValid(ss.store, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Value[[]T]{Value: v.Value.list(), ExpiresAt: v.ExpiresAt, Sliding: v.Sliding}, nil
+}
+
+// Remove will delete the set linked to the given key.
+// It will return an error if the set is not found.
+func (ss *setStore[T]) Remove(key string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return remove(ss.store, key)
+}
+
+// Add will add the given members to the set, creating the set if it does not exist.
+// It returns the number of members that were not already in the set.
+func (ss *setStore[T]) Add(key string, list ...T) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrExpired) {
+			return 0, err
+		}
+		if err := ss.keyspace.claim(ss, key); err != nil {
+			return 0, err
+		}
+		v = Value[members[T]]{Value: members[T]{}}
+		ss.store[key] = v
+	}
+
+	added := 0
+	for _, member := range list {
+		if _, ok := v.Value[member]; !ok {
+			v.Value[member] = struct{}{}
+			added++
+		}
+	}
+
+	return added, nil
+}
+
+// RemoveMembers will remove the given members from the set and return how many were removed.
+// The set is removed once it has no members left.
+// It will return an error if the set is not found or if it has expired.
+func (ss *setStore[T]) RemoveMembers(key string, list ...T) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, member := range list {
+		if _, ok := v.Value[member]; ok {
+			delete(v.Value, member)
+			removed++
+		}
+	}
+
+	if len(v.Value) == 0 {
+		delete(ss.store, key)
+	}
+
+	return removed, nil
+}
+
+// IsMember will check if the given member is in the set.
+// It will return an error if the set is not found or if it has expired.
+func (ss *setStore[T]) IsMember(key string, member T) (bool, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := readValid(ss.store, key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := v.Value[member]
+	return ok, nil
+}
+
+// Members will return the members of the set, in no particular order.
+// It will return an error if the set is not found or if it has expired.
+func (ss *setStore[T]) Members(key string) ([]T, error) {
+	v, err := ss.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return v.Value, nil
+}
+
+// Card will return the number of members of the set.
+// It will return an error if the set is not found or if it has expired.
+func (ss *setStore[T]) Card(key string) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := readValid(ss.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(v.Value), nil
+}
+
+// Pop will remove and return a random member of the set.
+// The set is removed once it has no members left.
+// It will return an error if the set is not found, has expired or is empty.
+func (ss *setStore[T]) Pop(key string) (T, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	member, err := ss.randomMember(key)
+	if err != nil {
+		return member, err
+	}
+
+	v := ss.store[key]
+	delete(v.Value, member)
+	if len(v.Value) == 0 {
+		delete(ss.store, key)
+	}
+
+	return member, nil
+}
+
+// RandomMember will return a random member of the set without removing it.
+// It will return an error if the set is not found, has expired or is empty.
+func (ss *setStore[T]) RandomMember(key string) (T, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.randomMember(key)
+}
+
+// randomMember relies on the randomized map iteration order to pick a member.
+// The caller must hold the lock.
+func (ss *setStore[T]) randomMember(key string) (T, error) {
+	var zero T
+
+	v, err := readValid(ss.store, key)
+	if err != nil {
+		return zero, err
+	}
+
+	for member := range v.Value {
+		return member, nil
+	}
+
+	return zero, ErrEmptySet
+}
+
+// Union will return the members that are in any of the given sets.
+// Sets that don't exist are considered empty.
+func (ss *setStore[T]) Union(keys ...string) ([]T, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	result, err := ss.union(keys)
+	if err != nil {
+		return nil, err
+	}
+	return result.list(), nil
+}
+
+// Intersect will return the members that are in all the given sets.
+// Sets that don't exist are considered empty.
+func (ss *setStore[T]) Intersect(keys ...string) ([]T, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	result, err := ss.intersect(keys)
+	if err != nil {
+		return nil, err
+	}
+	return result.list(), nil
+}
+
+// Diff will return the members of the first set that are not in any of the following sets.
+// Sets that don't exist are considered empty.
+func (ss *setStore[T]) Diff(keys ...string) ([]T, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	result, err := ss.diff(keys)
+	if err != nil {
+		return nil, err
+	}
+	return result.list(), nil
+}
+
+// UnionStore will store the union of the given sets in dst, overwriting it,
+// and return the number of members of the result.
+func (ss *setStore[T]) UnionStore(dst string, keys ...string) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	result, err := ss.union(keys)
+	if err != nil {
+		return 0, err
+	}
+	return ss.storeResult(dst, result)
+}
+
+// IntersectStore will store the intersection of the given sets in dst, overwriting it,
+// and return the number of members of the result.
+func (ss *setStore[T]) IntersectStore(dst string, keys ...string) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	result, err := ss.intersect(keys)
+	if err != nil {
+		return 0, err
+	}
+	return ss.storeResult(dst, result)
+}
+
+// DiffStore will store the difference of the given sets in dst, overwriting it,
+// and return the number of members of the result.
+func (ss *setStore[T]) DiffStore(dst string, keys ...string) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	result, err := ss.diff(keys)
+	if err != nil {
+		return 0, err
+	}
+	return ss.storeResult(dst, result)
+}
+
+// members returns the members of the given set, or an empty set if it does not exist.
+// The caller must hold the lock and must not modify the returned members.
+func (ss *setStore[T]) members(key string) members[T] {
+	v, err := readValid(ss.store, key)
+	if err != nil {
+		return members[T]{}
+	}
+	return v.Value
+}
+
+func (ss *setStore[T]) union(keys []string) (members[T], error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	result := members[T]{}
+	for _, key := range keys {
+		for member := range ss.members(key) {
+			result[member] = struct{}{}
+		}
+	}
+	return result, nil
+}
+
+func (ss *setStore[T]) intersect(keys []string) (members[T], error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	sets := make([]members[T], 0, len(keys))
+	for _, key := range keys {
+		sets = append(sets, ss.members(key))
+	}
+
+	// Iterate over the smallest set to do as few lookups as possible.
+	smallest := 0
+	for i, m := range sets {
+		if len(m) < len(sets[smallest]) {
+			smallest = i
+		}
+	}
+
+	result := members[T]{}
+	for member := range sets[smallest] {
+		inAll := true
+		for i, m := range sets {
+			if i == smallest {
+				continue
+			}
+			if _, ok := m[member]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			result[member] = struct{}{}
+		}
+	}
+	return result, nil
+}
+
+func (ss *setStore[T]) diff(keys []string) (members[T], error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	result := members[T]{}
+	for member := range ss.members(keys[0]) {
+		result[member] = struct{}{}
+	}
+	for _, key := range keys[1:] {
+		for member := range ss.members(key) {
+			delete(result, member)
+		}
+	}
+	return result, nil
+}
+
+// storeResult overwrites dst with the given members, without TTL.
+// An empty result removes dst. The caller must hold the lock.
+func (ss *setStore[T]) storeResult(dst string, result members[T]) (int, error) {
+	if len(result) == 0 {
+		delete(ss.store, dst)
+		return 0, nil
+	}
+	if err := ss.keyspace.claim(ss, dst); err != nil {
+		return 0, err
+	}
+	ss.store[dst] = Value[members[T]]{Value: result}
+	return len(result), nil
+}
+
+// Snapshot will return a point-in-time copy of all the sets that have not expired.
+func (ss *setStore[T]) Snapshot() map[string]Value[[]T] {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	data := map[string]Value[[]T]{}
+	for key, v := range snapshot(ss.store) {
+		data[key] = Value[[]T]{Value: v.Value.list(), ExpiresAt: v.ExpiresAt, Sliding: v.Sliding}
+	}
+	return data
+}
+
+// Restore will load the given sets into the store, overwriting existing keys.
+// Sets that have already expired are dropped. It returns the number of sets loaded.
+// Keys held by another store of the keyspace are skipped, as a key holds one type of value.
+func (ss *setStore[T]) Restore(data map[string]Value[[]T]) int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	sets := make(map[string]Value[members[T]], len(data))
+	for key, v := range data {
+		if ss.keyspace.claim(ss, key) != nil {
+			continue
+		}
+		sets[key] = Value[members[T]]{Value: newMembers(v.Value), ExpiresAt: v.ExpiresAt, Sliding: v.Sliding}
+	}
+	return restore(ss.store, sets)
+}
+
+// SweepStats returns the counters of the background sweeper.
+// All the counters are zero if the sweeper is not enabled.
+func (ss *setStore[T]) SweepStats() SweepStats {
+	return ss.sweeper.stats()
+}
+
+// Close stops the background sweeper, if enabled.
+// The store remains usable after closing it.
+func (ss *setStore[T]) Close() error {
+	ss.sweeper.stop()
+	return nil
+}
+
+func (ss *setStore[T]) sweep(sampleSize int) (sampled, expired int) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return sweepExpired(ss.store, sampleSize, nil)
+}
+
+func (ss *setStore[T]) keyType() KeyType {
+	return TypeSet
+}
+
+func (ss *setStore[T]) has(key string) bool {
+	return hasValid(ss.store, key)
+}
+
+func (ss *setStore[T]) drop(key string) bool {
+	return dropValid(ss.store, key)
+}
+
+func (ss *setStore[T]) move(src, dst string) {
+	moveKey(ss.store, src, dst)
+}
+
+func (ss *setStore[T]) count() int {
+	return countValid(ss.store)
+}
+
+func (ss *setStore[T]) scan(cursor, match string, count int) []string {
+	return scanKeys(ss.store, cursor, match, count)
+}
+
+func (ss *setStore[T]) expire(key string, at time.Time, sliding time.Duration) error {
+	_, err := expireKey(ss.store, key, at, sliding)
+	return err
+}
+
+func (ss *setStore[T]) ttl(key string) (time.Duration, error) {
+	return ttlOf(ss.store, key)
+}
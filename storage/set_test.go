@@ -0,0 +1,376 @@
+package storage_test
+
+import (
+	"in-memory-storage/storage"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetStore_Set(t *testing.T) {
+	store := storage.NewSetStore[string]()
+
+	// Populate existing values
+	err := store.Set("existing-key", []string{"foo"}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		key             string
+		members         []string
+		expectedMembers []string
+		expectedErr     error
+	}{
+		"it should return an error if key already exists": {
+			key:             "existing-key",
+			members:         []string{"bar"},
+			expectedMembers: []string{"foo"},
+			expectedErr:     storage.ErrAlreadyExists,
+		},
+		"it should discard duplicated members": {
+			key:             "new-key",
+			members:         []string{"foo", "bar", "foo"},
+			expectedMembers: []string{"foo", "bar"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := store.Set(tc.key, tc.members, 0)
+			assert.Equal(t, tc.expectedErr, err)
+
+			val, err := store.Get(tc.key)
+			assert.Nil(t, err)
+			assert.ElementsMatch(t, tc.expectedMembers, val.Value)
+			assert.True(t, val.ExpiresAt.IsZero())
+		})
+	}
+}
+
+func TestSetStore_Get(t *testing.T) {
+	store := storage.NewSetStore[int]()
+
+	err := store.Set("existing-key", []int{1, 2}, time.Minute)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", []int{1}, time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.Get("new-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should return an error if the key has expired", func(t *testing.T) {
+		_, err := store.Get("expired-key")
+		assert.Equal(t, storage.ErrExpired, err)
+	})
+
+	t.Run("it should return the members and the expiration time", func(t *testing.T) {
+		val, err := store.Get("existing-key")
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []int{1, 2}, val.Value)
+		assert.False(t, val.ExpiresAt.IsZero())
+	})
+}
+
+func TestSetStore_Add(t *testing.T) {
+	store := storage.NewSetStore[string]()
+
+	err := store.Set("existing-key", []string{"foo"}, time.Minute)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", []string{"foo"}, time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	testCases := map[string]struct {
+		key             string
+		members         []string
+		expectedAdded   int
+		expectedMembers []string
+	}{
+		"it should create the set if it does not exist": {
+			key:             "new-key",
+			members:         []string{"foo", "bar"},
+			expectedAdded:   2,
+			expectedMembers: []string{"foo", "bar"},
+		},
+		"it should only count the new members": {
+			key:             "existing-key",
+			members:         []string{"foo", "bar", "bar"},
+			expectedAdded:   1,
+			expectedMembers: []string{"foo", "bar"},
+		},
+		"it should replace an expired set": {
+			key:             "expired-key",
+			members:         []string{"bar"},
+			expectedAdded:   1,
+			expectedMembers: []string{"bar"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			added, err := store.Add(tc.key, tc.members...)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedAdded, added)
+
+			members, err := store.Members(tc.key)
+			assert.Nil(t, err)
+			assert.ElementsMatch(t, tc.expectedMembers, members)
+		})
+	}
+
+	t.Run("it should keep the TTL of the set", func(t *testing.T) {
+		val, err := store.Get("existing-key")
+		assert.Nil(t, err)
+		assert.False(t, val.ExpiresAt.IsZero())
+	})
+}
+
+func TestSetStore_RemoveMembers(t *testing.T) {
+	store := storage.NewSetStore[string]()
+
+	err := store.Set("existing-key", []string{"foo", "bar", "baz"}, 0)
+	assert.Nil(t, err)
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.RemoveMembers("new-key", "foo")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should remove the existing members", func(t *testing.T) {
+		removed, err := store.RemoveMembers("existing-key", "foo", "bar", "unknown")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, removed)
+
+		members, err := store.Members("existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"baz"}, members)
+	})
+
+	t.Run("it should remove the set when it has no members left", func(t *testing.T) {
+		removed, err := store.RemoveMembers("existing-key", "baz")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, removed)
+
+		_, err = store.Get("existing-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestSetStore_IsMemberAndCard(t *testing.T) {
+	store := storage.NewSetStore[string]()
+
+	err := store.Set("existing-key", []string{"foo", "bar"}, 0)
+	assert.Nil(t, err)
+
+	isMember, err := store.IsMember("existing-key", "foo")
+	assert.Nil(t, err)
+	assert.True(t, isMember)
+
+	isMember, err = store.IsMember("existing-key", "baz")
+	assert.Nil(t, err)
+	assert.False(t, isMember)
+
+	_, err = store.IsMember("new-key", "foo")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	card, err := store.Card("existing-key")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, card)
+
+	_, err = store.Card("new-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestSetStore_PopAndRandomMember(t *testing.T) {
+	store := storage.NewSetStore[string]()
+
+	err := store.Set("existing-key", []string{"foo", "bar"}, 0)
+	assert.Nil(t, err)
+	err = store.Set("empty-key", []string{}, 0)
+	assert.Nil(t, err)
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.Pop("new-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+		_, err = store.RandomMember("new-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should return an error if the set is empty", func(t *testing.T) {
+		_, err := store.Pop("empty-key")
+		assert.Equal(t, storage.ErrEmptySet, err)
+		_, err = store.RandomMember("empty-key")
+		assert.Equal(t, storage.ErrEmptySet, err)
+	})
+
+	t.Run("it should return a member without removing it", func(t *testing.T) {
+		member, err := store.RandomMember("existing-key")
+		assert.Nil(t, err)
+		assert.Contains(t, []string{"foo", "bar"}, member)
+
+		card, err := store.Card("existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, card)
+	})
+
+	t.Run("it should pop all the members and remove the set", func(t *testing.T) {
+		first, err := store.Pop("existing-key")
+		assert.Nil(t, err)
+		second, err := store.Pop("existing-key")
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"foo", "bar"}, []string{first, second})
+
+		_, err = store.Get("existing-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestSetStore_Algebra(t *testing.T) {
+	store := storage.NewSetStore[string]()
+
+	err := store.Set("a", []string{"1", "2", "3", "4"}, 0)
+	assert.Nil(t, err)
+	err = store.Set("b", []string{"3", "4", "5"}, 0)
+	assert.Nil(t, err)
+	err = store.Set("c", []string{"4", "6"}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		op              func(keys ...string) ([]string, error)
+		keys            []string
+		expectedMembers []string
+		expectedErr     error
+	}{
+		"union": {
+			op:              store.Union,
+			keys:            []string{"a", "b", "c"},
+			expectedMembers: []string{"1", "2", "3", "4", "5", "6"},
+		},
+		"union with a missing set": {
+			op:              store.Union,
+			keys:            []string{"c", "missing"},
+			expectedMembers: []string{"4", "6"},
+		},
+		"intersect": {
+			op:              store.Intersect,
+			keys:            []string{"a", "b", "c"},
+			expectedMembers: []string{"4"},
+		},
+		"intersect with a missing set": {
+			op:              store.Intersect,
+			keys:            []string{"a", "missing"},
+			expectedMembers: []string{},
+		},
+		"diff": {
+			op:              store.Diff,
+			keys:            []string{"a", "b", "c"},
+			expectedMembers: []string{"1", "2"},
+		},
+		"diff from a missing set": {
+			op:              store.Diff,
+			keys:            []string{"missing", "a"},
+			expectedMembers: []string{},
+		},
+		"it should return an error if no keys are provided": {
+			op:          store.Union,
+			expectedErr: storage.ErrNoKeys,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			members, err := tc.op(tc.keys...)
+			assert.Equal(t, tc.expectedErr, err)
+			if tc.expectedErr == nil {
+				assert.ElementsMatch(t, tc.expectedMembers, members)
+			}
+		})
+	}
+}
+
+func TestSetStore_AlgebraStore(t *testing.T) {
+	store := storage.NewSetStore[string]()
+
+	err := store.Set("a", []string{"1", "2", "3"}, 0)
+	assert.Nil(t, err)
+	err = store.Set("b", []string{"3", "4"}, 0)
+	assert.Nil(t, err)
+	err = store.Set("dst", []string{"old"}, time.Minute)
+	assert.Nil(t, err)
+
+	t.Run("it should overwrite the destination without TTL", func(t *testing.T) {
+		card, err := store.UnionStore("dst", "a", "b")
+		assert.Nil(t, err)
+		assert.Equal(t, 4, card)
+
+		val, err := store.Get("dst")
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, val.Value)
+		assert.True(t, val.ExpiresAt.IsZero())
+	})
+
+	t.Run("it should allow the destination to be one of the sources", func(t *testing.T) {
+		card, err := store.DiffStore("a", "a", "b")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, card)
+
+		members, err := store.Members("a")
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"1", "2"}, members)
+	})
+
+	t.Run("it should remove the destination if the result is empty", func(t *testing.T) {
+		card, err := store.IntersectStore("dst", "a", "b")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, card)
+
+		_, err = store.Get("dst")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestSetStore_SnapshotRestore(t *testing.T) {
+	store := storage.NewSetStore[string]()
+
+	err := store.Set("existing-key", []string{"foo", "bar"}, time.Minute)
+	assert.Nil(t, err)
+
+	data := store.Snapshot()
+	assert.ElementsMatch(t, []string{"foo", "bar"}, data["existing-key"].Value)
+
+	restored := storage.NewSetStore[string]()
+	loaded := restored.Restore(map[string]storage.Value[[]string]{
+		"existing-key": data["existing-key"],
+		"expired-key":  {Value: []string{"foo"}, ExpiresAt: time.Now().Add(-time.Second)},
+	})
+	assert.Equal(t, 1, loaded)
+
+	isMember, err := restored.IsMember("existing-key", "bar")
+	assert.Nil(t, err)
+	assert.True(t, isMember)
+}
+
+func TestSetStore_ConcurrentAdd(t *testing.T) {
+	store := storage.NewSetStore[string]()
+	const n = 100
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.Add("ids", strconv.Itoa(i), strconv.Itoa(i%10))
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	card, err := store.Card("ids")
+	assert.Nil(t, err)
+	assert.Equal(t, n, card)
+}
@@ -4,6 +4,8 @@
 package storage
 
 import (
+	"context"
+	"sync"
 	"time"
 )
 
@@ -12,37 +14,226 @@ import (
 type Value[T any] struct {
 	Value     T
 	ExpiresAt time.Time
+	// Version increases on every write to the key, and can be used for optimistic concurrency.
+	// It's only maintained by the StringStore and the ListStore and is zero for the other stores.
+	Version uint64 `json:",omitempty"`
+	// Sliding is the sliding expiration of the key, if any: every read of the key
+	// moves ExpiresAt to Sliding from the time of the read.
+	Sliding time.Duration `json:",omitempty"`
+	// Flags are opaque flags of the client stored with the value, like the flags of the memcached items.
+	// They're only maintained by the StringStore: Set stores the flags given with WithFlags, or 0,
+	// and the updates and increments keep them.
+	Flags uint32 `json:",omitempty"`
 }
 
+// NoTTL is the TTL returned for the keys that don't expire.
+const NoTTL time.Duration = -1
+
 // StringStore defines an interface for storing and retrieving string values.
 type StringStore interface {
 	Get(key string) (*Value[string], error)
-	Set(key string, val string, ttl time.Duration) error
+	Set(key string, val string, ttl time.Duration, opts ...SetOption) error
 	Update(key string, val string) error
 	Remove(key string) error
+	SetNX(key string, val string, ttl time.Duration) (bool, error)
+	SetXX(key string, val string, ttl time.Duration) (bool, error)
+	CompareAndSwap(key string, old, new string) (bool, error)
+	UpdateIfVersion(key string, val string, version uint64) error
+	Incr(key string) (int64, error)
+	IncrBy(key string, delta int64) (int64, error)
+	Decr(key string) (int64, error)
+	IncrByFloat(key string, delta float64) (float64, error)
+	Expire(key string, ttl time.Duration) error
+	ExpireAt(key string, t time.Time) error
+	ExpireSliding(key string, ttl time.Duration) error
+	Persist(key string) error
+	TTL(key string) (time.Duration, error)
+	Scan(cursor, match string, count int) ([]string, string, error)
+	Snapshot() map[string]Value[string]
+	Restore(data map[string]Value[string]) int
+	SweepStats() SweepStats
+	Close() error
 }
 
 // ListStore defines an interface for storing and retrieving lists of any type.
 type ListStore[T any] interface {
 	Get(key string) (*Value[[]T], error)
-	Set(key string, list []T, ttl time.Duration) error
+	Set(key string, list []T, ttl time.Duration, opts ...SetOption) error
 	Update(key string, list []T) error
 	Remove(key string) error
 	Push(key string, val T) error
 	Pop(key string) (T, error)
+	LPush(key string, vals ...T) (int, error)
+	RPush(key string, vals ...T) (int, error)
+	LPop(key string) (T, error)
+	RPop(key string) (T, error)
+	LPopN(key string, n int) ([]T, error)
+	RPopN(key string, n int) ([]T, error)
+	BLPop(ctx context.Context, timeout time.Duration, keys ...string) (string, T, error)
+	BRPop(ctx context.Context, timeout time.Duration, keys ...string) (string, T, error)
+	LMove(src, dst string, srcLeft, dstLeft bool) (T, error)
+	LMoveIf(src, dst string, srcLeft, dstLeft bool, check func(T) error) (T, error)
+	RPopLPush(src, dst string) (T, error)
+	Reserve(key string, visibility time.Duration) (*Reservation[T], error)
+	Ack(key, id string) error
+	Nack(key, id string) error
+	Range(key string, start, stop int) ([]T, error)
+	Index(key string, i int) (T, error)
+	SetIndex(key string, i int, val T) error
+	Len(key string) (int, error)
+	Trim(key string, start, stop int) error
+	Insert(key string, before bool, pivot, val T) (int, error)
+	RemoveValue(key string, count int, val T) (int, error)
+	Expire(key string, ttl time.Duration) error
+	ExpireAt(key string, t time.Time) error
+	ExpireSliding(key string, ttl time.Duration) error
+	Persist(key string) error
+	TTL(key string) (time.Duration, error)
+	Scan(cursor, match string, count int) ([]string, string, error)
+	Snapshot() map[string]Value[[]T]
+	Restore(data map[string]Value[[]T]) int
+	SweepStats() SweepStats
+	Close() error
+}
+
+// ListWaiter is implemented by the list stores of NewListStore. It lets the wrappers that must pop
+// the items of the lists under their own lock, like the operation log, wait for them outside of it.
+type ListWaiter interface {
+	WaitNonEmpty(ctx context.Context, timeout time.Duration, keys ...string) error
+}
+
+// ReservationStore is implemented by the list stores of NewListStore. It lets the wrappers that persist
+// the lists, like the operation log, save the reservations and take them again after a restart.
+type ReservationStore[T any] interface {
+	Reservations() map[string][]Reservation[T]
+	RestoreReservation(key string, r Reservation[T]) error
+	OnRequeue(lock sync.Locker, fn RequeueFunc)
+}
+
+// HashStore defines an interface for storing and retrieving hashes of field/value pairs.
+type HashStore interface {
+	Get(key string) (*Value[map[string]string], error)
+	Set(key string, fields map[string]string, ttl time.Duration) error
+	Remove(key string) error
+	HSet(key, field, val string) (bool, error)
+	HGet(key, field string) (string, error)
+	HDel(key string, fields ...string) (int, error)
+	HGetAll(key string) (map[string]string, error)
+	HExists(key, field string) (bool, error)
+	HLen(key string) (int, error)
+	HIncrBy(key, field string, delta int64) (int64, error)
+	Snapshot() map[string]Value[map[string]string]
+	Restore(data map[string]Value[map[string]string]) int
+	SweepStats() SweepStats
+	Close() error
+}
+
+// SetStore defines an interface for storing unordered collections of unique members.
+type SetStore[T comparable] interface {
+	Get(key string) (*Value[[]T], error)
+	Set(key string, members []T, ttl time.Duration) error
+	Remove(key string) error
+	Add(key string, members ...T) (int, error)
+	RemoveMembers(key string, members ...T) (int, error)
+	IsMember(key string, member T) (bool, error)
+	Members(key string) ([]T, error)
+	Card(key string) (int, error)
+	Pop(key string) (T, error)
+	RandomMember(key string) (T, error)
+	Union(keys ...string) ([]T, error)
+	Intersect(keys ...string) ([]T, error)
+	Diff(keys ...string) ([]T, error)
+	UnionStore(dst string, keys ...string) (int, error)
+	IntersectStore(dst string, keys ...string) (int, error)
+	DiffStore(dst string, keys ...string) (int, error)
+	Snapshot() map[string]Value[[]T]
+	Restore(data map[string]Value[[]T]) int
+	SweepStats() SweepStats
+	Close() error
+}
+
+// ZSetStore defines an interface for storing sets of unique members ordered by score.
+type ZSetStore interface {
+	Get(key string) (*Value[[]ScoredMember], error)
+	Set(key string, members []ScoredMember, ttl time.Duration) error
+	Remove(key string) error
+	ZAdd(key string, members ...ScoredMember) (int, error)
+	ZIncrBy(key, member string, delta float64) (float64, error)
+	ZScore(key, member string) (float64, error)
+	ZRank(key, member string) (int, error)
+	ZRevRank(key, member string) (int, error)
+	ZRange(key string, start, stop int) ([]ScoredMember, error)
+	ZRevRange(key string, start, stop int) ([]ScoredMember, error)
+	ZRangeByScore(key string, min, max float64, offset, count int) ([]ScoredMember, error)
+	ZRem(key string, members ...string) (int, error)
+	ZRemRangeByRank(key string, start, stop int) (int, error)
+	ZRemRangeByScore(key string, min, max float64) (int, error)
+	ZCard(key string) (int, error)
+	Snapshot() map[string]Value[[]ScoredMember]
+	Restore(data map[string]Value[[]ScoredMember]) int
+	SweepStats() SweepStats
+	Close() error
+}
+
+// StreamStore defines an interface for storing append-only logs of entries, read in order
+// or through consumer groups that track which entries each consumer has acknowledged.
+type StreamStore interface {
+	XAdd(key string, fields map[string]string, maxLen int) (StreamID, error)
+	XRange(key string, start, end StreamID, count int) ([]StreamEntry, error)
+	XRevRange(key string, end, start StreamID, count int) ([]StreamEntry, error)
+	XLen(key string) (int, error)
+	XTrim(key string, maxLen int) (int, error)
+	XDel(key string, ids ...StreamID) (int, error)
+	Remove(key string) error
+	XGroupCreate(key, group string, fromStart bool) error
+	XGroupDestroy(key, group string) error
+	XGroups(key string) ([]GroupInfo, error)
+	XReadGroup(key, group, consumer string, count int) ([]StreamEntry, error)
+	XAck(key, group string, ids ...StreamID) (int, error)
+	XPending(key, group, consumer string) ([]PendingEntry, error)
+	XClaim(key, group, consumer string, minIdle time.Duration, ids ...StreamID) ([]StreamEntry, error)
+	Get(key string) (*Value[StreamData], error)
+	Snapshot() map[string]Value[StreamData]
+	Restore(data map[string]Value[StreamData]) int
+	SweepStats() SweepStats
+	Close() error
+}
+
+// StreamRestorer is implemented by the stream stores of NewStreamStore. It lets the wrappers that persist
+// the streams, like the operation log, add the entries with their IDs and deliver them again after a restart.
+type StreamRestorer interface {
+	XAddID(key string, id StreamID, fields map[string]string, maxLen int) error
+	XSetPending(key, group, consumer string, lastDelivered StreamID, pending ...PendingEntry) error
 }
 
 func set[T any](store map[string]Value[T], key string, val T, ttl time.Duration) error {
-	if _, ok := store[key]; ok {
+	return setWith(store, key, val, ttl, setOptions{})
+}
+
+// setWith stores the value if the mode of the options allows it. Expired keys are treated as missing.
+// A replaced key gets the given TTL, or keeps its expiration with keepTTL.
+func setWith[T any](store map[string]Value[T], key string, val T, ttl time.Duration, o setOptions) error {
+	old, err := getValid(store, key)
+	exists := err == nil
+	if exists && o.mode == SetIfNotExists {
 		return ErrAlreadyExists
 	}
+	if !exists && (o.mode == SetIfExists || o.checkVersion) {
+		return ErrNotFound
+	}
+	if exists && o.checkVersion && old.Version != o.version {
+		return ErrVersionMismatch
+	}
 
-	expiresAt := time.Time{}
-	if ttl > 0 {
-		expiresAt = time.Now().Add(ttl)
+	v := Value[T]{Value: val}
+	switch {
+	case exists && o.keepTTL:
+		v.ExpiresAt, v.Sliding = old.ExpiresAt, old.Sliding
+	case ttl > 0:
+		v.ExpiresAt = time.Now().Add(ttl)
 	}
 
-	store[key] = Value[T]{Value: val, ExpiresAt: expiresAt}
+	store[key] = v
 	return nil
 }
 
@@ -54,12 +245,70 @@ func get[T any](store map[string]Value[T], key string) (Value[T], error) {
 	return store[key], nil
 }
 
+// getValid returns the value for the given key if it has not expired.
+// Expired values are removed from the store and ErrExpired is returned.
+func getValid[T any](store map[string]Value[T], key string) (Value[T], error) {
+	v, ok := store[key]
+	if !ok {
+		return v, ErrNotFound
+	}
+	if !v.ExpiresAt.IsZero() && v.ExpiresAt.Before(time.Now()) {
+		delete(store, key)
+		var zero Value[T]
+		return zero, ErrExpired
+	}
+	return v, nil
+}
+
+// readValid is getValid for the operations that read the key, which extend
+// the expiration time of the keys with sliding expiration.
+func readValid[T any](store map[string]Value[T], key string) (Value[T], error) {
+	v, err := getValid(store, key)
+	if err != nil || v.Sliding <= 0 {
+		return v, err
+	}
+	v.ExpiresAt = time.Now().Add(v.Sliding)
+	store[key] = v
+	return v, nil
+}
+
+// expireKey sets the expiration time and the sliding expiration of the key.
+// A zero time removes the expiration and a time in the past removes the key.
+// It returns false if the key has been removed.
+func expireKey[T any](store map[string]Value[T], key string, at time.Time, sliding time.Duration) (bool, error) {
+	v, err := getValid(store, key)
+	if err != nil {
+		return false, err
+	}
+	if !at.IsZero() && !at.After(time.Now()) {
+		delete(store, key)
+		return false, nil
+	}
+	v.ExpiresAt, v.Sliding = at, sliding
+	store[key] = v
+	return true, nil
+}
+
+// ttlOf returns the time left before the key expires, or NoTTL if it doesn't expire.
+// It doesn't extend the expiration time of the keys with sliding expiration.
+func ttlOf[T any](store map[string]Value[T], key string) (time.Duration, error) {
+	v, err := getValid(store, key)
+	if err != nil {
+		return 0, err
+	}
+	if v.ExpiresAt.IsZero() {
+		return NoTTL, nil
+	}
+	return max(time.Until(v.ExpiresAt), 0), nil
+}
+
 func update[T any](store map[string]Value[T], key string, val T) error {
 	if _, ok := store[key]; !ok {
 		return ErrNotFound
 	}
 
-	store[key] = Value[T]{Value: val, ExpiresAt: store[key].ExpiresAt}
+	old := store[key]
+	store[key] = Value[T]{Value: val, ExpiresAt: old.ExpiresAt, Sliding: old.Sliding, Flags: old.Flags}
 	return nil
 }
 
@@ -71,3 +320,112 @@ func remove[T any](store map[string]Value[T], key string) error {
 
 	return nil
 }
+
+// snapshot returns a copy of all the entries that have not expired.
+func snapshot[T any](store map[string]Value[T]) map[string]Value[T] {
+	now := time.Now()
+	data := make(map[string]Value[T], len(store))
+	for key, v := range store {
+		if !v.ExpiresAt.IsZero() && v.ExpiresAt.Before(now) {
+			continue
+		}
+		data[key] = v
+	}
+	return data
+}
+
+// restore loads the given entries into the store, overwriting existing keys.
+// Entries that have already expired are dropped. It returns the number of entries loaded.
+// The entries with sliding expiration are loaded as if they had just been read.
+func restore[T any](store map[string]Value[T], data map[string]Value[T]) int {
+	now := time.Now()
+	restored := 0
+	for key, v := range data {
+		if v.Sliding > 0 {
+			v.ExpiresAt = now.Add(v.Sliding)
+		}
+		if !v.ExpiresAt.IsZero() && v.ExpiresAt.Before(now) {
+			continue
+		}
+		store[key] = v
+		restored++
+	}
+	return restored
+}
+
+// versionCounter hands out the versions of the values of a store. It's shared by all the keys,
+// so the version of a key never goes back even if it's removed and set again.
+type versionCounter struct {
+	last uint64
+}
+
+// next returns a version greater than any given before. The caller must hold the lock of the store.
+func (c *versionCounter) next() uint64 {
+	c.last++
+	return c.last
+}
+
+// stamp gives a new version to the value of the key. The caller must hold the lock of the store.
+func stamp[T any](store map[string]Value[T], key string, versions *versionCounter) {
+	v := store[key]
+	v.Version = versions.next()
+	store[key] = v
+}
+
+// versionOf returns the version of the key, or 0 if it doesn't exist or has expired.
+// The caller must hold the lock of the store.
+func versionOf[T any](store map[string]Value[T], key string) uint64 {
+	v, err := getValid(store, key)
+	if err != nil {
+		return 0
+	}
+	return v.Version
+}
+
+// restoreVersioned is like restore, but it keeps the versions of the entries
+// and gives a new version to the entries without one.
+func restoreVersioned[T any](store map[string]Value[T], data map[string]Value[T], versions *versionCounter) int {
+	for _, v := range data {
+		versions.last = max(versions.last, v.Version)
+	}
+
+	versioned := make(map[string]Value[T], len(data))
+	for key, v := range data {
+		if v.Version == 0 {
+			v.Version = versions.next()
+		}
+		versioned[key] = v
+	}
+	return restore(store, versioned)
+}
+
+// sweepExpired checks up to sampleSize keys with a TTL and removes the expired ones,
+// calling onExpired, if given, with each of them.
+// Go randomizes the map iteration order, which gives us a random sample.
+func sweepExpired[T any](store map[string]Value[T], sampleSize int, onExpired func(key string)) (sampled, expired int) {
+	now := time.Now()
+	visited := 0
+	for key, v := range store {
+		visited++
+		if visited > sampleSize*sweepVisitFactor {
+			break
+		}
+		if v.ExpiresAt.IsZero() {
+			continue
+		}
+
+		sampled++
+		if v.ExpiresAt.Before(now) {
+			delete(store, key)
+			expired++
+			if onExpired != nil {
+				onExpired(key)
+			}
+		}
+		if sampled >= sampleSize {
+			break
+		}
+	}
+
+	return sampled, expired
+}
@@ -0,0 +1,781 @@
+package storage
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamID identifies an entry of a stream. Ms is the Unix time in milliseconds when the entry was added
+// and Seq tells apart the entries added in the same millisecond. It's written as "ms-seq".
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+var (
+	// MinStreamID is lower than the ID of any entry, to range from the start of a stream.
+	MinStreamID = StreamID{}
+	// MaxStreamID is greater than the ID of any entry, to range until the end of a stream.
+	MaxStreamID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+)
+
+// ParseStreamID parses an ID written as "ms-seq". The sequence can be omitted, in which case it's 0.
+func ParseStreamID(s string) (StreamID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return StreamID{}, ErrInvalidStreamID
+	}
+	if !hasSeq {
+		return StreamID{Ms: ms}, nil
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return StreamID{}, ErrInvalidStreamID
+	}
+	return StreamID{Ms: ms, Seq: seq}, nil
+}
+
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// MarshalText writes the ID as "ms-seq", so it's encoded as a JSON string.
+func (id StreamID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText parses an ID written as "ms-seq".
+func (id *StreamID) UnmarshalText(text []byte) error {
+	parsed, err := ParseStreamID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// Compare returns -1, 0 or +1 depending on whether id is lower, equal or greater than other.
+func (id StreamID) Compare(other StreamID) int {
+	if c := cmp.Compare(id.Ms, other.Ms); c != 0 {
+		return c
+	}
+	return cmp.Compare(id.Seq, other.Seq)
+}
+
+// StreamEntry is an entry of a stream: a set of fields and values identified by its ID.
+type StreamEntry struct {
+	ID     StreamID
+	Fields map[string]string
+}
+
+// PendingEntry is an entry delivered to a consumer of a group that has not been acknowledged yet.
+type PendingEntry struct {
+	ID       StreamID
+	Consumer string
+	// DeliveredAt is the last time the entry was delivered, either read or claimed.
+	DeliveredAt time.Time
+	// Deliveries is the number of times the entry has been delivered.
+	Deliveries int
+}
+
+// GroupInfo describes a consumer group of a stream.
+type GroupInfo struct {
+	Name string
+	// LastDeliveredID is the ID of the last entry delivered to the group, the next reads start after it.
+	LastDeliveredID StreamID
+	Consumers       int
+	Pending         int
+}
+
+// StreamData is a copy of a stream with its consumer groups, used to snapshot and restore the streams.
+type StreamData struct {
+	Entries []StreamEntry
+	// LastID is the ID of the last entry added, which may have been deleted since.
+	LastID StreamID
+	Groups map[string]GroupData
+}
+
+// GroupData is a copy of a consumer group of a stream.
+type GroupData struct {
+	LastDeliveredID StreamID
+	// Pending is the pending entry list of the group, ordered by ID.
+	Pending []PendingEntry
+	// Consumers are the names of the consumers that have read from the group, ordered by name,
+	// including the ones without pending entries.
+	Consumers []string
+}
+
+// stream keeps the entries ordered by ID, which is also the order they were added in.
+type stream struct {
+	entries []StreamEntry
+	// lastID is the ID of the last entry added, even if it has been deleted since,
+	// so the IDs never go back.
+	lastID StreamID
+	groups map[string]*consumerGroup
+}
+
+type consumerGroup struct {
+	lastDelivered StreamID
+	// pending is the pending entry list of the group, indexed by ID.
+	pending map[StreamID]*PendingEntry
+	// consumers counts the pending entries of each consumer that has read from the group.
+	consumers map[string]int
+}
+
+func newStream() *stream {
+	return &stream{groups: map[string]*consumerGroup{}}
+}
+
+func newConsumerGroup(lastDelivered StreamID) *consumerGroup {
+	return &consumerGroup{lastDelivered: lastDelivered, pending: map[StreamID]*PendingEntry{}, consumers: map[string]int{}}
+}
+
+// newStreamFrom builds a stream from a copy of its data.
+func newStreamFrom(data StreamData) *stream {
+	s := newStream()
+	s.lastID = data.LastID
+	s.entries = make([]StreamEntry, 0, len(data.Entries))
+	for _, e := range data.Entries {
+		s.entries = append(s.entries, cloneEntry(e))
+	}
+	// The entries are kept ordered by ID, whatever the order of the data.
+	slices.SortFunc(s.entries, func(a, b StreamEntry) int {
+		return a.ID.Compare(b.ID)
+	})
+	if n := len(s.entries); n > 0 && s.entries[n-1].ID.Compare(s.lastID) > 0 {
+		s.lastID = s.entries[n-1].ID
+	}
+	for name, gd := range data.Groups {
+		g := newConsumerGroup(gd.LastDeliveredID)
+		for _, consumer := range gd.Consumers {
+			g.consumers[consumer] = 0
+		}
+		for _, p := range gd.Pending {
+			g.pending[p.ID] = &p
+			g.consumers[p.Consumer]++
+		}
+		s.groups[name] = g
+	}
+	return s
+}
+
+// data returns a copy of the stream with its consumer groups.
+func (s *stream) data() StreamData {
+	data := StreamData{
+		Entries: make([]StreamEntry, 0, len(s.entries)),
+		LastID:  s.lastID,
+		Groups:  make(map[string]GroupData, len(s.groups)),
+	}
+	for _, e := range s.entries {
+		data.Entries = append(data.Entries, cloneEntry(e))
+	}
+	for name, g := range s.groups {
+		gd := GroupData{
+			LastDeliveredID: g.lastDelivered,
+			Pending:         make([]PendingEntry, 0, len(g.pending)),
+			Consumers:       slices.Sorted(maps.Keys(g.consumers)),
+		}
+		for _, p := range g.pending {
+			gd.Pending = append(gd.Pending, *p)
+		}
+		slices.SortFunc(gd.Pending, func(a, b PendingEntry) int {
+			return a.ID.Compare(b.ID)
+		})
+		data.Groups[name] = gd
+	}
+	return data
+}
+
+// nextID returns an ID greater than the last one, using the current time unless the clock has gone back.
+func (s *stream) nextID(now time.Time) StreamID {
+	ms := uint64(max(now.UnixMilli(), 0))
+	if ms > s.lastID.Ms {
+		return StreamID{Ms: ms}
+	}
+	return StreamID{Ms: s.lastID.Ms, Seq: s.lastID.Seq + 1}
+}
+
+// search returns the position of the first entry with an ID greater than or equal to id.
+func (s *stream) search(id StreamID) int {
+	return sort.Search(len(s.entries), func(i int) bool {
+		return s.entries[i].ID.Compare(id) >= 0
+	})
+}
+
+// get returns the entry with the given ID.
+func (s *stream) get(id StreamID) (StreamEntry, bool) {
+	i := s.search(id)
+	if i == len(s.entries) || s.entries[i].ID != id {
+		return StreamEntry{}, false
+	}
+	return s.entries[i], true
+}
+
+// rangeByID returns at most count entries with an ID between start and end, both inclusive.
+// A negative count returns all the entries in the range.
+func (s *stream) rangeByID(start, end StreamID, count int, reverse bool) []StreamEntry {
+	entries := []StreamEntry{}
+	from, to := s.search(start), s.search(end)
+	if to < len(s.entries) && s.entries[to].ID == end {
+		to++
+	}
+	if reverse {
+		for i := to - 1; i >= from && count != 0; i-- {
+			entries = append(entries, cloneEntry(s.entries[i]))
+			count--
+		}
+		return entries
+	}
+	for i := from; i < to && count != 0; i++ {
+		entries = append(entries, cloneEntry(s.entries[i]))
+		count--
+	}
+	return entries
+}
+
+// add appends an entry with the given ID, which must be greater than the last one, and trims the stream
+// to maxLen entries if it's positive.
+func (s *stream) add(id StreamID, fields map[string]string, maxLen int) {
+	s.entries = append(s.entries, StreamEntry{ID: id, Fields: maps.Clone(fields)})
+	s.lastID = id
+	if maxLen > 0 {
+		s.trim(maxLen)
+	}
+}
+
+// trim removes the oldest entries until there are at most maxLen and returns how many were removed.
+// The entries are resliced rather than shifted, so trimming on every capped add costs the removed
+// entries only. The next append that runs out of capacity moves the remaining ones to a new array.
+func (s *stream) trim(maxLen int) int {
+	n := len(s.entries) - maxLen
+	if n <= 0 {
+		return 0
+	}
+	// Clear the removed entries so their fields can be collected before the array is reallocated.
+	clear(s.entries[:n])
+	s.entries = s.entries[n:]
+	return n
+}
+
+func cloneEntry(e StreamEntry) StreamEntry {
+	return StreamEntry{ID: e.ID, Fields: maps.Clone(e.Fields)}
+}
+
+// release removes the entry from the pending entry list of the group.
+func (g *consumerGroup) release(id StreamID) bool {
+	p, ok := g.pending[id]
+	if !ok {
+		return false
+	}
+	delete(g.pending, id)
+	g.consumers[p.Consumer]--
+	return true
+}
+
+// deliver adds the entry to the pending entry list of the consumer, moving it from another consumer if needed.
+func (g *consumerGroup) deliver(id StreamID, consumer string, now time.Time) {
+	deliveries := 0
+	if p, ok := g.pending[id]; ok {
+		deliveries = p.Deliveries
+		g.release(id)
+	}
+	g.pending[id] = &PendingEntry{ID: id, Consumer: consumer, DeliveredAt: now, Deliveries: deliveries + 1}
+	g.consumers[consumer]++
+}
+
+type streamStore struct {
+	store map[string]Value[*stream]
+	// Mutex to handle concurrent access to memory
+	mu *sync.RWMutex
+
+	// keyspace is the keyspace the store has joined, if any
+	keyspace *keyspace
+	sweeper  *sweeper
+}
+
+// NewStreamStore initializes a new stream store.
+// Use WithSweeper to actively remove expired streams in the background.
+func NewStreamStore(opts ...Option) StreamStore {
+	ss := &streamStore{
+		store: map[string]Value[*stream]{},
+	}
+	o := newOptions(opts)
+	ss.keyspace = o.keyspace
+	ss.mu = o.keyspace.join(ss)
+	ss.sweeper = startSweeper(ss, o)
+	return ss
+}
+
+// XAdd will append an entry with the given fields to the stream and return its ID, which is greater
+// than the IDs of all the entries added before. The stream is created if it doesn't exist.
+// When maxLen is positive, the oldest entries are removed so the stream keeps at most maxLen entries.
+func (ss *streamStore) XAdd(key string, fields map[string]string, maxLen int) (StreamID, error) {
+	if maxLen < 0 {
+		return StreamID{}, ErrInvalidMaxLen
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := ss.getOrCreate(key)
+	if err != nil {
+		return StreamID{}, err
+	}
+
+	id := v.Value.nextID(time.Now())
+	v.Value.add(id, fields, maxLen)
+	return id, nil
+}
+
+// XAddID is the same as XAdd, but the entry gets the given ID instead of one based on the current time.
+// It will return ErrStreamIDTooSmall if the ID is not greater than the IDs of all the entries added before.
+func (ss *streamStore) XAddID(key string, id StreamID, fields map[string]string, maxLen int) error {
+	if maxLen < 0 {
+		return ErrInvalidMaxLen
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if v, err := getValid(ss.store, key); err == nil && id.Compare(v.Value.lastID) <= 0 {
+		return ErrStreamIDTooSmall
+	}
+	v, err := ss.getOrCreate(key)
+	if err != nil {
+		return err
+	}
+
+	v.Value.add(id, fields, maxLen)
+	return nil
+}
+
+// XRange will return at most count entries with an ID between start and end, both inclusive,
+// from the oldest. A negative count returns all the entries in the range.
+// It will return an error if the stream is not found or if it has expired.
+func (ss *streamStore) XRange(key string, start, end StreamID, count int) ([]StreamEntry, error) {
+	return ss.rangeByID(key, start, end, count, false)
+}
+
+// XRevRange is the same as XRange, but it returns the entries from the newest.
+func (ss *streamStore) XRevRange(key string, end, start StreamID, count int) ([]StreamEntry, error) {
+	return ss.rangeByID(key, start, end, count, true)
+}
+
+func (ss *streamStore) rangeByID(key string, start, end StreamID, count int, reverse bool) ([]StreamEntry, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := readValid(ss.store, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Value.rangeByID(start, end, count, reverse), nil
+}
+
+// XLen will return the number of entries of the stream.
+// It will return an error if the stream is not found or if it has expired.
+func (ss *streamStore) XLen(key string) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := readValid(ss.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(v.Value.entries), nil
+}
+
+// XTrim will remove the oldest entries of the stream until it has at most maxLen entries,
+// and return how many were removed. The stream is kept even if it has no entries left.
+// It will return an error if the stream is not found or if it has expired.
+func (ss *streamStore) XTrim(key string, maxLen int) (int, error) {
+	if maxLen < 0 {
+		return 0, ErrInvalidMaxLen
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Value.trim(maxLen), nil
+}
+
+// XDel will remove the entries with the given IDs from the stream and return how many were removed.
+// The entries stay in the pending entry lists of the groups until they're acknowledged or claimed.
+// It will return an error if the stream is not found or if it has expired.
+func (ss *streamStore) XDel(key string, ids ...StreamID) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	s := v.Value
+	removed := 0
+	for _, id := range ids {
+		i := s.search(id)
+		if i < len(s.entries) && s.entries[i].ID == id {
+			s.entries = slices.Delete(s.entries, i, i+1)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Remove will remove the stream with its consumer groups.
+// It will return an error if the stream is not found.
+func (ss *streamStore) Remove(key string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return remove(ss.store, key)
+}
+
+// XGroupCreate will create a consumer group of the stream, creating the stream if it doesn't exist.
+// The group delivers all the entries of the stream if fromStart is true, or only the entries added
+// after it's created otherwise.
+// It will return ErrGroupExists if the stream already has a group with that name.
+func (ss *streamStore) XGroupCreate(key, group string, fromStart bool) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := ss.getOrCreate(key)
+	if err != nil {
+		return err
+	}
+	if _, ok := v.Value.groups[group]; ok {
+		return ErrGroupExists
+	}
+
+	g := newConsumerGroup(StreamID{})
+	if !fromStart {
+		g.lastDelivered = v.Value.lastID
+	}
+	v.Value.groups[group] = g
+	return nil
+}
+
+// XGroupDestroy will remove the consumer group of the stream with its pending entry list.
+// It will return an error if the stream or the group are not found.
+func (ss *streamStore) XGroupDestroy(key, group string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		return err
+	}
+	if _, ok := v.Value.groups[group]; !ok {
+		return ErrGroupNotFound
+	}
+
+	delete(v.Value.groups, group)
+	return nil
+}
+
+// XGroups will return the consumer groups of the stream, ordered by name.
+// It will return an error if the stream is not found or if it has expired.
+func (ss *streamStore) XGroups(key string) ([]GroupInfo, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := readValid(ss.store, key)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]GroupInfo, 0, len(v.Value.groups))
+	for name, g := range v.Value.groups {
+		groups = append(groups, GroupInfo{
+			Name:            name,
+			LastDeliveredID: g.lastDelivered,
+			Consumers:       len(g.consumers),
+			Pending:         len(g.pending),
+		})
+	}
+	slices.SortFunc(groups, func(a, b GroupInfo) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return groups, nil
+}
+
+// XReadGroup will deliver to the consumer at most count entries of the group that have not been
+// delivered to any of its consumers yet, from the oldest. The entries are added to the pending entry
+// list of the group until they're acknowledged with XAck. An empty slice is returned if there are no new entries.
+// It will return an error if the stream or the group are not found.
+func (ss *streamStore) XReadGroup(key, group, consumer string, count int) ([]StreamEntry, error) {
+	if count <= 0 {
+		return nil, ErrInvalidCount
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	s, g, err := ss.group(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	// The entries after the last delivered one
+	start := s.search(g.lastDelivered)
+	if start < len(s.entries) && s.entries[start].ID == g.lastDelivered {
+		start++
+	}
+	end := min(start+count, len(s.entries))
+
+	now := time.Now()
+	entries := make([]StreamEntry, 0, end-start)
+	for _, e := range s.entries[start:end] {
+		g.deliver(e.ID, consumer, now)
+		g.lastDelivered = e.ID
+		entries = append(entries, cloneEntry(e))
+	}
+	if _, ok := g.consumers[consumer]; !ok {
+		g.consumers[consumer] = 0
+	}
+	return entries, nil
+}
+
+// XAck will remove the entries from the pending entry list of the group and return how many were pending.
+// It will return an error if the stream or the group are not found.
+func (ss *streamStore) XAck(key, group string, ids ...StreamID) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	_, g, err := ss.group(key, group)
+	if err != nil {
+		return 0, err
+	}
+
+	acked := 0
+	for _, id := range ids {
+		if g.release(id) {
+			acked++
+		}
+	}
+	return acked, nil
+}
+
+// XPending will return the pending entries of the group ordered by ID, only the ones of the consumer if given.
+// It will return an error if the stream or the group are not found.
+func (ss *streamStore) XPending(key, group, consumer string) ([]PendingEntry, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	_, g, err := ss.group(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []PendingEntry{}
+	for _, p := range g.pending {
+		if consumer == "" || p.Consumer == consumer {
+			pending = append(pending, *p)
+		}
+	}
+	slices.SortFunc(pending, func(a, b PendingEntry) int {
+		return a.ID.Compare(b.ID)
+	})
+	return pending, nil
+}
+
+// XClaim will give to the consumer the pending entries of the group with the given IDs that have not been
+// delivered for at least minIdle, as if it had read them, and return them. It's used to take over the entries
+// of a consumer that has failed. The pending entries that have been deleted from the stream are acknowledged.
+// It will return an error if the stream or the group are not found.
+func (ss *streamStore) XClaim(key, group, consumer string, minIdle time.Duration, ids ...StreamID) ([]StreamEntry, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	s, g, err := ss.group(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries := []StreamEntry{}
+	for _, id := range ids {
+		p, ok := g.pending[id]
+		if !ok || now.Sub(p.DeliveredAt) < minIdle {
+			continue
+		}
+		e, ok := s.get(id)
+		if !ok {
+			g.release(id)
+			continue
+		}
+		g.deliver(id, consumer, now)
+		entries = append(entries, cloneEntry(e))
+	}
+	return entries, nil
+}
+
+// XSetPending will set the pending entries of the group, with their consumer, delivery time and number of
+// deliveries, as if they had been delivered by XReadGroup or XClaim, and register the consumer if it's not empty.
+// The last delivered ID of the group is moved to lastDelivered if it's greater.
+// It's used to replay the reads of the groups, whose delivery times can't be replayed by XReadGroup.
+// It will return an error if the stream or the group are not found.
+func (ss *streamStore) XSetPending(key, group, consumer string, lastDelivered StreamID, pending ...PendingEntry) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	_, g, err := ss.group(key, group)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		g.release(p.ID)
+		g.pending[p.ID] = &p
+		g.consumers[p.Consumer]++
+	}
+	if _, ok := g.consumers[consumer]; !ok && consumer != "" {
+		g.consumers[consumer] = 0
+	}
+	if lastDelivered.Compare(g.lastDelivered) > 0 {
+		g.lastDelivered = lastDelivered
+	}
+	return nil
+}
+
+// Get will return a copy of the stream with its consumer groups.
+// It will return an error if the stream is not found or if it has expired.
+func (ss *streamStore) Get(key string) (*Value[StreamData], error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := readValid(ss.store, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Value[StreamData]{Value: v.Value.data(), ExpiresAt: v.ExpiresAt, Sliding: v.Sliding}, nil
+}
+
+// Snapshot will return a point-in-time copy of all the streams that have not expired, with their consumer groups.
+func (ss *streamStore) Snapshot() map[string]Value[StreamData] {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	data := map[string]Value[StreamData]{}
+	for key, v := range snapshot(ss.store) {
+		data[key] = Value[StreamData]{Value: v.Value.data(), ExpiresAt: v.ExpiresAt, Sliding: v.Sliding}
+	}
+	return data
+}
+
+// Restore will load the given streams into the store, overwriting existing keys.
+// Streams that have already expired are dropped. It returns the number of streams loaded.
+// Keys held by another store of the keyspace are skipped, as a key holds one type of value.
+func (ss *streamStore) Restore(data map[string]Value[StreamData]) int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	streams := make(map[string]Value[*stream], len(data))
+	for key, v := range data {
+		if ss.keyspace.claim(ss, key) != nil {
+			continue
+		}
+		streams[key] = Value[*stream]{Value: newStreamFrom(v.Value), ExpiresAt: v.ExpiresAt, Sliding: v.Sliding}
+	}
+	return restore(ss.store, streams)
+}
+
+// SweepStats returns the counters of the background sweeper.
+// All the counters are zero if the sweeper is not enabled.
+func (ss *streamStore) SweepStats() SweepStats {
+	return ss.sweeper.stats()
+}
+
+// Close stops the background sweeper, if enabled.
+// The store remains usable after closing it.
+func (ss *streamStore) Close() error {
+	ss.sweeper.stop()
+	return nil
+}
+
+func (ss *streamStore) sweep(sampleSize int) (sampled, expired int) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return sweepExpired(ss.store, sampleSize, nil)
+}
+
+// getOrCreate returns the stream for the given key, creating it if it does not exist
+// or has expired. The caller must hold the lock.
+func (ss *streamStore) getOrCreate(key string) (Value[*stream], error) {
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrExpired) {
+			return v, err
+		}
+		if err := ss.keyspace.claim(ss, key); err != nil {
+			return v, err
+		}
+		v = Value[*stream]{Value: newStream()}
+		ss.store[key] = v
+	}
+	return v, nil
+}
+
+// group returns the stream and its consumer group. The caller must hold the lock.
+func (ss *streamStore) group(key, group string) (*stream, *consumerGroup, error) {
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	g, ok := v.Value.groups[group]
+	if !ok {
+		return nil, nil, ErrGroupNotFound
+	}
+	return v.Value, g, nil
+}
+
+func (ss *streamStore) keyType() KeyType {
+	return TypeStream
+}
+
+func (ss *streamStore) has(key string) bool {
+	return hasValid(ss.store, key)
+}
+
+func (ss *streamStore) drop(key string) bool {
+	return dropValid(ss.store, key)
+}
+
+func (ss *streamStore) move(src, dst string) {
+	moveKey(ss.store, src, dst)
+}
+
+func (ss *streamStore) count() int {
+	return countValid(ss.store)
+}
+
+func (ss *streamStore) scan(cursor, match string, count int) []string {
+	return scanKeys(ss.store, cursor, match, count)
+}
+
+func (ss *streamStore) expire(key string, at time.Time, sliding time.Duration) error {
+	_, err := expireKey(ss.store, key, at, sliding)
+	return err
+}
+
+func (ss *streamStore) ttl(key string) (time.Duration, error) {
+	return ttlOf(ss.store, key)
+}
@@ -0,0 +1,562 @@
+package storage_test
+
+import (
+	"in-memory-storage/storage"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStreamID(t *testing.T) {
+	testCases := map[string]struct {
+		id          string
+		expectedID  storage.StreamID
+		expectedErr error
+	}{
+		"milliseconds and sequence": {
+			id:         "1700000000000-3",
+			expectedID: storage.StreamID{Ms: 1700000000000, Seq: 3},
+		},
+		"it should default the sequence to 0": {
+			id:         "1700000000000",
+			expectedID: storage.StreamID{Ms: 1700000000000},
+		},
+		"it should return an error if the milliseconds are not a number": {
+			id:          "abc-1",
+			expectedErr: storage.ErrInvalidStreamID,
+		},
+		"it should return an error if the sequence is not a number": {
+			id:          "1-",
+			expectedErr: storage.ErrInvalidStreamID,
+		},
+		"it should return an error if the id is negative": {
+			id:          "-1",
+			expectedErr: storage.ErrInvalidStreamID,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			id, err := storage.ParseStreamID(tc.id)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedID, id)
+			if err == nil {
+				assert.Equal(t, tc.expectedID.String(), id.String())
+			}
+		})
+	}
+}
+
+func TestStreamStore_XAdd(t *testing.T) {
+	store := storage.NewStreamStore()
+
+	t.Run("it should generate increasing ids", func(t *testing.T) {
+		var last storage.StreamID
+		for i := 0; i < 100; i++ {
+			id, err := store.XAdd("events", map[string]string{"n": "1"}, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, id.Compare(last))
+			last = id
+		}
+		assert.WithinDuration(t, time.Now(), time.UnixMilli(int64(last.Ms)), time.Second)
+
+		n, err := store.XLen("events")
+		assert.Nil(t, err)
+		assert.Equal(t, 100, n)
+	})
+
+	t.Run("it should keep at most max length entries", func(t *testing.T) {
+		var ids []storage.StreamID
+		for i := 0; i < 5; i++ {
+			id, err := store.XAdd("capped", map[string]string{"n": "1"}, 3)
+			assert.Nil(t, err)
+			ids = append(ids, id)
+		}
+
+		entries, err := store.XRange("capped", storage.MinStreamID, storage.MaxStreamID, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, ids[2:], entryIDs(entries))
+	})
+
+	t.Run("it should keep the newest entries over many capped adds", func(t *testing.T) {
+		var ids []storage.StreamID
+		for i := 0; i < 1000; i++ {
+			id, err := store.XAdd("long-capped", map[string]string{"n": strconv.Itoa(i)}, 10)
+			assert.Nil(t, err)
+			ids = append(ids, id)
+		}
+
+		entries, err := store.XRange("long-capped", storage.MinStreamID, storage.MaxStreamID, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, ids[990:], entryIDs(entries))
+		assert.Equal(t, map[string]string{"n": "999"}, entries[9].Fields)
+	})
+
+	t.Run("it should return an error if the max length is negative", func(t *testing.T) {
+		_, err := store.XAdd("events", map[string]string{"n": "1"}, -1)
+		assert.Equal(t, storage.ErrInvalidMaxLen, err)
+	})
+
+	t.Run("it should not keep a reference to the fields", func(t *testing.T) {
+		fields := map[string]string{"name": "alice"}
+		id, err := store.XAdd("users", fields, 0)
+		assert.Nil(t, err)
+		fields["name"] = "bob"
+
+		entries, err := store.XRange("users", id, id, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, []storage.StreamEntry{{ID: id, Fields: map[string]string{"name": "alice"}}}, entries)
+	})
+}
+
+func TestStreamStore_XRange(t *testing.T) {
+	store := storage.NewStreamStore()
+
+	var ids []storage.StreamID
+	for _, v := range []string{"a", "b", "c", "d"} {
+		id, err := store.XAdd("key", map[string]string{"v": v}, 0)
+		assert.Nil(t, err)
+		ids = append(ids, id)
+	}
+
+	testCases := map[string]struct {
+		start       storage.StreamID
+		end         storage.StreamID
+		count       int
+		reverse     bool
+		expectedIDs []storage.StreamID
+	}{
+		"all the entries": {
+			start:       storage.MinStreamID,
+			end:         storage.MaxStreamID,
+			count:       -1,
+			expectedIDs: ids,
+		},
+		"it should include both ends": {
+			start:       ids[1],
+			end:         ids[2],
+			count:       -1,
+			expectedIDs: ids[1:3],
+		},
+		"it should return at most count entries": {
+			start:       ids[1],
+			end:         storage.MaxStreamID,
+			count:       2,
+			expectedIDs: ids[1:3],
+		},
+		"start after end": {
+			start:       ids[3],
+			end:         ids[0],
+			count:       -1,
+			expectedIDs: []storage.StreamID{},
+		},
+		"the newest two in reverse": {
+			start:       storage.MinStreamID,
+			end:         storage.MaxStreamID,
+			count:       2,
+			reverse:     true,
+			expectedIDs: []storage.StreamID{ids[3], ids[2]},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var entries []storage.StreamEntry
+			var err error
+			if tc.reverse {
+				entries, err = store.XRevRange("key", tc.end, tc.start, tc.count)
+			} else {
+				entries, err = store.XRange("key", tc.start, tc.end, tc.count)
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedIDs, entryIDs(entries))
+		})
+	}
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.XRange("new-key", storage.MinStreamID, storage.MaxStreamID, -1)
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestStreamStore_XTrimAndXDel(t *testing.T) {
+	store := storage.NewStreamStore()
+
+	var ids []storage.StreamID
+	for i := 0; i < 5; i++ {
+		id, err := store.XAdd("key", map[string]string{"n": "1"}, 0)
+		assert.Nil(t, err)
+		ids = append(ids, id)
+	}
+
+	t.Run("it should remove the given entries", func(t *testing.T) {
+		removed, err := store.XDel("key", ids[1], ids[3], storage.StreamID{Ms: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, removed)
+
+		entries, err := store.XRange("key", storage.MinStreamID, storage.MaxStreamID, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, []storage.StreamID{ids[0], ids[2], ids[4]}, entryIDs(entries))
+	})
+
+	t.Run("it should remove the oldest entries", func(t *testing.T) {
+		removed, err := store.XTrim("key", 1)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, removed)
+
+		removed, err = store.XTrim("key", 10)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, removed)
+	})
+
+	t.Run("it should keep the stream and its last id when it's empty", func(t *testing.T) {
+		removed, err := store.XTrim("key", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, removed)
+
+		n, err := store.XLen("key")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, n)
+
+		id, err := store.XAdd("key", map[string]string{"n": "1"}, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, id.Compare(ids[4]))
+	})
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.XTrim("new-key", 0)
+		assert.Equal(t, storage.ErrNotFound, err)
+		_, err = store.XDel("new-key", ids[0])
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestStreamStore_Groups(t *testing.T) {
+	store := storage.NewStreamStore()
+
+	old, err := store.XAdd("orders", map[string]string{"id": "1"}, 0)
+	assert.Nil(t, err)
+
+	t.Run("it should create the groups and the stream", func(t *testing.T) {
+		assert.Nil(t, store.XGroupCreate("orders", "billing", true))
+		assert.Nil(t, store.XGroupCreate("orders", "shipping", false))
+		assert.Nil(t, store.XGroupCreate("new-stream", "billing", false))
+
+		n, err := store.XLen("new-stream")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, n)
+	})
+
+	t.Run("it should return an error if the group exists", func(t *testing.T) {
+		err := store.XGroupCreate("orders", "billing", false)
+		assert.Equal(t, storage.ErrGroupExists, err)
+	})
+
+	t.Run("it should deliver the entries after the start of the group", func(t *testing.T) {
+		entries, err := store.XReadGroup("orders", "billing", "worker-1", 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []storage.StreamID{old}, entryIDs(entries))
+
+		entries, err = store.XReadGroup("orders", "shipping", "worker-1", 10)
+		assert.Nil(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("it should describe the groups", func(t *testing.T) {
+		groups, err := store.XGroups("orders")
+		assert.Nil(t, err)
+		assert.Equal(t, []storage.GroupInfo{
+			{Name: "billing", LastDeliveredID: old, Consumers: 1, Pending: 1},
+			{Name: "shipping", LastDeliveredID: old, Consumers: 1},
+		}, groups)
+	})
+
+	t.Run("it should remove the group", func(t *testing.T) {
+		assert.Nil(t, store.XGroupDestroy("orders", "shipping"))
+
+		err := store.XGroupDestroy("orders", "shipping")
+		assert.Equal(t, storage.ErrGroupNotFound, err)
+		_, err = store.XReadGroup("orders", "shipping", "worker-1", 10)
+		assert.Equal(t, storage.ErrGroupNotFound, err)
+	})
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.XReadGroup("missing", "billing", "worker-1", 10)
+		assert.Equal(t, storage.ErrNotFound, err)
+		_, err = store.XGroups("missing")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should return an error if the count is not positive", func(t *testing.T) {
+		_, err := store.XReadGroup("orders", "billing", "worker-1", 0)
+		assert.Equal(t, storage.ErrInvalidCount, err)
+	})
+}
+
+func TestStreamStore_ReadGroupAndAck(t *testing.T) {
+	store := storage.NewStreamStore()
+	assert.Nil(t, store.XGroupCreate("jobs", "workers", true))
+
+	var ids []storage.StreamID
+	for i := 0; i < 3; i++ {
+		id, err := store.XAdd("jobs", map[string]string{"n": "1"}, 0)
+		assert.Nil(t, err)
+		ids = append(ids, id)
+	}
+
+	// Each entry is delivered to a single consumer of the group
+	first, err := store.XReadGroup("jobs", "workers", "worker-1", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, ids[:2], entryIDs(first))
+	second, err := store.XReadGroup("jobs", "workers", "worker-2", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, ids[2:], entryIDs(second))
+
+	pending, err := store.XPending("jobs", "workers", "")
+	assert.Nil(t, err)
+	assert.Equal(t, ids, pendingIDs(pending))
+	assert.Equal(t, "worker-1", pending[0].Consumer)
+	assert.Equal(t, 1, pending[0].Deliveries)
+	assert.WithinDuration(t, time.Now(), pending[0].DeliveredAt, time.Second)
+
+	pending, err = store.XPending("jobs", "workers", "worker-2")
+	assert.Nil(t, err)
+	assert.Equal(t, ids[2:], pendingIDs(pending))
+
+	acked, err := store.XAck("jobs", "workers", ids[0], ids[0], storage.StreamID{Ms: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, acked)
+
+	pending, err = store.XPending("jobs", "workers", "")
+	assert.Nil(t, err)
+	assert.Equal(t, ids[1:], pendingIDs(pending))
+
+	_, err = store.XAck("jobs", "missing", ids[1])
+	assert.Equal(t, storage.ErrGroupNotFound, err)
+}
+
+func TestStreamStore_XClaim(t *testing.T) {
+	store := storage.NewStreamStore()
+	assert.Nil(t, store.XGroupCreate("jobs", "workers", true))
+
+	var ids []storage.StreamID
+	for i := 0; i < 3; i++ {
+		id, err := store.XAdd("jobs", map[string]string{"n": "1"}, 0)
+		assert.Nil(t, err)
+		ids = append(ids, id)
+	}
+	_, err := store.XReadGroup("jobs", "workers", "worker-1", 3)
+	assert.Nil(t, err)
+
+	t.Run("it should not claim the entries delivered recently", func(t *testing.T) {
+		entries, err := store.XClaim("jobs", "workers", "worker-2", time.Minute, ids...)
+		assert.Nil(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("it should claim the idle entries", func(t *testing.T) {
+		time.Sleep(5 * time.Millisecond)
+		entries, err := store.XClaim("jobs", "workers", "worker-2", time.Millisecond, ids[0], storage.StreamID{Ms: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, ids[:1], entryIDs(entries))
+
+		pending, err := store.XPending("jobs", "workers", "worker-2")
+		assert.Nil(t, err)
+		assert.Equal(t, ids[:1], pendingIDs(pending))
+		assert.Equal(t, 2, pending[0].Deliveries)
+	})
+
+	t.Run("it should acknowledge the claimed entries that were deleted", func(t *testing.T) {
+		_, err := store.XDel("jobs", ids[1])
+		assert.Nil(t, err)
+
+		entries, err := store.XClaim("jobs", "workers", "worker-2", 0, ids[1], ids[2])
+		assert.Nil(t, err)
+		assert.Equal(t, ids[2:], entryIDs(entries))
+
+		pending, err := store.XPending("jobs", "workers", "")
+		assert.Nil(t, err)
+		assert.Equal(t, []storage.StreamID{ids[0], ids[2]}, pendingIDs(pending))
+	})
+}
+
+func TestStreamStore_XAddID(t *testing.T) {
+	store := storage.NewStreamStore()
+	restorer := store.(storage.StreamRestorer)
+
+	assert.Nil(t, restorer.XAddID("events", storage.StreamID{Ms: 5, Seq: 1}, map[string]string{"n": "1"}, 0))
+	err := restorer.XAddID("events", storage.StreamID{Ms: 5, Seq: 1}, map[string]string{"n": "2"}, 0)
+	assert.Equal(t, storage.ErrStreamIDTooSmall, err)
+
+	// The IDs generated afterwards are greater than the given one
+	id, err := store.XAdd("events", map[string]string{"n": "3"}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, id.Compare(storage.StreamID{Ms: 5, Seq: 1}))
+
+	assert.Nil(t, restorer.XAddID("events", storage.StreamID{Ms: id.Ms + 1}, map[string]string{"n": "4"}, 2))
+	entries, err := store.XRange("events", storage.MinStreamID, storage.MaxStreamID, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, []storage.StreamID{id, {Ms: id.Ms + 1}}, entryIDs(entries))
+}
+
+func TestStreamStore_XSetPending(t *testing.T) {
+	store := storage.NewStreamStore()
+	restorer := store.(storage.StreamRestorer)
+	assert.Nil(t, store.XGroupCreate("jobs", "workers", true))
+	var ids []storage.StreamID
+	for i := 0; i < 2; i++ {
+		id, err := store.XAdd("jobs", map[string]string{"n": "1"}, 0)
+		assert.Nil(t, err)
+		ids = append(ids, id)
+	}
+	_, err := store.XReadGroup("jobs", "workers", "worker-1", 1)
+	assert.Nil(t, err)
+
+	at := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	err = restorer.XSetPending("jobs", "workers", "worker-3", ids[1],
+		storage.PendingEntry{ID: ids[0], Consumer: "worker-2", DeliveredAt: at, Deliveries: 2},
+		storage.PendingEntry{ID: ids[1], Consumer: "worker-2", DeliveredAt: at, Deliveries: 1})
+	assert.Nil(t, err)
+
+	pending, err := store.XPending("jobs", "workers", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []storage.PendingEntry{
+		{ID: ids[0], Consumer: "worker-2", DeliveredAt: at, Deliveries: 2},
+		{ID: ids[1], Consumer: "worker-2", DeliveredAt: at, Deliveries: 1},
+	}, pending)
+	groups, err := store.XGroups("jobs")
+	assert.Nil(t, err)
+	assert.Equal(t, []storage.GroupInfo{{Name: "workers", LastDeliveredID: ids[1], Consumers: 3, Pending: 2}}, groups)
+
+	err = restorer.XSetPending("jobs", "missing", "worker-1", ids[1])
+	assert.Equal(t, storage.ErrGroupNotFound, err)
+}
+
+func TestStreamStore_SnapshotRestore(t *testing.T) {
+	store := storage.NewStreamStore()
+	first, err := store.XAdd("jobs", map[string]string{"n": "1"}, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, store.XGroupCreate("jobs", "workers", true))
+	_, err = store.XReadGroup("jobs", "workers", "worker-1", 1)
+	assert.Nil(t, err)
+	_, err = store.XReadGroup("jobs", "workers", "worker-2", 1)
+	assert.Nil(t, err)
+	last, err := store.XAdd("jobs", map[string]string{"n": "2"}, 0)
+	assert.Nil(t, err)
+	_, err = store.XDel("jobs", last)
+	assert.Nil(t, err)
+
+	data := store.Snapshot()
+	assert.Equal(t, []storage.StreamEntry{{ID: first, Fields: map[string]string{"n": "1"}}}, data["jobs"].Value.Entries)
+	assert.Equal(t, last, data["jobs"].Value.LastID)
+	assert.Equal(t, []string{"worker-1", "worker-2"}, data["jobs"].Value.Groups["workers"].Consumers)
+
+	restored := storage.NewStreamStore()
+	loaded := restored.Restore(map[string]storage.Value[storage.StreamData]{
+		"jobs":        data["jobs"],
+		"expired-key": {Value: storage.StreamData{}, ExpiresAt: time.Now().Add(-time.Second)},
+	})
+	assert.Equal(t, 1, loaded)
+
+	v, err := restored.Get("jobs")
+	assert.Nil(t, err)
+	assert.Equal(t, data["jobs"], *v)
+	pending, err := restored.XPending("jobs", "workers", "worker-1")
+	assert.Nil(t, err)
+	assert.Equal(t, []storage.StreamID{first}, pendingIDs(pending))
+
+	// The IDs keep increasing from the last one, even if it was deleted
+	id, err := restored.XAdd("jobs", map[string]string{"n": "3"}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, id.Compare(last))
+}
+
+func TestStreamStore_Keyspace(t *testing.T) {
+	ks := storage.NewKeyspace()
+	strings := storage.NewStringStore(storage.WithKeyspace(ks))
+	streams := storage.NewStreamStore(storage.WithKeyspace(ks))
+	assert.Nil(t, strings.Set("name", "alice", 0))
+
+	_, err := streams.XAdd("name", map[string]string{"n": "1"}, 0)
+	assert.Equal(t, storage.ErrWrongType, err)
+	err = streams.XGroupCreate("name", "workers", true)
+	assert.Equal(t, storage.ErrWrongType, err)
+
+	_, err = streams.XAdd("events", map[string]string{"n": "1"}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, storage.TypeStream, ks.Type("events"))
+
+	assert.Nil(t, ks.Expire("events", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, err = streams.XLen("events")
+	assert.Equal(t, storage.ErrExpired, err)
+}
+
+func TestStreamStore_ConcurrentReadGroup(t *testing.T) {
+	store := storage.NewStreamStore()
+	assert.Nil(t, store.XGroupCreate("jobs", "workers", true))
+	for i := 0; i < 100; i++ {
+		_, err := store.XAdd("jobs", map[string]string{"n": "1"}, 0)
+		assert.Nil(t, err)
+	}
+
+	var mu sync.Mutex
+	delivered := map[storage.StreamID]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				entries, err := store.XReadGroup("jobs", "workers", "worker", 3)
+				assert.Nil(t, err)
+				if len(entries) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, e := range entries {
+					delivered[e.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every entry is delivered exactly once
+	assert.Len(t, delivered, 100)
+	for _, n := range delivered {
+		assert.Equal(t, 1, n)
+	}
+}
+
+func entryIDs(entries []storage.StreamEntry) []storage.StreamID {
+	ids := make([]storage.StreamID, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func pendingIDs(pending []storage.PendingEntry) []storage.StreamID {
+	ids := make([]storage.StreamID, 0, len(pending))
+	for _, p := range pending {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func BenchmarkStreamStore_XAddCapped(b *testing.B) {
+	store := storage.NewStreamStore()
+	fields := map[string]string{"n": "1"}
+	for i := 0; i < 100_000; i++ {
+		_, _ = store.XAdd("key", fields, 100_000)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = store.XAdd("key", fields, 100_000)
+	}
+}
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"errors"
+	"math"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -9,22 +11,54 @@ import (
 type stringStore struct {
 	store map[string]Value[string]
 	// Mutex to handle concurrent access to memory
-	mu sync.RWMutex
+	mu       *sync.RWMutex
+	versions versionCounter
+
+	// keyspace is the keyspace the store has joined, if any
+	keyspace *keyspace
+	sweeper  *sweeper
+	events   notifier
 }
 
-// NewStringStore initializes a new string store
-func NewStringStore() StringStore {
-	return &stringStore{
-		store: map[string]Value[string]{}, // TODO: Here we could init with existing data
+// NewStringStore initializes a new string store.
+// Use WithSweeper to actively remove expired keys in the background,
+// and WithObserver to be notified of the changes of the keys.
+func NewStringStore(opts ...Option) StringStore {
+	ss := &stringStore{
+		store: map[string]Value[string]{},
 	}
+	o := newOptions(opts)
+	ss.keyspace = o.keyspace
+	ss.mu = o.keyspace.join(ss)
+	ss.events = newNotifier(TypeString, o)
+	ss.sweeper = startSweeper(ss, o)
+	return ss
 }
 
 // Set will store the given key/value pair.
-// It will check if the key already exists and return an error.
-func (ss *stringStore) Set(key, val string, ttl time.Duration) error {
+// It will check if the key already exists and return an error, unless another mode
+// is given with WithSetMode. Use WithKeepTTL to keep the TTL of a key that is replaced.
+func (ss *stringStore) Set(key, val string, ttl time.Duration, opts ...SetOption) error {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
-	return set(ss.store, key, val, ttl)
+	return ss.setLocked(key, val, ttl, newSetOptions(opts))
+}
+
+// setLocked is Set for callers that already hold the lock, like transactions.
+func (ss *stringStore) setLocked(key, val string, ttl time.Duration, o setOptions) error {
+	reapExpired(ss.store, key, &ss.events)
+	if err := ss.keyspace.claim(ss, key); err != nil {
+		return err
+	}
+	if err := setWith(ss.store, key, val, ttl, o); err != nil {
+		return err
+	}
+	v := ss.store[key]
+	v.Flags = o.flags
+	ss.store[key] = v
+	stamp(ss.store, key, &ss.versions)
+	ss.events.notify(EventSet, key)
+	return nil
 }
 
 // Get will return the value for the given key.
@@ -32,22 +66,21 @@ func (ss *stringStore) Set(key, val string, ttl time.Duration) error {
 // If the value has an expiration time and it is in the past, it will remove
 // the key and return an error indicating it has expired.
 func (ss *stringStore) Get(key string) (*Value[string], error) {
-	ss.mu.RLock()
-	defer ss.mu.RUnlock()
-	value, err := get(ss.store, key)
+	// A write lock is required as expired keys are removed on access.
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.getLocked(key)
+}
+
+// getLocked is Get for callers that already hold the lock, like transactions.
+func (ss *stringStore) getLocked(key string) (*Value[string], error) {
+	// Expired values are removed and ErrExpired is returned.
+	value, err := readValid(ss.store, key)
 	if err != nil {
+		ss.events.notifyExpired(key, err)
 		return nil, err
 	}
 
-	// If the value has an expiration time and it is in the past, remove it
-	// and return an error indicating it has expired.
-	if !value.ExpiresAt.IsZero() && value.ExpiresAt.Before(time.Now()) {
-		if err := remove(ss.store, key); err != nil {
-			return nil, errors.New("failed to remove expired key: " + err.Error())
-		}
-		return nil, ErrExpired
-	}
-
 	return &value, nil
 }
 
@@ -56,7 +89,11 @@ func (ss *stringStore) Get(key string) (*Value[string], error) {
 func (ss *stringStore) Update(key, val string) error {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
+	return ss.updateLocked(key, val)
+}
 
+// updateLocked is Update for callers that already hold the lock, like transactions.
+func (ss *stringStore) updateLocked(key, val string) error {
 	// Check if the key exists and if it has expired.
 	v, ok := ss.store[key]
 	if !ok {
@@ -65,10 +102,108 @@ func (ss *stringStore) Update(key, val string) error {
 
 	if !v.ExpiresAt.IsZero() && v.ExpiresAt.Before(time.Now()) {
 		delete(ss.store, key)
+		ss.events.notify(EventExpired, key)
 		return ErrExpired
 	}
 
-	return update(ss.store, key, val)
+	if err := update(ss.store, key, val); err != nil {
+		return err
+	}
+	stamp(ss.store, key, &ss.versions)
+	ss.events.notify(EventUpdate, key)
+	return nil
+}
+
+// SetNX will store the given key/value pair only if the key doesn't exist or has expired.
+// It returns false if the key already exists.
+func (ss *stringStore) SetNX(key, val string, ttl time.Duration) (bool, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	_, err := getValid(ss.store, key)
+	if err == nil {
+		return false, nil
+	}
+	ss.events.notifyExpired(key, err)
+
+	if err := ss.keyspace.claim(ss, key); err != nil {
+		return false, err
+	}
+	if err := set(ss.store, key, val, ttl); err != nil {
+		return false, err
+	}
+	stamp(ss.store, key, &ss.versions)
+	ss.events.notify(EventSet, key)
+	return true, nil
+}
+
+// SetXX will replace the value and the TTL of the key only if it exists and has not expired.
+// It returns false if the key doesn't exist.
+func (ss *stringStore) SetXX(key, val string, ttl time.Duration) (bool, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if _, err := getValid(ss.store, key); err != nil {
+		ss.events.notifyExpired(key, err)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrExpired) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	delete(ss.store, key)
+	if err := set(ss.store, key, val, ttl); err != nil {
+		return false, err
+	}
+	stamp(ss.store, key, &ss.versions)
+	ss.events.notify(EventSet, key)
+	return true, nil
+}
+
+// CompareAndSwap will replace the value of the key with new only if its current value is old.
+// The TTL of the key is kept. It returns false if the current value is different.
+// It will return an error if not found or if the key has expired.
+func (ss *stringStore) CompareAndSwap(key, old, new string) (bool, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		ss.events.notifyExpired(key, err)
+		return false, err
+	}
+	if v.Value != old {
+		return false, nil
+	}
+
+	v.Value = new
+	ss.store[key] = v
+	stamp(ss.store, key, &ss.versions)
+	ss.events.notify(EventUpdate, key)
+	return true, nil
+}
+
+// UpdateIfVersion will update the value of the key only if its current version is the given one,
+// as returned by Get. The TTL of the key is kept.
+// It will return an error if not found, if the key has expired or if the version doesn't match.
+func (ss *stringStore) UpdateIfVersion(key, val string, version uint64) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		ss.events.notifyExpired(key, err)
+		return err
+	}
+	if v.Version != version {
+		return ErrVersionMismatch
+	}
+
+	v.Value = val
+	ss.store[key] = v
+	stamp(ss.store, key, &ss.versions)
+	ss.events.notify(EventUpdate, key)
+	return nil
 }
 
 // Remove will delete the value linked to the given key.
@@ -76,5 +211,272 @@ func (ss *stringStore) Update(key, val string) error {
 func (ss *stringStore) Remove(key string) error {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
-	return remove(ss.store, key)
+	return ss.removeLocked(key)
+}
+
+// removeLocked is Remove for callers that already hold the lock, like transactions.
+func (ss *stringStore) removeLocked(key string) error {
+	if err := remove(ss.store, key); err != nil {
+		return err
+	}
+	ss.events.notify(EventRemove, key)
+	return nil
+}
+
+// Incr will increment the integer value of the key by one and return the new value.
+// See IncrBy for the details.
+func (ss *stringStore) Incr(key string) (int64, error) {
+	return ss.IncrBy(key, 1)
+}
+
+// Decr will decrement the integer value of the key by one and return the new value.
+// See IncrBy for the details.
+func (ss *stringStore) Decr(key string) (int64, error) {
+	return ss.IncrBy(key, -1)
+}
+
+// IncrBy will increment the integer value of the key by delta and return the new value.
+// The key is created without TTL if it doesn't exist, starting from 0. The TTL of an existing key is kept.
+// It will return an error if the value is not an integer or if the increment overflows.
+func (ss *stringStore) IncrBy(key string, delta int64) (int64, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.incrByLocked(key, delta)
+}
+
+// incrByLocked is IncrBy for callers that already hold the lock, like transactions.
+func (ss *stringStore) incrByLocked(key string, delta int64) (int64, error) {
+	v, err := ss.getOrZero(key)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := strconv.ParseInt(v.Value, 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+
+	if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+		return 0, ErrOverflow
+	}
+
+	current += delta
+	v.Value = strconv.FormatInt(current, 10)
+	ss.store[key] = v
+	stamp(ss.store, key, &ss.versions)
+	ss.events.notify(incrEvent(v), key)
+
+	return current, nil
+}
+
+// IncrByFloat will increment the numeric value of the key by delta and return the new value.
+// The key is created without TTL if it doesn't exist, starting from 0. The TTL of an existing key is kept.
+// It will return an error if the value is not a number or if the result is not finite.
+func (ss *stringStore) IncrByFloat(key string, delta float64) (float64, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	v, err := ss.getOrZero(key)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := strconv.ParseFloat(v.Value, 64)
+	if err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+		return 0, ErrNotFloat
+	}
+
+	current += delta
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		return 0, ErrOverflow
+	}
+
+	v.Value = strconv.FormatFloat(current, 'f', -1, 64)
+	ss.store[key] = v
+	stamp(ss.store, key, &ss.versions)
+	ss.events.notify(incrEvent(v), key)
+
+	return current, nil
+}
+
+// getOrZero returns the value for the given key, or a "0" value without TTL
+// if it doesn't exist or has expired. The caller must hold the lock.
+func (ss *stringStore) getOrZero(key string) (Value[string], error) {
+	v, err := getValid(ss.store, key)
+	if err != nil {
+		ss.events.notifyExpired(key, err)
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrExpired) {
+			return v, err
+		}
+		if err := ss.keyspace.claim(ss, key); err != nil {
+			return v, err
+		}
+		return Value[string]{Value: "0"}, nil
+	}
+	return v, nil
+}
+
+// incrEvent returns the event of an increment: EventSet if it has created the key, which had no version
+// before it was stamped by the increment, or EventUpdate otherwise.
+func incrEvent(old Value[string]) EventType {
+	if old.Version == 0 {
+		return EventSet
+	}
+	return EventUpdate
+}
+
+// Snapshot will return a point-in-time copy of all the values that have not expired.
+func (ss *stringStore) Snapshot() map[string]Value[string] {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return snapshot(ss.store)
+}
+
+// Expire will make the key expire after the TTL, removing its sliding expiration.
+// A TTL that is not positive removes the key.
+// It will return an error if the key is not found or if it has expired.
+func (ss *stringStore) Expire(key string, ttl time.Duration) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.expire(key, time.Now().Add(ttl), 0)
+}
+
+// ExpireAt will make the key expire at the given time, removing its sliding expiration.
+// A time in the past removes the key and the zero time removes its expiration, like Persist.
+// It will return an error if the key is not found or if it has expired.
+func (ss *stringStore) ExpireAt(key string, t time.Time) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.expire(key, t, 0)
+}
+
+// ExpireSliding will make the key expire after the TTL, which starts again on every read of the key.
+// It will return ErrInvalidTTL if the TTL is not positive,
+// or an error if the key is not found or if it has expired.
+func (ss *stringStore) ExpireSliding(key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.expire(key, time.Now().Add(ttl), ttl)
+}
+
+// Persist will remove the expiration of the key, so it never expires.
+// It will return an error if the key is not found or if it has expired.
+func (ss *stringStore) Persist(key string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.expire(key, time.Time{}, 0)
+}
+
+// TTL will return the time left before the key expires, or NoTTL if it doesn't expire.
+// Unlike the other reads, it doesn't extend a sliding expiration.
+// It will return an error if the key is not found or if it has expired.
+func (ss *stringStore) TTL(key string) (time.Duration, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.ttl(key)
+}
+
+// Scan will return up to count keys after the cursor that match the glob pattern, in lexicographic order,
+// and the cursor of the next page, which is empty once all the keys have been returned.
+// Use an empty cursor to start a scan and an empty pattern to match every key.
+// Keys that exist during the whole scan are returned exactly once, even if other keys are written meanwhile,
+// and expired keys are never returned. It returns ErrInvalidCount if count is not positive.
+func (ss *stringStore) Scan(cursor, match string, count int) ([]string, string, error) {
+	if count <= 0 {
+		return nil, "", ErrInvalidCount
+	}
+
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	keys, next := scanPage(scanKeys(ss.store, cursor, match, count), count)
+	return keys, next, nil
+}
+
+// Restore will load the given values into the store, overwriting existing keys.
+// Values that have already expired are dropped. It returns the number of values loaded.
+// Keys held by another store of the keyspace are skipped, as a key holds one type of value.
+// The versions of the values are kept, and values without version are given a new one.
+func (ss *stringStore) Restore(data map[string]Value[string]) int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	claimed := make(map[string]Value[string], len(data))
+	for key, v := range data {
+		if ss.keyspace.claim(ss, key) != nil {
+			continue
+		}
+		claimed[key] = v
+	}
+	return restoreVersioned(ss.store, claimed, &ss.versions)
+}
+
+// SweepStats returns the counters of the background sweeper.
+// All the counters are zero if the sweeper is not enabled.
+func (ss *stringStore) SweepStats() SweepStats {
+	return ss.sweeper.stats()
+}
+
+// Close stops the background sweeper, if enabled.
+// The store remains usable after closing it.
+func (ss *stringStore) Close() error {
+	ss.sweeper.stop()
+	return nil
+}
+
+func (ss *stringStore) sweep(sampleSize int) (sampled, expired int) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return sweepExpired(ss.store, sampleSize, ss.events.onExpired)
+}
+
+func (ss *stringStore) keyType() KeyType {
+	return TypeString
+}
+
+func (ss *stringStore) has(key string) bool {
+	_, err := getValid(ss.store, key)
+	ss.events.notifyExpired(key, err)
+	return err == nil
+}
+
+func (ss *stringStore) drop(key string) bool {
+	if !ss.has(key) {
+		return false
+	}
+	delete(ss.store, key)
+	ss.events.notify(EventRemove, key)
+	return true
+}
+
+func (ss *stringStore) move(src, dst string) {
+	moveKey(ss.store, src, dst)
+	stamp(ss.store, dst, &ss.versions)
+	ss.events.notify(EventRemove, src)
+	ss.events.notify(EventSet, dst)
+}
+
+func (ss *stringStore) count() int {
+	return countValid(ss.store)
+}
+
+func (ss *stringStore) scan(cursor, match string, count int) []string {
+	return scanKeys(ss.store, cursor, match, count)
+}
+
+func (ss *stringStore) expire(key string, at time.Time, sliding time.Duration) error {
+	ok, err := expireKey(ss.store, key, at, sliding)
+	if ok {
+		stamp(ss.store, key, &ss.versions)
+	}
+	notifyExpire(&ss.events, key, ok, err)
+	return err
+}
+
+func (ss *stringStore) ttl(key string) (time.Duration, error) {
+	d, err := ttlOf(ss.store, key)
+	ss.events.notifyExpired(key, err)
+	return d, err
 }
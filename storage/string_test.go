@@ -44,7 +44,7 @@ func TestStringStore_Set(t *testing.T) {
 			val, err := store.Get(tc.key)
 			assert.Nil(t, err)
 
-			assert.Equal(t, tc.expectedVal, val)
+			assert.Equal(t, tc.expectedVal.Value, val.Value)
 		})
 	}
 }
@@ -152,7 +152,7 @@ func TestStringStore_Update(t *testing.T) {
 			if err == nil {
 				val, err := store.Get(tc.key)
 				assert.Nil(t, err)
-				assert.Equal(t, tc.expectedVal, val)
+				assert.Equal(t, tc.expectedVal.Value, val.Value)
 			}
 		})
 	}
@@ -243,3 +243,709 @@ func TestStringStore_ConcurrentUpdate(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Contains(t, val.Value, "val-")
 }
+
+func TestStringStore_SnapshotRestore(t *testing.T) {
+	store := storage.NewStringStore()
+
+	// Populate existing values
+	err := store.Set("existing-key", "existing-value", 0)
+	assert.Nil(t, err)
+	err = store.Set("ttl-valid-key", "valid-value", time.Minute)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", "expired-value", time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	data := store.Snapshot()
+	assert.Len(t, data, 2)
+	assert.Equal(t, "existing-value", data["existing-key"].Value)
+	assert.Equal(t, "valid-value", data["ttl-valid-key"].Value)
+
+	// Add an entry that expires before the snapshot is restored
+	data["expired-key"] = storage.Value[string]{Value: "expired-value", ExpiresAt: time.Now().Add(-time.Second)}
+
+	newStore := storage.NewStringStore()
+	restored := newStore.Restore(data)
+	assert.Equal(t, 2, restored)
+
+	val, err := newStore.Get("ttl-valid-key")
+	assert.Nil(t, err)
+	assert.Equal(t, data["ttl-valid-key"], *val)
+
+	_, err = newStore.Get("expired-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestStringStore_IncrBy(t *testing.T) {
+	store := storage.NewStringStore()
+
+	// Populate existing values
+	err := store.Set("counter", "10", time.Minute)
+	assert.Nil(t, err)
+	err = store.Set("text", "foo", 0)
+	assert.Nil(t, err)
+	err = store.Set("max", "9223372036854775807", 0)
+	assert.Nil(t, err)
+	err = store.Set("min", "-9223372036854775808", 0)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", "10", time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	testCases := map[string]struct {
+		key         string
+		delta       int64
+		expectedVal int64
+		expectedErr error
+	}{
+		"it should increment an existing value": {
+			key:         "counter",
+			delta:       5,
+			expectedVal: 15,
+		},
+		"it should decrement with a negative delta": {
+			key:         "counter",
+			delta:       -20,
+			expectedVal: -5,
+		},
+		"it should create a missing key": {
+			key:         "new-key",
+			delta:       3,
+			expectedVal: 3,
+		},
+		"it should start from 0 if the key has expired": {
+			key:         "expired-key",
+			delta:       1,
+			expectedVal: 1,
+		},
+		"it should return an error if the value is not an integer": {
+			key:         "text",
+			delta:       1,
+			expectedErr: storage.ErrNotInteger,
+		},
+		"it should return an error if the increment overflows": {
+			key:         "max",
+			delta:       1,
+			expectedErr: storage.ErrOverflow,
+		},
+		"it should return an error if the decrement overflows": {
+			key:         "min",
+			delta:       -1,
+			expectedErr: storage.ErrOverflow,
+		},
+	}
+
+	// Run sequentially as cases depend on each other
+	for _, name := range []string{
+		"it should increment an existing value",
+		"it should decrement with a negative delta",
+		"it should create a missing key",
+		"it should start from 0 if the key has expired",
+		"it should return an error if the value is not an integer",
+		"it should return an error if the increment overflows",
+		"it should return an error if the decrement overflows",
+	} {
+		tc := testCases[name]
+		t.Run(name, func(t *testing.T) {
+			val, err := store.IncrBy(tc.key, tc.delta)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedVal, val)
+		})
+	}
+
+	t.Run("it should store the value as a string and keep the TTL", func(t *testing.T) {
+		val, err := store.Get("counter")
+		assert.Nil(t, err)
+		assert.Equal(t, "-5", val.Value)
+		assert.False(t, val.ExpiresAt.IsZero())
+
+		val, err = store.Get("expired-key")
+		assert.Nil(t, err)
+		assert.True(t, val.ExpiresAt.IsZero())
+	})
+
+	t.Run("it should increment and decrement by one", func(t *testing.T) {
+		val, err := store.Incr("new-key")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4), val)
+
+		val, err = store.Decr("new-key")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), val)
+	})
+}
+
+func TestStringStore_IncrByFloat(t *testing.T) {
+	store := storage.NewStringStore()
+
+	// Populate existing values
+	err := store.Set("price", "10.5", time.Minute)
+	assert.Nil(t, err)
+	err = store.Set("integer", "3", 0)
+	assert.Nil(t, err)
+	err = store.Set("text", "foo", 0)
+	assert.Nil(t, err)
+	err = store.Set("max", "1.7976931348623157e308", 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		key         string
+		delta       float64
+		expectedVal float64
+		expectedStr string
+		expectedErr error
+	}{
+		"it should increment an existing value": {
+			key:         "price",
+			delta:       0.25,
+			expectedVal: 10.75,
+			expectedStr: "10.75",
+		},
+		"it should increment an integer value": {
+			key:         "integer",
+			delta:       -0.5,
+			expectedVal: 2.5,
+			expectedStr: "2.5",
+		},
+		"it should create a missing key": {
+			key:         "new-key",
+			delta:       1.5,
+			expectedVal: 1.5,
+			expectedStr: "1.5",
+		},
+		"it should return an error if the value is not a number": {
+			key:         "text",
+			delta:       1,
+			expectedErr: storage.ErrNotFloat,
+			expectedStr: "foo",
+		},
+		"it should return an error if the result is not finite": {
+			key:         "max",
+			delta:       1.7976931348623157e308,
+			expectedErr: storage.ErrOverflow,
+			expectedStr: "1.7976931348623157e308",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			val, err := store.IncrByFloat(tc.key, tc.delta)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedVal, val)
+
+			stored, err := store.Get(tc.key)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedStr, stored.Value)
+		})
+	}
+
+	t.Run("it should keep the TTL", func(t *testing.T) {
+		val, err := store.Get("price")
+		assert.Nil(t, err)
+		assert.False(t, val.ExpiresAt.IsZero())
+	})
+}
+
+func TestStringStore_ConcurrentIncr(t *testing.T) {
+	store := storage.NewStringStore()
+	const n = 100
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.Incr("counter")
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	val, err := store.Get("counter")
+	assert.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(n), val.Value)
+}
+
+func TestStringStore_SetNX(t *testing.T) {
+	store := storage.NewStringStore()
+
+	// Populate existing values
+	err := store.Set("existing-key", "existing-value", 0)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", "expired-value", time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	testCases := map[string]struct {
+		key         string
+		val         string
+		expectedSet bool
+		expectedVal string
+	}{
+		"it should not set the value if the key exists": {
+			key:         "existing-key",
+			val:         "new-value",
+			expectedVal: "existing-value",
+		},
+		"it should set the value if the key doesn't exist": {
+			key:         "new-key",
+			val:         "new-value",
+			expectedSet: true,
+			expectedVal: "new-value",
+		},
+		"it should set the value if the key has expired": {
+			key:         "expired-key",
+			val:         "new-value",
+			expectedSet: true,
+			expectedVal: "new-value",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ok, err := store.SetNX(tc.key, tc.val, time.Minute)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedSet, ok)
+
+			val, err := store.Get(tc.key)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedVal, val.Value)
+		})
+	}
+}
+
+func TestStringStore_SetXX(t *testing.T) {
+	store := storage.NewStringStore()
+
+	// Populate existing values
+	err := store.Set("existing-key", "existing-value", time.Minute)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", "expired-value", time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	t.Run("it should replace the value and the TTL if the key exists", func(t *testing.T) {
+		ok, err := store.SetXX("existing-key", "new-value", 0)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		val, err := store.Get("existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, "new-value", val.Value)
+		assert.True(t, val.ExpiresAt.IsZero())
+	})
+
+	t.Run("it should not set the value if the key doesn't exist", func(t *testing.T) {
+		ok, err := store.SetXX("new-key", "new-value", 0)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+
+		_, err = store.Get("new-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should not set the value if the key has expired", func(t *testing.T) {
+		ok, err := store.SetXX("expired-key", "new-value", 0)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestStringStore_SetModes(t *testing.T) {
+	testCases := map[string]struct {
+		key         string
+		ttl         time.Duration
+		opts        []storage.SetOption
+		expectedErr error
+		expectedVal string
+		expectedTTL time.Duration
+	}{
+		"it should not replace an existing key by default": {
+			key:         "existing-key",
+			expectedErr: storage.ErrAlreadyExists,
+			expectedVal: "existing-value",
+			expectedTTL: time.Minute,
+		},
+		"it should create a key that has expired": {
+			key:         "expired-key",
+			expectedVal: "new-value",
+		},
+		"it should not create a key that doesn't exist with SetIfExists": {
+			key:         "new-key",
+			opts:        []storage.SetOption{storage.WithSetMode(storage.SetIfExists)},
+			expectedErr: storage.ErrNotFound,
+		},
+		"it should replace an existing key and its TTL with SetIfExists": {
+			key:         "existing-key",
+			ttl:         time.Hour,
+			opts:        []storage.SetOption{storage.WithSetMode(storage.SetIfExists)},
+			expectedVal: "new-value",
+			expectedTTL: time.Hour,
+		},
+		"it should create a key with SetAlways": {
+			key:         "new-key",
+			ttl:         time.Hour,
+			opts:        []storage.SetOption{storage.WithSetMode(storage.SetAlways), storage.WithKeepTTL()},
+			expectedVal: "new-value",
+			expectedTTL: time.Hour,
+		},
+		"it should replace an existing key and keep its TTL with SetAlways and WithKeepTTL": {
+			key:         "existing-key",
+			ttl:         time.Hour,
+			opts:        []storage.SetOption{storage.WithSetMode(storage.SetAlways), storage.WithKeepTTL()},
+			expectedVal: "new-value",
+			expectedTTL: time.Minute,
+		},
+		"it should replace an existing key and remove its TTL with SetAlways": {
+			key:         "existing-key",
+			opts:        []storage.SetOption{storage.WithSetMode(storage.SetAlways)},
+			expectedVal: "new-value",
+		},
+		"it should replace an existing key with its current version": {
+			key:         "existing-key",
+			ttl:         time.Hour,
+			opts:        []storage.SetOption{storage.WithSetMode(storage.SetAlways), storage.WithVersion(1)},
+			expectedVal: "new-value",
+			expectedTTL: time.Hour,
+		},
+		"it should not replace an existing key with an outdated version": {
+			key:         "existing-key",
+			opts:        []storage.SetOption{storage.WithSetMode(storage.SetAlways), storage.WithVersion(42)},
+			expectedErr: storage.ErrVersionMismatch,
+			expectedVal: "existing-value",
+			expectedTTL: time.Minute,
+		},
+		"it should not create a key that doesn't exist with a version": {
+			key:         "new-key",
+			opts:        []storage.SetOption{storage.WithSetMode(storage.SetAlways), storage.WithVersion(1)},
+			expectedErr: storage.ErrNotFound,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			store := storage.NewStringStore()
+			assert.Nil(t, store.Set("existing-key", "existing-value", time.Minute))
+			assert.Nil(t, store.Set("expired-key", "expired-value", time.Millisecond))
+			time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+			err := store.Set(tc.key, "new-value", tc.ttl, tc.opts...)
+			assert.Equal(t, tc.expectedErr, err)
+
+			val, err := store.Get(tc.key)
+			if tc.expectedVal == "" {
+				assert.Equal(t, storage.ErrNotFound, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedVal, val.Value)
+			if tc.expectedTTL > 0 {
+				assert.WithinDuration(t, time.Now().Add(tc.expectedTTL), val.ExpiresAt, time.Second)
+			} else {
+				assert.True(t, val.ExpiresAt.IsZero())
+			}
+		})
+	}
+}
+
+func TestStringStore_CompareAndSwap(t *testing.T) {
+	store := storage.NewStringStore()
+
+	// Populate existing values
+	err := store.Set("existing-key", "existing-value", time.Minute)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		key            string
+		old            string
+		new            string
+		expectedOK     bool
+		expectedVal    string
+		expectedErr    error
+		expectedGetErr error
+	}{
+		"it should return an error if key not found": {
+			key:            "new-key",
+			old:            "",
+			new:            "new-value",
+			expectedErr:    storage.ErrNotFound,
+			expectedGetErr: storage.ErrNotFound,
+		},
+		"it should not swap if the value is different": {
+			key:         "existing-key",
+			old:         "other-value",
+			new:         "new-value",
+			expectedVal: "existing-value",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ok, err := store.CompareAndSwap(tc.key, tc.old, tc.new)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedOK, ok)
+
+			val, err := store.Get(tc.key)
+			assert.Equal(t, tc.expectedGetErr, err)
+			if val != nil {
+				assert.Equal(t, tc.expectedVal, val.Value)
+			}
+		})
+	}
+
+	t.Run("it should swap the value and keep the TTL", func(t *testing.T) {
+		ok, err := store.CompareAndSwap("existing-key", "existing-value", "new-value")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		val, err := store.Get("existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, "new-value", val.Value)
+		assert.False(t, val.ExpiresAt.IsZero())
+	})
+}
+
+func TestStringStore_UpdateIfVersion(t *testing.T) {
+	store := storage.NewStringStore()
+
+	// Populate existing values
+	err := store.Set("existing-key", "existing-value", time.Minute)
+	assert.Nil(t, err)
+
+	current, err := store.Get("existing-key")
+	assert.Nil(t, err)
+	assert.NotZero(t, current.Version)
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		err := store.UpdateIfVersion("new-key", "new-value", current.Version)
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should return an error if the version doesn't match", func(t *testing.T) {
+		err := store.UpdateIfVersion("existing-key", "new-value", current.Version+1)
+		assert.Equal(t, storage.ErrVersionMismatch, err)
+	})
+
+	t.Run("it should update the value and give it a new version", func(t *testing.T) {
+		err := store.UpdateIfVersion("existing-key", "new-value", current.Version)
+		assert.Nil(t, err)
+
+		val, err := store.Get("existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, "new-value", val.Value)
+		assert.Greater(t, val.Version, current.Version)
+		assert.False(t, val.ExpiresAt.IsZero())
+
+		err = store.UpdateIfVersion("existing-key", "other-value", current.Version)
+		assert.Equal(t, storage.ErrVersionMismatch, err)
+	})
+}
+
+func TestStringStore_Version(t *testing.T) {
+	store := storage.NewStringStore()
+
+	err := store.Set("key", "value", 0)
+	assert.Nil(t, err)
+	first, err := store.Get("key")
+	assert.Nil(t, err)
+
+	t.Run("it should increase the version on every write", func(t *testing.T) {
+		_, err := store.Incr("other-key")
+		assert.Nil(t, err)
+		err = store.Update("key", "1")
+		assert.Nil(t, err)
+		updated, err := store.Get("key")
+		assert.Nil(t, err)
+		assert.Greater(t, updated.Version, first.Version)
+
+		_, err = store.Incr("key")
+		assert.Nil(t, err)
+		incremented, err := store.Get("key")
+		assert.Nil(t, err)
+		assert.Greater(t, incremented.Version, updated.Version)
+	})
+
+	t.Run("it should not reuse versions if the key is set again", func(t *testing.T) {
+		before, err := store.Get("key")
+		assert.Nil(t, err)
+
+		err = store.Remove("key")
+		assert.Nil(t, err)
+		err = store.Set("key", "value", 0)
+		assert.Nil(t, err)
+
+		after, err := store.Get("key")
+		assert.Nil(t, err)
+		assert.Greater(t, after.Version, before.Version)
+	})
+
+	t.Run("it should keep the versions on restore", func(t *testing.T) {
+		before, err := store.Get("key")
+		assert.Nil(t, err)
+
+		newStore := storage.NewStringStore()
+		newStore.Restore(store.Snapshot())
+
+		restored, err := newStore.Get("key")
+		assert.Nil(t, err)
+		assert.Equal(t, before.Version, restored.Version)
+
+		err = newStore.Set("new-key", "value", 0)
+		assert.Nil(t, err)
+		created, err := newStore.Get("new-key")
+		assert.Nil(t, err)
+		assert.Greater(t, created.Version, restored.Version)
+	})
+}
+
+func TestStringStore_Flags(t *testing.T) {
+	store := storage.NewStringStore()
+
+	err := store.Set("key", "1", 0, storage.WithFlags(7))
+	assert.Nil(t, err)
+
+	t.Run("it should keep the flags on updates and increments", func(t *testing.T) {
+		err := store.Update("key", "2")
+		assert.Nil(t, err)
+		_, err = store.Incr("key")
+		assert.Nil(t, err)
+		v, err := store.Get("key")
+		assert.Nil(t, err)
+		err = store.UpdateIfVersion("key", "4", v.Version)
+		assert.Nil(t, err)
+
+		v, err = store.Get("key")
+		assert.Nil(t, err)
+		assert.Equal(t, "4", v.Value)
+		assert.Equal(t, uint32(7), v.Flags)
+	})
+
+	t.Run("it should keep the flags on restore", func(t *testing.T) {
+		newStore := storage.NewStringStore()
+		newStore.Restore(store.Snapshot())
+
+		v, err := newStore.Get("key")
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(7), v.Flags)
+	})
+
+	t.Run("it should reset the flags when the key is set again", func(t *testing.T) {
+		err := store.Set("key", "5", 0, storage.WithSetMode(storage.SetAlways))
+		assert.Nil(t, err)
+
+		v, err := store.Get("key")
+		assert.Nil(t, err)
+		assert.Zero(t, v.Flags)
+	})
+}
+
+func TestStringStore_Expire(t *testing.T) {
+	store := storage.NewStringStore()
+
+	t.Run("it should return an error if the key doesn't exist", func(t *testing.T) {
+		assert.Equal(t, storage.ErrNotFound, store.Expire("missing", time.Minute))
+		assert.Equal(t, storage.ErrNotFound, store.ExpireAt("missing", time.Now().Add(time.Minute)))
+		assert.Equal(t, storage.ErrNotFound, store.Persist("missing"))
+		_, err := store.TTL("missing")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should set, extend and remove the TTL", func(t *testing.T) {
+		assert.NoError(t, store.Set("session", "value", 0))
+		ttl, err := store.TTL("session")
+		assert.NoError(t, err)
+		assert.Equal(t, storage.NoTTL, ttl)
+
+		before, _ := store.Get("session")
+		assert.NoError(t, store.Expire("session", time.Minute))
+		ttl, err = store.TTL("session")
+		assert.NoError(t, err)
+		assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+		after, _ := store.Get("session")
+		assert.Greater(t, after.Version, before.Version)
+
+		at := time.Now().Add(time.Hour)
+		assert.NoError(t, store.ExpireAt("session", at))
+		v, err := store.Get("session")
+		assert.NoError(t, err)
+		assert.True(t, at.Equal(v.ExpiresAt))
+
+		assert.NoError(t, store.Persist("session"))
+		v, err = store.Get("session")
+		assert.NoError(t, err)
+		assert.True(t, v.ExpiresAt.IsZero())
+	})
+
+	t.Run("it should keep the TTL on update", func(t *testing.T) {
+		assert.NoError(t, store.Set("kept", "value", 0))
+		assert.NoError(t, store.Expire("kept", time.Minute))
+		assert.NoError(t, store.Update("kept", "new-value"))
+		ttl, err := store.TTL("kept")
+		assert.NoError(t, err)
+		assert.Greater(t, ttl, time.Duration(0))
+	})
+
+	t.Run("it should remove the key with a TTL that is not positive or a time in the past", func(t *testing.T) {
+		assert.NoError(t, store.Set("gone", "value", 0))
+		assert.NoError(t, store.Expire("gone", 0))
+		_, err := store.Get("gone")
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		assert.NoError(t, store.Set("gone", "value", 0))
+		assert.NoError(t, store.ExpireAt("gone", time.Now().Add(-time.Second)))
+		_, err = store.Get("gone")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestStringStore_ExpireSliding(t *testing.T) {
+	store := storage.NewStringStore()
+	assert.NoError(t, store.Set("session", "value", 0))
+
+	assert.Equal(t, storage.ErrInvalidTTL, store.ExpireSliding("session", 0))
+	assert.NoError(t, store.ExpireSliding("session", 50*time.Millisecond))
+
+	// Every read extends the expiration, so the key outlives its TTL while it's read.
+	for range 5 {
+		time.Sleep(20 * time.Millisecond)
+		v, err := store.Get("session")
+		assert.NoError(t, err)
+		assert.Equal(t, 50*time.Millisecond, v.Sliding)
+	}
+
+	// TTL doesn't extend the expiration.
+	time.Sleep(30 * time.Millisecond)
+	ttl, err := store.TTL("session")
+	assert.NoError(t, err)
+	assert.Less(t, ttl, 30*time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = store.Get("session")
+	assert.Equal(t, storage.ErrExpired, err)
+
+	t.Run("it should remove the sliding expiration with Expire and Persist", func(t *testing.T) {
+		assert.NoError(t, store.Set("other", "value", 0))
+		assert.NoError(t, store.ExpireSliding("other", time.Minute))
+		assert.NoError(t, store.Expire("other", time.Hour))
+		v, err := store.Get("other")
+		assert.NoError(t, err)
+		assert.Zero(t, v.Sliding)
+
+		assert.NoError(t, store.ExpireSliding("other", time.Minute))
+		assert.NoError(t, store.Persist("other"))
+		v, err = store.Get("other")
+		assert.NoError(t, err)
+		assert.Zero(t, v.Sliding)
+		assert.True(t, v.ExpiresAt.IsZero())
+	})
+
+	t.Run("it should restart the sliding expiration on restore", func(t *testing.T) {
+		restored := storage.NewStringStore()
+		restored.Restore(map[string]storage.Value[string]{
+			"session": {Value: "value", ExpiresAt: time.Now().Add(-time.Second), Sliding: time.Minute},
+		})
+		ttl, err := restored.TTL("session")
+		assert.NoError(t, err)
+		assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+	})
+}
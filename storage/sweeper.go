@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// sweepMaxIterations bounds the work done on a single sweep cycle so the
+	// store lock is released regularly.
+	sweepMaxIterations = 16
+	// sweepVisitFactor bounds how many keys are visited per iteration, relative
+	// to the sample size, when most keys don't have a TTL.
+	sweepVisitFactor = 10
+)
+
+// SweepStats holds the counters of the background sweeper.
+type SweepStats struct {
+	// Runs is the number of sweep cycles executed.
+	Runs uint64
+	// Sampled is the number of keys with a TTL that were checked.
+	Sampled uint64
+	// Reclaimed is the number of expired keys removed.
+	Reclaimed uint64
+}
+
+// sweepable is implemented by the stores that can be actively swept.
+type sweepable interface {
+	// sweep checks up to sampleSize keys with a TTL, removes the expired ones
+	// and returns how many were checked and removed.
+	sweep(sampleSize int) (sampled, expired int)
+}
+
+// sweeper periodically removes expired keys using Redis-style probabilistic sampling:
+// on each cycle it samples keys with a TTL and removes the expired ones, repeating
+// while more than 25% of the sample was expired.
+type sweeper struct {
+	target     sweepable
+	interval   time.Duration
+	sampleSize int
+
+	runs      atomic.Uint64
+	sampled   atomic.Uint64
+	reclaimed atomic.Uint64
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// startSweeper starts a sweeper for the given store if enabled in the options.
+// It returns nil when the sweeper is disabled.
+func startSweeper(target sweepable, o options) *sweeper {
+	if o.sweepInterval <= 0 {
+		return nil
+	}
+
+	s := &sweeper{
+		target:     target,
+		interval:   o.sweepInterval,
+		sampleSize: o.sweepSampleSize,
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+	go s.run()
+
+	return s
+}
+
+func (s *sweeper) run() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.cycle()
+		}
+	}
+}
+
+func (s *sweeper) cycle() {
+	s.runs.Add(1)
+	for i := 0; i < sweepMaxIterations; i++ {
+		sampled, expired := s.target.sweep(s.sampleSize)
+		s.sampled.Add(uint64(sampled))
+		s.reclaimed.Add(uint64(expired))
+
+		// Stop when the sample shows that few keys are expired.
+		if sampled == 0 || expired*4 <= sampled {
+			return
+		}
+	}
+}
+
+// stop stops the sweeper and waits for the running cycle to finish.
+// It's safe to call it several times and on a nil sweeper.
+func (s *sweeper) stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	<-s.doneCh
+}
+
+// stats returns the current counters. It's safe to call it on a nil sweeper.
+func (s *sweeper) stats() SweepStats {
+	if s == nil {
+		return SweepStats{}
+	}
+	return SweepStats{
+		Runs:      s.runs.Load(),
+		Sampled:   s.sampled.Load(),
+		Reclaimed: s.reclaimed.Load(),
+	}
+}
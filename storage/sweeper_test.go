@@ -0,0 +1,89 @@
+package storage_test
+
+import (
+	"in-memory-storage/storage"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringStore_Sweeper(t *testing.T) {
+	t.Run("it should remove expired keys in the background", func(t *testing.T) {
+		store := storage.NewStringStore(storage.WithSweeper(time.Millisecond), storage.WithSweepSampleSize(5))
+		defer store.Close()
+
+		const n = 50
+		for i := 0; i < n; i++ {
+			err := store.Set("expiring-key-"+strconv.Itoa(i), "value", time.Millisecond)
+			assert.Nil(t, err)
+		}
+		err := store.Set("persistent-key", "value", 0)
+		assert.Nil(t, err)
+		err = store.Set("ttl-valid-key", "value", time.Minute)
+		assert.Nil(t, err)
+
+		assert.Eventually(t, func() bool {
+			return store.SweepStats().Reclaimed == n
+		}, time.Second, time.Millisecond)
+
+		// Expired keys have been removed, not just expired.
+		_, err = store.Get("expiring-key-0")
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		// Keys that have not expired are kept.
+		_, err = store.Get("persistent-key")
+		assert.Nil(t, err)
+		_, err = store.Get("ttl-valid-key")
+		assert.Nil(t, err)
+
+		stats := store.SweepStats()
+		assert.NotZero(t, stats.Runs)
+		assert.GreaterOrEqual(t, stats.Sampled, stats.Reclaimed)
+	})
+
+	t.Run("it should not sweep if the sweeper is disabled", func(t *testing.T) {
+		store := storage.NewStringStore()
+		defer store.Close()
+
+		err := store.Set("expired-key", "value", time.Millisecond)
+		assert.Nil(t, err)
+		time.Sleep(5 * time.Millisecond)
+
+		assert.Equal(t, storage.SweepStats{}, store.SweepStats())
+		_, err = store.Get("expired-key")
+		assert.Equal(t, storage.ErrExpired, err)
+	})
+
+	t.Run("it should stop the sweeper on close", func(t *testing.T) {
+		store := storage.NewStringStore(storage.WithSweeper(time.Millisecond))
+
+		assert.Nil(t, store.Close())
+		// Closing twice is a no-op.
+		assert.Nil(t, store.Close())
+
+		runs := store.SweepStats().Runs
+		time.Sleep(5 * time.Millisecond)
+		assert.Equal(t, runs, store.SweepStats().Runs)
+	})
+}
+
+func TestListStore_Sweeper(t *testing.T) {
+	store := storage.NewListStore[string](storage.WithSweeper(time.Millisecond))
+	defer store.Close()
+
+	err := store.Set("expiring-key", []string{"a"}, time.Millisecond)
+	assert.Nil(t, err)
+	err = store.Set("persistent-key", []string{"b"}, 0)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		return store.SweepStats().Reclaimed == 1
+	}, time.Second, time.Millisecond)
+
+	_, err = store.Get("expiring-key")
+	assert.Equal(t, storage.ErrNotFound, err)
+	_, err = store.Get("persistent-key")
+	assert.Nil(t, err)
+}
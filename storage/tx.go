@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// txKind is the store a key of a transaction belongs to.
+type txKind int
+
+const (
+	txString txKind = iota
+	txList
+)
+
+// Tx is a batch of operations over the keys of a StringStore and a ListStore of the same Transactor.
+// Its methods only queue the operations, which are applied in order by Transactor.Exec.
+type Tx[T any] struct {
+	ops     []txOp[T]
+	watches []txWatch
+}
+
+type txOp[T any] struct {
+	kind  txKind
+	key   string
+	write bool
+	apply func(ss *stringStore, ls *listStore[T]) (any, error)
+}
+
+type txWatch struct {
+	kind    txKind
+	key     string
+	version uint64
+}
+
+// NewTx creates an empty transaction over strings and lists of the given type.
+func NewTx[T any]() *Tx[T] {
+	return &Tx[T]{}
+}
+
+// WatchString will abort the transaction with ErrTxAborted if the version of the string key,
+// as returned by Get, is no longer the given one when the transaction is executed.
+// Use version 0 to watch a key that doesn't exist.
+func (tx *Tx[T]) WatchString(key string, version uint64) {
+	tx.watches = append(tx.watches, txWatch{kind: txString, key: key, version: version})
+}
+
+// WatchList is the same as WatchString for a list key.
+func (tx *Tx[T]) WatchList(key string, version uint64) {
+	tx.watches = append(tx.watches, txWatch{kind: txList, key: key, version: version})
+}
+
+// GetString will queue a Get of the string key.
+// Its result is a *Value[string], or nil if the key doesn't exist or has expired.
+func (tx *Tx[T]) GetString(key string) {
+	tx.read(txString, key, func(ss *stringStore, _ *listStore[T]) (any, error) {
+		v, err := ss.getLocked(key)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrExpired) {
+			return (*Value[string])(nil), nil
+		}
+		return v, err
+	})
+}
+
+// SetString will queue a Set of the string key.
+func (tx *Tx[T]) SetString(key, val string, ttl time.Duration) {
+	tx.write(txString, key, func(ss *stringStore, _ *listStore[T]) (any, error) {
+		return nil, ss.setLocked(key, val, ttl, setOptions{})
+	})
+}
+
+// UpdateString will queue an Update of the string key.
+func (tx *Tx[T]) UpdateString(key, val string) {
+	tx.write(txString, key, func(ss *stringStore, _ *listStore[T]) (any, error) {
+		return nil, ss.updateLocked(key, val)
+	})
+}
+
+// RemoveString will queue a Remove of the string key.
+func (tx *Tx[T]) RemoveString(key string) {
+	tx.write(txString, key, func(ss *stringStore, _ *listStore[T]) (any, error) {
+		return nil, ss.removeLocked(key)
+	})
+}
+
+// IncrBy will queue an IncrBy of the string key. Its result is the new value as an int64.
+func (tx *Tx[T]) IncrBy(key string, delta int64) {
+	tx.write(txString, key, func(ss *stringStore, _ *listStore[T]) (any, error) {
+		return ss.incrByLocked(key, delta)
+	})
+}
+
+// GetList will queue a Get of the list key.
+// Its result is a *Value[[]T], or nil if the key doesn't exist or has expired.
+func (tx *Tx[T]) GetList(key string) {
+	tx.read(txList, key, func(_ *stringStore, ls *listStore[T]) (any, error) {
+		v, err := ls.getLocked(key)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrExpired) {
+			return (*Value[[]T])(nil), nil
+		}
+		return v, err
+	})
+}
+
+// SetList will queue a Set of the list key.
+func (tx *Tx[T]) SetList(key string, list []T, ttl time.Duration) {
+	tx.write(txList, key, func(_ *stringStore, ls *listStore[T]) (any, error) {
+		return nil, ls.setLocked(key, list, ttl, setOptions{})
+	})
+}
+
+// UpdateList will queue an Update of the list key.
+func (tx *Tx[T]) UpdateList(key string, list []T) {
+	tx.write(txList, key, func(_ *stringStore, ls *listStore[T]) (any, error) {
+		return nil, ls.updateLocked(key, list)
+	})
+}
+
+// RemoveList will queue a Remove of the list key.
+func (tx *Tx[T]) RemoveList(key string) {
+	tx.write(txList, key, func(_ *stringStore, ls *listStore[T]) (any, error) {
+		return nil, ls.removeLocked(key)
+	})
+}
+
+// Push will queue a Push to the list key.
+func (tx *Tx[T]) Push(key string, val T) {
+	tx.write(txList, key, func(_ *stringStore, ls *listStore[T]) (any, error) {
+		_, err := ls.pushLocked(key, false, val)
+		return nil, err
+	})
+}
+
+// Pop will queue a Pop from the list key. Its result is the popped value of type T.
+func (tx *Tx[T]) Pop(key string) {
+	tx.write(txList, key, func(_ *stringStore, ls *listStore[T]) (any, error) {
+		return ls.popLocked(key, true)
+	})
+}
+
+// Writes returns the string and list keys written by the transaction, in order and without duplicates.
+func (tx *Tx[T]) Writes() (strings, lists []string) {
+	for _, op := range tx.ops {
+		if !op.write {
+			continue
+		}
+		switch op.kind {
+		case txString:
+			if !slices.Contains(strings, op.key) {
+				strings = append(strings, op.key)
+			}
+		case txList:
+			if !slices.Contains(lists, op.key) {
+				lists = append(lists, op.key)
+			}
+		}
+	}
+	return strings, lists
+}
+
+func (tx *Tx[T]) read(kind txKind, key string, apply func(*stringStore, *listStore[T]) (any, error)) {
+	tx.ops = append(tx.ops, txOp[T]{kind: kind, key: key, apply: apply})
+}
+
+func (tx *Tx[T]) write(kind txKind, key string, apply func(*stringStore, *listStore[T]) (any, error)) {
+	tx.ops = append(tx.ops, txOp[T]{kind: kind, key: key, write: true, apply: apply})
+}
+
+// TxError is returned when an operation of a transaction fails.
+// None of the operations of the transaction are applied.
+type TxError struct {
+	// Index is the position of the failed operation in the transaction.
+	Index int
+	Err   error
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("operation %d failed: %v", e.Index, e.Err)
+}
+
+func (e *TxError) Unwrap() error {
+	return e.Err
+}
+
+// Transactor applies transactions atomically over a StringStore and a ListStore.
+type Transactor[T any] interface {
+	// Exec applies the operations of the transaction in order and returns their results,
+	// with a nil result for the operations without one. Either all the operations are applied or none:
+	// it returns ErrTxAborted if a watched key has changed and a *TxError if an operation fails.
+	Exec(tx *Tx[T]) ([]any, error)
+}
+
+type transactor[T any] struct {
+	strings *stringStore
+	lists   *listStore[T]
+}
+
+// NewTransactor creates a Transactor over the given stores, which hold their locks while
+// a transaction is applied. The stores must be the ones returned by NewStringStore and NewListStore,
+// otherwise it returns ErrNotTransactional.
+func NewTransactor[T any](strings StringStore, lists ListStore[T]) (Transactor[T], error) {
+	ss, ok := strings.(*stringStore)
+	if !ok {
+		return nil, ErrNotTransactional
+	}
+	ls, ok := lists.(*listStore[T])
+	if !ok {
+		return nil, ErrNotTransactional
+	}
+	return &transactor[T]{strings: ss, lists: ls}, nil
+}
+
+func (t *transactor[T]) Exec(tx *Tx[T]) ([]any, error) {
+	// The stores are always locked in the same order so transactions can't deadlock.
+	// Stores of the same keyspace share their lock, which is only taken once.
+	t.strings.mu.Lock()
+	defer t.strings.mu.Unlock()
+	if t.lists.mu != t.strings.mu {
+		t.lists.mu.Lock()
+		defer t.lists.mu.Unlock()
+	}
+
+	for _, w := range tx.watches {
+		if t.version(w.kind, w.key) != w.version {
+			return nil, ErrTxAborted
+		}
+	}
+
+	undo := newTxUndo(t.strings.store, t.lists.store)
+	// The events of the operations are only emitted if all of them are applied.
+	release := holdEvents(&t.strings.events, &t.lists.events)
+	results := make([]any, 0, len(tx.ops))
+	for i, op := range tx.ops {
+		if op.write {
+			undo.save(op.kind, op.key)
+		}
+		res, err := op.apply(t.strings, t.lists)
+		if err != nil {
+			undo.rollback()
+			release(false)
+			return nil, &TxError{Index: i, Err: err}
+		}
+		results = append(results, res)
+	}
+
+	release(true)
+	return results, nil
+}
+
+// version returns the current version of the key. The caller must hold the locks.
+func (t *transactor[T]) version(kind txKind, key string) uint64 {
+	if kind == txString {
+		reapExpired(t.strings.store, key, &t.strings.events)
+		return versionOf(t.strings.store, key)
+	}
+	reapExpired(t.lists.store, key, &t.lists.events)
+	return versionOf(t.lists.store, key)
+}
+
+// txUndo keeps the values of the keys written by a transaction before their first write,
+// so they can be put back if the transaction fails.
+type txUndo[T any] struct {
+	strings     map[string]Value[string]
+	lists       map[string]Value[*deque[T]]
+	savedString map[string]*Value[string]
+	savedList   map[string]*Value[*deque[T]]
+}
+
+func newTxUndo[T any](strings map[string]Value[string], lists map[string]Value[*deque[T]]) *txUndo[T] {
+	return &txUndo[T]{
+		strings:     strings,
+		lists:       lists,
+		savedString: map[string]*Value[string]{},
+		savedList:   map[string]*Value[*deque[T]]{},
+	}
+}
+
+// save keeps the current value of the key, if it has not been saved yet.
+// A nil value is saved for keys that don't exist.
+func (u *txUndo[T]) save(kind txKind, key string) {
+	switch kind {
+	case txString:
+		if _, ok := u.savedString[key]; !ok {
+			u.savedString[key] = savedValue(u.strings, key)
+		}
+	case txList:
+		if _, ok := u.savedList[key]; !ok {
+			// Lists are changed in place, so the saved one has to be a copy.
+			v := savedValue(u.lists, key)
+			if v != nil {
+				v.Value = v.Value.clone()
+			}
+			u.savedList[key] = v
+		}
+	}
+}
+
+// rollback puts back the saved values, removing the keys that didn't exist.
+func (u *txUndo[T]) rollback() {
+	restoreSaved(u.strings, u.savedString)
+	restoreSaved(u.lists, u.savedList)
+}
+
+func savedValue[V any](store map[string]Value[V], key string) *Value[V] {
+	v, ok := store[key]
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+func restoreSaved[V any](store map[string]Value[V], saved map[string]*Value[V]) {
+	for key, v := range saved {
+		if v == nil {
+			delete(store, key)
+			continue
+		}
+		store[key] = *v
+	}
+}
@@ -0,0 +1,236 @@
+package storage_test
+
+import (
+	"errors"
+	"in-memory-storage/storage"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTransactor(t *testing.T) (storage.StringStore, storage.ListStore[string], storage.Transactor[string]) {
+	t.Helper()
+	stringStore := storage.NewStringStore()
+	listStore := storage.NewListStore[string]()
+	transactor, err := storage.NewTransactor(stringStore, listStore)
+	assert.NoError(t, err)
+	return stringStore, listStore, transactor
+}
+
+func TestNewTransactor(t *testing.T) {
+	t.Run("it should return an error if the stores are not transactional", func(t *testing.T) {
+		_, err := storage.NewTransactor[string](struct{ storage.StringStore }{storage.NewStringStore()}, storage.NewListStore[string]())
+		assert.Equal(t, storage.ErrNotTransactional, err)
+	})
+}
+
+func TestTransactor_Exec(t *testing.T) {
+	stringStore, listStore, transactor := newTransactor(t)
+
+	// Populate existing values
+	err := stringStore.Set("existing-key", "existing-value", time.Minute)
+	assert.Nil(t, err)
+	err = listStore.Set("queue", []string{"job1", "job2"}, 0)
+	assert.Nil(t, err)
+
+	t.Run("it should apply the operations in order and return their results", func(t *testing.T) {
+		tx := storage.NewTx[string]()
+		tx.Pop("queue")
+		tx.SetString("current-job", "job1", 0)
+		tx.IncrBy("processed", 1)
+		tx.GetString("current-job")
+		tx.GetString("missing-key")
+		tx.GetList("queue")
+
+		results, err := transactor.Exec(tx)
+		assert.Nil(t, err)
+		assert.Len(t, results, 6)
+		assert.Equal(t, "job1", results[0])
+		assert.Nil(t, results[1])
+		assert.Equal(t, int64(1), results[2])
+		assert.Equal(t, "job1", results[3].(*storage.Value[string]).Value)
+		assert.Nil(t, results[4].(*storage.Value[string]))
+		assert.Equal(t, []string{"job2"}, results[5].(*storage.Value[[]string]).Value)
+
+		val, err := stringStore.Get("current-job")
+		assert.Nil(t, err)
+		assert.Equal(t, "job1", val.Value)
+	})
+
+	t.Run("it should not apply any operation if one fails", func(t *testing.T) {
+		before, err := stringStore.Get("existing-key")
+		assert.Nil(t, err)
+
+		tx := storage.NewTx[string]()
+		tx.UpdateString("existing-key", "new-value")
+		tx.SetString("new-key", "new-value", 0)
+		tx.Push("queue", "job3")
+		tx.RemoveList("queue")
+		tx.Pop("missing-queue")
+
+		_, err = transactor.Exec(tx)
+		var txErr *storage.TxError
+		assert.True(t, errors.As(err, &txErr))
+		assert.Equal(t, 4, txErr.Index)
+		assert.True(t, errors.Is(err, storage.ErrNotFound))
+
+		val, err := stringStore.Get("existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, *before, *val)
+
+		_, err = stringStore.Get("new-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+
+		list, err := listStore.Get("queue")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"job2"}, list.Value)
+	})
+}
+
+func TestTransactor_Watch(t *testing.T) {
+	stringStore, listStore, transactor := newTransactor(t)
+
+	// Populate existing values
+	err := stringStore.Set("balance", "100", 0)
+	assert.Nil(t, err)
+	err = listStore.Set("queue", []string{"job1"}, 0)
+	assert.Nil(t, err)
+
+	balance, err := stringStore.Get("balance")
+	assert.Nil(t, err)
+	queue, err := listStore.Get("queue")
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		setup       func()
+		watch       func(tx *storage.Tx[string])
+		expectedErr error
+	}{
+		"it should apply the transaction if the watched keys have not changed": {
+			watch: func(tx *storage.Tx[string]) {
+				tx.WatchString("balance", balance.Version)
+				tx.WatchList("queue", queue.Version)
+				tx.WatchString("missing-key", 0)
+			},
+		},
+		"it should abort the transaction if a watched string has changed": {
+			setup: func() {
+				err := stringStore.Update("balance", "50")
+				assert.Nil(t, err)
+			},
+			watch: func(tx *storage.Tx[string]) {
+				tx.WatchString("balance", balance.Version)
+			},
+			expectedErr: storage.ErrTxAborted,
+		},
+		"it should abort the transaction if a watched list has changed": {
+			setup: func() {
+				err := listStore.Push("queue", "job2")
+				assert.Nil(t, err)
+			},
+			watch: func(tx *storage.Tx[string]) {
+				tx.WatchList("queue", queue.Version)
+			},
+			expectedErr: storage.ErrTxAborted,
+		},
+		"it should abort the transaction if a missing watched key has been created": {
+			setup: func() {
+				err := stringStore.Set("created-key", "value", 0)
+				assert.Nil(t, err)
+			},
+			watch: func(tx *storage.Tx[string]) {
+				tx.WatchString("created-key", 0)
+			},
+			expectedErr: storage.ErrTxAborted,
+		},
+	}
+
+	// Run sequentially as the setups change the watched keys
+	for _, name := range []string{
+		"it should apply the transaction if the watched keys have not changed",
+		"it should abort the transaction if a watched string has changed",
+		"it should abort the transaction if a watched list has changed",
+		"it should abort the transaction if a missing watched key has been created",
+	} {
+		tc := testCases[name]
+		t.Run(name, func(t *testing.T) {
+			if tc.setup != nil {
+				tc.setup()
+			}
+
+			tx := storage.NewTx[string]()
+			tc.watch(tx)
+			tx.SetString(name, "applied", 0)
+
+			_, err := transactor.Exec(tx)
+			assert.Equal(t, tc.expectedErr, err)
+
+			_, err = stringStore.Get(name)
+			if tc.expectedErr != nil {
+				assert.Equal(t, storage.ErrNotFound, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestTransactor_ConcurrentExec(t *testing.T) {
+	stringStore, _, transactor := newTransactor(t)
+	const n = 100
+
+	// Populate existing values
+	err := stringStore.Set("from", strconv.Itoa(n), 0)
+	assert.Nil(t, err)
+	err = stringStore.Set("to", "0", 0)
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx := storage.NewTx[string]()
+			tx.IncrBy("from", -1)
+			tx.IncrBy("to", 1)
+			_, err := transactor.Exec(tx)
+			assert.Nil(t, err)
+		}()
+	}
+
+	// Both keys are always read in the same transaction, so they always add up
+	for i := 0; i < n; i++ {
+		tx := storage.NewTx[string]()
+		tx.GetString("from")
+		tx.GetString("to")
+		results, err := transactor.Exec(tx)
+		assert.Nil(t, err)
+
+		from, _ := strconv.Atoi(results[0].(*storage.Value[string]).Value)
+		to, _ := strconv.Atoi(results[1].(*storage.Value[string]).Value)
+		assert.Equal(t, n, from+to)
+	}
+	wg.Wait()
+
+	val, err := stringStore.Get("to")
+	assert.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(n), val.Value)
+}
+
+func TestTx_Writes(t *testing.T) {
+	tx := storage.NewTx[string]()
+	tx.GetString("read-key")
+	tx.SetString("a", "1", 0)
+	tx.IncrBy("b", 1)
+	tx.IncrBy("a", 1)
+	tx.Push("list", "val")
+	tx.GetList("read-list")
+	tx.Pop("list")
+
+	strings, lists := tx.Writes()
+	assert.Equal(t, []string{"a", "b"}, strings)
+	assert.Equal(t, []string{"list"}, lists)
+}
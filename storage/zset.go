@@ -0,0 +1,530 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ScoredMember is a member of a sorted set together with its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// sortedSet indexes the members by name, to look up their score,
+// and by score, to answer rank and range queries.
+type sortedSet struct {
+	scores map[string]float64
+	list   *skipList
+}
+
+func newSortedSet(members []ScoredMember) *sortedSet {
+	zs := &sortedSet{scores: map[string]float64{}, list: newSkipList()}
+	for _, m := range members {
+		zs.add(m.Member, m.Score)
+	}
+	return zs
+}
+
+// add sets the score of the member and returns true if the member is new.
+func (zs *sortedSet) add(member string, score float64) bool {
+	current, ok := zs.scores[member]
+	if ok {
+		if current == score {
+			return false
+		}
+		zs.list.delete(current, member)
+	}
+	zs.list.insert(score, member)
+	zs.scores[member] = score
+	return !ok
+}
+
+// remove deletes the member and returns false if it was not in the set.
+func (zs *sortedSet) remove(member string) bool {
+	score, ok := zs.scores[member]
+	if !ok {
+		return false
+	}
+	zs.list.delete(score, member)
+	delete(zs.scores, member)
+	return true
+}
+
+// rangeByRank returns the members between the start and stop positions, both inclusive.
+// Negative positions are counted from the end of the set.
+func (zs *sortedSet) rangeByRank(start, stop int, reverse bool) []ScoredMember {
+	start, stop, ok := normalizeRange(start, stop, zs.list.length)
+	if !ok {
+		return []ScoredMember{}
+	}
+
+	members := make([]ScoredMember, 0, stop-start+1)
+	if reverse {
+		x := zs.list.byRank(zs.list.length - 1 - start)
+		for i := start; i <= stop; i++ {
+			members = append(members, ScoredMember{Member: x.member, Score: x.score})
+			x = x.backward
+		}
+		return members
+	}
+
+	x := zs.list.byRank(start)
+	for i := start; i <= stop; i++ {
+		members = append(members, ScoredMember{Member: x.member, Score: x.score})
+		x = x.levels[0].forward
+	}
+	return members
+}
+
+// rangeByScore returns the members with a score between min and max, both inclusive,
+// skipping the first offset members and returning at most count of them.
+// A negative count returns all the members in the range.
+func (zs *sortedSet) rangeByScore(min, max float64, offset, count int) []ScoredMember {
+	members := []ScoredMember{}
+	x := zs.list.firstInRange(min)
+	for ; x != nil && offset > 0 && x.score <= max; offset-- {
+		x = x.levels[0].forward
+	}
+	for ; x != nil && count != 0 && x.score <= max; count-- {
+		members = append(members, ScoredMember{Member: x.member, Score: x.score})
+		x = x.levels[0].forward
+	}
+	return members
+}
+
+func (zs *sortedSet) all() []ScoredMember {
+	return zs.rangeByRank(0, -1, false)
+}
+
+// normalizeRange converts the start and stop positions, which can be negative,
+// into valid indexes for a collection of the given length.
+// It returns false if the range is empty.
+func normalizeRange(start, stop, length int) (int, int, bool) {
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
+
+type zsetStore struct {
+	store map[string]Value[*sortedSet]
+	// Mutex to handle concurrent access to memory
+	mu *sync.RWMutex
+
+	// keyspace is the keyspace the store has joined, if any
+	keyspace *keyspace
+	sweeper  *sweeper
+}
+
+// NewZSetStore initializes a new sorted set store.
+// Use WithSweeper to actively remove expired sorted sets in the background.
+func NewZSetStore(opts ...Option) ZSetStore {
+	zs := &zsetStore{
+		store: map[string]Value[*sortedSet]{},
+	}
+	o := newOptions(opts)
+	zs.keyspace = o.keyspace
+	zs.mu = o.keyspace.join(zs)
+	zs.sweeper = startSweeper(zs, o)
+	return zs
+}
+
+// Set will store the given members as a sorted set with an optional TTL for the whole key.
+// When a member is repeated, its last score is kept.
+// It will check if the key already exists and return an error.
+func (zs *zsetStore) Set(key string, members []ScoredMember, ttl time.Duration) error {
+	if err := validScores(members); err != nil {
+		return err
+	}
+
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+	if err := zs.keyspace.claim(zs, key); err != nil {
+		return err
+	}
+	return set(zs.store, key, newSortedSet(members), ttl)
+}
+
+// Get will return the members of the sorted set, ordered by score, and its expiration time.
+// It will return an error if the sorted set is not found or if it has expired.
+func (zs *zsetStore) Get(key string) (*Value[[]ScoredMember], error) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := readValid(zs.store, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Value[[]ScoredMember]{Value: v.Value.all(), ExpiresAt: v.ExpiresAt, Sliding: v.Sliding}, nil
+}
+
+// Remove will delete the sorted set linked to the given key.
+// It will return an error if the sorted set is not found.
+func (zs *zsetStore) Remove(key string) error {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+	return remove(zs.store, key)
+}
+
+// ZAdd will add the given members to the sorted set or update their score if they already exist.
+// The sorted set is created if it does not exist.
+// It returns the number of members that were not already in the sorted set.
+func (zs *zsetStore) ZAdd(key string, members ...ScoredMember) (int, error) {
+	if err := validScores(members); err != nil {
+		return 0, err
+	}
+
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := zs.getOrCreate(key)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, m := range members {
+		if v.Value.add(m.Member, m.Score) {
+			added++
+		}
+	}
+
+	return added, nil
+}
+
+// ZIncrBy will increment the score of a member by delta and return the new score.
+// The sorted set and the member are created if they don't exist, starting from 0.
+// It will return an error if the resulting score is not a finite number, so it can still be encoded in JSON.
+func (zs *zsetStore) ZIncrBy(key, member string, delta float64) (float64, error) {
+	if !validScore(delta) {
+		return 0, ErrInvalidScore
+	}
+
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := zs.getOrCreate(key)
+	if err != nil {
+		return 0, err
+	}
+
+	score := v.Value.scores[member] + delta
+	if !validScore(score) {
+		zs.removeIfEmpty(key, v)
+		return 0, ErrInvalidScore
+	}
+	v.Value.add(member, score)
+
+	return score, nil
+}
+
+// ZScore will return the score of a member.
+// It will return an error if the sorted set is not found, has expired or does not contain the member.
+func (zs *zsetStore) ZScore(key, member string) (float64, error) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := readValid(zs.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	score, ok := v.Value.scores[member]
+	if !ok {
+		return 0, ErrMemberNotFound
+	}
+
+	return score, nil
+}
+
+// ZRank will return the 0-based position of a member, ordered from the lowest score.
+// It will return an error if the sorted set is not found, has expired or does not contain the member.
+func (zs *zsetStore) ZRank(key, member string) (int, error) {
+	return zs.rank(key, member, false)
+}
+
+// ZRevRank will return the 0-based position of a member, ordered from the highest score.
+// It will return an error if the sorted set is not found, has expired or does not contain the member.
+func (zs *zsetStore) ZRevRank(key, member string) (int, error) {
+	return zs.rank(key, member, true)
+}
+
+func (zs *zsetStore) rank(key, member string, reverse bool) (int, error) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := readValid(zs.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	score, ok := v.Value.scores[member]
+	if !ok {
+		return 0, ErrMemberNotFound
+	}
+
+	rank := v.Value.list.rank(score, member)
+	if reverse {
+		rank = v.Value.list.length - 1 - rank
+	}
+
+	return rank, nil
+}
+
+// ZRange will return the members between the start and stop positions, both inclusive,
+// ordered from the lowest score. Negative positions are counted from the end.
+// It will return an error if the sorted set is not found or if it has expired.
+func (zs *zsetStore) ZRange(key string, start, stop int) ([]ScoredMember, error) {
+	return zs.rangeByRank(key, start, stop, false)
+}
+
+// ZRevRange will return the members between the start and stop positions, both inclusive,
+// ordered from the highest score. Negative positions are counted from the end.
+// It will return an error if the sorted set is not found or if it has expired.
+func (zs *zsetStore) ZRevRange(key string, start, stop int) ([]ScoredMember, error) {
+	return zs.rangeByRank(key, start, stop, true)
+}
+
+func (zs *zsetStore) rangeByRank(key string, start, stop int, reverse bool) ([]ScoredMember, error) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := readValid(zs.store, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Value.rangeByRank(start, stop, reverse), nil
+}
+
+// ZRangeByScore will return the members with a score between min and max, both inclusive,
+// ordered from the lowest score. The first offset members are skipped and at most count
+// members are returned; a negative count returns all of them.
+// It will return an error if the sorted set is not found or if it has expired.
+func (zs *zsetStore) ZRangeByScore(key string, min, max float64, offset, count int) ([]ScoredMember, error) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := readValid(zs.store, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Value.rangeByScore(min, max, offset, count), nil
+}
+
+// ZRem will remove the given members from the sorted set and return how many were removed.
+// The sorted set is removed once it has no members left.
+// It will return an error if the sorted set is not found or if it has expired.
+func (zs *zsetStore) ZRem(key string, members ...string) (int, error) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := getValid(zs.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, member := range members {
+		if v.Value.remove(member) {
+			removed++
+		}
+	}
+
+	zs.removeIfEmpty(key, v)
+	return removed, nil
+}
+
+// ZRemRangeByRank will remove the members between the start and stop positions, both inclusive,
+// and return how many were removed. Negative positions are counted from the end.
+// It will return an error if the sorted set is not found or if it has expired.
+func (zs *zsetStore) ZRemRangeByRank(key string, start, stop int) (int, error) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := getValid(zs.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	members := v.Value.rangeByRank(start, stop, false)
+	for _, m := range members {
+		v.Value.remove(m.Member)
+	}
+
+	zs.removeIfEmpty(key, v)
+	return len(members), nil
+}
+
+// ZRemRangeByScore will remove the members with a score between min and max, both inclusive,
+// and return how many were removed.
+// It will return an error if the sorted set is not found or if it has expired.
+func (zs *zsetStore) ZRemRangeByScore(key string, min, max float64) (int, error) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := getValid(zs.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	members := v.Value.rangeByScore(min, max, 0, -1)
+	for _, m := range members {
+		v.Value.remove(m.Member)
+	}
+
+	zs.removeIfEmpty(key, v)
+	return len(members), nil
+}
+
+// ZCard will return the number of members of the sorted set.
+// It will return an error if the sorted set is not found or if it has expired.
+func (zs *zsetStore) ZCard(key string) (int, error) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	v, err := readValid(zs.store, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Value.list.length, nil
+}
+
+// Snapshot will return a point-in-time copy of all the sorted sets that have not expired.
+func (zs *zsetStore) Snapshot() map[string]Value[[]ScoredMember] {
+	zs.mu.RLock()
+	defer zs.mu.RUnlock()
+
+	data := map[string]Value[[]ScoredMember]{}
+	for key, v := range snapshot(zs.store) {
+		data[key] = Value[[]ScoredMember]{Value: v.Value.all(), ExpiresAt: v.ExpiresAt, Sliding: v.Sliding}
+	}
+	return data
+}
+
+// Restore will load the given sorted sets into the store, overwriting existing keys.
+// Sorted sets that have already expired are dropped. It returns the number of sorted sets loaded.
+// Keys held by another store of the keyspace are skipped, as a key holds one type of value.
+func (zs *zsetStore) Restore(data map[string]Value[[]ScoredMember]) int {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+
+	sets := make(map[string]Value[*sortedSet], len(data))
+	for key, v := range data {
+		if zs.keyspace.claim(zs, key) != nil {
+			continue
+		}
+		sets[key] = Value[*sortedSet]{Value: newSortedSet(v.Value), ExpiresAt: v.ExpiresAt, Sliding: v.Sliding}
+	}
+	return restore(zs.store, sets)
+}
+
+// SweepStats returns the counters of the background sweeper.
+// All the counters are zero if the sweeper is not enabled.
+func (zs *zsetStore) SweepStats() SweepStats {
+	return zs.sweeper.stats()
+}
+
+// Close stops the background sweeper, if enabled.
+// The store remains usable after closing it.
+func (zs *zsetStore) Close() error {
+	zs.sweeper.stop()
+	return nil
+}
+
+func (zs *zsetStore) sweep(sampleSize int) (sampled, expired int) {
+	zs.mu.Lock()
+	defer zs.mu.Unlock()
+	return sweepExpired(zs.store, sampleSize, nil)
+}
+
+// getOrCreate returns the sorted set for the given key, creating it if it does not exist
+// or has expired. The caller must hold the lock.
+func (zs *zsetStore) getOrCreate(key string) (Value[*sortedSet], error) {
+	v, err := getValid(zs.store, key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrExpired) {
+			return v, err
+		}
+		if err := zs.keyspace.claim(zs, key); err != nil {
+			return v, err
+		}
+		v = Value[*sortedSet]{Value: newSortedSet(nil)}
+		zs.store[key] = v
+	}
+	return v, nil
+}
+
+// removeIfEmpty deletes the sorted set once it has no members left.
+// The caller must hold the lock.
+func (zs *zsetStore) removeIfEmpty(key string, v Value[*sortedSet]) {
+	if v.Value.list.length == 0 {
+		delete(zs.store, key)
+	}
+}
+
+func validScores(members []ScoredMember) error {
+	for _, m := range members {
+		if !validScore(m.Score) {
+			return ErrInvalidScore
+		}
+	}
+	return nil
+}
+
+// validScore reports whether the score is a finite number. The infinite scores are rejected
+// as they can't be encoded in JSON, in the snapshots, the operation log or the responses.
+func validScore(score float64) bool {
+	return !math.IsNaN(score) && !math.IsInf(score, 0)
+}
+
+func (zs *zsetStore) keyType() KeyType {
+	return TypeZSet
+}
+
+func (zs *zsetStore) has(key string) bool {
+	return hasValid(zs.store, key)
+}
+
+func (zs *zsetStore) drop(key string) bool {
+	return dropValid(zs.store, key)
+}
+
+func (zs *zsetStore) move(src, dst string) {
+	moveKey(zs.store, src, dst)
+}
+
+func (zs *zsetStore) count() int {
+	return countValid(zs.store)
+}
+
+func (zs *zsetStore) scan(cursor, match string, count int) []string {
+	return scanKeys(zs.store, cursor, match, count)
+}
+
+func (zs *zsetStore) expire(key string, at time.Time, sliding time.Duration) error {
+	_, err := expireKey(zs.store, key, at, sliding)
+	return err
+}
+
+func (zs *zsetStore) ttl(key string) (time.Duration, error) {
+	return ttlOf(zs.store, key)
+}
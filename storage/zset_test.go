@@ -0,0 +1,578 @@
+package storage_test
+
+import (
+	"in-memory-storage/storage"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZSetStore_Set(t *testing.T) {
+	store := storage.NewZSetStore()
+
+	// Populate existing values
+	err := store.Set("existing-key", []storage.ScoredMember{{Member: "foo", Score: 1}}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		key             string
+		members         []storage.ScoredMember
+		expectedMembers []storage.ScoredMember
+		expectedErr     error
+	}{
+		"it should return an error if key already exists": {
+			key:             "existing-key",
+			members:         []storage.ScoredMember{{Member: "bar", Score: 2}},
+			expectedMembers: []storage.ScoredMember{{Member: "foo", Score: 1}},
+			expectedErr:     storage.ErrAlreadyExists,
+		},
+		"it should return an error if a score is not a number": {
+			key:         "nan-key",
+			members:     []storage.ScoredMember{{Member: "foo", Score: math.NaN()}},
+			expectedErr: storage.ErrInvalidScore,
+		},
+		"it should return an error if a score is infinite": {
+			key:         "inf-key",
+			members:     []storage.ScoredMember{{Member: "foo", Score: math.Inf(1)}},
+			expectedErr: storage.ErrInvalidScore,
+		},
+		"it should order the members by score and then by member": {
+			key: "new-key",
+			members: []storage.ScoredMember{
+				{Member: "c", Score: 2},
+				{Member: "b", Score: 1},
+				{Member: "a", Score: 2},
+				{Member: "d", Score: -1},
+			},
+			expectedMembers: []storage.ScoredMember{
+				{Member: "d", Score: -1},
+				{Member: "b", Score: 1},
+				{Member: "a", Score: 2},
+				{Member: "c", Score: 2},
+			},
+		},
+		"it should keep the last score of a repeated member": {
+			key: "repeated-key",
+			members: []storage.ScoredMember{
+				{Member: "a", Score: 1},
+				{Member: "a", Score: 3},
+			},
+			expectedMembers: []storage.ScoredMember{{Member: "a", Score: 3}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := store.Set(tc.key, tc.members, 0)
+			assert.Equal(t, tc.expectedErr, err)
+
+			val, err := store.Get(tc.key)
+			if tc.expectedMembers == nil {
+				assert.Equal(t, storage.ErrNotFound, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedMembers, val.Value)
+		})
+	}
+}
+
+func TestZSetStore_Get(t *testing.T) {
+	store := storage.NewZSetStore()
+
+	err := store.Set("existing-key", []storage.ScoredMember{{Member: "foo", Score: 1}}, time.Minute)
+	assert.Nil(t, err)
+	err = store.Set("expired-key", []storage.ScoredMember{{Member: "foo", Score: 1}}, time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond) // Ensure the value is expired
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.Get("new-key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should return an error if the key has expired", func(t *testing.T) {
+		_, err := store.Get("expired-key")
+		assert.Equal(t, storage.ErrExpired, err)
+	})
+
+	t.Run("it should return the members and the expiration time", func(t *testing.T) {
+		val, err := store.Get("existing-key")
+		assert.Nil(t, err)
+		assert.Equal(t, []storage.ScoredMember{{Member: "foo", Score: 1}}, val.Value)
+		assert.False(t, val.ExpiresAt.IsZero())
+	})
+}
+
+func TestZSetStore_ZAddAndZIncrBy(t *testing.T) {
+	store := storage.NewZSetStore()
+
+	err := store.Set("existing-key", []storage.ScoredMember{{Member: "foo", Score: 1}}, time.Minute)
+	assert.Nil(t, err)
+
+	t.Run("it should add new members and update existing ones", func(t *testing.T) {
+		added, err := store.ZAdd("existing-key",
+			storage.ScoredMember{Member: "foo", Score: 5},
+			storage.ScoredMember{Member: "bar", Score: 2},
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, added)
+
+		members, err := store.ZRange("existing-key", 0, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, []storage.ScoredMember{{Member: "bar", Score: 2}, {Member: "foo", Score: 5}}, members)
+	})
+
+	t.Run("it should keep the TTL of the sorted set", func(t *testing.T) {
+		val, err := store.Get("existing-key")
+		assert.Nil(t, err)
+		assert.False(t, val.ExpiresAt.IsZero())
+	})
+
+	t.Run("it should create the sorted set if it does not exist", func(t *testing.T) {
+		added, err := store.ZAdd("new-key", storage.ScoredMember{Member: "foo", Score: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, added)
+	})
+
+	t.Run("it should increment the score of a member", func(t *testing.T) {
+		score, err := store.ZIncrBy("existing-key", "bar", 4.5)
+		assert.Nil(t, err)
+		assert.Equal(t, 6.5, score)
+
+		rank, err := store.ZRank("existing-key", "bar")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, rank)
+	})
+
+	t.Run("it should create a missing member", func(t *testing.T) {
+		score, err := store.ZIncrBy("existing-key", "baz", -1)
+		assert.Nil(t, err)
+		assert.Equal(t, float64(-1), score)
+	})
+
+	t.Run("it should return an error if the score is not a number", func(t *testing.T) {
+		_, err := store.ZAdd("existing-key", storage.ScoredMember{Member: "foo", Score: math.NaN()})
+		assert.Equal(t, storage.ErrInvalidScore, err)
+
+		_, err = store.ZAdd("existing-key", storage.ScoredMember{Member: "foo", Score: math.Inf(-1)})
+		assert.Equal(t, storage.ErrInvalidScore, err)
+
+		_, err = store.ZIncrBy("infinite-key", "foo", math.Inf(1))
+		assert.Equal(t, storage.ErrInvalidScore, err)
+		_, err = store.ZScore("infinite-key", "foo")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should return an error if the score overflows", func(t *testing.T) {
+		_, err := store.ZIncrBy("existing-key", "big", math.MaxFloat64)
+		assert.Nil(t, err)
+		_, err = store.ZIncrBy("existing-key", "big", math.MaxFloat64)
+		assert.Equal(t, storage.ErrInvalidScore, err)
+
+		score, err := store.ZScore("existing-key", "big")
+		assert.Nil(t, err)
+		assert.Equal(t, math.MaxFloat64, score)
+	})
+}
+
+func TestZSetStore_ScoreAndRank(t *testing.T) {
+	store := storage.NewZSetStore()
+
+	err := store.Set("leaderboard", []storage.ScoredMember{
+		{Member: "alice", Score: 30},
+		{Member: "bob", Score: 10},
+		{Member: "carol", Score: 20},
+	}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		key             string
+		member          string
+		expectedScore   float64
+		expectedRank    int
+		expectedRevRank int
+		expectedErr     error
+	}{
+		"it should return an error if key not found": {
+			key:         "new-key",
+			member:      "alice",
+			expectedErr: storage.ErrNotFound,
+		},
+		"it should return an error if member not found": {
+			key:         "leaderboard",
+			member:      "dave",
+			expectedErr: storage.ErrMemberNotFound,
+		},
+		"lowest score": {
+			key:             "leaderboard",
+			member:          "bob",
+			expectedScore:   10,
+			expectedRank:    0,
+			expectedRevRank: 2,
+		},
+		"highest score": {
+			key:             "leaderboard",
+			member:          "alice",
+			expectedScore:   30,
+			expectedRank:    2,
+			expectedRevRank: 0,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			score, err := store.ZScore(tc.key, tc.member)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedScore, score)
+
+			rank, err := store.ZRank(tc.key, tc.member)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedRank, rank)
+
+			rank, err = store.ZRevRank(tc.key, tc.member)
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedRevRank, rank)
+		})
+	}
+}
+
+func TestZSetStore_ZRange(t *testing.T) {
+	store := storage.NewZSetStore()
+
+	err := store.Set("key", []storage.ScoredMember{
+		{Member: "a", Score: 1},
+		{Member: "b", Score: 2},
+		{Member: "c", Score: 3},
+		{Member: "d", Score: 4},
+	}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		start           int
+		stop            int
+		reverse         bool
+		expectedMembers []string
+	}{
+		"all the members": {
+			start:           0,
+			stop:            -1,
+			expectedMembers: []string{"a", "b", "c", "d"},
+		},
+		"a range in the middle": {
+			start:           1,
+			stop:            2,
+			expectedMembers: []string{"b", "c"},
+		},
+		"negative positions": {
+			start:           -2,
+			stop:            -1,
+			expectedMembers: []string{"c", "d"},
+		},
+		"stop out of range": {
+			start:           2,
+			stop:            100,
+			expectedMembers: []string{"c", "d"},
+		},
+		"start after stop": {
+			start:           3,
+			stop:            1,
+			expectedMembers: []string{},
+		},
+		"start out of range": {
+			start:           10,
+			stop:            20,
+			expectedMembers: []string{},
+		},
+		"top two in reverse": {
+			start:           0,
+			stop:            1,
+			reverse:         true,
+			expectedMembers: []string{"d", "c"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rangeFn := store.ZRange
+			if tc.reverse {
+				rangeFn = store.ZRevRange
+			}
+			members, err := rangeFn("key", tc.start, tc.stop)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedMembers, memberNames(members))
+		})
+	}
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.ZRange("new-key", 0, -1)
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+func TestZSetStore_ZRangeByScore(t *testing.T) {
+	store := storage.NewZSetStore()
+
+	err := store.Set("key", []storage.ScoredMember{
+		{Member: "a", Score: 1},
+		{Member: "b", Score: 2},
+		{Member: "c", Score: 2},
+		{Member: "d", Score: 4},
+		{Member: "e", Score: 5},
+	}, 0)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		min             float64
+		max             float64
+		offset          int
+		count           int
+		expectedMembers []string
+	}{
+		"inclusive bounds": {
+			min:             2,
+			max:             4,
+			count:           -1,
+			expectedMembers: []string{"b", "c", "d"},
+		},
+		"infinite bounds": {
+			min:             math.Inf(-1),
+			max:             math.Inf(1),
+			count:           -1,
+			expectedMembers: []string{"a", "b", "c", "d", "e"},
+		},
+		"with offset and count": {
+			min:             2,
+			max:             5,
+			offset:          1,
+			count:           2,
+			expectedMembers: []string{"c", "d"},
+		},
+		"offset out of range": {
+			min:             2,
+			max:             5,
+			offset:          10,
+			count:           -1,
+			expectedMembers: []string{},
+		},
+		"no members in range": {
+			min:             2.5,
+			max:             3.5,
+			count:           -1,
+			expectedMembers: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			members, err := store.ZRangeByScore("key", tc.min, tc.max, tc.offset, tc.count)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedMembers, memberNames(members))
+		})
+	}
+}
+
+func TestZSetStore_ZRem(t *testing.T) {
+	store := storage.NewZSetStore()
+
+	err := store.Set("key", []storage.ScoredMember{
+		{Member: "a", Score: 1},
+		{Member: "b", Score: 2},
+		{Member: "c", Score: 3},
+		{Member: "d", Score: 4},
+		{Member: "e", Score: 5},
+		{Member: "f", Score: 6},
+	}, 0)
+	assert.Nil(t, err)
+
+	t.Run("it should return an error if key not found", func(t *testing.T) {
+		_, err := store.ZRem("new-key", "a")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+
+	t.Run("it should remove the existing members", func(t *testing.T) {
+		removed, err := store.ZRem("key", "a", "unknown")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, removed)
+	})
+
+	t.Run("it should remove a range by rank", func(t *testing.T) {
+		removed, err := store.ZRemRangeByRank("key", -2, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, removed)
+
+		members, err := store.ZRange("key", 0, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"b", "c", "d"}, memberNames(members))
+	})
+
+	t.Run("it should remove a range by score", func(t *testing.T) {
+		removed, err := store.ZRemRangeByScore("key", 2, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, removed)
+
+		card, err := store.ZCard("key")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, card)
+	})
+
+	t.Run("it should remove the sorted set when it has no members left", func(t *testing.T) {
+		removed, err := store.ZRemRangeByScore("key", math.Inf(-1), math.Inf(1))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, removed)
+
+		_, err = store.Get("key")
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+}
+
+// TestZSetStore_RandomOperations checks the ranks and ranges against a sorted slice
+// after a random sequence of additions, updates and removals.
+func TestZSetStore_RandomOperations(t *testing.T) {
+	store := storage.NewZSetStore()
+	scores := map[string]float64{}
+
+	for i := 0; i < 5000; i++ {
+		member := "member-" + strconv.Itoa(rand.IntN(500))
+		if rand.IntN(4) == 0 {
+			_, _ = store.ZRem("key", member)
+			delete(scores, member)
+			continue
+		}
+		score := float64(rand.IntN(100))
+		_, err := store.ZAdd("key", storage.ScoredMember{Member: member, Score: score})
+		assert.Nil(t, err)
+		scores[member] = score
+	}
+
+	expected := make([]storage.ScoredMember, 0, len(scores))
+	for member, score := range scores {
+		expected = append(expected, storage.ScoredMember{Member: member, Score: score})
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		if expected[i].Score != expected[j].Score {
+			return expected[i].Score < expected[j].Score
+		}
+		return expected[i].Member < expected[j].Member
+	})
+
+	members, err := store.ZRange("key", 0, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, members)
+
+	for i, m := range expected {
+		rank, err := store.ZRank("key", m.Member)
+		assert.Nil(t, err)
+		assert.Equal(t, i, rank)
+	}
+
+	members, err = store.ZRevRange("key", 0, -1)
+	assert.Nil(t, err)
+	for i, m := range members {
+		assert.Equal(t, expected[len(expected)-1-i], m)
+	}
+}
+
+func TestZSetStore_SnapshotRestore(t *testing.T) {
+	store := storage.NewZSetStore()
+
+	err := store.Set("existing-key", []storage.ScoredMember{{Member: "b", Score: 2}, {Member: "a", Score: 1}}, time.Minute)
+	assert.Nil(t, err)
+
+	data := store.Snapshot()
+	assert.Equal(t, []storage.ScoredMember{{Member: "a", Score: 1}, {Member: "b", Score: 2}}, data["existing-key"].Value)
+
+	restored := storage.NewZSetStore()
+	loaded := restored.Restore(map[string]storage.Value[[]storage.ScoredMember]{
+		"existing-key": data["existing-key"],
+		"expired-key":  {Value: []storage.ScoredMember{{Member: "a"}}, ExpiresAt: time.Now().Add(-time.Second)},
+	})
+	assert.Equal(t, 1, loaded)
+
+	rank, err := restored.ZRank("existing-key", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, rank)
+}
+
+func TestZSetStore_ConcurrentZIncrBy(t *testing.T) {
+	store := storage.NewZSetStore()
+	const n = 100
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.ZIncrBy("leaderboard", "player-"+strconv.Itoa(i%10), 1)
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	members, err := store.ZRange("leaderboard", 0, -1)
+	assert.Nil(t, err)
+	assert.Len(t, members, 10)
+	for _, m := range members {
+		assert.Equal(t, float64(n/10), m.Score)
+	}
+}
+
+func BenchmarkZSetStore_ZAdd(b *testing.B) {
+	store := storage.NewZSetStore()
+	for i := 0; i < b.N; i++ {
+		_, _ = store.ZAdd("key", storage.ScoredMember{Member: strconv.Itoa(i), Score: rand.Float64()})
+	}
+}
+
+func BenchmarkZSetStore_ZRank(b *testing.B) {
+	store := newBenchmarkZSet(b, 100_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = store.ZRank("key", strconv.Itoa(i%100_000))
+	}
+}
+
+func BenchmarkZSetStore_ZRange(b *testing.B) {
+	store := newBenchmarkZSet(b, 100_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := i % 100_000
+		_, _ = store.ZRange("key", start, start+9)
+	}
+}
+
+func BenchmarkZSetStore_ZRangeByScore(b *testing.B) {
+	store := newBenchmarkZSet(b, 100_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		min := rand.Float64()
+		_, _ = store.ZRangeByScore("key", min, 1, 0, 10)
+	}
+}
+
+func newBenchmarkZSet(b *testing.B, size int) storage.ZSetStore {
+	b.Helper()
+	members := make([]storage.ScoredMember, size)
+	for i := range members {
+		members[i] = storage.ScoredMember{Member: strconv.Itoa(i), Score: rand.Float64()}
+	}
+	store := storage.NewZSetStore()
+	if err := store.Set("key", members, 0); err != nil {
+		b.Fatal(err)
+	}
+	return store
+}
+
+func memberNames(members []storage.ScoredMember) []string {
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		names = append(names, m.Member)
+	}
+	return names
+}